@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"runtime"
+	"text/template"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -46,6 +48,16 @@ func Bold(text string) string {
 	return color.Sprintf(color.Bold(text))
 }
 
+// ClearLines returns the ANSI sequence moving the cursor up the lines last
+// printed and clearing them, to print them again in place
+func ClearLines(n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\x1b[%dA\x1b[J", n)
+}
+
 // Color returns an aurora.Aurora instance with colors enabled or disabled
 // depending on whether the writer supports colors.
 func Color(w io.Writer) aurora.Aurora {
@@ -69,8 +81,29 @@ func ColorizeJSON(json string, darkStyle bool, w io.Writer) string {
 
 // ColorizeStatus returns a colorized number for HTTP status code
 func ColorizeStatus(status int) aurora.Value {
-	color := Color(os.Stdout)
+	return colorizeStatus(Color(os.Stdout), status)
+}
+
+// TemplateFuncs returns text/template functions coloring their argument if
+// the writer supports colors: bold, faint, italic, red, green, yellow, blue,
+// cyan, and status, which colors an HTTP status code like ColorizeStatus.
+func TemplateFuncs(w io.Writer) template.FuncMap {
+	color := Color(w)
+
+	return template.FuncMap{
+		"bold":   func(arg interface{}) string { return color.Bold(arg).String() },
+		"faint":  func(arg interface{}) string { return color.Faint(arg).String() },
+		"italic": func(arg interface{}) string { return color.Italic(arg).String() },
+		"red":    func(arg interface{}) string { return color.Red(arg).String() },
+		"green":  func(arg interface{}) string { return color.Green(arg).String() },
+		"yellow": func(arg interface{}) string { return color.Yellow(arg).String() },
+		"blue":   func(arg interface{}) string { return color.Blue(arg).String() },
+		"cyan":   func(arg interface{}) string { return color.Cyan(arg).String() },
+		"status": func(status int) string { return colorizeStatus(color, status).String() },
+	}
+}
 
+func colorizeStatus(color aurora.Aurora, status int) aurora.Value {
 	switch {
 	case status >= 500:
 		return color.Red(status).Bold()
@@ -87,6 +120,11 @@ func Faint(text string) string {
 	return color.Sprintf(color.Faint(text))
 }
 
+// IsTerminal returns whether the writer is a terminal
+func IsTerminal(w io.Writer) bool {
+	return isTerminal(w)
+}
+
 // Italic returns italicized text if the writer supports it.
 func Italic(text string) string {
 	color := Color(os.Stdout)
@@ -175,6 +213,14 @@ func StrikeThrough(text string) string {
 	return color.Sprintf(color.StrikeThrough(text))
 }
 
+// escapeSequenceRegexp matches the colors and hyperlinks this package writes
+var escapeSequenceRegexp = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]|\x1b\\]8;;[^\x1b]*\x1b\\\\")
+
+// Strip returns the text without its colors and other ANSI sequences
+func Strip(text string) string {
+	return escapeSequenceRegexp.ReplaceAllString(text, "")
+}
+
 //
 // Private functions
 //
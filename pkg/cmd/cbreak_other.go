@@ -0,0 +1,10 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!freebsd,!linux,!netbsd,!openbsd
+
+package cmd
+
+import "errors"
+
+func enableCbreakMode(fd int) (func(), error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -19,9 +19,10 @@ func newDeleteCmd() *deleteCmd {
 	gc.reqs.Method = http.MethodDelete
 	gc.reqs.Profile = &Config.Profile
 	gc.reqs.Cmd = &cobra.Command{
-		Use:   "delete <path>",
-		Args:  validators.ExactArgs(1),
-		Short: "Make a DELETE request to the Stripe API",
+		Use:               "delete <path>",
+		Args:              validators.ExactArgs(1),
+		ValidArgsFunction: completePaths(http.MethodDelete),
+		Short:             "Make a DELETE request to the Stripe API",
 		Long: `Make DELETE requests to the Stripe API using your test mode key.
 
 For a full list of supported paths, see the API reference:
@@ -29,7 +30,10 @@ https://stripe.com/docs/api
 
 To delete a charge:
 
-  $ stripe delete /customers/cus_FROPkgsHVRRspg`,
+  $ stripe delete /customers/cus_FROPkgsHVRRspg
+
+With a live mode key, the deletion is confirmed by entering the ID of the
+object or 'yes'. Use --force to skip the confirmation, like in scripts.`,
 		RunE: gc.reqs.RunRequestsCmd,
 	}
 
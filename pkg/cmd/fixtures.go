@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
@@ -17,11 +22,25 @@ type FixturesCmd struct {
 	Cmd *cobra.Command
 	Cfg *config.Config
 
-	stripeAccount string
-	skip          []string
-	override      []string
-	add           []string
-	remove        []string
+	stripeAccount    string
+	skip             []string
+	only             []string
+	set              []string
+	override         []string
+	add              []string
+	remove           []string
+	seed             int64
+	outputFile       string
+	dryRun           bool
+	format           string
+	ignoreAssertions bool
+	noCache          bool
+	allowUploads     bool
+	cleanup          bool
+	validateOnly     bool
+	continueOnError  bool
+	apiBaseURL       string
+	filesBaseURL     string
 }
 
 func newFixturesCmd(cfg *config.Config) *FixturesCmd {
@@ -33,15 +52,40 @@ func newFixturesCmd(cfg *config.Config) *FixturesCmd {
 		Use:   "fixtures",
 		Args:  validators.ExactArgs(1),
 		Short: "Run fixtures to populate your account with data",
-		Long:  `Run fixtures to populate your account with data`,
-		RunE:  fixturesCmd.runFixturesCmd,
+		Long: `Run fixtures to populate your account with data. The fixture may be a file or
+an http(s) URL, which is cached in the config directory for later runs.
+
+The fixture may also be a directory, whose .json files run one after the other
+in the order of their names, or in the order listed by an "order" file in the
+directory, one file per line. A file may reference the steps of the previous
+ones, like ${cust:id}.`,
+		RunE: fixturesCmd.runFixturesCmd,
 	}
 
-	fixturesCmd.Cmd.Flags().StringVar(&fixturesCmd.stripeAccount, "stripe-account", "", "Set a header identifying the connected account")
+	fixturesCmd.Cmd.AddCommand(newFixturesCleanupCmd(cfg).cmd)
+
 	fixturesCmd.Cmd.Flags().StringArrayVar(&fixturesCmd.skip, "skip", []string{}, "Skip specific steps in the fixture")
+	fixturesCmd.Cmd.Flags().StringArrayVar(&fixturesCmd.only, "only", []string{}, "Only run these steps of the fixture")
+	fixturesCmd.Cmd.Flags().StringArrayVar(&fixturesCmd.set, "set", []string{}, "Set a variable the steps reference with ${var:<variable>}, with <variable>=value, or the output of a step that doesn't run, with <fixture_name>:path.to.field=value. Ex: price=price_123 or customer:id=cus_123")
 	fixturesCmd.Cmd.Flags().StringArrayVar(&fixturesCmd.override, "override", []string{}, "Override parameters in the fixture")
 	fixturesCmd.Cmd.Flags().StringArrayVar(&fixturesCmd.add, "add", []string{}, "Add parameters in the fixture")
 	fixturesCmd.Cmd.Flags().StringArrayVar(&fixturesCmd.remove, "remove", []string{}, "Remove parameters from the fixture")
+	fixturesCmd.Cmd.Flags().Int64Var(&fixturesCmd.seed, "seed", 0, "Seed the random values of the fixture, like ${random:email}, to generate the same ones on every run (default: a different seed each run)")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.cleanup, "cleanup", false, "Delete the objects created by the fixture once it ran, instead of listing them for `stripe fixtures cleanup`")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.noCache, "no-cache", false, "Fetch the fixture again when it is a URL, instead of using the copy cached by a previous run")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.allowUploads, "allow-remote-uploads", false, "Let a fixture fetched from a URL upload local files with the absolute paths of its upload steps")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.ignoreAssertions, "ignore-assertions", false, "Don't fail when the response of a step doesn't match its assert block")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.validateOnly, "validate-only", false, "Only check that the fixture and the files it includes are valid, without running it")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.continueOnError, "continue-on-error", false, "Keep running the other files of a fixtures directory when one fails")
+	fixturesCmd.Cmd.Flags().BoolVar(&fixturesCmd.dryRun, "dry-run", false, "Print the requests the fixture would make without making them")
+	fixturesCmd.Cmd.Flags().StringVar(&fixturesCmd.format, "format", "default", "The format to print the requests of --dry-run as (either 'default' or 'json')")
+	fixturesCmd.Cmd.Flags().StringVar(&fixturesCmd.outputFile, "output-file", "", "Write the ID, object type and status code of each step to this file as JSON")
+
+	// Hidden configuration flags, useful for dev/debugging
+	fixturesCmd.Cmd.Flags().StringVar(&fixturesCmd.apiBaseURL, "api-base", stripe.DefaultAPIBaseURL, "Sets the API base URL")
+	fixturesCmd.Cmd.Flags().MarkHidden("api-base") // #nosec G104
+	fixturesCmd.Cmd.Flags().StringVar(&fixturesCmd.filesBaseURL, "files-base", stripe.DefaultFilesAPIBaseURL, "Sets the files API base URL")
+	fixturesCmd.Cmd.Flags().MarkHidden("files-base") // #nosec G104
 
 	return fixturesCmd
 }
@@ -49,40 +93,149 @@ func newFixturesCmd(cfg *config.Config) *FixturesCmd {
 func (fc *FixturesCmd) runFixturesCmd(cmd *cobra.Command, args []string) error {
 	version.CheckLatestVersion()
 
-	apiKey, err := fc.Cfg.Profile.GetAPIKey(false)
+	fc.stripeAccount = fc.Cfg.Profile.StripeAccount
+	if fc.stripeAccount != "" {
+		if err := validators.AccountID(fc.stripeAccount); err != nil {
+			return fmt.Errorf("--stripe-account: %w", err)
+		}
+	}
+
+	if fc.format != "default" && fc.format != "json" {
+		return fmt.Errorf("invalid format, must be one of 'default' or 'json', received %s", fc.format)
+	}
+
+	// Dry runs and validations make no requests and don't need to be logged in
+	var apiKey string
+	if !fc.dryRun && !fc.validateOnly {
+		key, err := fc.Cfg.Profile.GetAPIKey(false)
+		if err != nil {
+			return err
+		}
+		apiKey = key
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+		return fc.runFixturesDir(cmd, args[0], apiKey)
+	}
+
+	fixture, err := fc.newFixture(args[0], apiKey, fc.seed)
 	if err != nil {
 		return err
 	}
 
-	if len(args) == 0 {
+	if fc.validateOnly {
+		fmt.Printf("The fixture %s is valid.\n", args[0])
 		return nil
 	}
 
-	fixture, err := fixtures.NewFixtureFromFile(
+	if err := fixture.SetValues(fc.set); err != nil {
+		return err
+	}
+
+	if fc.dryRun {
+		steps, err := fixture.DryRun()
+		if err != nil {
+			return err
+		}
+
+		return fixtures.PrintDryRun(os.Stdout, steps, fc.format)
+	}
+
+	_, err = fixture.Execute(cmd.Context())
+
+	// The objects created before a failure are cleaned up too
+	if cleanupErr := fc.cleanupRun(cmd, fixture.Fs, fixture.Created(), apiKey); cleanupErr != nil && err == nil {
+		err = cleanupErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = fixture.UpdateEnv()
+	if err != nil {
+		return err
+	}
+
+	if fc.outputFile != "" {
+		return fixture.WriteOutputFile(fc.outputFile)
+	}
+
+	return nil
+}
+
+// newFixture loads the fixture file with the flags of the command
+func (fc *FixturesCmd) newFixture(file, apiKey string, seed int64) (*fixtures.Fixture, error) {
+	fixture, err := fixtures.NewFixture(
 		afero.NewOsFs(),
 		apiKey,
 		fc.stripeAccount,
-		stripe.DefaultAPIBaseURL,
-		args[0],
+		fc.apiBaseURL,
+		file,
 		fc.skip,
 		fc.override,
 		fc.add,
 		fc.remove,
+		fixtures.RemoteOptions{
+			CacheDir:     filepath.Join(fc.Cfg.GetConfigFolder(os.Getenv("XDG_CONFIG_HOME")), "fixtures"),
+			NoCache:      fc.noCache,
+			AllowUploads: fc.allowUploads,
+		},
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = fixture.Execute(cmd.Context())
+	fixture.FilesBaseURL = fc.filesBaseURL
+	fixture.IgnoreAssertions = fc.ignoreAssertions
+	fixture.Only = fc.only
+	if seed != 0 {
+		fixture.SetSeed(seed)
+	}
 
-	if err != nil {
-		return err
+	for _, included := range fixture.IncludedFiles() {
+		log.WithFields(log.Fields{
+			"prefix": "cmd.FixturesCmd.newFixture",
+		}).Debugf("Merged the steps of the included fixture file %s", included)
 	}
 
-	err = fixture.UpdateEnv()
+	return fixture, nil
+}
+
+// cleanupRun deletes the objects created by the fixtures with --cleanup, and
+// otherwise lists them in a manifest for `stripe fixtures cleanup`
+func (fc *FixturesCmd) cleanupRun(cmd *cobra.Command, fs afero.Fs, created []fixtures.CreatedObject, apiKey string) error {
+	if !fc.cleanup {
+		file, err := fixtures.WriteManifest(fs, fixtureRunsDir(fc.Cfg), created)
+		if err != nil {
+			return err
+		}
+		if file != "" {
+			fmt.Printf("The objects created by the fixture are listed in %s, delete them with `stripe fixtures cleanup`\n", file)
+		}
+		return nil
+	}
+
+	result := fixtures.Cleanup(cmd.Context(), apiKey, fc.apiBaseURL, created, os.Stdout)
+	if len(result.Failed) == 0 {
+		return nil
+	}
+
+	// List the objects that failed so that cleaning up again retries them
+	file, err := fixtures.WriteManifest(fs, fixtureRunsDir(fc.Cfg), result.Failed)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return fmt.Errorf("Failed to delete %d of the objects created by the fixture, they are listed in %s", len(result.Failed), file)
+}
+
+// fixtureRunsDir is the directory the manifests of the objects created by
+// fixtures and triggers are written to
+func fixtureRunsDir(cfg *config.Config) string {
+	return filepath.Join(cfg.GetConfigFolder(os.Getenv("XDG_CONFIG_HOME")), "fixture_runs")
 }
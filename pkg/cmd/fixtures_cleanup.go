@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+	"github.com/stripe/stripe-cli/pkg/fixtures"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+	"github.com/stripe/stripe-cli/pkg/validators"
+)
+
+type fixturesCleanupCmd struct {
+	cmd *cobra.Command
+	cfg *config.Config
+	fs  afero.Fs
+
+	all        bool
+	apiBaseURL string
+}
+
+func newFixturesCleanupCmd(cfg *config.Config) *fixturesCleanupCmd {
+	cc := &fixturesCleanupCmd{
+		cfg: cfg,
+		fs:  afero.NewOsFs(),
+	}
+
+	cc.cmd = &cobra.Command{
+		Use:   "cleanup [manifest]",
+		Args:  validators.MaximumNArgs(1),
+		Short: "Delete the objects created by fixtures and triggers",
+		Long: `Delete the objects created by a previous run of a fixture or trigger, in the
+reverse order they were created in. Subscriptions are canceled, customers and
+products deleted, and objects that can't be deleted, like charges, skipped.
+
+Each run lists the objects it created in a manifest in the config directory.
+The latest run is cleaned up unless a manifest or --all is given. Objects that
+fail to be deleted stay in the manifest, to retry them later.`,
+		Example: `stripe fixtures cleanup
+  stripe fixtures cleanup --all`,
+		RunE: cc.runFixturesCleanupCmd,
+	}
+
+	cc.cmd.Flags().BoolVar(&cc.all, "all", false, "Clean up the objects of every previous run")
+
+	// Hidden configuration flags, useful for dev/debugging
+	cc.cmd.Flags().StringVar(&cc.apiBaseURL, "api-base", stripe.DefaultAPIBaseURL, "Sets the API base URL")
+	cc.cmd.Flags().MarkHidden("api-base") // #nosec G104
+
+	return cc
+}
+
+func (cc *fixturesCleanupCmd) runFixturesCleanupCmd(cmd *cobra.Command, args []string) error {
+	if cc.all && len(args) > 0 {
+		return fmt.Errorf("--all cannot be used together with a manifest")
+	}
+
+	manifests := args
+	if len(manifests) == 0 {
+		runs, err := fixtures.Manifests(cc.fs, fixtureRunsDir(cc.cfg))
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			fmt.Println("There are no created objects to clean up.")
+			return nil
+		}
+
+		manifests = runs[len(runs)-1:]
+		if cc.all {
+			// The latest runs first, as later runs may use the objects of
+			// earlier ones
+			manifests = make([]string, 0, len(runs))
+			for i := len(runs) - 1; i >= 0; i-- {
+				manifests = append(manifests, runs[i])
+			}
+		}
+	}
+
+	apiKey, err := cc.cfg.Profile.GetAPIKey(false)
+	if err != nil {
+		return err
+	}
+
+	var deleted, skipped, failed int
+	for _, manifest := range manifests {
+		result, err := fixtures.CleanupManifest(cmd.Context(), cc.fs, apiKey, cc.apiBaseURL, manifest, os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		deleted += len(result.Deleted)
+		skipped += len(result.Skipped)
+		failed += len(result.Failed)
+	}
+
+	fmt.Printf("Deleted %d objects, skipped %d that can't be deleted.\n", deleted, skipped)
+	if failed > 0 {
+		return fmt.Errorf("Failed to delete %d objects, run `stripe fixtures cleanup` again to retry them", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	"github.com/stripe/stripe-cli/pkg/fixtures"
+)
+
+// fixtureFileFailure is a file of a fixtures directory whose run failed
+type fixtureFileFailure struct {
+	file string
+	// step is the step the file failed at, empty if it failed before running
+	// any
+	step string
+	err  error
+}
+
+// runFixturesDir runs the fixture files of the directory one after the other,
+// sharing the outputs of their steps. A failure stops the run unless
+// --continue-on-error is set.
+func (fc *FixturesCmd) runFixturesDir(cmd *cobra.Command, dir, apiKey string) error {
+	fs := afero.NewOsFs()
+
+	files, err := fixtures.DirectoryFiles(fs, dir)
+	if err != nil {
+		return err
+	}
+
+	// Every file is loaded before any runs, so that an invalid file fails the
+	// run before it makes requests
+	rc := fixtures.NewRunContext()
+	loaded := make([]*fixtures.Fixture, 0, len(files))
+	for i, file := range files {
+		seed := fc.seed
+		if seed != 0 {
+			// The files don't generate the same random values, like the same
+			// ${random:email}
+			seed += int64(i)
+		}
+
+		fixture, err := fc.newFixture(file, apiKey, seed)
+		if err != nil {
+			if _, ok := err.(*fixtures.ValidationError); ok {
+				return err
+			}
+			return fmt.Errorf("Failed to load the fixture file %s: %v", file, err)
+		}
+
+		fixture.UseContext(rc)
+		loaded = append(loaded, fixture)
+	}
+
+	if fc.validateOnly {
+		fmt.Printf("The %d fixture files of %s are valid.\n", len(files), dir)
+		return nil
+	}
+
+	// The files share the values, which may be set for the steps of any of them
+	if err := loaded[len(loaded)-1].SetValues(fc.set); err != nil {
+		return err
+	}
+
+	if fc.dryRun {
+		return dryRunFixturesDir(os.Stdout, files, loaded, fc.format)
+	}
+
+	var created []fixtures.CreatedObject
+	var failures []fixtureFileFailure
+	ran := 0
+	for i, fixture := range loaded {
+		fmt.Printf("[%d/%d] Running %s\n", i+1, len(files), files[i])
+		ran++
+
+		_, err := fixture.Execute(cmd.Context())
+		created = append(created, fixture.Created()...)
+		if err == nil {
+			err = fixture.UpdateEnv()
+		}
+		if err != nil {
+			failures = append(failures, fixtureFileFailure{file: files[i], step: fixture.FailedStep(), err: err})
+			if !fc.continueOnError {
+				break
+			}
+		}
+	}
+
+	// The objects are cleaned up once every file ran, as the later files may
+	// use the objects the earlier ones created
+	err = fc.cleanupRun(cmd, fs, created, apiKey)
+
+	// The fixtures share the outcomes of their steps, so the last one has
+	// them all
+	if err == nil && len(failures) == 0 && fc.outputFile != "" {
+		err = loaded[ran-1].WriteOutputFile(fc.outputFile)
+	}
+
+	printFixturesDirSummary(os.Stdout, len(files), ran, failures)
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of the %d fixture files of %s failed", len(failures), len(files), dir)
+	}
+
+	return err
+}
+
+// dryRunFixturesDir prints the requests of the fixture files, under the name
+// of each file, or as a single list of steps in JSON
+func dryRunFixturesDir(out io.Writer, files []string, loaded []*fixtures.Fixture, format string) error {
+	var all []fixtures.DryRunStep
+	for i, fixture := range loaded {
+		steps, err := fixture.DryRun()
+		if err != nil {
+			return fmt.Errorf("Failed to dry run the fixture file %s: %v", files[i], err)
+		}
+
+		if format == "json" {
+			all = append(all, steps...)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s:\n", files[i])
+		if err := fixtures.PrintDryRun(out, steps, format); err != nil {
+			return err
+		}
+	}
+
+	if format == "json" {
+		return fixtures.PrintDryRun(out, all, format)
+	}
+
+	return nil
+}
+
+// printFixturesDirSummary prints how many of the files ran and succeeded, and
+// the file and step of each failure
+func printFixturesDirSummary(out io.Writer, total, ran int, failures []fixtureFileFailure) {
+	fmt.Fprintf(out, "Ran %d of %d fixture files: %d succeeded, %d failed\n", ran, total, ran-len(failures), len(failures))
+
+	color := ansi.Color(os.Stdout)
+	for _, failure := range failures {
+		location := failure.file
+		if failure.step != "" {
+			location = fmt.Sprintf("%s, step %s", failure.file, failure.step)
+		}
+
+		fmt.Fprintf(out, "  %s %s: %s\n", color.Red("✘"), location, strings.TrimSpace(failure.err.Error()))
+	}
+}
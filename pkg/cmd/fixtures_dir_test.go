@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+)
+
+func TestRunFixturesDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		switch req.URL.Path {
+		case "/v1/prices":
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte(`{"error": {"type": "invalid_request_error", "message": "Missing required param: currency."}}`))
+		default:
+			res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	writeFixture := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	writeFixture("01-prices.json", `{"fixtures": [{"name": "price", "path": "/v1/prices", "method": "post"}]}`)
+	writeFixture("02-customers.json", `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post"}]}`)
+	writeFixture("03-invoices.json", `{"fixtures": [{"name": "invoice", "path": "/v1/invoices", "method": "post", "params": {"customer": "${cust:id}"}}]}`)
+
+	fc := &FixturesCmd{Cfg: &config.Config{}, apiBaseURL: ts.URL}
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fc.runFixturesDir(cmd, dir, "sk_test_123")
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.SetArgs([]string{})
+
+	err := cmd.ExecuteContext(context.Background())
+	require.EqualError(t, err, "1 of the 3 fixture files of "+dir+" failed")
+	require.Equal(t, []string{"/v1/prices"}, paths)
+
+	paths = nil
+	fc.continueOnError = true
+	err = cmd.ExecuteContext(context.Background())
+	require.EqualError(t, err, "1 of the 3 fixture files of "+dir+" failed")
+	require.Equal(t, []string{"/v1/prices", "/v1/customers", "/v1/invoices"}, paths)
+}
+
+func TestPrintFixturesDirSummary(t *testing.T) {
+	var out bytes.Buffer
+	printFixturesDirSummary(&out, 3, 2, []fixtureFileFailure{
+		{file: "seed/02-customers.json", step: "cust", err: errors.New("Request failed, status=400\n")},
+	})
+
+	require.Equal(t, "Ran 2 of 3 fixture files: 1 succeeded, 1 failed\n  ✘ seed/02-customers.json, step cust: Request failed, status=400\n", out.String())
+}
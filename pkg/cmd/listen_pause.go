@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+// pausable is the part of the proxy toggled by the pause keys
+type pausable interface {
+	Pause()
+	Resume() int
+	Paused() bool
+}
+
+// listenForPauseKeys toggles forwarding when space or p is pressed. It does
+// nothing unless both stdin and stdout are terminals, and returns a function
+// restoring the terminal.
+func listenForPauseKeys(p pausable, bufferSize int) func() {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}
+	}
+
+	// read keys as they are pressed, without echoing them, while still
+	// handling ^C and output as usual
+	restore, err := enableCbreakMode(int(os.Stdin.Fd()))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"prefix": "cmd.listenForPauseKeys",
+		}).Debugf("Pausing with a keypress is not available: %v", err)
+		return func() {}
+	}
+
+	go watchPauseKeys(os.Stdin, os.Stdout, p, bufferSize)
+
+	return restore
+}
+
+// terminalRestorer restores the terminal once, whether listen returns or
+// exits
+type terminalRestorer struct {
+	mu sync.Mutex
+	fn func()
+}
+
+func (r *terminalRestorer) set(restore func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fn = restore
+}
+
+func (r *terminalRestorer) restore() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fn != nil {
+		r.fn()
+		r.fn = nil
+	}
+}
+
+func watchPauseKeys(in io.Reader, out io.Writer, p pausable, bufferSize int) {
+	reader := bufio.NewReader(in)
+
+	for {
+		key, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+
+		if key != ' ' && key != 'p' && key != 'P' {
+			continue
+		}
+
+		color := ansi.Color(out)
+		localTime := color.Faint(time.Now().Format(timeLayout))
+
+		if p.Paused() {
+			n := p.Resume()
+			fmt.Fprintf(out, "%s   Forwarding resumed, %d buffered events forwarded\n", localTime, n)
+		} else {
+			p.Pause()
+			fmt.Fprintf(out, "%s   %s events are printed but not forwarded, buffering up to %d of them. Press space or p to resume\n", localTime, color.Yellow("Forwarding paused,"), bufferSize)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/proxy"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestParseEventRoutes(t *testing.T) {
+	routes, err := parseEventRoutes([]string{"invoice.*=localhost:4000/billing", " checkout.session.* = http://localhost:5000/a?b=c "})
+	require.NoError(t, err)
+	require.Equal(t, []proxy.EventRoute{
+		{Pattern: "invoice.*", URL: "localhost:4000/billing"},
+		{Pattern: "checkout.session.*", URL: "http://localhost:5000/a?b=c"},
+	}, routes)
+
+	_, err = parseEventRoutes([]string{"invoice.*"})
+	require.Error(t, err)
+
+	_, err = parseEventRoutes([]string{"=localhost:4000"})
+	require.Error(t, err)
+}
+
+type fakePausable struct {
+	paused  bool
+	resumes int
+}
+
+func (f *fakePausable) Pause() {
+	f.paused = true
+}
+
+func (f *fakePausable) Resume() int {
+	f.paused = false
+	f.resumes++
+	return 3
+}
+
+func (f *fakePausable) Paused() bool {
+	return f.paused
+}
+
+func TestWatchPauseKeys(t *testing.T) {
+	p := &fakePausable{}
+	var out bytes.Buffer
+
+	watchPauseKeys(strings.NewReader("x p"), &out, p, 10)
+
+	require.False(t, p.paused)
+	require.Equal(t, 1, p.resumes)
+	require.Contains(t, out.String(), "Forwarding paused")
+	require.Contains(t, out.String(), "buffering up to 10")
+	require.Contains(t, out.String(), "3 buffered events forwarded")
+}
+
+func TestFormatResponseBody(t *testing.T) {
+	indent := "                         "
+
+	require.Equal(t, indent+"{\n"+indent+"  \"error\": \"bad\"\n"+indent+"}", formatResponseBody([]byte(`{"error":"bad"}`), false))
+	require.Equal(t, indent+"not found", formatResponseBody([]byte("not found\n"), false))
+	require.Equal(t, indent+"{\"error\":... (truncated)", formatResponseBody([]byte(`{"error":`), true))
+	require.Equal(t, indent+"(binary body, 2 bytes)", formatResponseBody([]byte{0xff, 0xfe}, false))
+	require.Equal(t, indent+"(empty body)", formatResponseBody([]byte{}, false))
+}
+
+func TestJSONLinesVisitor(t *testing.T) {
+	var out bytes.Buffer
+	visitor := createJSONLinesVisitor(&out)
+
+	require.NoError(t, websocket.StateElement{State: websocket.Loading}.Accept(visitor))
+	require.NoError(t, websocket.DataElement{Data: proxy.StripeEvent{ID: "evt_123"}}.Accept(visitor))
+	require.NoError(t, websocket.DataElement{Data: proxy.LifecycleEvent{Type: "forward_result", EventID: "evt_123", StatusCode: 200}}.Accept(visitor))
+	require.NoError(t, websocket.ErrorElement{Error: proxy.FailedToPostError{Err: errors.New("refused")}}.Accept(visitor))
+	require.Error(t, websocket.ErrorElement{Error: errors.New("session expired")}.Accept(visitor))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"type":"forward_result"`)
+	require.Contains(t, lines[0], `"status_code":200`)
+	require.Contains(t, lines[1], `"type":"error"`)
+	require.Contains(t, lines[1], `"error":"session expired"`)
+}
+
+func TestCompleteEventTypes(t *testing.T) {
+	require.Equal(t, []string{"charge.dispute.closed", "charge.dispute.created", "charge.dispute.funds_reinstated", "charge.dispute.funds_withdrawn", "charge.dispute.updated"}, completeEventTypes("charge.dispute."))
+	require.Equal(t, []string{"invoice.paid,charge.captured"}, completeEventTypes("invoice.paid,charge.capt"))
+	require.Contains(t, completeEventTypes("customer.subscription.*"), "customer.subscription.updated")
+	require.Empty(t, completeEventTypes("nothing"))
+}
+
+func TestSkipVerifyFlag(t *testing.T) {
+	parse := func(args ...string) (*listenCmd, error) {
+		lc := newListenCmd()
+		lc.cmd.RunE = func(*cobra.Command, []string) error { return nil }
+		lc.cmd.SetArgs(args)
+		return lc, lc.cmd.Execute()
+	}
+
+	lc, err := parse()
+	require.NoError(t, err)
+	require.Equal(t, skipVerifyValue{}, lc.skipVerify)
+
+	lc, err = parse("--skip-verify")
+	require.NoError(t, err)
+	require.Equal(t, skipVerifyValue{all: true}, lc.skipVerify)
+
+	lc, err = parse("--skip-verify", "localhost:8443")
+	require.NoError(t, err)
+	require.Equal(t, skipVerifyValue{hosts: []string{"localhost:8443"}}, lc.skipVerify)
+
+	lc, err = parse("--skip-verify=localhost:8443,dev.internal")
+	require.NoError(t, err)
+	require.Equal(t, skipVerifyValue{hosts: []string{"localhost:8443", "dev.internal"}}, lc.skipVerify)
+
+	_, err = parse("--skip-verify=https://localhost:8443/webhooks")
+	require.Error(t, err)
+
+	_, err = parse("localhost:8443")
+	require.Error(t, err)
+}
+
+func TestTerminalRestorer(t *testing.T) {
+	restored := 0
+	terminal := &terminalRestorer{}
+
+	// nothing to restore yet
+	terminal.restore()
+
+	terminal.set(func() { restored++ })
+	terminal.restore()
+	terminal.restore()
+	require.Equal(t, 1, restored)
+}
+
+func TestCreateVisitorReturnsErrors(t *testing.T) {
+	visitor := createVisitor(log.New(), false)
+
+	err := websocket.ErrorElement{Error: errors.New("Authorization failed")}.Accept(visitor)
+	require.EqualError(t, err, "Authorization failed")
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	logTailing "github.com/stripe/stripe-cli/pkg/logtailing"
+)
+
+// errorBodyPrinter prints the errors of --show-error-body once they are
+// fetched, for the logs received meanwhile to be printed without waiting
+type errorBodyPrinter struct {
+	fetcher *logTailing.ErrorBodyFetcher
+	wg      sync.WaitGroup
+}
+
+func newErrorBodyPrinter(fetcher *logTailing.ErrorBodyFetcher) *errorBodyPrinter {
+	return &errorBodyPrinter{fetcher: fetcher}
+}
+
+// print fetches the error of the request in the background and prints it
+// with its request ID, since other logs may have been printed after its own
+func (p *errorBodyPrinter) print(ctx context.Context, requestID string) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		fetchCtx, cancel := context.WithTimeout(ctx, errorBodyFetchTimeout)
+		defer cancel()
+
+		// The log is left as is when its error can't be fetched
+		if fetched, ok := p.fetcher.Fetch(fetchCtx, requestID); ok {
+			color := ansi.Color(os.Stdout)
+			fmt.Printf("    %s %s\n", color.Faint("["+requestID+"]"), color.Red(fetched.Describe()))
+		}
+	}()
+}
+
+// wait waits for the errors being fetched to be printed
+func (p *errorBodyPrinter) wait() {
+	p.wg.Wait()
+}
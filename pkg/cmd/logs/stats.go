@@ -0,0 +1,108 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	logTailing "github.com/stripe/stripe-cli/pkg/logtailing"
+)
+
+const (
+	// statsRedrawInterval is how often --stats is redrawn in a terminal
+	statsRedrawInterval = time.Second
+	// statsSnapshotInterval is how often --stats is printed otherwise
+	statsSnapshotInterval = 30 * time.Second
+)
+
+// statsPrinter prints the stats of --stats periodically, in place in a
+// terminal. The other messages go through it so that they aren't redrawn over.
+type statsPrinter struct {
+	stats    *logTailing.Stats
+	terminal bool
+
+	mu sync.Mutex
+	// lines is the number of lines of the stats last drawn in place
+	lines     int
+	startOnce sync.Once
+	done      chan struct{}
+}
+
+func newStatsPrinter() *statsPrinter {
+	return &statsPrinter{
+		stats:    logTailing.NewStats(),
+		terminal: ansi.IsTerminal(os.Stdout),
+		done:     make(chan struct{}),
+	}
+}
+
+// start prints the stats until the context is done or the printer stopped
+func (p *statsPrinter) start(ctx context.Context) {
+	p.startOnce.Do(func() {
+		interval := statsSnapshotInterval
+		if p.terminal {
+			interval = statsRedrawInterval
+		}
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			p.draw("")
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-p.done:
+					return
+				case <-ticker.C:
+					p.draw("")
+				}
+			}
+		}()
+	})
+}
+
+func (p *statsPrinter) add(payload logTailing.EventPayload) {
+	p.stats.Add(payload)
+}
+
+// message prints the message under the stats, which are then drawn after it
+func (p *statsPrinter) message(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Print(ansi.ClearLines(p.lines))
+	fmt.Println(msg)
+	p.lines = 0
+}
+
+func (p *statsPrinter) draw(title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lines := p.stats.Snapshot().Lines()
+	if title != "" {
+		lines = append([]string{title}, lines...)
+	}
+
+	if p.terminal {
+		fmt.Print(ansi.ClearLines(p.lines))
+		p.lines = len(lines)
+	} else {
+		// The snapshots are printed one after another
+		lines = append(lines, "")
+	}
+
+	fmt.Println(strings.Join(lines, "\n"))
+}
+
+// stop prints the stats a last time
+func (p *statsPrinter) stop() {
+	close(p.done)
+	p.draw(ansi.Bold("Summary"))
+}
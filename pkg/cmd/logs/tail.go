@@ -1,11 +1,18 @@
 package logs
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -19,6 +26,7 @@ import (
 	"github.com/stripe/stripe-cli/pkg/config"
 	"github.com/stripe/stripe-cli/pkg/logtailing"
 	logTailing "github.com/stripe/stripe-cli/pkg/logtailing"
+	"github.com/stripe/stripe-cli/pkg/stripe"
 	"github.com/stripe/stripe-cli/pkg/validators"
 	"github.com/stripe/stripe-cli/pkg/version"
 	"github.com/stripe/stripe-cli/pkg/websocket"
@@ -26,14 +34,36 @@ import (
 
 const outputFormatJSON = "JSON"
 
+// errorBodyFetchInterval is the least time between the fetches of the errors
+// of --show-error-body, and errorBodyFetchTimeout the most a fetch waits
+const (
+	errorBodyFetchInterval = 200 * time.Millisecond
+	errorBodyFetchTimeout  = 5 * time.Second
+)
+
+// idempotencyKeyHintDelay is how long --filter-idempotency-key matches no log
+// before a hint is printed
+const idempotencyKeyHintDelay = 60 * time.Second
+
 // TailCmd wraps the configuration for the tail command
 type TailCmd struct {
-	apiBaseURL string
-	cfg        *config.Config
-	Cmd        *cobra.Command
-	format     string
-	LogFilters *logTailing.LogFilters
-	noWSS      bool
+	account        string
+	apiBaseURL     string
+	cfg            *config.Config
+	Cmd            *cobra.Command
+	exitAfter      time.Duration
+	format         string
+	grep           string
+	grepInvert     bool
+	grepRaw        bool
+	LogFilters     *logTailing.LogFilters
+	logFile        string
+	logFileMaxSize int64
+	maxEvents      int
+	noWSS          bool
+	requestID      string
+	showErrorBody  bool
+	stats          bool
 }
 
 // NewTailCmd creates and initializes the tail command for the logs package
@@ -52,7 +82,14 @@ Log tailing allows you to filter data similarly to the Stripe Dashboard; filter
 HTTP methods, IP addresses, paths, response status, and more.`,
 		Example: `stripe logs tail
   stripe logs tail --filter-http-methods GET
-  stripe logs tail --filter-status-code-type 4XX`,
+  stripe logs tail --filter-status-code-type 4XX
+  stripe logs tail --filter-status-code-type 4XX,5XX --exclude-status-code 402
+  stripe logs tail --exclude-request-path /v1/balance
+  stripe logs tail --filter-request-path /v1/payment_intents --filter-http-method POST
+  stripe logs tail --grep 'cus_[0-9A-Za-z]+'
+  stripe logs tail --stats
+  stripe logs tail --request-id req_123
+  stripe logs tail --exit-after 2m --log-file api.log`,
 		RunE: tailCmd.runTailCmd,
 	}
 
@@ -62,9 +99,34 @@ HTTP methods, IP addresses, paths, response status, and more.`,
 		"",
 		`Specifies the output format of request logs
 Acceptable values:
-	'JSON' - Output logs in JSON format`,
+	'JSON' - Output each log as a line of JSON, without colors, like
+	         {"created":1615734566,"livemode":false,"account":"acct_123","method":"POST",
+	         "path":"/v1/charges","status":402,"request_id":"req_123",
+	         "api_version":"2020-08-27","idempotency_key":"retry-123",
+	         "source":"API","error_type":"card_error",
+	         "error_code":"card_declined"}
+	         account, api_version, idempotency_key, source, error_type
+	         and error_code are omitted when empty. The logs of the requests made while
+	         reconnecting are printed once reconnected, with "backfilled":true.
+	         The status messages are written to stderr.`,
 	)
 
+	tailCmd.Cmd.Flags().StringVar(&tailCmd.logFile, "log-file", "", "Also write the request logs to this file, in the JSON format of --format json whatever the format printed")
+	tailCmd.Cmd.Flags().Int64Var(&tailCmd.logFileMaxSize, "log-file-max-size", 10, "The size in MB past which --log-file is rotated to <file>.1, keeping 5 rotated files")
+
+	tailCmd.Cmd.Flags().DurationVar(&tailCmd.exitAfter, "exit-after", 0, "Stop tailing after this long (e.g. 2m), exiting successfully")
+	tailCmd.Cmd.Flags().IntVar(&tailCmd.maxEvents, "max-events", 0, "Stop tailing after printing this many request logs, exiting successfully")
+
+	tailCmd.Cmd.Flags().StringVar(&tailCmd.account, "account", "", "Tail the request logs of this connected account (e.g. acct_123) instead of the platform's, like --stripe-account")
+	tailCmd.Cmd.Flags().StringVar(&tailCmd.requestID, "request-id", "", "Print the log of this request (e.g. req_123), then tail its retries, which share its idempotency key")
+	tailCmd.Cmd.Flags().BoolVar(&tailCmd.showErrorBody, "show-error-body", false, "Fetch the error the failed requests responded with and print it along with their request ID once fetched")
+
+	tailCmd.Cmd.Flags().BoolVar(&tailCmd.stats, "stats", false, "Print live statistics of the request logs, like the requests per minute, status codes and top paths, instead of the logs")
+
+	tailCmd.Cmd.Flags().StringVar(&tailCmd.grep, "grep", "", "Only print the request logs whose line matches this regular expression, in RE2 syntax (e.g. cus_123)")
+	tailCmd.Cmd.Flags().BoolVar(&tailCmd.grepInvert, "grep-invert", false, "Only print the request logs whose line doesn't match --grep")
+	tailCmd.Cmd.Flags().BoolVar(&tailCmd.grepRaw, "grep-raw", false, "Match --grep against the JSON payload of the request logs instead of their line")
+
 	// Log filters
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterAccount,
@@ -76,6 +138,8 @@ Acceptable values:
 	'connect_out' - Outgoing connect requests
 	'self'        - Non-connect requests`,
 	)
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterAPIVersion, "filter-api-version", []string{}, "Filter request logs by the API version they were made with (e.g. 2020-08-27)")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterIdempotencyKey, "filter-idempotency-key", []string{}, "Filter request logs by idempotency key, to follow the retries of a request")
 	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterIPAddress, "filter-ip-address", []string{}, "Filter request logs by ip address")
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterHTTPMethod,
@@ -87,7 +151,8 @@ Acceptable values:
 	'POST'   - HTTP post requests
 	'DELETE' - HTTP delete requests`,
 	)
-	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterRequestPath, "filter-request-path", []string{}, "Filter request logs by request path")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterRequestPath, "filter-request-path", []string{}, "Filter request logs by request path, matching the paths starting with it (e.g. /v1/payment_intents)")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.ExcludeRequestPath, "exclude-request-path", []string{}, "Filter out the request logs of the paths starting with this path (e.g. /v1/balance), or matching it if it is a glob (e.g. /v1/customers/*/sources)")
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterRequestStatus,
 		"filter-request-status",
@@ -101,22 +166,24 @@ Acceptable values:
 		&tailCmd.LogFilters.FilterSource,
 		"filter-source",
 		[]string{},
-		`Filter request logs by source
+		`Filter request logs by source (default: every source)
 Acceptable values:
 	'API'       - Requests that came through the Stripe API
-	'DASHBOARD' - Requests that came through the Stripe Dashboard`,
+	'DASHBOARD' - Requests that came through the Stripe Dashboard
+	'CLI'       - Requests that came through the Stripe CLI`,
 	)
-	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterStatusCode, "filter-status-code", []string{}, "Filter request logs by status code")
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.FilterStatusCode, "filter-status-code", []string{}, "Filter request logs by status code (e.g. 402,429), matching the logs with either these codes or the --filter-status-code-type")
 	tailCmd.Cmd.Flags().StringSliceVar(
 		&tailCmd.LogFilters.FilterStatusCodeType,
 		"filter-status-code-type",
 		[]string{},
-		`Filter request logs by status code type
+		`Filter request logs by status code type (e.g. 4XX,5XX)
 Acceptable values:
 	'2XX' - All 2XX status codes
 	'4XX' - All 4XX status codes
 	'5XX' - All 5XX status codes`,
 	)
+	tailCmd.Cmd.Flags().StringSliceVar(&tailCmd.LogFilters.ExcludeStatusCode, "exclude-status-code", []string{}, "Filter out the request logs with these status codes (e.g. 402), even of the --filter-status-code-type")
 
 	// Hidden configuration flags, useful for dev/debugging
 	tailCmd.Cmd.Flags().StringVar(&tailCmd.apiBaseURL, "api-base", "", "Sets the API base URL")
@@ -154,6 +221,14 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var grep *logTailing.Grep
+	if tailCmd.grep != "" {
+		grep, err = logTailing.NewGrep(tailCmd.grep, tailCmd.grepInvert, tailCmd.grepRaw)
+		if err != nil {
+			return fmt.Errorf("--grep: %w", err)
+		}
+	}
+
 	deviceName, err := tailCmd.cfg.Profile.GetDeviceName()
 	if err != nil {
 		return err
@@ -168,18 +243,55 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 
 	logger := log.StandardLogger()
 
-	logtailingVisitor := createVisitor(logger, tailCmd.format)
-
 	logtailingOutCh := make(chan websocket.IElement)
 
+	// The output of JSON only has the logs
+	out := os.Stdout
+	if strings.ToUpper(tailCmd.format) == outputFormatJSON {
+		out = os.Stderr
+	}
+
+	if tailCmd.account != "" {
+		fmt.Fprintf(out, "Tailing the request logs of the connected account %s\n", tailCmd.account)
+	}
+
+	var requestLog *websocket.DataElement
+	if tailCmd.requestID != "" {
+		requestLog, err = tailCmd.followRequest(cmd.Context(), key, out)
+		if err != nil {
+			return err
+		}
+	}
+	if filters := tailCmd.LogFilters.Describe(); filters != "" {
+		fmt.Fprintf(out, "Filtering request logs by %s\n", filters)
+	}
+	for _, warning := range tailCmd.LogFilters.RequestPathWarnings() {
+		fmt.Fprintf(out, "%s %s\n", ansi.Color(out).Yellow("Warning"), warning)
+	}
+
+	var logFile *logTailing.LogFile
+	if tailCmd.logFile != "" {
+		logFile, err = logTailing.OpenLogFile(tailCmd.logFile, tailCmd.logFileMaxSize<<20)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+
+		fmt.Fprintf(out, "Writing request logs to %s\n", logFile.Path())
+	}
+
 	tailer := logTailing.New(&logTailing.Config{
-		APIBaseURL: tailCmd.apiBaseURL,
-		DeviceName: deviceName,
-		Filters:    tailCmd.LogFilters,
-		Key:        key,
-		Log:        logger,
-		NoWSS:      tailCmd.noWSS,
-		OutCh:      logtailingOutCh,
+		APIBaseURL:    tailCmd.apiBaseURL,
+		DeviceName:    deviceName,
+		ExitAfter:     tailCmd.exitAfter,
+		Filters:       tailCmd.LogFilters,
+		Key:           key,
+		Log:           logger,
+		LogFile:       logFile,
+		MaxEvents:     tailCmd.maxEvents,
+		NoWSS:         tailCmd.noWSS,
+		OutCh:         logtailingOutCh,
+		StripeAccount: tailCmd.account,
 	})
 
 	ctx := withSIGTERMCancel(cmd.Context(), func() {
@@ -188,6 +300,30 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 		}).Debug("Ctrl+C received, cleaning up...")
 	})
 
+	var errorBodies *errorBodyPrinter
+	if tailCmd.showErrorBody {
+		fetcher, err := tailCmd.newErrorBodyFetcher(key)
+		if err != nil {
+			return err
+		}
+		errorBodies = newErrorBodyPrinter(fetcher)
+	}
+
+	var stats *statsPrinter
+	if tailCmd.stats {
+		stats = newStatsPrinter()
+	}
+
+	logtailingVisitor := createVisitor(ctx, logger, tailCmd.format, tailCmd.LogFilters, grep, errorBodies, stats)
+
+	// The request followed is printed before its retries
+	if requestLog != nil {
+		if err := logtailingVisitor.VisitData(*requestLog); err != nil {
+			return err
+		}
+	}
+
+	started := time.Now()
 	go tailer.Run(ctx)
 
 	for el := range logtailingOutCh {
@@ -197,20 +333,113 @@ func (tailCmd *TailCmd) runTailCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// The errors still being fetched are printed before anything else
+	if errorBodies != nil {
+		errorBodies.wait()
+	}
+
+	// The summary is printed once the tail is done, like on Ctrl+C
+	if stats != nil {
+		stats.stop()
+	}
+
+	// What was excluded is reported so that it isn't mistaken for missing
+	for _, excluded := range tailer.ExcludedRequestPaths() {
+		fmt.Fprintf(out, "Excluded %d request logs of %s\n", excluded.Count, excluded.Path)
+	}
+
+	// The log file is flushed once returned
+	if tailCmd.exitAfter > 0 || tailCmd.maxEvents > 0 {
+		count := tailer.EventCount()
+		elapsed := time.Since(started)
+
+		reason := ""
+		switch {
+		case tailCmd.maxEvents > 0 && count >= tailCmd.maxEvents:
+			reason = " (--max-events)"
+		case tailCmd.exitAfter > 0 && elapsed >= tailCmd.exitAfter:
+			reason = " (--exit-after)"
+		}
+		fmt.Fprintf(out, "Stopped after %d request logs in %s%s\n", count, elapsed.Round(time.Second), reason)
+	}
+
 	return nil
 }
 
 func (tailCmd *TailCmd) validateArgs() error {
+	if tailCmd.format != "" && strings.ToUpper(tailCmd.format) != outputFormatJSON {
+		return fmt.Errorf("invalid format, must be 'json', received %s", tailCmd.format)
+	}
+
+	if tailCmd.requestID != "" {
+		if err := validators.RequestID(tailCmd.requestID); err != nil {
+			return fmt.Errorf("--request-id: %w", err)
+		}
+
+		if len(tailCmd.LogFilters.FilterIdempotencyKey) > 0 {
+			return fmt.Errorf("--request-id can't be used with --filter-idempotency-key, as it filters by the idempotency key of the request")
+		}
+	}
+
+	if tailCmd.stats && tailCmd.format != "" {
+		return fmt.Errorf("--stats can't be used with --format")
+	}
+
+	// The global --stripe-account is tailed like --account
+	if stripeAccount := tailCmd.cfg.Profile.StripeAccount; stripeAccount != "" {
+		if tailCmd.account != "" && tailCmd.account != stripeAccount {
+			return fmt.Errorf("--account %s and --stripe-account %s are different accounts, set only one of them", tailCmd.account, stripeAccount)
+		}
+		tailCmd.account = stripeAccount
+	}
+
+	if tailCmd.account != "" {
+		if err := validators.AccountID(tailCmd.account); err != nil {
+			return fmt.Errorf("--account: %w", err)
+		}
+	}
+
+	if tailCmd.exitAfter < 0 {
+		return fmt.Errorf("--exit-after can't be negative, got %s", tailCmd.exitAfter)
+	}
+
+	if tailCmd.maxEvents < 0 {
+		return fmt.Errorf("--max-events can't be negative, got %d", tailCmd.maxEvents)
+	}
+
+	if tailCmd.grep == "" && (tailCmd.grepInvert || tailCmd.grepRaw) {
+		return fmt.Errorf("--grep-invert and --grep-raw require --grep")
+	}
+
+	if tailCmd.logFile != "" && tailCmd.logFileMaxSize <= 0 {
+		return fmt.Errorf("--log-file-max-size must be positive, got %d", tailCmd.logFileMaxSize)
+	}
+
 	err := validators.CallNonEmptyArray(validators.Account, tailCmd.LogFilters.FilterAccount)
 	if err != nil {
 		return err
 	}
 
+	err = validators.CallNonEmptyArray(validators.APIVersion, tailCmd.LogFilters.FilterAPIVersion)
+	if err != nil {
+		return fmt.Errorf("--filter-api-version: %w", err)
+	}
+
 	err = validators.CallNonEmptyArray(validators.HTTPMethod, tailCmd.LogFilters.FilterHTTPMethod)
 	if err != nil {
 		return err
 	}
 
+	err = validators.CallNonEmptyArray(validators.RequestPath, tailCmd.LogFilters.FilterRequestPath)
+	if err != nil {
+		return err
+	}
+
+	err = validators.CallNonEmptyArray(validators.RequestPathPattern, tailCmd.LogFilters.ExcludeRequestPath)
+	if err != nil {
+		return fmt.Errorf("--exclude-request-path: %w", err)
+	}
+
 	err = validators.CallNonEmptyArray(validators.StatusCode, tailCmd.LogFilters.FilterStatusCode)
 	if err != nil {
 		return err
@@ -221,6 +450,16 @@ func (tailCmd *TailCmd) validateArgs() error {
 		return err
 	}
 
+	err = validators.CallNonEmptyArray(validators.StatusCode, tailCmd.LogFilters.ExcludeStatusCode)
+	if err != nil {
+		return fmt.Errorf("--exclude-status-code: %w", err)
+	}
+
+	err = tailCmd.LogFilters.CheckStatusCodes()
+	if err != nil {
+		return err
+	}
+
 	err = validators.CallNonEmptyArray(validators.RequestSource, tailCmd.LogFilters.FilterSource)
 	if err != nil {
 		return err
@@ -235,6 +474,10 @@ func (tailCmd *TailCmd) validateArgs() error {
 }
 
 func (tailCmd *TailCmd) convertArgs() error {
+	for i, source := range tailCmd.LogFilters.FilterSource {
+		tailCmd.LogFilters.FilterSource[i] = strings.ToUpper(source)
+	}
+
 	// The backend expects to receive the status code type as a string representing the start of the range (e.g., '200')
 	if len(tailCmd.LogFilters.FilterStatusCodeType) > 0 {
 		for i, code := range tailCmd.LogFilters.FilterStatusCodeType {
@@ -245,8 +488,110 @@ func (tailCmd *TailCmd) convertArgs() error {
 	return nil
 }
 
-func createVisitor(logger *log.Logger, format string) *websocket.Visitor {
+// newErrorBodyFetcher creates the fetcher of the errors of --show-error-body
+func (tailCmd *TailCmd) newErrorBodyFetcher(key string) (*logTailing.ErrorBodyFetcher, error) {
+	client, err := tailCmd.newAPIClient(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return logTailing.NewErrorBodyFetcher(client, tailCmd.account, errorBodyFetchInterval), nil
+}
+
+func (tailCmd *TailCmd) newAPIClient(key string) (*stripe.Client, error) {
+	baseURL := tailCmd.apiBaseURL
+	if baseURL == "" {
+		baseURL = stripe.DefaultAPIBaseURL
+	}
+
+	apiURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid API base URL %s: %v", baseURL, err)
+	}
+
+	return &stripe.Client{BaseURL: apiURL, APIKey: key}, nil
+}
+
+// followRequest looks up the log of --request-id, and filters the tail by its
+// idempotency key to follow its retries, or by its ID if it has none or isn't
+// found yet. It returns the log to print, if found.
+func (tailCmd *TailCmd) followRequest(ctx context.Context, key string, out io.Writer) (*websocket.DataElement, error) {
+	client, err := tailCmd.newAPIClient(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var configure func(*http.Request)
+	if tailCmd.account != "" {
+		configure = func(req *http.Request) {
+			req.Header.Set("Stripe-Account", tailCmd.account)
+		}
+	}
+
+	resp, err := client.PerformRequest(ctx, http.MethodGet, "/v1/request_logs/"+tailCmd.requestID, "", configure)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to look up the request %s: %v", tailCmd.requestID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to look up the request %s: %v", tailCmd.requestID, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		mode, otherMode := "test mode", "live mode"
+		if strings.Contains(key, "_live_") {
+			mode, otherMode = otherMode, mode
+		}
+		fmt.Fprintf(out, "The request %s wasn't found in %s. If it was made in %s, it can't be found with this key. Waiting for it in case it was just made...\n", tailCmd.requestID, mode, otherMode)
+
+		tailCmd.LogFilters.FilterRequestID = []string{tailCmd.requestID}
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to look up the request %s: status=%d, body=%s", tailCmd.requestID, resp.StatusCode, body)
+	}
+
+	var payload logTailing.EventPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("Failed to look up the request %s: %v", tailCmd.requestID, err)
+	}
+	if payload.RequestID == "" {
+		payload.RequestID = tailCmd.requestID
+	}
+
+	if payload.IdempotencyKey == "" {
+		fmt.Fprintf(out, "The request %s has no idempotency key, so it has no retries to follow\n", tailCmd.requestID)
+		tailCmd.LogFilters.FilterRequestID = []string{tailCmd.requestID}
+	} else {
+		fmt.Fprintf(out, "Following the retries of the request %s, which share its idempotency key %s\n", tailCmd.requestID, payload.IdempotencyKey)
+		tailCmd.LogFilters.FilterIdempotencyKey = []string{payload.IdempotencyKey}
+	}
+
+	return &websocket.DataElement{Data: payload, Marshaled: string(body)}, nil
+}
+
+func createVisitor(ctx context.Context, logger *log.Logger, format string, filters *logTailing.LogFilters, grep *logTailing.Grep, errorBodies *errorBodyPrinter, stats *statsPrinter) *websocket.Visitor {
 	var s *spinner.Spinner
+	isJSON := strings.ToUpper(format) == outputFormatJSON
+
+	// A key that matches no log for a while is likely from another mode, as
+	// the tail is in test mode
+	var received int32
+	var hintOnce sync.Once
+	startIdempotencyKeyHint := func() {
+		if len(filters.FilterIdempotencyKey) == 0 {
+			return
+		}
+		hintOnce.Do(func() {
+			time.AfterFunc(idempotencyKeyHintDelay, func() {
+				if atomic.LoadInt32(&received) == 0 && ctx.Err() == nil {
+					fmt.Fprintf(logger.Out, "No request logs with the idempotency key %s yet. If the request was made in live mode, note that stripe logs tail only shows test mode requests.\n", strings.Join(filters.FilterIdempotencyKey, " or "))
+				}
+			})
+		})
+	}
 
 	return &websocket.Visitor{
 		VisitError: func(ee websocket.ErrorElement) error {
@@ -254,11 +599,46 @@ func createVisitor(logger *log.Logger, format string) *websocket.Visitor {
 			return ee.Error
 		},
 		VisitWarning: func(we websocket.WarningElement) error {
+			if stats != nil {
+				stats.message(fmt.Sprintf("Warning %s", we.Warning))
+				return nil
+			}
+			if isJSON {
+				fmt.Fprintf(logger.Out, "Warning %s\n", we.Warning)
+				return nil
+			}
 			color := ansi.Color(os.Stdout)
 			fmt.Printf("%s %s\n", color.Yellow("Warning"), we.Warning)
 			return nil
 		},
 		VisitStatus: func(se websocket.StateElement) error {
+			if se.State == websocket.Ready {
+				startIdempotencyKeyHint()
+			}
+
+			// The stats are drawn once ready, without a spinner
+			if stats != nil {
+				switch se.State {
+				case websocket.Reconnecting:
+					stats.message("Session expired, reconnecting...")
+				case websocket.Ready:
+					stats.message("Ready! You're now waiting to receive API request logs (^C to quit)")
+					stats.start(ctx)
+				}
+				return nil
+			}
+
+			// The output of JSON has no spinner, to be piped to other tools
+			if isJSON {
+				switch se.State {
+				case websocket.Reconnecting:
+					fmt.Fprintln(logger.Out, "Session expired, reconnecting...")
+				case websocket.Ready:
+					fmt.Fprintln(logger.Out, "Ready! You're now waiting to receive API request logs (^C to quit)")
+				}
+				return nil
+			}
+
 			switch se.State {
 			case websocket.Loading:
 				s = ansi.StartNewSpinner("Getting ready...", logger.Out)
@@ -276,9 +656,17 @@ func createVisitor(logger *log.Logger, format string) *websocket.Visitor {
 			if !ok {
 				return fmt.Errorf("VisitData received unexpected type for DataElement, got %T expected %T", de, logtailing.EventPayload{})
 			}
+			atomic.AddInt32(&received, 1)
 
-			if strings.ToUpper(format) == outputFormatJSON {
-				fmt.Println(ansi.ColorizeJSON(de.Marshaled, false, os.Stdout))
+			if isJSON {
+				line, err := logtailing.FormatJSON(log)
+				if err != nil {
+					return err
+				}
+				if !grep.Match(line, de.Marshaled) {
+					return nil
+				}
+				fmt.Println(line)
 				return nil
 			}
 
@@ -295,7 +683,32 @@ func createVisitor(logger *log.Logger, format string) *websocket.Visitor {
 			localTime := time.Unix(int64(log.CreatedAt), 0).Format(exampleLayout)
 
 			color := ansi.Color(os.Stdout)
-			outputStr := fmt.Sprintf("%s [%d] %s %s [%s]", color.Faint(localTime), coloredStatus, log.Method, log.URL, requestLink)
+			outputStr := fmt.Sprintf("%s%s%s [%d] %s %s [%s]", color.Faint(localTime), accountTag(log.Account), sourceTag(log.Source), coloredStatus, log.Method, log.URL, requestLink)
+			// The replays of a request are told apart by their key
+			if len(filters.FilterIdempotencyKey) > 0 && log.IdempotencyKey != "" {
+				outputStr += fmt.Sprintf(" [idempotency key: %s]", log.IdempotencyKey)
+			}
+			// The logs missed while reconnecting are printed late
+			if log.Backfilled {
+				outputStr += color.Faint(" [backfilled]").String()
+			}
+
+			plainStr := ansi.Strip(outputStr)
+			if !grep.Match(plainStr, de.Marshaled) {
+				return nil
+			}
+			// The matches are highlighted in the line without its other colors
+			highlighted := grep.Highlight(plainStr, func(match string) string {
+				return color.Bold(color.Red(match)).String()
+			})
+			if highlighted != plainStr {
+				outputStr = highlighted
+			}
+
+			if stats != nil {
+				stats.add(log)
+				return nil
+			}
 			fmt.Println(outputStr)
 
 			errorValues := reflect.ValueOf(&log.Error).Elem()
@@ -307,11 +720,34 @@ func createVisitor(logger *log.Logger, format string) *websocket.Visitor {
 					fmt.Printf("%s: %s\n", errType.Field(i).Name, fieldValue)
 				}
 			}
+
+			if errorBodies != nil && (log.Status < 200 || log.Status >= 300) {
+				errorBodies.print(ctx, log.RequestID)
+			}
 			return nil
 		},
 	}
 }
 
+// accountTag returns the tag of the connected account the request was made
+// on, like [acct_123]
+func accountTag(account string) string {
+	if account == "" {
+		return ""
+	}
+
+	return " [" + account + "]"
+}
+
+// sourceTag returns the tag of where the request came from, like [dashboard]
+func sourceTag(source string) string {
+	if source == "" {
+		return ""
+	}
+
+	return " [" + strings.ToLower(source) + "]"
+}
+
 func urlForRequestID(payload *logtailing.EventPayload) string {
 	maybeTest := ""
 	if !payload.Livemode {
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// v2Paths are the paths of the /v2 API by method, which has no generated
+// commands to complete them from
+var v2Paths = map[string][]string{
+	http.MethodGet: {
+		"/v2/core/events",
+		"/v2/core/events/{id}",
+		"/v2/core/event_destinations",
+		"/v2/core/event_destinations/{id}",
+	},
+	http.MethodPost: {
+		"/v2/billing/meter_event_adjustments",
+		"/v2/billing/meter_event_session",
+		"/v2/billing/meter_events",
+		"/v2/core/event_destinations",
+		"/v2/core/event_destinations/{id}",
+		"/v2/core/event_destinations/{id}/disable",
+		"/v2/core/event_destinations/{id}/enable",
+		"/v2/core/event_destinations/{id}/ping",
+	},
+	http.MethodDelete: {
+		"/v2/core/event_destinations/{id}",
+	},
+}
+
+// completePaths returns the completion of the paths of the API for the
+// method, from the generated operations and the /v2 paths. The paths are
+// completed up to their first parameter, like /v1/customers/ for
+// /v1/customers/{customer}, for the ID to be typed.
+func completePaths(method string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return matchingPaths(operationPaths(cmd.Root(), method), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// operationPaths returns the paths of the operations for the method under
+// the command, and the /v2 paths
+func operationPaths(cmd *cobra.Command, method string) []string {
+	paths := append([]string{}, v2Paths[method]...)
+
+	var walk func(*cobra.Command)
+	walk = func(c *cobra.Command) {
+		if path, ok := c.Annotations["path"]; ok && c.Annotations["method"] == method {
+			paths = append(paths, path)
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(cmd)
+
+	return paths
+}
+
+func matchingPaths(paths []string, toComplete string) []string {
+	seen := make(map[string]bool)
+	matches := []string{}
+	for _, path := range paths {
+		if i := strings.Index(path, "{"); i >= 0 {
+			path = path[:i]
+		}
+		if seen[path] || !strings.HasPrefix(path, toComplete) {
+			continue
+		}
+		seen[path] = true
+		matches = append(matches, path)
+	}
+	sort.Strings(matches)
+
+	return matches
+}
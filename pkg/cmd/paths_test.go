@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationPaths(t *testing.T) {
+	root := &cobra.Command{}
+	customers := &cobra.Command{Use: "customers"}
+	customers.AddCommand(
+		&cobra.Command{Use: "list", Annotations: map[string]string{"path": "/v1/customers", "method": http.MethodGet}},
+		&cobra.Command{Use: "retrieve", Annotations: map[string]string{"path": "/v1/customers/{customer}", "method": http.MethodGet}},
+		&cobra.Command{Use: "create", Annotations: map[string]string{"path": "/v1/customers", "method": http.MethodPost}},
+	)
+	root.AddCommand(customers)
+
+	paths := operationPaths(root, http.MethodGet)
+	require.Contains(t, paths, "/v1/customers")
+	require.Contains(t, paths, "/v1/customers/{customer}")
+	require.Contains(t, paths, "/v2/core/events")
+	require.NotContains(t, paths, "/v2/billing/meter_events")
+}
+
+func TestMatchingPaths(t *testing.T) {
+	paths := []string{"/v1/customers", "/v1/customers/{customer}", "/v1/charges", "/v2/core/events", "/v2/core/events/{id}"}
+
+	require.Equal(t, []string{"/v1/charges", "/v1/customers", "/v1/customers/", "/v2/core/events", "/v2/core/events/"}, matchingPaths(paths, ""))
+	require.Equal(t, []string{"/v1/customers", "/v1/customers/"}, matchingPaths(paths, "/v1/cu"))
+	require.Equal(t, []string{"/v2/core/events", "/v2/core/events/"}, matchingPaths(paths, "/v2"))
+	require.Equal(t, []string{}, matchingPaths(paths, "/v3"))
+}
@@ -192,7 +192,7 @@ func (pc *playbackCmd) runPlaybackCmd(cmd *cobra.Command, args []string) error {
 
 func startListenCmdLoop(mode string, address string, httpWrapper *playback.Server) {
 	lc := newListenCmd()
-	lc.forwardURL = address + "/playback/webhooks"
+	lc.forwardURLs = []string{address + "/playback/webhooks"}
 	startListenCmd := func() {
 		fmt.Println("Starting `stripe listen` to proxy webhooks to playback server...")
 		lc.runListenCmd(lc.cmd, []string{})
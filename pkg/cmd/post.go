@@ -19,9 +19,10 @@ func newPostCmd() *postCmd {
 	gc.reqs.Method = http.MethodPost
 	gc.reqs.Profile = &Config.Profile
 	gc.reqs.Cmd = &cobra.Command{
-		Use:   "post <path>",
-		Args:  validators.ExactArgs(1),
-		Short: "Make a POST request to the Stripe API",
+		Use:               "post <path>",
+		Args:              validators.ExactArgs(1),
+		ValidArgsFunction: completePaths(http.MethodPost),
+		Short:             "Make a POST request to the Stripe API",
 		Long: `Make POST requests to the Stripe API using your test mode key.
 
 The post command supports API features like idempotency keys and expand flags.
@@ -32,7 +33,18 @@ https://stripe.com/docs/api
 		Example: `stripe post /payment_intents \
     -d amount=2000 \
     -d currency=usd \
-    -d "payment_method_types[]=card"`,
+    -d "payment_method_types[]=card"
+  stripe post /v1/customers -d email=bender@example.com --auto-idempotency
+  stripe post /v1/customers -d email=bender@example.com --show-curl-only
+  stripe post /v1/checkout/sessions --json-input session.json -d mode=payment
+  cat session.json | stripe post /v1/checkout/sessions -d @-
+  stripe post /v1/files --file ./doc.pdf --purpose dispute_evidence
+  stripe post /v1/payment_intents -d amount=2000 -d currency=usd \
+    --stripe-version 2024-06-20 --preview-feature feature_beta=v3
+  stripe post /v2/billing/meter_events \
+    -d event_name=api_requests \
+    -d "payload[stripe_customer_id]=cus_G6GQwbr1dWXt9O" \
+    -d "payload[value]=25"`,
 		RunE: gc.reqs.RunRequestsCmd,
 	}
 
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	"github.com/stripe/stripe-cli/pkg/proxy"
+	"github.com/stripe/stripe-cli/pkg/validators"
+)
+
+type replayCmd struct {
+	cmd *cobra.Command
+
+	from           string
+	forwardURL     string
+	forwardHeaders []string
+	secret         string
+	events         []string
+	speed          float64
+	skipVerify     bool
+	livemode       bool
+	apiBaseURL     string
+}
+
+func newReplayCmd() *replayCmd {
+	rc := &replayCmd{}
+
+	rc.cmd = &cobra.Command{
+		Use:   "replay",
+		Args:  validators.NoArgs,
+		Short: "Replay recorded webhook events against a local endpoint",
+		Long: `The replay command re-delivers events recorded with "stripe listen --record-to"
+to your local endpoint. Each event is signed with a fresh Stripe-Signature
+header using your webhook signing secret, so your endpoint can verify it as
+usual. The command exits with a non-zero status if any delivery does not
+receive a 2xx response.`,
+		Example: `stripe replay --from events.jsonl --forward-to localhost:4242/webhook
+  stripe replay --from events.jsonl --forward-to localhost:4242/webhook \
+    --filter "invoice.*" --speed 1`,
+		RunE: rc.runReplayCmd,
+	}
+
+	rc.cmd.Flags().StringVar(&rc.from, "from", "", "The JSON Lines file of recorded events to replay")
+	rc.cmd.Flags().StringVarP(&rc.forwardURL, "forward-to", "f", "", "The URL to deliver the events to")
+	rc.cmd.Flags().StringSliceVarP(&rc.forwardHeaders, "headers", "H", []string{}, "A comma-separated list of custom headers to forward. Ex: \"Key1:Value1, Key2:Value2\"")
+	rc.cmd.Flags().StringVar(&rc.secret, "secret", "", "The webhook signing secret used to sign events (default: the secret of your `stripe listen` session)")
+	rc.cmd.Flags().StringSliceVar(&rc.events, "filter", []string{}, "A comma-separated list of event types to replay, which may contain wildcards. Ex: \"invoice.*,charge.succeeded\"")
+	rc.cmd.Flags().Float64Var(&rc.speed, "speed", 0, "Replay events respecting their original timing, sped up by this factor (default: as fast as possible)")
+	rc.cmd.Flags().BoolVar(&rc.skipVerify, "skip-verify", false, "Skip certificate verification when forwarding to HTTPS endpoints")
+	rc.cmd.Flags().BoolVar(&rc.livemode, "live", false, "Use the signing secret of a live mode session (default: test)")
+
+	rc.cmd.MarkFlagRequired("from")       // #nosec G104
+	rc.cmd.MarkFlagRequired("forward-to") // #nosec G104
+
+	// Hidden configuration flags, useful for dev/debugging
+	rc.cmd.Flags().StringVar(&rc.apiBaseURL, "api-base", "", "Sets the API base URL")
+	rc.cmd.Flags().MarkHidden("api-base") // #nosec G104
+
+	return rc
+}
+
+func (rc *replayCmd) runReplayCmd(cmd *cobra.Command, args []string) error {
+	if rc.speed < 0 {
+		return fmt.Errorf("--speed must be positive, got %v", rc.speed)
+	}
+
+	file, err := os.Open(rc.from)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	recorded, err := proxy.ReadRecordedEvents(file)
+	if err != nil {
+		return err
+	}
+
+	ctx := withSIGTERMCancel(cmd.Context(), func() {
+		log.WithFields(log.Fields{
+			"prefix": "cmd.replayCmd.runReplayCmd",
+		}).Debug("Ctrl+C received, cleaning up...")
+	})
+
+	secret := rc.secret
+	if secret == "" {
+		deviceName, err := Config.Profile.GetDeviceName()
+		if err != nil {
+			return err
+		}
+
+		key, err := Config.Profile.GetAPIKey(rc.livemode)
+		if err != nil {
+			return err
+		}
+
+		secret, err = proxy.GetSessionSecret(ctx, deviceName, key, rc.apiBaseURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	delivered := 0
+	failed, err := proxy.Replay(ctx, recorded, &proxy.ReplayConfig{
+		ForwardURL:     rc.forwardURL,
+		ForwardHeaders: rc.forwardHeaders,
+		Secret:         secret,
+		Events:         rc.events,
+		Speed:          rc.speed,
+		SkipVerify:     rc.skipVerify,
+		Log:            log.StandardLogger(),
+		OnDelivery: func(delivery proxy.ReplayDelivery) {
+			delivered++
+
+			color := ansi.Color(os.Stdout)
+			localTime := time.Now().Format(timeLayout)
+
+			if delivery.Err != nil {
+				fmt.Printf("%s  <--  [%s] %s [%s] %v\n",
+					color.Faint(localTime),
+					color.Red("ERROR"),
+					ansi.Bold(delivery.Event.Type),
+					delivery.Event.ID,
+					delivery.Err,
+				)
+				return
+			}
+
+			fmt.Printf("%s  <--  [%d] %s [%s]\n",
+				color.Faint(localTime),
+				ansi.ColorizeStatus(delivery.StatusCode),
+				ansi.Bold(delivery.Event.Type),
+				delivery.Event.ID,
+			)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d replayed events were not delivered successfully", failed, delivered)
+	}
+
+	return nil
+}
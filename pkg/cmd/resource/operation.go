@@ -85,22 +85,19 @@ func (oc *OperationCmd) runOperationCmd(cmd *cobra.Command, args []string) error
 		if displayName != "" {
 			fmt.Printf("> Account Name: %s\n", displayName)
 		}
+	}
 
-		// call the confirm command from base request
-		confirmation, err := oc.Confirm()
-		if err != nil {
-			return err
-		} else if !confirmation {
-			fmt.Println("Exiting without execution. User did not confirm the command.")
-			return nil
-		}
-
-		// if confirmation is provided, make the request
-		_, err = oc.MakeRequest(cmd.Context(), apiKey, path, &oc.Parameters, false)
-
+	// call the confirm command from base request, which asks for the
+	// deletions and the destructive requests in live mode
+	confirmation, err := oc.ConfirmRequest(apiKey, path)
+	if err != nil {
 		return err
+	} else if !confirmation {
+		fmt.Println("Exiting without execution. User did not confirm the command.")
+		return nil
 	}
-	// else
+
+	// if confirmation is provided, make the request
 	_, err = oc.MakeRequest(cmd.Context(), apiKey, path, &oc.Parameters, false)
 	return err
 }
@@ -126,8 +123,10 @@ func NewOperationCmd(parentCmd *cobra.Command, name, path, httpVerb string, prop
 		stringFlags: make(map[string]*string),
 	}
 	cmd := &cobra.Command{
-		Use:         name,
-		Annotations: make(map[string]string),
+		Use: name,
+		// The path and method complete the paths of stripe get, post and
+		// delete
+		Annotations: map[string]string{"path": path, "method": httpVerb},
 		RunE:        operationCmd.runOperationCmd,
 		Args:        validators.ExactArgs(len(urlParams)),
 	}
@@ -29,6 +29,8 @@ func TestNewOperationCmd(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, "operation", val)
 	require.Contains(t, oc.Cmd.UsageTemplate(), "<id>")
+	require.Equal(t, "/v1/bars/{id}", oc.Cmd.Annotations["path"])
+	require.Equal(t, "GET", oc.Cmd.Annotations["method"])
 }
 
 func TestRunOperationCmd(t *testing.T) {
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stripe/stripe-cli/pkg/requests"
+	"github.com/stripe/stripe-cli/pkg/validators"
+)
+
+// searchableResources are the resources of the Search API, with an example
+// query of each
+var searchableResources = []struct {
+	name    string
+	example string
+}{
+	{"charges", "amount>999 AND metadata['order_id']:'6735'"},
+	{"customers", "email:'jenny.rosen@example.com'"},
+	{"invoices", "total>1000 AND status:'open'"},
+	{"payment_intents", "status:'succeeded' AND currency:'usd'"},
+	{"prices", "active:'true' AND metadata['order_id']:'6735'"},
+	{"products", "active:'true' AND name~'shirt'"},
+	{"subscriptions", "status:'active' AND metadata['order_id']:'6735'"},
+}
+
+type searchCmd struct {
+	reqs  requests.Base
+	query string
+	page  string
+}
+
+func newSearchCmd() *searchCmd {
+	sc := &searchCmd{}
+
+	sc.reqs.Method = http.MethodGet
+	sc.reqs.Profile = &Config.Profile
+	sc.reqs.Cmd = &cobra.Command{
+		Use:       "search <resource>",
+		Args:      validators.ExactArgs(1),
+		ValidArgs: searchableResourceNames(),
+		Short:     "Search resources with the Search API",
+		Long: fmt.Sprintf(`Search the charges, customers, invoices, payment intents, prices, products or
+subscriptions of your account with a query of the Search API, like:
+
+%s
+For the syntax of the queries, see:
+https://stripe.com/docs/search#search-query-language`, searchExamples()),
+		Example: `stripe search customers --query "email:'jenny.rosen@example.com'"
+  stripe search charges --query "amount>999" --limit 50
+  stripe search payment_intents --query "status:'succeeded'" --paginate`,
+		RunE: sc.runSearchCmd,
+	}
+
+	// Before the flags of the requests, so that --query is the one of the
+	// Search API
+	sc.reqs.Cmd.Flags().StringVar(&sc.query, "query", "", "The search query, like email:'jenny.rosen@example.com' (required)")
+	sc.reqs.Cmd.Flags().StringVar(&sc.page, "page", "", "Retrieve the next page of the results. This is a cursor for pagination, the next_page of the previous one")
+	sc.reqs.InitFlags()
+
+	// The example query of the resource, to start from
+	sc.reqs.Cmd.RegisterFlagCompletionFunc("query", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 || !isSearchableResource(args[0]) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return []string{searchExample(args[0])}, cobra.ShellCompDirectiveNoFileComp
+	}) // #nosec G104
+
+	// The search results are paginated with --page
+	sc.reqs.Cmd.Flags().MarkHidden("starting-after") // #nosec G104
+	sc.reqs.Cmd.Flags().MarkHidden("ending-before")  // #nosec G104
+
+	return sc
+}
+
+func (sc *searchCmd) runSearchCmd(cmd *cobra.Command, args []string) error {
+	resource := args[0]
+	if !isSearchableResource(resource) {
+		return fmt.Errorf("%s is not a searchable resource, expected one of %s", resource, strings.Join(searchableResourceNames(), ", "))
+	}
+
+	if sc.query == "" {
+		return fmt.Errorf("--query is required, like --query \"%s\"", searchExample(resource))
+	}
+
+	apiKey, err := sc.reqs.Profile.GetAPIKey(sc.reqs.Livemode)
+	if err != nil {
+		return err
+	}
+
+	sc.reqs.Parameters.AppendData([]string{"query=" + sc.query})
+	sc.reqs.Parameters.SetPage(sc.page)
+
+	_, err = sc.reqs.MakeRequest(cmd.Context(), apiKey, fmt.Sprintf("/v1/%s/search", resource), &sc.reqs.Parameters, false)
+
+	return err
+}
+
+func searchableResourceNames() []string {
+	names := make([]string, 0, len(searchableResources))
+	for _, resource := range searchableResources {
+		names = append(names, resource.name)
+	}
+
+	return names
+}
+
+func isSearchableResource(name string) bool {
+	for _, resource := range searchableResources {
+		if resource.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func searchExample(name string) string {
+	for _, resource := range searchableResources {
+		if resource.name == name {
+			return resource.example
+		}
+	}
+
+	return ""
+}
+
+func searchExamples() string {
+	var b strings.Builder
+	for _, resource := range searchableResources {
+		fmt.Fprintf(&b, "  stripe search %s --query \"%s\"\n", resource.name, resource.example)
+	}
+
+	return b.String()
+}
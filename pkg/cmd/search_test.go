@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSearchableResource(t *testing.T) {
+	require.True(t, isSearchableResource("customers"))
+	require.True(t, isSearchableResource("payment_intents"))
+	require.False(t, isSearchableResource("refunds"))
+	require.False(t, isSearchableResource("customer"))
+}
+
+func TestSearchCmdInvalidResource(t *testing.T) {
+	sc := newSearchCmd()
+
+	err := sc.runSearchCmd(sc.reqs.Cmd, []string{"refunds"})
+	require.EqualError(t, err, "refunds is not a searchable resource, expected one of charges, customers, invoices, payment_intents, prices, products, subscriptions")
+
+	err = sc.runSearchCmd(sc.reqs.Cmd, []string{"customers"})
+	require.EqualError(t, err, "--query is required, like --query \"email:'jenny.rosen@example.com'\"")
+}
@@ -1,42 +1,102 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
 
 	"github.com/stripe/stripe-cli/pkg/ansi"
 	"github.com/stripe/stripe-cli/pkg/fixtures"
+	"github.com/stripe/stripe-cli/pkg/proxy"
 	"github.com/stripe/stripe-cli/pkg/stripe"
 	"github.com/stripe/stripe-cli/pkg/validators"
 	"github.com/stripe/stripe-cli/pkg/version"
 )
 
+// defaultTriggerStatusPort is the --status-port of `stripe listen` that
+// --wait-for-delivery polls by default
+const defaultTriggerStatusPort = 4279
+
+// triggerDeliveryPollInterval is how often --wait-for-delivery polls the
+// deliveries of `stripe listen`
+const triggerDeliveryPollInterval = 250 * time.Millisecond
+
 type triggerCmd struct {
 	cmd *cobra.Command
 
 	fs            afero.Fs
 	stripeAccount string
 	skip          []string
+	only          []string
 	override      []string
 	add           []string
 	remove        []string
+	set           []string
 	raw           string
+	idempotency   string
+	seed          int64
+	outputFile    string
+	forwardURL    string
+	parallel      int
+	failFast      bool
+	dryRun        bool
+	list          bool
+	format        string
+	count         int
+	interval      time.Duration
+	jitter        time.Duration
+	schedule      string
+	every         time.Duration
+	apiVersion    string
 	apiBaseURL    string
+	filesBaseURL  string
+
+	waitForDelivery bool
+	timeout         time.Duration
+	statusPort      int
+}
+
+// triggerResult is the outcome of triggering one of several events
+type triggerResult struct {
+	event    string
+	err      error
+	duration time.Duration
+	// skipped is set if the event was not triggered because another one
+	// failed with --fail-fast
+	skipped bool
 }
 
 func newTriggerCmd() *triggerCmd {
 	tc := &triggerCmd{}
 	tc.fs = afero.NewOsFs()
 	tc.cmd = &cobra.Command{
-		Use:       "trigger <event>",
-		Args:      validators.MaximumNArgs(1),
+		Use:       "trigger <event>...",
+		Args:      cobra.ArbitraryArgs,
 		ValidArgs: fixtures.EventNames(),
 		Short:     "Trigger test webhook events",
 		Long: fmt.Sprintf(`Trigger specific webhook events to be sent. Webhooks events created through
 the trigger command will also create all necessary side-effect events that are
 needed to create the triggered event as well as the corresponding API objects.
+Several events can be triggered at once, up to --parallel of them at a time.
 
 %s
 %s
@@ -44,20 +104,48 @@ needed to create the triggered event as well as the corresponding API objects.
 			ansi.Bold("Supported events:"),
 			fixtures.EventList(),
 		),
-		Example: `stripe trigger payment_intent.created`,
-		RunE:    tc.runTriggerCmd,
+		Example: `stripe trigger payment_intent.created
+  stripe trigger payment_intent.succeeded customer.created invoice.paid --parallel 3
+  stripe trigger customer.created --count 0 --interval 500ms --jitter 100ms
+  stripe trigger payment_intent.succeeded --schedule "*/5 * * * *"
+  stripe trigger customer.created --wait-for-delivery --timeout 30s
+  stripe trigger --list --format json
+  stripe trigger --raw ./event.json --forward-to localhost:4242/webhook \
+    --override data.object.reason=fraudulent`,
+		RunE: tc.runTriggerCmd,
 	}
 
-	tc.cmd.Flags().StringVar(&tc.stripeAccount, "stripe-account", "", "Set a header identifying the connected account")
 	tc.cmd.Flags().StringArrayVar(&tc.skip, "skip", []string{}, "Skip specific steps in the trigger")
-	tc.cmd.Flags().StringArrayVar(&tc.override, "override", []string{}, "Override params in the trigger")
+	tc.cmd.Flags().StringArrayVar(&tc.only, "only", []string{}, "Only run these steps of the trigger")
+	tc.cmd.Flags().StringArrayVar(&tc.set, "set", []string{}, "Set a variable the steps reference with ${var:<variable>}, with <variable>=value, or the output of a step that doesn't run, with <fixture_name>:path.to.field=value. Ex: price=price_123 or customer:id=cus_123")
+	tc.cmd.Flags().StringArrayVar(&tc.override, "override", []string{}, "Override params in the trigger, with <fixture_name>:path.to.field=value. Brackets index into arrays, ex: checkout_session:line_items[0][price]=price_123, and - removes the field")
 	tc.cmd.Flags().StringArrayVar(&tc.add, "add", []string{}, "Add params to the trigger")
 	tc.cmd.Flags().StringArrayVar(&tc.remove, "remove", []string{}, "Remove params from the trigger")
-	tc.cmd.Flags().StringVar(&tc.raw, "raw", "", "Raw fixture in string format to replace all default fixtures")
+	tc.cmd.Flags().StringVar(&tc.idempotency, "idempotency-key-prefix", "", "Send an idempotency key derived from this prefix and the step name with each request, so that triggering again with the same prefix returns the objects created the first time")
+	tc.cmd.Flags().StringVar(&tc.apiVersion, "api-version", "", "Make the requests of the trigger with this API version, unless a step sets its own. Ex: 2020-08-27 (default: the version of the fixture, or your account's default)")
+	tc.cmd.Flags().Int64Var(&tc.seed, "seed", 0, "Seed the random values of the fixture, like ${random:email}, to generate the same ones on every run (default: a different seed each run)")
+	tc.cmd.Flags().StringVar(&tc.outputFile, "output-file", "", "Write the ID, object type and status code of each step, and the expected events, to this file as JSON")
+	tc.cmd.Flags().StringVar(&tc.raw, "raw", "", "Raw fixture in string format to replace all default fixtures, or the path to a JSON file of a custom event to deliver to the `stripe listen` session of --status-port, or to --forward-to without one")
+	tc.cmd.Flags().IntVar(&tc.parallel, "parallel", 1, "The number of events to trigger at the same time when triggering several events")
+	tc.cmd.Flags().BoolVar(&tc.failFast, "fail-fast", false, "Stop triggering events as soon as one of them fails, when triggering several events")
+	tc.cmd.Flags().IntVar(&tc.count, "count", 1, "Trigger the event this many times, or until interrupted with 0")
+	tc.cmd.Flags().DurationVar(&tc.interval, "interval", time.Second, "The time to wait between triggers with --count")
+	tc.cmd.Flags().DurationVar(&tc.jitter, "jitter", 0, "Randomly shorten or lengthen each --interval by up to this duration")
+	tc.cmd.Flags().StringVar(&tc.schedule, "schedule", "", "Keep running and trigger the event on a cron schedule, like \"*/5 * * * *\", up to --count times if set")
+	tc.cmd.Flags().DurationVar(&tc.every, "every", 0, "Keep running and trigger the event right away and then at this interval, like 5m, up to --count times if set")
+	tc.cmd.Flags().BoolVar(&tc.dryRun, "dry-run", false, "Print the requests the trigger would make without making them")
+	tc.cmd.Flags().BoolVar(&tc.list, "list", false, "List the supported events with a description of what their fixtures do")
+	tc.cmd.Flags().StringVar(&tc.format, "format", "default", "The format to print the requests of --dry-run or the events of --list as (either 'default' or 'json')")
+	tc.cmd.Flags().BoolVar(&tc.waitForDelivery, "wait-for-delivery", false, "Wait until `stripe listen --status-port` forwarded the triggered events to your endpoints, and fail if a delivery doesn't receive a 2xx response")
+	tc.cmd.Flags().DurationVar(&tc.timeout, "timeout", 30*time.Second, "The time to wait for the deliveries of --wait-for-delivery")
+	tc.cmd.Flags().IntVar(&tc.statusPort, "status-port", defaultTriggerStatusPort, "The --status-port of the `stripe listen` session to wait for with --wait-for-delivery, or to deliver the custom event of --raw to")
+	tc.cmd.Flags().StringVarP(&tc.forwardURL, "forward-to", "f", "", "The URL to deliver the custom event of --raw to when no `stripe listen` session is running on --status-port, signed with the secret of a new session")
 
 	// Hidden configuration flags, useful for dev/debugging
 	tc.cmd.Flags().StringVar(&tc.apiBaseURL, "api-base", stripe.DefaultAPIBaseURL, "Sets the API base URL")
 	tc.cmd.Flags().MarkHidden("api-base") // #nosec G104
+	tc.cmd.Flags().StringVar(&tc.filesBaseURL, "files-base", stripe.DefaultFilesAPIBaseURL, "Sets the files API base URL")
+	tc.cmd.Flags().MarkHidden("files-base") // #nosec G104
 
 	return tc
 }
@@ -65,24 +153,596 @@ needed to create the triggered event as well as the corresponding API objects.
 func (tc *triggerCmd) runTriggerCmd(cmd *cobra.Command, args []string) error {
 	version.CheckLatestVersion()
 
+	if tc.list {
+		return tc.listEvents(args)
+	}
+
+	isFile, err := tc.isRawFile()
+	if err != nil {
+		return err
+	}
+	if isFile {
+		if len(args) > 0 {
+			return errors.New("An event cannot be triggered together with the custom event of --raw")
+		}
+		return tc.triggerCustomEvent(cmd)
+	}
+
+	if tc.forwardURL != "" {
+		return errors.New("--forward-to can only be used with the custom event of --raw")
+	}
+
 	if len(args) == 0 {
 		cmd.Help()
 
 		return nil
 	}
 
+	// The requests of the fixture are made on the account of the global
+	// --stripe-account
+	tc.stripeAccount = Config.Profile.StripeAccount
+	if tc.stripeAccount != "" {
+		if err := validators.AccountID(tc.stripeAccount); err != nil {
+			return fmt.Errorf("--stripe-account: %w", err)
+		}
+	}
+
+	if tc.apiVersion != "" {
+		if err := validators.APIVersion(tc.apiVersion); err != nil {
+			return fmt.Errorf("--api-version: %w", err)
+		}
+	}
+
+	if tc.dryRun {
+		return tc.dryRunTrigger(args)
+	}
+
+	schedule, err := tc.parseTriggerSchedule()
+	if err != nil {
+		return err
+	}
+	if schedule != nil {
+		if err := tc.checkSchedule(args); err != nil {
+			return err
+		}
+	}
+
+	var statusURL string
+	if tc.waitForDelivery {
+		if err := tc.checkWaitForDelivery(args); err != nil {
+			return err
+		}
+		statusURL = fmt.Sprintf("http://localhost:%d", tc.statusPort)
+		if err := checkListenReady(cmd.Context(), http.DefaultClient, statusURL, tc.statusPort); err != nil {
+			return err
+		}
+	}
+	triggeredAt := time.Now()
+
 	apiKey, err := Config.Profile.GetAPIKey(false)
 	if err != nil {
 		return err
 	}
 
-	event := args[0]
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		_, err := fixtures.Trigger(ctx, event, tc.stripeAccount, tc.apiBaseURL, tc.filesBaseURL, apiKey, tc.skip, tc.only, tc.override, tc.add, tc.remove, tc.set, tc.raw, tc.idempotency, tc.seed, tc.apiVersion, tc.outputFile, fixtureRunsDir(&Config), out)
+		return err
+	}
+
+	if schedule != nil {
+		return tc.scheduledTrigger(cmd, args[0], schedule, trigger)
+	}
+
+	if tc.count != 1 {
+		return tc.stressTrigger(cmd, args, trigger)
+	}
+
+	if len(args) == 1 {
+		if err := trigger(cmd.Context(), args[0], nil); err != nil {
+			return err
+		}
+
+		fmt.Println("Trigger succeeded! Check dashboard for event details.")
+
+		if tc.waitForDelivery {
+			return waitForDeliveries(cmd.Context(), http.DefaultClient, statusURL, args, triggeredAt, tc.timeout, os.Stdout)
+		}
+		return nil
+	}
+
+	if tc.parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1, got %d", tc.parallel)
+	}
+	if tc.raw != "" {
+		return errors.New("--raw can only be used when triggering a single event")
+	}
+	if tc.outputFile != "" {
+		return errors.New("--output-file can only be used when triggering a single event")
+	}
+
+	results := runTriggers(cmd.Context(), args, tc.parallel, tc.failFast, os.Stdout, trigger)
+	printTriggerSummary(os.Stdout, results)
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events failed to trigger", failed, len(results))
+	}
+
+	fmt.Println("Triggers succeeded! Check dashboard for event details.")
+
+	if tc.waitForDelivery {
+		return waitForDeliveries(cmd.Context(), http.DefaultClient, statusURL, args, triggeredAt, tc.timeout, os.Stdout)
+	}
+	return nil
+}
+
+// dryRunTrigger prints the requests the fixture of the event would make
+func (tc *triggerCmd) dryRunTrigger(args []string) error {
+	if len(args) > 1 {
+		return errors.New("--dry-run can only be used when triggering a single event")
+	}
+	if tc.format != "default" && tc.format != "json" {
+		return fmt.Errorf("invalid format, must be one of 'default' or 'json', received %s", tc.format)
+	}
+
+	fixture, err := fixtures.NewTriggerFixture(tc.fs, args[0], tc.stripeAccount, tc.apiBaseURL, "", tc.skip, tc.override, tc.add, tc.remove, tc.raw)
+	if err != nil {
+		return err
+	}
+
+	fixture.IdempotencyKeyPrefix = tc.idempotency
+	fixture.APIVersion = tc.apiVersion
+	fixture.Only = tc.only
+	if tc.seed != 0 {
+		fixture.SetSeed(tc.seed)
+	}
+	if err := fixture.SetValues(tc.set); err != nil {
+		return err
+	}
+
+	steps, err := fixture.DryRun()
+	if err != nil {
+		return err
+	}
+
+	return fixtures.PrintDryRun(os.Stdout, steps, tc.format)
+}
+
+// listEvents prints the catalog of the supported events
+func (tc *triggerCmd) listEvents(args []string) error {
+	if len(args) > 0 {
+		return errors.New("--list cannot be used together with events to trigger")
+	}
+	if tc.format != "default" && tc.format != "json" {
+		return fmt.Errorf("invalid format, must be one of 'default' or 'json', received %s", tc.format)
+	}
 
-	_, err = fixtures.Trigger(cmd.Context(), event, tc.stripeAccount, tc.apiBaseURL, apiKey, tc.skip, tc.override, tc.add, tc.remove, tc.raw)
+	catalog, err := fixtures.Catalog()
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Trigger succeeded! Check dashboard for event details.")
+	return fixtures.PrintCatalog(os.Stdout, catalog, tc.format)
+}
+
+// stressTrigger triggers the event --count times, or until interrupted,
+// printing a line with the tallies of the runs after each of them
+func (tc *triggerCmd) stressTrigger(cmd *cobra.Command, args []string, trigger func(ctx context.Context, event string, out io.Writer) error) error {
+	if len(args) > 1 {
+		return errors.New("--count can only be used when triggering a single event")
+	}
+	if tc.count < 0 {
+		return fmt.Errorf("--count must be positive, got %d", tc.count)
+	}
+	if tc.interval < 0 || tc.jitter < 0 {
+		return errors.New("--interval and --jitter must be positive")
+	}
+
+	ctx := withSIGTERMCancel(cmd.Context(), func() {
+		log.WithFields(log.Fields{
+			"prefix": "cmd.triggerCmd.stressTrigger",
+		}).Debug("Ctrl+C received, stopping...")
+	})
+
+	succeeded, failed := runStress(ctx, args[0], tc.count, tc.interval, tc.jitter, os.Stdout, trigger)
+
+	fmt.Printf("Triggered %s %d times: %d succeeded, %d failed\n", ansi.Bold(args[0]), succeeded+failed, succeeded, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d triggers failed", failed, succeeded+failed)
+	}
+
+	return nil
+}
+
+// runStress triggers the event count times, or until ctx is done if count is
+// 0, waiting interval give or take a random jitter between runs. A run
+// interrupted by ctx is not tallied.
+func runStress(ctx context.Context, event string, count int, interval, jitter time.Duration, out io.Writer, trigger func(ctx context.Context, event string, out io.Writer) error) (int, int) {
+	succeeded, failed := 0, 0
+
+	total := "∞"
+	if count > 0 {
+		total = strconv.Itoa(count)
+	}
+
+	for run := 1; count == 0 || run <= count; run++ {
+		if run > 1 {
+			wait := interval
+			if jitter > 0 {
+				wait += time.Duration(rand.Int63n(2*int64(jitter)+1)) - jitter // #nosec G404
+			}
+
+			select {
+			case <-ctx.Done():
+				return succeeded, failed
+			case <-time.After(wait):
+			}
+		}
+
+		start := time.Now()
+		err := trigger(ctx, event, ioutil.Discard)
+		if ctx.Err() != nil {
+			return succeeded, failed
+		}
+		took := time.Since(start).Round(time.Millisecond)
+
+		color := ansi.Color(os.Stdout)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "[%d/%s] %s in %s: %s · %d succeeded, %d failed\n", run, total, color.Red("failed"), took, strings.TrimSpace(err.Error()), succeeded, failed)
+		} else {
+			succeeded++
+			fmt.Fprintf(out, "[%d/%s] %s in %s · %d succeeded, %d failed\n", run, total, color.Green("succeeded"), took, succeeded, failed)
+		}
+	}
+
+	return succeeded, failed
+}
+
+// runTriggers triggers the events with up to parallel of them at a time. The
+// output of each event is buffered and printed to out once it is done, so
+// that the output of concurrent events doesn't interleave. With failFast, a
+// failed event skips the events not started yet, and the running ones finish.
+func runTriggers(ctx context.Context, events []string, parallel int, failFast bool, out io.Writer, trigger func(ctx context.Context, event string, out io.Writer) error) []triggerResult {
+	var failed int32
+
+	results := make([]triggerResult, len(events))
+	indexes := make(chan int)
+
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for w := 0; w < parallel && w < len(events); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i].event = events[i]
+				if ctx.Err() != nil || atomic.LoadInt32(&failed) != 0 {
+					results[i].skipped = true
+					continue
+				}
+
+				var output bytes.Buffer
+				start := time.Now()
+				results[i].err = trigger(ctx, events[i], &output)
+				results[i].duration = time.Since(start)
+
+				if results[i].err != nil && failFast {
+					atomic.StoreInt32(&failed, 1)
+				}
+
+				outMu.Lock()
+				fmt.Fprintf(out, "%s\n%s\n", ansi.Bold(events[i]), output.String())
+				outMu.Unlock()
+			}
+		}()
+	}
+
+	for i := range events {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func printTriggerSummary(out io.Writer, results []triggerResult) {
+	color := ansi.Color(os.Stdout)
+
+	width := 0
+	for _, result := range results {
+		if len(result.event) > width {
+			width = len(result.event)
+		}
+	}
+
+	fmt.Fprintln(out, ansi.Bold("Trigger summary"))
+	for _, result := range results {
+		name := result.event + strings.Repeat(" ", width-len(result.event))
+
+		switch {
+		case result.skipped:
+			fmt.Fprintf(out, "  %s  %s\n", name, color.Faint("skipped  "))
+		case result.err != nil:
+			fmt.Fprintf(out, "  %s  %s  %s  %s\n", name, color.Red("failed   "), result.duration.Round(time.Millisecond), strings.TrimSpace(result.err.Error()))
+		default:
+			fmt.Fprintf(out, "  %s  %s  %s\n", name, color.Green("succeeded"), result.duration.Round(time.Millisecond))
+		}
+	}
+}
+
+// checkWaitForDelivery returns an error if --wait-for-delivery can't be used
+// with the other flags. The deliveries are matched by event type, which is
+// only known for the supported events.
+func (tc *triggerCmd) checkWaitForDelivery(events []string) error {
+	if tc.count != 1 {
+		return errors.New("--wait-for-delivery cannot be used with --count")
+	}
+	if tc.raw != "" {
+		return errors.New("--wait-for-delivery cannot be used with --raw")
+	}
+	if tc.timeout <= 0 {
+		return fmt.Errorf("--timeout must be positive, got %s", tc.timeout)
+	}
+	if tc.statusPort <= 0 || tc.statusPort > 65535 {
+		return fmt.Errorf("--status-port must be a valid port number, got %d", tc.statusPort)
+	}
+
+	for _, event := range events {
+		if _, ok := fixtures.Events[event]; !ok {
+			return fmt.Errorf("--wait-for-delivery can only be used with the supported events, %s isn't one", event)
+		}
+	}
+
+	return nil
+}
+
+// checkListenReady returns an error unless the `stripe listen` session of the
+// status server is ready to forward events
+func checkListenReady(ctx context.Context, client *http.Client, statusURL string, port int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL+"/ready", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to reach `stripe listen` on port %d, start it with `stripe listen --status-port %d` to use --wait-for-delivery", port, port)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("`stripe listen` on port %d is not ready to forward events yet", port)
+	}
+
+	return nil
+}
+
+// waitForDeliveries polls the status server of `stripe listen` until each of
+// the event types was forwarded with a 2xx response since the time. It fails
+// as soon as a delivery of one of them fails, or once the timeout elapsed.
+func waitForDeliveries(ctx context.Context, client *http.Client, statusURL string, events []string, since time.Time, timeout time.Duration, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	expected := make(map[string]bool)
+	for _, event := range events {
+		expected[event] = true
+	}
+	delivered := make(map[string]bool)
+
+	ticker := time.NewTicker(triggerDeliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliveries, err := fetchDeliveries(ctx, client, statusURL, since)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("Failed to get the deliveries of `stripe listen`: %v", err)
+		}
+
+		for _, delivery := range deliveries {
+			if !expected[delivery.EventType] {
+				continue
+			}
+
+			switch {
+			case delivery.Error != "":
+				return fmt.Errorf("The %s event %s failed to be forwarded to %s: %s", delivery.EventType, delivery.EventID, delivery.URL, delivery.Error)
+			case delivery.StatusCode < 200 || delivery.StatusCode >= 300:
+				return fmt.Errorf("The %s event %s was forwarded to %s and received a %d response", delivery.EventType, delivery.EventID, delivery.URL, delivery.StatusCode)
+			case !delivered[delivery.EventType]:
+				delivered[delivery.EventType] = true
+				fmt.Fprintf(out, "Delivered %s %s to %s [%d]\n", delivery.EventType, delivery.EventID, delivery.URL, delivery.StatusCode)
+			}
+		}
+
+		if len(delivered) == len(expected) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			pending := []string{}
+			for event := range expected {
+				if !delivered[event] {
+					pending = append(pending, event)
+				}
+			}
+			sort.Strings(pending)
+
+			return fmt.Errorf("Timed out after %s waiting for `stripe listen` to forward %s", timeout, strings.Join(pending, ", "))
+		case <-ticker.C:
+		}
+	}
+}
+
+func fetchDeliveries(ctx context.Context, client *http.Client, statusURL string, since time.Time) ([]proxy.DeliveryRecord, error) {
+	query := url.Values{"since": {since.UTC().Format(time.RFC3339Nano)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL+"/deliveries?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var deliveries []proxy.DeliveryRecord
+	if err := json.NewDecoder(resp.Body).Decode(&deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// isRawFile returns whether --raw is the path of a custom event rather than a
+// raw fixture. It is a path with --forward-to or when it isn't a JSON object,
+// and must then exist.
+func (tc *triggerCmd) isRawFile() (bool, error) {
+	if tc.raw == "" {
+		return false, nil
+	}
+
+	if tc.forwardURL == "" && strings.HasPrefix(strings.TrimSpace(tc.raw), "{") {
+		return false, nil
+	}
+
+	exists, err := afero.Exists(tc.fs, tc.raw)
+	if err != nil {
+		return false, fmt.Errorf("--raw: %w", err)
+	}
+	if !exists {
+		return false, fmt.Errorf("--raw: file not found: %s", tc.raw)
+	}
+
+	return true, nil
+}
+
+// triggerCustomEvent delivers the custom event of the --raw file to the
+// `stripe listen` session of --status-port, which forwards it to its
+// endpoints like the events from Stripe. Custom events don't go through
+// Stripe, so without a session to send them to, they are delivered to the
+// --forward-to endpoint directly, signed like the events of `stripe listen`.
+func (tc *triggerCmd) triggerCustomEvent(cmd *cobra.Command) error {
+	content, err := afero.ReadFile(tc.fs, tc.raw)
+	if err != nil {
+		return err
+	}
+
+	payload, err := fixtures.CustomEvent(content, tc.override)
+	if err != nil {
+		return err
+	}
+
+	statusURL := fmt.Sprintf("http://localhost:%d", tc.statusPort)
+	err = sendCustomEvent(cmd.Context(), http.DefaultClient, statusURL, payload)
+	if err == nil {
+		fmt.Printf("Sent the custom event %s [%s] to `stripe listen` on port %d\n", ansi.Bold(gjson.GetBytes(payload, "type").String()), gjson.GetBytes(payload, "id").String(), tc.statusPort)
+		return nil
+	}
+
+	var unavailable listenUnavailableError
+	if !errors.As(err, &unavailable) {
+		return err
+	}
+	if tc.forwardURL == "" {
+		return fmt.Errorf("%v. Start it with `stripe listen --status-port %d`, or deliver the custom event to your endpoint directly with --forward-to", err, tc.statusPort)
+	}
+
+	return tc.forwardCustomEvent(cmd, payload)
+}
+
+// listenUnavailableError is returned when no `stripe listen` session can take
+// the custom event
+type listenUnavailableError struct {
+	reason string
+}
+
+func (e listenUnavailableError) Error() string {
+	return e.reason
+}
+
+// sendCustomEvent sends the custom event to the status server of `stripe
+// listen`, which forwards it to its endpoints
+func sendCustomEvent(ctx context.Context, client *http.Client, statusURL string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, statusURL+"/events", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return listenUnavailableError{"No `stripe listen` session is running to deliver the custom event of --raw to"}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return nil
+	case http.StatusServiceUnavailable:
+		return listenUnavailableError{"`stripe listen` is not ready to forward events yet"}
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		// a version of `stripe listen` without custom events
+		return listenUnavailableError{"This `stripe listen` session can't deliver custom events"}
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("`stripe listen` rejected the custom event: %s", strings.TrimSpace(string(body)))
+}
+
+// forwardCustomEvent delivers the custom event to the --forward-to endpoint,
+// signed with the secret of a new session like the events of `stripe listen`
+func (tc *triggerCmd) forwardCustomEvent(cmd *cobra.Command, payload []byte) error {
+	deviceName, err := Config.Profile.GetDeviceName()
+	if err != nil {
+		return err
+	}
+
+	apiKey, err := Config.Profile.GetAPIKey(false)
+	if err != nil {
+		return err
+	}
+
+	secret, err := proxy.GetSessionSecret(cmd.Context(), deviceName, apiKey, tc.apiBaseURL)
+	if err != nil {
+		return err
+	}
+
+	var delivery proxy.ReplayDelivery
+	_, err = proxy.Replay(cmd.Context(), []proxy.RecordedEvent{{ReceivedAt: time.Now(), Event: payload}}, &proxy.ReplayConfig{
+		ForwardURL: tc.forwardURL,
+		Secret:     secret,
+		Log:        log.StandardLogger(),
+		OnDelivery: func(d proxy.ReplayDelivery) {
+			delivery = d
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if delivery.Err != nil {
+		return fmt.Errorf("Failed to deliver the custom event %s: %v", delivery.Event.ID, delivery.Err)
+	}
+	if !delivery.Success() {
+		return fmt.Errorf("Your endpoint responded to the custom event %s with a %d", delivery.Event.ID, delivery.StatusCode)
+	}
+
+	fmt.Printf("Delivered the custom event %s [%s] to %s [%d]\n", ansi.Bold(delivery.Event.Type), delivery.Event.ID, tc.forwardURL, ansi.ColorizeStatus(delivery.StatusCode))
 	return nil
 }
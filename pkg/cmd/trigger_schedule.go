@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+// triggerSchedule is when `stripe trigger --schedule` or `--every` triggers
+// the event
+type triggerSchedule interface {
+	// next returns the time of the run following the one at t
+	next(t time.Time) time.Time
+	// immediate returns whether the first run is right away, instead of at
+	// next(now)
+	immediate() bool
+}
+
+// everySchedule triggers the event right away, then at a fixed interval
+type everySchedule time.Duration
+
+func (s everySchedule) next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+func (s everySchedule) immediate() bool {
+	return true
+}
+
+// cronSchedule triggers the event at the minutes matching a cron expression,
+// in local time. Each field is a bitset of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Like cron, when both the day of the month and of the week are
+	// restricted, a day matching either of them matches
+	domRestricted, dowRestricted bool
+}
+
+// cronField are the bounds and names of the values of a cron field
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	// 7 is Sunday too
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCronSchedule parses a cron expression of five fields, like
+// `*/5 * * * *`, or a macro like @hourly
+func parseCronSchedule(expression string) (*cronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		bits, err := cronFields[i].parse(field)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = bits
+	}
+
+	// Sunday is both 0 and 7
+	if values[4]&(1<<7) != 0 {
+		values[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute:        values[0],
+		hour:          values[1],
+		dom:           values[2],
+		month:         values[3],
+		dow:           values[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+// parse returns the bitset of the values of a field, which is a list of
+// values, ranges like 1-5 and steps like */15 or 0-30/10
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangeAndStep := strings.SplitN(part, "/", 2)
+
+		step := 1
+		if len(rangeAndStep) == 2 {
+			n, err := strconv.Atoi(rangeAndStep[1])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in the %s field", rangeAndStep[1], f.name)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		if rangeAndStep[0] != "*" {
+			bounds := strings.SplitN(rangeAndStep[0], "-", 2)
+
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if len(rangeAndStep) == 2 {
+				// 5/15 is 5-59/15
+				high = f.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in the %s field", rangeAndStep[0], f.name)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in the %s field, expected %d-%d", s, f.name, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+func (s *cronSchedule) immediate() bool {
+	return false
+}
+
+// next returns the first matching minute after t. It skips the months,
+// days and hours that don't match instead of trying every minute.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Any valid expression matches within a few years, like Feb 29
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// parseTriggerSchedule returns the schedule of --schedule or --every, or nil
+// if neither is set
+func (tc *triggerCmd) parseTriggerSchedule() (triggerSchedule, error) {
+	switch {
+	case tc.schedule != "" && tc.every != 0:
+		return nil, errors.New("--schedule and --every cannot be used together")
+	case tc.schedule != "":
+		schedule, err := parseCronSchedule(tc.schedule)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --schedule %q: %v", tc.schedule, err)
+		}
+		if schedule.next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("Invalid --schedule %q: it never matches", tc.schedule)
+		}
+		return schedule, nil
+	case tc.every < 0:
+		return nil, fmt.Errorf("--every must be positive, got %s", tc.every)
+	case tc.every != 0:
+		return everySchedule(tc.every), nil
+	default:
+		return nil, nil
+	}
+}
+
+// runSchedule triggers the event on the schedule until stop is done, or
+// maxRuns times if it is positive. A run in progress when stop is done
+// completes, as the run has its own context, so that the fixture isn't left
+// halfway.
+func runSchedule(stop, ctx context.Context, event string, schedule triggerSchedule, maxRuns int, out io.Writer, trigger func(ctx context.Context, event string, out io.Writer) error) (int, int) {
+	succeeded, failed := 0, 0
+
+	total := "∞"
+	if maxRuns > 0 {
+		total = strconv.Itoa(maxRuns)
+	}
+
+	at := time.Now()
+	if !schedule.immediate() {
+		at = schedule.next(at)
+	}
+
+	for run := 1; maxRuns == 0 || run <= maxRuns; run++ {
+		if stop.Err() != nil {
+			return succeeded, failed
+		}
+		if run > 1 {
+			at = schedule.next(at)
+		}
+
+		select {
+		case <-stop.Done():
+			return succeeded, failed
+		case <-time.After(time.Until(at)):
+		}
+
+		start := time.Now()
+		err := trigger(ctx, event, ioutil.Discard)
+		took := time.Since(start).Round(time.Millisecond)
+
+		color := ansi.Color(os.Stdout)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "[%d/%s] %s %s in %s: %s\n", run, total, start.Format(time.RFC3339), color.Red("failed"), took, strings.TrimSpace(err.Error()))
+		} else {
+			succeeded++
+			fmt.Fprintf(out, "[%d/%s] %s %s in %s\n", run, total, start.Format(time.RFC3339), color.Green("succeeded"), took)
+		}
+
+		// A schedule shorter than a run skips the runs that were due during
+		// it instead of running them back to back
+		for schedule.next(at).Before(time.Now()) {
+			at = schedule.next(at)
+		}
+	}
+
+	return succeeded, failed
+}
+
+// checkSchedule returns an error if --schedule or --every can't be used with
+// the other flags
+func (tc *triggerCmd) checkSchedule(events []string) error {
+	if len(events) != 1 {
+		return errors.New("--schedule and --every can only be used when triggering a single event")
+	}
+	if tc.count < 0 {
+		return fmt.Errorf("--count must be positive, got %d", tc.count)
+	}
+	if tc.waitForDelivery {
+		return errors.New("--wait-for-delivery cannot be used with --schedule or --every")
+	}
+
+	return nil
+}
+
+// scheduledTrigger triggers the event on the schedule until interrupted, or
+// --count times if it is set, and prints the tallies of the runs
+func (tc *triggerCmd) scheduledTrigger(cmd *cobra.Command, event string, schedule triggerSchedule, trigger func(ctx context.Context, event string, out io.Writer) error) error {
+	maxRuns := 0
+	if cmd.Flags().Changed("count") {
+		maxRuns = tc.count
+	}
+
+	mode := "every"
+	if tc.schedule != "" {
+		mode = "schedule"
+	}
+	if telemetryClient := stripe.GetTelemetryClient(cmd.Context()); telemetryClient != nil {
+		go telemetryClient.SendEvent(cmd.Context(), "Scheduled Trigger", mode)
+	}
+
+	stop := withSIGTERMCancel(cmd.Context(), func() {
+		fmt.Println("Stopping after the current run...")
+	})
+
+	if schedule.immediate() {
+		fmt.Printf("Triggering %s every %s, press Ctrl+C to stop\n", ansi.Bold(event), tc.every)
+	} else {
+		fmt.Printf("Triggering %s on the schedule %q, next at %s, press Ctrl+C to stop\n", ansi.Bold(event), tc.schedule, schedule.next(time.Now()).Format(time.RFC3339))
+	}
+
+	succeeded, failed := runSchedule(stop, cmd.Context(), event, schedule, maxRuns, os.Stdout, trigger)
+
+	fmt.Printf("Triggered %s %d times: %d succeeded, %d failed\n", ansi.Bold(event), succeeded+failed, succeeded, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d triggers failed", failed, succeeded+failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	from := time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"*/5 * * * *", time.Date(2021, time.March, 14, 15, 10, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2021, time.March, 14, 15, 10, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2021, time.March, 15, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon-fri", time.Date(2021, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, time.March, 21, 0, 0, 0, 0, time.UTC)},
+		{"15,45 */6 * * *", time.Date(2021, time.March, 14, 18, 15, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// a day matching either the day of the month or of the week matches
+		{"0 0 20 * 1", time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, time.March, 14, 16, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := parseCronSchedule(test.expression)
+		require.NoError(t, err, test.expression)
+		require.Equal(t, test.expected, schedule.next(from), test.expression)
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	tests := map[string]string{
+		"* * * *":     "expected 5 fields (minute hour day-of-month month day-of-week), got 4",
+		"60 * * * *":  `invalid value "60" in the minute field, expected 0-59`,
+		"*/0 * * * *": `invalid step "0" in the minute field`,
+		"* 5-1 * * *": `invalid range "5-1" in the hour field`,
+		"* * * foo *": `invalid value "foo" in the month field, expected 1-12`,
+	}
+
+	for expression, expected := range tests {
+		_, err := parseCronSchedule(expression)
+		require.EqualError(t, err, expected, expression)
+	}
+}
+
+func TestParseTriggerSchedule(t *testing.T) {
+	tc := &triggerCmd{schedule: "0 0 30 2 *"}
+	_, err := tc.parseTriggerSchedule()
+	require.EqualError(t, err, `Invalid --schedule "0 0 30 2 *": it never matches`)
+
+	tc = &triggerCmd{schedule: "* * * * *", every: time.Minute}
+	_, err = tc.parseTriggerSchedule()
+	require.EqualError(t, err, "--schedule and --every cannot be used together")
+
+	tc = &triggerCmd{every: 5 * time.Minute}
+	schedule, err := tc.parseTriggerSchedule()
+	require.NoError(t, err)
+	require.Equal(t, everySchedule(5*time.Minute), schedule)
+
+	tc = &triggerCmd{}
+	schedule, err = tc.parseTriggerSchedule()
+	require.NoError(t, err)
+	require.Nil(t, schedule)
+}
+
+func TestRunSchedule(t *testing.T) {
+	runs := 0
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		runs++
+		if runs == 2 {
+			return errors.New("Trigger failed: rate_limit\n")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	succeeded, failed := runSchedule(context.Background(), context.Background(), "customer.created", everySchedule(time.Millisecond), 3, &out, trigger)
+
+	require.Equal(t, 2, succeeded)
+	require.Equal(t, 1, failed)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Regexp(t, `^\[1/3\] \S+ succeeded in \S+$`, lines[0])
+	require.Regexp(t, `^\[2/3\] \S+ failed in \S+: Trigger failed: rate_limit$`, lines[1])
+}
+
+func TestRunScheduleStopsAtRunBoundary(t *testing.T) {
+	stop, cancel := context.WithCancel(context.Background())
+
+	runs := 0
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		runs++
+		if runs == 3 {
+			// the run in progress completes
+			cancel()
+		}
+		return ctx.Err()
+	}
+
+	succeeded, failed := runSchedule(stop, context.Background(), "customer.created", everySchedule(time.Millisecond), 0, io.Discard, trigger)
+
+	require.Equal(t, 3, succeeded)
+	require.Equal(t, 0, failed)
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/proxy"
+)
+
+func TestRunTriggers(t *testing.T) {
+	var running, maxRunning int32
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+
+		fmt.Fprintf(out, "Setting up fixture for: %s\n", event)
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(out, "Running fixture for: %s\n", event)
+
+		if event == "invoice.paid" {
+			return errors.New("Trigger failed: card_declined\n")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	events := []string{"payment_intent.succeeded", "customer.created", "invoice.paid", "charge.captured"}
+	results := runTriggers(context.Background(), events, 2, false, &out, trigger)
+
+	require.Equal(t, int32(2), maxRunning)
+	require.Len(t, results, 4)
+	for i, result := range results {
+		require.Equal(t, events[i], result.event)
+		require.False(t, result.skipped)
+	}
+	require.EqualError(t, results[2].err, "Trigger failed: card_declined\n")
+	require.NoError(t, results[3].err)
+
+	// the output of each event is printed in one piece
+	for _, event := range events {
+		require.Contains(t, out.String(), fmt.Sprintf("Setting up fixture for: %s\nRunning fixture for: %s\n", event, event))
+	}
+
+	var summary bytes.Buffer
+	printTriggerSummary(&summary, results)
+	require.Contains(t, summary.String(), "invoice.paid")
+	require.Contains(t, summary.String(), "Trigger failed: card_declined")
+	require.Equal(t, 5, strings.Count(summary.String(), "\n"))
+}
+
+func TestRunTriggersFailFast(t *testing.T) {
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		if event == "customer.created" {
+			return errors.New("Trigger failed")
+		}
+		return ctx.Err()
+	}
+
+	results := runTriggers(context.Background(), []string{"customer.created", "invoice.paid", "charge.captured"}, 1, true, io.Discard, trigger)
+
+	require.Error(t, results[0].err)
+	require.True(t, results[1].skipped)
+	require.True(t, results[2].skipped)
+}
+
+func TestRunTriggersFailFastLetsRunningFinish(t *testing.T) {
+	failed := make(chan struct{})
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		switch event {
+		case "customer.created":
+			defer close(failed)
+			return errors.New("Trigger failed")
+		case "invoice.paid":
+			<-failed
+			time.Sleep(10 * time.Millisecond)
+		}
+		return ctx.Err()
+	}
+
+	results := runTriggers(context.Background(), []string{"invoice.paid", "customer.created", "charge.captured"}, 2, true, io.Discard, trigger)
+
+	require.NoError(t, results[0].err)
+	require.False(t, results[0].skipped)
+	require.Error(t, results[1].err)
+	require.True(t, results[2].skipped)
+}
+
+func TestRunStress(t *testing.T) {
+	runs := 0
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		runs++
+		if runs == 2 {
+			return errors.New("Trigger failed: rate_limit\n")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	succeeded, failed := runStress(context.Background(), "customer.created", 3, time.Millisecond, time.Millisecond, &out, trigger)
+
+	require.Equal(t, 2, succeeded)
+	require.Equal(t, 1, failed)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Regexp(t, `^\[1/3\] succeeded in \S+ · 1 succeeded, 0 failed$`, lines[0])
+	require.Regexp(t, `^\[2/3\] failed in \S+: Trigger failed: rate_limit · 1 succeeded, 1 failed$`, lines[1])
+}
+
+func TestRunStressUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := 0
+	trigger := func(ctx context.Context, event string, out io.Writer) error {
+		runs++
+		if runs == 5 {
+			cancel()
+		}
+		return ctx.Err()
+	}
+
+	succeeded, failed := runStress(ctx, "customer.created", 0, 0, 0, io.Discard, trigger)
+
+	// the interrupted run is not tallied
+	require.Equal(t, 4, succeeded)
+	require.Equal(t, 0, failed)
+}
+
+func deliveriesServer(t *testing.T, deliveries func(polls int) []proxy.DeliveryRecord) *httptest.Server {
+	polls := 0
+	return httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/ready":
+			res.WriteHeader(http.StatusOK)
+		case "/deliveries":
+			_, err := time.Parse(time.RFC3339Nano, req.URL.Query().Get("since"))
+			require.NoError(t, err)
+
+			polls++
+			json.NewEncoder(res).Encode(deliveries(polls))
+		}
+	}))
+}
+
+func TestWaitForDeliveries(t *testing.T) {
+	ts := deliveriesServer(t, func(polls int) []proxy.DeliveryRecord {
+		deliveries := []proxy.DeliveryRecord{
+			{EventID: "evt_1", EventType: "charge.succeeded", URL: "http://localhost:4242/webhook", StatusCode: 200},
+		}
+		if polls > 1 {
+			deliveries = append(deliveries, proxy.DeliveryRecord{EventID: "evt_2", EventType: "customer.created", URL: "http://localhost:4242/webhook", StatusCode: 204})
+		}
+		return deliveries
+	})
+	defer ts.Close()
+
+	require.NoError(t, checkListenReady(context.Background(), ts.Client(), ts.URL, 4279))
+
+	var out bytes.Buffer
+	err := waitForDeliveries(context.Background(), ts.Client(), ts.URL, []string{"customer.created"}, time.Now(), time.Second, &out)
+	require.NoError(t, err)
+	require.Equal(t, "Delivered customer.created evt_2 to http://localhost:4242/webhook [204]\n", out.String())
+}
+
+func TestWaitForDeliveriesFailure(t *testing.T) {
+	ts := deliveriesServer(t, func(polls int) []proxy.DeliveryRecord {
+		return []proxy.DeliveryRecord{
+			{EventID: "evt_1", EventType: "customer.created", URL: "http://localhost:4242/webhook", StatusCode: 500},
+		}
+	})
+	defer ts.Close()
+
+	err := waitForDeliveries(context.Background(), ts.Client(), ts.URL, []string{"customer.created"}, time.Now(), time.Second, io.Discard)
+	require.EqualError(t, err, "The customer.created event evt_1 was forwarded to http://localhost:4242/webhook and received a 500 response")
+}
+
+func TestWaitForDeliveriesTimeout(t *testing.T) {
+	ts := deliveriesServer(t, func(polls int) []proxy.DeliveryRecord {
+		return []proxy.DeliveryRecord{}
+	})
+	defer ts.Close()
+
+	err := waitForDeliveries(context.Background(), ts.Client(), ts.URL, []string{"invoice.paid", "customer.created"}, time.Now(), 100*time.Millisecond, io.Discard)
+	require.EqualError(t, err, "Timed out after 100ms waiting for `stripe listen` to forward customer.created, invoice.paid")
+}
+
+func TestCheckListenReadyUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	err := checkListenReady(context.Background(), http.DefaultClient, ts.URL, 4279)
+	require.EqualError(t, err, "Failed to reach `stripe listen` on port 4279, start it with `stripe listen --status-port 4279` to use --wait-for-delivery")
+}
+
+func TestIsRawFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "event.json", []byte(`{"type": "customer.created"}`), 0644))
+
+	tc := &triggerCmd{fs: fs, raw: "event.json"}
+	isFile, err := tc.isRawFile()
+	require.NoError(t, err)
+	require.True(t, isFile)
+
+	tc = &triggerCmd{fs: fs, raw: `{"fixtures": []}`}
+	isFile, err = tc.isRawFile()
+	require.NoError(t, err)
+	require.False(t, isFile)
+
+	tc = &triggerCmd{fs: fs, raw: "./evnt.json"}
+	_, err = tc.isRawFile()
+	require.EqualError(t, err, "--raw: file not found: ./evnt.json")
+
+	tc = &triggerCmd{fs: fs, raw: `{"fixtures": []}`, forwardURL: "http://localhost:4242/webhook"}
+	_, err = tc.isRawFile()
+	require.EqualError(t, err, `--raw: file not found: {"fixtures": []}`)
+}
+
+func TestSendCustomEvent(t *testing.T) {
+	status := http.StatusAccepted
+	var sent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/events", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		sent = string(body)
+		if status != http.StatusAccepted {
+			http.Error(w, "Invalid custom event", status)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+
+	err := sendCustomEvent(context.Background(), http.DefaultClient, ts.URL, []byte(`{"id": "evt_123"}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"id": "evt_123"}`, sent)
+
+	status = http.StatusBadRequest
+	err = sendCustomEvent(context.Background(), http.DefaultClient, ts.URL, []byte(`{}`))
+	require.EqualError(t, err, "`stripe listen` rejected the custom event: Invalid custom event")
+
+	// falls back to --forward-to when no session can take the event
+	status = http.StatusServiceUnavailable
+	err = sendCustomEvent(context.Background(), http.DefaultClient, ts.URL, []byte(`{}`))
+	require.ErrorAs(t, err, &listenUnavailableError{})
+
+	ts.Close()
+	err = sendCustomEvent(context.Background(), http.DefaultClient, ts.URL, []byte(`{}`))
+	require.ErrorAs(t, err, &listenUnavailableError{})
+}
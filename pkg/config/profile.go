@@ -23,6 +23,9 @@ type Profile struct {
 	TerminalPOSDeviceID    string
 	DisplayName            string
 	AccountID              string
+	// StripeAccount is the connected account of --stripe-account, which the
+	// API requests are made on. It isn't saved.
+	StripeAccount string
 }
 
 // CreateProfile creates a profile when logging in
@@ -107,6 +110,12 @@ func (p *Profile) GetAPIKey(livemode bool) (string, error) {
 		return p.APIKey, nil
 	}
 
+	return p.GetStoredAPIKey(livemode)
+}
+
+// GetStoredAPIKey returns the key stored in the config file for the profile,
+// ignoring STRIPE_API_KEY, for commands using the keys of several profiles
+func (p *Profile) GetStoredAPIKey(livemode bool) (string, error) {
 	// If the user doesn't have an api_key field set, they might be using an
 	// old configuration so try to read from secret_key
 	if !livemode {
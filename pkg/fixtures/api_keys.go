@@ -0,0 +1,101 @@
+package fixtures
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+	"github.com/stripe/stripe-cli/pkg/validators"
+)
+
+// A step may make its request with another API key than the fixture's, like
+// the restricted key of a connected account, taken from a profile of the
+// config or from an environment variable:
+//
+//	{"name": "charge", "profile": "connected", ...}
+//	{"name": "charge", "api_key_env": "CONNECTED_KEY", ...}
+//
+// The keys are resolved before the first request, so that a missing profile
+// or variable fails the run before it creates anything. Later steps reference
+// the responses of a step whichever key made its request.
+
+// resolveAPIKeys resolves the API keys of the selected steps that set their
+// own, which are masked like secrets
+func (fxt *Fixture) resolveAPIKeys() error {
+	fxt.stepKeys = make(map[string]string)
+
+	for _, data := range fxt.fixture.Fixtures {
+		if !fxt.isSelected(data) {
+			continue
+		}
+
+		key, err := fxt.stepAPIKey(data)
+		if err != nil {
+			return err
+		}
+		if key != "" {
+			fxt.stepKeys[data.Name] = key
+			fxt.secretValues = append(fxt.secretValues, key)
+		}
+	}
+
+	return nil
+}
+
+// stepAPIKey returns the API key the step sets, if any. Errors never contain
+// the key.
+func (fxt *Fixture) stepAPIKey(data fixture) (string, error) {
+	switch {
+	case data.Profile != "" && data.APIKeyEnv != "":
+		return "", fmt.Errorf("Fixture %s sets both profile and api_key_env, only one of them can be set", data.Name)
+	case data.Profile != "":
+		key, err := fxt.profileAPIKey(data.Profile)
+		if err != nil {
+			return "", fmt.Errorf("Failed to get the API key of the profile %s for fixture %s: %v", data.Profile, data.Name, err)
+		}
+		return key, nil
+	case data.APIKeyEnv != "":
+		key := os.Getenv(data.APIKeyEnv)
+		if key == "" {
+			return "", fmt.Errorf("Fixture %s uses the API key of the environment variable %s, which is not set", data.Name, data.APIKeyEnv)
+		}
+		if err := validators.APIKey(key); err != nil {
+			return "", fmt.Errorf("Invalid API key in the environment variable %s for fixture %s: %v", data.APIKeyEnv, data.Name, err)
+		}
+		return key, nil
+	default:
+		return "", nil
+	}
+}
+
+func (fxt *Fixture) profileAPIKey(name string) (string, error) {
+	if fxt.ProfileAPIKey != nil {
+		return fxt.ProfileAPIKey(name)
+	}
+
+	profile := config.Profile{ProfileName: name}
+
+	return profile.GetStoredAPIKey(false)
+}
+
+// apiKeyOf returns the API key of the request of the step
+func (fxt *Fixture) apiKeyOf(data fixture) string {
+	if key, ok := fxt.stepKeys[data.Name]; ok {
+		return key
+	}
+
+	return fxt.APIKey
+}
+
+// apiKeySource describes where the API key of the step comes from, for dry
+// runs
+func apiKeySource(data fixture) string {
+	switch {
+	case data.Profile != "":
+		return "profile " + data.Profile
+	case data.APIKeyEnv != "":
+		return "$" + data.APIKeyEnv
+	default:
+		return ""
+	}
+}
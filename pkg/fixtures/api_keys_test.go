@@ -0,0 +1,128 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const connectedKey = "rk_test_connected123"
+
+const apiKeysFixture = `{
+	"fixtures": [
+		{"name": "account", "path": "/v1/accounts", "method": "post"},
+		{"name": "charge", "path": "/v1/charges", "method": "post", "profile": "connected", "params": {"description": "${account:id}"}},
+		{"name": "refund", "path": "/v1/refunds", "method": "post", "api_key_env": "CONNECTED_KEY", "params": {"charge": "${charge:id}"}}
+	]
+}`
+
+func profileKeys(keys map[string]string) func(string) (string, error) {
+	return func(profile string) (string, error) {
+		if key, ok := keys[profile]; ok {
+			return key, nil
+		}
+		return "", errors.New("you have not configured API keys yet")
+	}
+}
+
+func TestStepAPIKeys(t *testing.T) {
+	t.Setenv("CONNECTED_KEY", connectedKey)
+
+	keys := make(map[string]string)
+	params := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		keys[req.URL.Path] = req.Header.Get("Authorization")
+
+		switch req.URL.Path {
+		case "/v1/accounts":
+			res.Write([]byte(`{"id": "acct_123", "object": "account"}`))
+		case "/v1/charges":
+			params["description"] = req.PostForm.Get("description")
+			res.Write([]byte(`{"id": "ch_123", "object": "charge"}`))
+		case "/v1/refunds":
+			params["charge"] = req.PostForm.Get("charge")
+			res.Write([]byte(`{"id": "re_123", "object": "refund"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, apiKeysFixture)
+	require.NoError(t, err)
+	fxt.ProfileAPIKey = profileKeys(map[string]string{"connected": "sk_test_profile123"})
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"/v1/accounts": "Bearer " + apiKey,
+		"/v1/charges":  "Bearer sk_test_profile123",
+		"/v1/refunds":  "Bearer " + connectedKey,
+	}, keys)
+	require.Equal(t, map[string]string{"description": "acct_123", "charge": "ch_123"}, params)
+}
+
+func TestStepAPIKeysMissingProfile(t *testing.T) {
+	t.Setenv("CONNECTED_KEY", connectedKey)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		t.Errorf("Unexpected request to %s", req.URL.Path)
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, apiKeysFixture)
+	require.NoError(t, err)
+	fxt.ProfileAPIKey = profileKeys(map[string]string{})
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "Failed to get the API key of the profile connected for fixture charge: you have not configured API keys yet")
+}
+
+func TestStepAPIKeysInvalidEnv(t *testing.T) {
+	t.Setenv("CONNECTED_KEY", "pk_test_publishable123")
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", apiKeysFixture)
+	require.NoError(t, err)
+	fxt.ProfileAPIKey = profileKeys(map[string]string{"connected": "sk_test_profile123"})
+
+	_, err = fxt.DryRun()
+	require.EqualError(t, err, "Invalid API key in the environment variable CONNECTED_KEY for fixture refund: the CLI only supports using a secret or restricted key")
+	require.NotContains(t, err.Error(), "pk_test_publishable123")
+}
+
+func TestStepAPIKeysDryRun(t *testing.T) {
+	t.Setenv("CONNECTED_KEY", connectedKey)
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", apiKeysFixture)
+	require.NoError(t, err)
+	fxt.ProfileAPIKey = profileKeys(map[string]string{"connected": "sk_test_profile123"})
+
+	steps, err := fxt.DryRun()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, PrintDryRun(&out, steps, "default"))
+	require.Contains(t, out.String(), "    API key: profile connected\n")
+	require.Contains(t, out.String(), "    API key: $CONNECTED_KEY\n")
+	require.NotContains(t, out.String(), connectedKey)
+}
+
+func TestStepAPIKeysBothSet(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", `{
+		"fixtures": [
+			{"name": "charge", "path": "/v1/charges", "method": "post", "profile": "connected", "api_key_env": "CONNECTED_KEY"}
+		]
+	}`)
+	require.NoError(t, err)
+
+	_, err = fxt.DryRun()
+	require.EqualError(t, err, "Fixture charge sets both profile and api_key_env, only one of them can be set")
+}
@@ -0,0 +1,130 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// A fixture step may assert fields of its response, by path:
+//
+//	"assert": {
+//	  "status": "succeeded",
+//	  "metadata.tenant": "alice",
+//	  "charges.data.0.paid": true,
+//	  "customer": "${cust:id}",
+//	  "latest_charge": {"exists": true}
+//	}
+//
+// Paths are dotted and go through maps and arrays alike. A field is compared
+// with its expected value, which may reference previous steps, except for
+// `{"exists": true}` and `{"exists": false}` which only check whether the
+// field is set. A field that is null or missing equals null.
+
+// checkAssertions returns an error listing the assertions of the step the
+// response fails
+func (fxt *Fixture) checkAssertions(data fixture, response gjson.Result) error {
+	if len(data.Assert) == 0 || fxt.IgnoreAssertions {
+		return nil
+	}
+
+	paths := make([]string, 0, len(data.Assert))
+	for path := range data.Assert {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var failures []string
+	for _, path := range paths {
+		failure, err := fxt.checkAssertion(response.Get(path), data.Assert[path])
+		if err != nil {
+			return fmt.Errorf("Invalid assertion %s for fixture %s: %v", path, data.Name, err)
+		}
+		if failure != "" {
+			failures = append(failures, fmt.Sprintf("  %s: %s", path, failure))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Assertions failed for fixture %s:\n%s", data.Name, strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// checkAssertion returns why the field doesn't match the expected value, or
+// an empty string if it does
+func (fxt *Fixture) checkAssertion(actual gjson.Result, expected interface{}) (string, error) {
+	if presence, ok := expected.(map[string]interface{}); ok && len(presence) == 1 {
+		if exists, ok := presence["exists"].(bool); ok {
+			isSet := actual.Exists() && actual.Type != gjson.Null
+			switch {
+			case exists && !isSet:
+				return "expected to be set, got " + describe(actual), nil
+			case !exists && isSet:
+				return "expected not to be set, got " + describe(actual), nil
+			}
+			return "", nil
+		}
+	}
+
+	if s, ok := expected.(string); ok {
+		if _, isQuery := toFixtureQuery(s); isQuery {
+			value, err := fxt.parseQuery(s)
+			if err != nil {
+				return "", err
+			}
+			expected = value
+		}
+	}
+
+	var matches bool
+	switch e := expected.(type) {
+	case nil:
+		matches = !actual.Exists() || actual.Type == gjson.Null
+	case string:
+		matches = actual.Type == gjson.String && actual.String() == e
+	case float64:
+		matches = actual.Type == gjson.Number && actual.Float() == e
+	case bool:
+		matches = (actual.Type == gjson.True || actual.Type == gjson.False) && actual.Bool() == e
+	default:
+		matches = actual.Exists() && reflect.DeepEqual(actual.Value(), normalize(e))
+	}
+
+	if matches {
+		return "", nil
+	}
+
+	want, err := json.Marshal(expected)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("expected %s, got %s", want, describe(actual)), nil
+}
+
+// describe returns the JSON of the field, or `missing` if the response
+// doesn't have it
+func describe(actual gjson.Result) string {
+	if !actual.Exists() {
+		return "missing"
+	}
+
+	return actual.Raw
+}
+
+// normalize converts the value to the types gjson decodes JSON to, so that
+// maps and arrays can be compared with the Value of a result
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	return gjson.ParseBytes(data).Value()
+}
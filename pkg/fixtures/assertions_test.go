@@ -0,0 +1,96 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+const assertionsTestFixture = `
+{
+	"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post"},
+		{
+			"name": "pi",
+			"path": "/v1/payment_intents",
+			"method": "post",
+			"assert": {
+				"status": "succeeded",
+				"metadata.tenant": "alice",
+				"customer": "${cust:id}",
+				"charges.data.0.amount": 2000,
+				"charges.data.0.paid": false,
+				"latest_charge": {"exists": true}
+			}
+		}
+	]
+}`
+
+func TestAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123"}`))
+		default:
+			res.Write([]byte(`{
+				"id": "pi_123",
+				"status": "requires_payment_method",
+				"customer": "cus_123",
+				"metadata": {"tenant": "bob"},
+				"charges": {"data": [{"amount": 2000, "paid": true}]},
+				"latest_charge": null
+			}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, assertionsTestFixture)
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, `Assertions failed for fixture pi:
+  charges.data.0.paid: expected false, got true
+  latest_charge: expected to be set, got null
+  metadata.tenant: expected "alice", got "bob"
+  status: expected "succeeded", got "requires_payment_method"`)
+
+	fxt, err = NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, assertionsTestFixture)
+	require.NoError(t, err)
+	fxt.IgnoreAssertions = true
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+}
+
+func TestCheckAssertion(t *testing.T) {
+	fxt := Fixture{}
+	response := gjson.Parse(`{"amount": 2000, "livemode": false, "cancel_at": null, "lines": {"data": [{"id": "li_1"}]}, "tags": ["a", "b"]}`)
+
+	tests := []struct {
+		path     string
+		expected interface{}
+		failure  string
+	}{
+		{"amount", 2000.0, ""},
+		{"amount", "2000", `expected "2000", got 2000`},
+		{"livemode", false, ""},
+		{"cancel_at", nil, ""},
+		{"missing", nil, ""},
+		{"missing", "x", `expected "x", got missing`},
+		{"lines.data.0.id", "li_1", ""},
+		{"lines.data.1.id", map[string]interface{}{"exists": false}, ""},
+		{"tags", []interface{}{"a", "b"}, ""},
+		{"tags", []interface{}{"b", "a"}, `expected ["b","a"], got ["a", "b"]`},
+	}
+
+	for _, test := range tests {
+		failure, err := fxt.checkAssertion(response.Get(test.path), test.expected)
+		require.NoError(t, err)
+		require.Equal(t, test.failure, failure, test.path)
+	}
+}
@@ -0,0 +1,136 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// The catalog describes the events `stripe trigger` supports, for editor
+// plugins and other tools. Its JSON fields are part of the interface of the
+// CLI, fields may be added but not renamed or removed.
+
+// TriggerInfo describes a supported trigger event
+type TriggerInfo struct {
+	Event       string `json:"event"`
+	Description string `json:"description"`
+	// Steps are the names of the steps of the fixture, in the order they run
+	Steps []string `json:"steps"`
+	// ObjectsCreated are the types of the objects the steps create, like
+	// customer or checkout.session
+	ObjectsCreated []string `json:"objects_created"`
+}
+
+// namespacedResources are the first segments of the paths of resources whose
+// object types are namespaced, like issuing.card for /v1/issuing/cards
+var namespacedResources = map[string]bool{
+	"billing_portal": true,
+	"checkout":       true,
+	"identity":       true,
+	"issuing":        true,
+	"radar":          true,
+	"terminal":       true,
+	"test_helpers":   true,
+	"treasury":       true,
+}
+
+// Catalog returns the supported trigger events, sorted by name
+func Catalog() ([]TriggerInfo, error) {
+	catalog := make([]TriggerInfo, 0, len(Events))
+	for _, event := range EventNames() {
+		f, err := triggers.Open(Events[event])
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var parsed fixtureFile
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("Failed to parse the fixture of %s: %v", event, err)
+		}
+
+		info := TriggerInfo{
+			Event:          event,
+			Description:    parsed.Meta.Description,
+			Steps:          make([]string, 0, len(parsed.Fixtures)),
+			ObjectsCreated: []string{},
+		}
+		for _, data := range parsed.Fixtures {
+			info.Steps = append(info.Steps, data.Name)
+
+			object := createdObject(data)
+			if object != "" && !isNameIn(object, info.ObjectsCreated) {
+				info.ObjectsCreated = append(info.ObjectsCreated, object)
+			}
+		}
+
+		catalog = append(catalog, info)
+	}
+
+	return catalog, nil
+}
+
+// createdObject returns the type of the object the step creates, if it posts
+// to a collection like /v1/customers or /v1/customers/${customer:id}/sources,
+// and not an action like /v1/invoices/${invoice:id}/pay
+func createdObject(data fixture) string {
+	if strings.ToLower(data.Method) != "post" {
+		return ""
+	}
+
+	segments := strings.Split(strings.TrimPrefix(data.Path, "/v1/"), "/")
+	last := segments[len(segments)-1]
+	if strings.Contains(last, "${") || !strings.HasSuffix(last, "s") {
+		return ""
+	}
+
+	// A literal segment before the collection is an ID, like
+	// /v1/customers/cus_123, unless it is a namespace
+	if len(segments) > 1 {
+		previous := segments[len(segments)-2]
+		if !strings.Contains(previous, "${") && !namespacedResources[previous] && previous != "test" {
+			return ""
+		}
+	}
+
+	object := strings.TrimSuffix(last, "s")
+	if namespacedResources[segments[0]] && len(segments) > 1 {
+		object = segments[0] + "." + object
+	}
+
+	return object
+}
+
+// PrintCatalog prints the catalog as a table of the events and their
+// descriptions, or as JSON if format is json
+func PrintCatalog(out io.Writer, catalog []TriggerInfo, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(catalog, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	width := len("EVENT")
+	for _, info := range catalog {
+		if len(info.Event) > width {
+			width = len(info.Event)
+		}
+	}
+
+	fmt.Fprintf(out, "%-*s  %s\n", width, "EVENT", "DESCRIPTION")
+	for _, info := range catalog {
+		fmt.Fprintf(out, "%-*s  %s\n", width, info.Event, info.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	catalog, err := Catalog()
+	require.NoError(t, err)
+	require.Len(t, catalog, len(Events))
+
+	for i, info := range catalog {
+		require.NotEmpty(t, info.Description, "the fixture of %s has no description", info.Event)
+		require.NotEmpty(t, info.Steps, info.Event)
+		if i > 0 {
+			require.Less(t, catalog[i-1].Event, info.Event)
+		}
+	}
+
+	byEvent := make(map[string]TriggerInfo)
+	for _, info := range catalog {
+		byEvent[info.Event] = info
+	}
+
+	require.Equal(t, []string{"customer", "invoiceitem", "invoice", "payment_method"}, byEvent["invoice.payment_action_required"].ObjectsCreated)
+	require.Equal(t, []string{"customer", "source"}, byEvent["customer.source.updated"].ObjectsCreated)
+	require.Equal(t, []string{"issuing.cardholder", "issuing.card", "issuing.authorization"}, byEvent["issuing_authorization.request"].ObjectsCreated)
+	require.Equal(t, []string{"checkout.session", "payment_method"}, byEvent["checkout.session.completed"].ObjectsCreated)
+	require.Equal(t, []string{"customer"}, byEvent["payment_method.attached"].ObjectsCreated)
+}
+
+func TestPrintCatalog(t *testing.T) {
+	catalog := []TriggerInfo{
+		{Event: "customer.created", Description: "Creates a customer", Steps: []string{"customer"}, ObjectsCreated: []string{"customer"}},
+		{Event: "plan.deleted", Description: "Creates a plan and deletes it", Steps: []string{"plan", "plan_deleted"}, ObjectsCreated: []string{"plan"}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, PrintCatalog(&out, catalog, "default"))
+	require.Equal(t, strings.Join([]string{
+		"EVENT             DESCRIPTION",
+		"customer.created  Creates a customer",
+		"plan.deleted      Creates a plan and deletes it",
+		"",
+	}, "\n"), out.String())
+
+	out.Reset()
+	require.NoError(t, PrintCatalog(&out, catalog, "json"))
+
+	var parsed []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &parsed))
+	require.Equal(t, map[string]interface{}{
+		"event":           "plan.deleted",
+		"description":     "Creates a plan and deletes it",
+		"steps":           []interface{}{"plan", "plan_deleted"},
+		"objects_created": []interface{}{"plan"},
+	}, parsed[1])
+}
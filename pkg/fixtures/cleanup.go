@@ -0,0 +1,251 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/tidwall/gjson"
+
+	"github.com/stripe/stripe-cli/pkg/requests"
+)
+
+// The objects a run creates are listed in a manifest, so that they can be
+// deleted later with Cleanup. Objects are deleted in the reverse order they
+// were created in, so that subscriptions are canceled before their customer
+// is deleted, and objects that can't be deleted are skipped.
+
+// CreatedObject is an object created by a step of the fixture
+type CreatedObject struct {
+	Fixture string `json:"fixture"`
+	Object  string `json:"object"`
+	ID      string `json:"id"`
+	// Account is the connected account the object was created on, if any
+	Account string `json:"account,omitempty"`
+}
+
+// Manifest lists the objects created by a run, in the order they were created
+type Manifest struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Objects   []CreatedObject `json:"objects"`
+}
+
+// cleanupRequest is the request that deletes an object of a type, whose ID
+// replaces the {id} of the path
+type cleanupRequest struct {
+	method string
+	path   string
+	params []string
+}
+
+// cleanupRequests are the requests deleting, canceling or archiving the types
+// of objects that support it
+var cleanupRequests = map[string]cleanupRequest{
+	"account":               {http.MethodDelete, "/v1/accounts/{id}", nil},
+	"checkout.session":      {http.MethodPost, "/v1/checkout/sessions/{id}/expire", nil},
+	"coupon":                {http.MethodDelete, "/v1/coupons/{id}", nil},
+	"customer":              {http.MethodDelete, "/v1/customers/{id}", nil},
+	"invoice":               {http.MethodDelete, "/v1/invoices/{id}", nil},
+	"invoiceitem":           {http.MethodDelete, "/v1/invoiceitems/{id}", nil},
+	"payment_intent":        {http.MethodPost, "/v1/payment_intents/{id}/cancel", nil},
+	"plan":                  {http.MethodDelete, "/v1/plans/{id}", nil},
+	"price":                 {http.MethodPost, "/v1/prices/{id}", []string{"active=false"}},
+	"product":               {http.MethodDelete, "/v1/products/{id}", nil},
+	"quote":                 {http.MethodPost, "/v1/quotes/{id}/cancel", nil},
+	"setup_intent":          {http.MethodPost, "/v1/setup_intents/{id}/cancel", nil},
+	"subscription":          {http.MethodDelete, "/v1/subscriptions/{id}", nil},
+	"subscription_schedule": {http.MethodPost, "/v1/subscription_schedules/{id}/cancel", nil},
+	"webhook_endpoint":      {http.MethodDelete, "/v1/webhook_endpoints/{id}", nil},
+}
+
+// CleanupResult is the outcome of a cleanup
+type CleanupResult struct {
+	Deleted []CreatedObject
+	// Skipped are the objects of types that can't be deleted
+	Skipped []CreatedObject
+	// Failed are the objects whose deletion failed, in the order they were
+	// created in
+	Failed []CreatedObject
+	Errors []error
+}
+
+// recordCreated records the object of the response of a POST request, unless
+// the request updated it: its ID is a segment of the path of the request, or
+// a previous step created it
+func (fxt *Fixture) recordCreated(name, account, path string, resp []byte) {
+	response := gjson.ParseBytes(resp)
+	id := response.Get("id").String()
+	object := response.Get("object").String()
+	if id == "" || object == "" {
+		return
+	}
+
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == id {
+			return
+		}
+	}
+
+	for _, created := range fxt.created {
+		if created.ID == id {
+			return
+		}
+	}
+
+	fxt.created = append(fxt.created, CreatedObject{Fixture: name, Object: object, ID: id, Account: account})
+}
+
+// Created returns the objects created by the run, in the order they were
+// created in
+func (fxt *Fixture) Created() []CreatedObject {
+	return fxt.created
+}
+
+// WriteManifest writes the objects created by the run to a new manifest in
+// the directory, and returns its path. Nothing is written if the run created
+// no objects.
+func (fxt *Fixture) WriteManifest(dir string) (string, error) {
+	return WriteManifest(fxt.Fs, dir, fxt.created)
+}
+
+// WriteManifest writes the objects to a new manifest in the directory, and
+// returns its path. Nothing is written if there are no objects.
+func WriteManifest(fs afero.Fs, dir string, objects []CreatedObject) (string, error) {
+	if len(objects) == 0 {
+		return "", nil
+	}
+
+	manifest := Manifest{CreatedAt: time.Now().UTC(), Objects: objects}
+	file := filepath.Join(dir, manifest.CreatedAt.Format("20060102T150405.000000000")+".json")
+
+	if err := writeManifest(fs, file, manifest); err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+func writeManifest(fs afero.Fs, file string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("Failed to write the manifest of created objects: %v", err)
+	}
+	if err := afero.WriteFile(fs, file, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("Failed to write the manifest of created objects: %v", err)
+	}
+
+	return nil
+}
+
+// ReadManifest reads the manifest of a run
+func ReadManifest(fs afero.Fs, file string) (Manifest, error) {
+	var manifest Manifest
+
+	data, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return manifest, fmt.Errorf("Failed to read the manifest of created objects: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("Failed to read the manifest of created objects %s: %v", file, err)
+	}
+
+	return manifest, nil
+}
+
+// Manifests returns the paths of the manifests in the directory, from the
+// oldest to the latest run
+func Manifests(fs afero.Fs, dir string) ([]string, error) {
+	entries, err := afero.ReadDir(fs, dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Cleanup deletes the objects of the manifest in the reverse order they were
+// created in. It carries on past failures, which are returned in the result.
+func Cleanup(ctx context.Context, apiKey, baseURL string, objects []CreatedObject, out io.Writer) CleanupResult {
+	var result CleanupResult
+
+	for i := len(objects) - 1; i >= 0; i-- {
+		object := objects[i]
+
+		cleanup, ok := cleanupRequests[object.Object]
+		if !ok {
+			fmt.Fprintf(out, "Skipping %s %s, %s objects can't be deleted\n", object.Object, object.ID, object.Object)
+			result.Skipped = append(result.Skipped, object)
+			continue
+		}
+
+		req := requests.Base{
+			Method:         cleanup.method,
+			SuppressOutput: true,
+			APIBaseURL:     baseURL,
+		}
+
+		params := &requests.RequestParameters{}
+		params.AppendData(cleanup.params)
+		params.SetStripeAccount(object.Account)
+
+		path := strings.ReplaceAll(cleanup.path, "{id}", object.ID)
+		if _, err := req.MakeRequest(ctx, apiKey, path, params, true); err != nil {
+			fmt.Fprintf(out, "Failed to delete %s %s: %v\n", object.Object, object.ID, err)
+			result.Failed = append([]CreatedObject{object}, result.Failed...)
+			result.Errors = append(result.Errors, fmt.Errorf("%s %s: %v", object.Object, object.ID, err))
+			continue
+		}
+
+		fmt.Fprintf(out, "Deleted %s %s\n", object.Object, object.ID)
+		result.Deleted = append(result.Deleted, object)
+	}
+
+	return result
+}
+
+// CleanupManifest deletes the objects of the manifest. The manifest is
+// removed if all of them were deleted or skipped, and otherwise rewritten
+// with the objects that failed, so that cleaning up again retries them.
+func CleanupManifest(ctx context.Context, fs afero.Fs, apiKey, baseURL, file string, out io.Writer) (CleanupResult, error) {
+	manifest, err := ReadManifest(fs, file)
+	if err != nil {
+		return CleanupResult{}, err
+	}
+
+	result := Cleanup(ctx, apiKey, baseURL, manifest.Objects, out)
+
+	if len(result.Failed) == 0 {
+		return result, fs.Remove(file)
+	}
+
+	manifest.Objects = result.Failed
+
+	return result, writeManifest(fs, file, manifest)
+}
@@ -0,0 +1,147 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordCreated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+		case "/v1/customers/cus_123":
+			res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+		case "/v1/subscriptions":
+			res.Write([]byte(`{"id": "sub_123", "object": "subscription"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{
+		"fixtures": [
+			{"name": "cust", "path": "/v1/customers", "method": "post"},
+			{"name": "cust_updated", "path": "/v1/customers/${cust:id}", "method": "post"},
+			{"name": "cust_retrieved", "path": "/v1/customers/${cust:id}", "method": "get"},
+			{"name": "sub", "path": "/v1/subscriptions", "method": "post", "account": "acct_123"}
+		]
+	}`)
+	require.NoError(t, err)
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []CreatedObject{
+		{Fixture: "cust", Object: "customer", ID: "cus_123"},
+		{Fixture: "sub", Object: "subscription", ID: "sub_123", Account: "acct_123"},
+	}, fxt.Created())
+
+	file, err := fxt.WriteManifest("/config/fixture_runs")
+	require.NoError(t, err)
+
+	manifests, err := Manifests(fxt.Fs, "/config/fixture_runs")
+	require.NoError(t, err)
+	require.Equal(t, []string{file}, manifests)
+
+	manifest, err := ReadManifest(fxt.Fs, file)
+	require.NoError(t, err)
+	require.Equal(t, fxt.Created(), manifest.Objects)
+}
+
+func TestRecordCreatedSkipsUpdatesOfExistingObjects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/customers/cus_existing":
+			res.Write([]byte(`{"id": "cus_existing", "object": "customer"}`))
+		case "/v1/accounts/acct_existing":
+			res.Write([]byte(`{"id": "acct_existing", "object": "account"}`))
+		case "/v1/customers/cus_existing/sources":
+			res.Write([]byte(`{"id": "card_123", "object": "card"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{
+		"fixtures": [
+			{"name": "cust_updated", "path": "/v1/customers/cus_existing", "method": "post"},
+			{"name": "acct_updated", "path": "/v1/accounts/acct_existing", "method": "post"},
+			{"name": "card", "path": "/v1/customers/cus_existing/sources", "method": "post"}
+		]
+	}`)
+	require.NoError(t, err)
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []CreatedObject{
+		{Fixture: "card", Object: "card", ID: "card_123"},
+	}, fxt.Created())
+}
+
+func TestCleanupManifest(t *testing.T) {
+	var requests []string
+	hasPrice := true
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests = append(requests, strings.TrimSpace(fmt.Sprintf("%s %s %s", req.Method, req.URL.Path, req.Header.Get("Stripe-Account"))))
+
+		if req.URL.Path == "/v1/products/prod_123" && hasPrice {
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte(`{"error": {"type": "invalid_request_error", "message": "This product cannot be deleted because it has one or more user-created prices."}}`))
+			return
+		}
+		res.Write([]byte(`{"deleted": true}`))
+	}))
+	defer ts.Close()
+
+	fs := afero.NewMemMapFs()
+	file, err := WriteManifest(fs, "/config/fixture_runs", []CreatedObject{
+		{Fixture: "product", Object: "product", ID: "prod_123"},
+		{Fixture: "price", Object: "price", ID: "price_123"},
+		{Fixture: "customer", Object: "customer", ID: "cus_123", Account: "acct_123"},
+		{Fixture: "charge", Object: "charge", ID: "ch_123"},
+		{Fixture: "subscription", Object: "subscription", ID: "sub_123"},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	result, err := CleanupManifest(context.Background(), fs, apiKey, ts.URL, file, &out)
+	require.NoError(t, err)
+
+	// deletes in the reverse order and carries on past failures
+	require.Equal(t, []string{
+		"DELETE /v1/subscriptions/sub_123",
+		"DELETE /v1/customers/cus_123 acct_123",
+		"POST /v1/prices/price_123",
+		"DELETE /v1/products/prod_123",
+	}, requests)
+	require.Len(t, result.Deleted, 3)
+	require.Equal(t, []CreatedObject{{Fixture: "charge", Object: "charge", ID: "ch_123"}}, result.Skipped)
+	require.Equal(t, []CreatedObject{{Fixture: "product", Object: "product", ID: "prod_123"}}, result.Failed)
+	require.Len(t, result.Errors, 1)
+	require.Contains(t, out.String(), "Skipping charge ch_123, charge objects can't be deleted\n")
+	require.Contains(t, out.String(), "Failed to delete product prod_123")
+
+	// the manifest keeps the objects to retry
+	manifest, err := ReadManifest(fs, file)
+	require.NoError(t, err)
+	require.Equal(t, result.Failed, manifest.Objects)
+
+	// and is removed once they are deleted
+	requests = nil
+	hasPrice = false
+	_, err = CleanupManifest(context.Background(), fs, apiKey, ts.URL, file, &out)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DELETE /v1/products/prod_123"}, requests)
+
+	exists, err := afero.Exists(fs, file)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -0,0 +1,157 @@
+package fixtures
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/stripe/stripe-cli/pkg/requests"
+)
+
+// A fixture step with `only_if` only runs when its condition holds. The
+// condition compares two values with `==` or `!=`, where each value is a
+// template like `${cust:default_source}`, a quoted string like `""` or a bare
+// word like `true`. A template referencing a field the response doesn't have,
+// or has as null, is the empty string:
+//
+//	"only_if": "${cust:default_source} == \"\""
+//
+// A step with an `expected_error` block treats an error matching its code
+// and/or type as success. The error is stored as the response of the step, so
+// that later steps can reference it, like `${card:error.decline_code}`.
+
+// expectedError matches the errors a step may fail with without failing the
+// run. Each field that is set must match the error.
+type expectedError struct {
+	Code string `json:"code,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+// condition is a parsed only_if expression
+type condition struct {
+	left     string
+	operator string
+	right    string
+}
+
+// shouldRun evaluates the only_if condition of the step
+func (fxt *Fixture) shouldRun(data fixture) (bool, error) {
+	if data.OnlyIf == "" {
+		return true, nil
+	}
+
+	cond, err := parseCondition(data.OnlyIf)
+	if err != nil {
+		return false, fmt.Errorf("Invalid only_if for fixture %s: %v", data.Name, err)
+	}
+
+	left, err := fxt.conditionValue(cond.left)
+	if err != nil {
+		return false, err
+	}
+	right, err := fxt.conditionValue(cond.right)
+	if err != nil {
+		return false, err
+	}
+
+	if cond.operator == "==" {
+		return left == right, nil
+	}
+	return left != right, nil
+}
+
+// conditionValue resolves an operand of a condition
+func (fxt *Fixture) conditionValue(operand string) (string, error) {
+	if len(operand) >= 2 && (operand[0] == '"' || operand[0] == '\'') {
+		operand = operand[1 : len(operand)-1]
+	}
+
+	if _, isQuery := toFixtureQuery(operand); !isQuery {
+		return operand, nil
+	}
+
+	value, err := fxt.parseQuery(operand)
+	if err != nil {
+		return "", err
+	}
+
+	// parseQuery leaves queries of missing fields as they are
+	if value == operand {
+		return "", nil
+	}
+
+	return value, nil
+}
+
+// parseCondition splits the expression around its operator, ignoring
+// operators in quotes and templates
+func parseCondition(expression string) (condition, error) {
+	var quote byte
+	depth := 0
+	operatorAt := -1
+
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case depth == 0 && (c == '=' || c == '!') && i+1 < len(expression) && expression[i+1] == '=':
+			if operatorAt != -1 {
+				return condition{}, fmt.Errorf("%q has more than one comparison", expression)
+			}
+			operatorAt = i
+			i++
+		}
+	}
+
+	if quote != 0 {
+		return condition{}, fmt.Errorf("%q has an unterminated string", expression)
+	}
+	if operatorAt == -1 {
+		return condition{}, fmt.Errorf("%q is not a comparison, expected <value> == <value> or <value> != <value>", expression)
+	}
+
+	cond := condition{
+		left:     strings.TrimSpace(expression[:operatorAt]),
+		operator: expression[operatorAt : operatorAt+2],
+		right:    strings.TrimSpace(expression[operatorAt+2:]),
+	}
+	if cond.left == "" || cond.right == "" {
+		return condition{}, fmt.Errorf("%q is missing a value to compare", expression)
+	}
+
+	return cond, nil
+}
+
+// matches returns whether the error of the request is the expected one
+func (e *expectedError) matches(rerr requests.RequestError) bool {
+	if e.Code == "" && e.Type == "" {
+		return false
+	}
+
+	body := gjson.Parse(errorBody(rerr))
+	if e.Code != "" && body.Get("error.code").String() != e.Code {
+		return false
+	}
+	if e.Type != "" && rerr.ErrorType != e.Type {
+		return false
+	}
+
+	return true
+}
+
+// errorBody returns the body of the response of the failed request
+func errorBody(rerr requests.RequestError) string {
+	body, _ := rerr.Body.(string)
+	return body
+}
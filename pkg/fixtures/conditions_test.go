@@ -0,0 +1,121 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+const conditionsTestFixture = `
+{
+	"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post"},
+		{"name": "pm", "path": "/v1/payment_methods", "method": "post", "only_if": "${cust:default_source} == \"\""},
+		{"name": "source", "path": "/v1/sources", "method": "post", "only_if": "${cust:default_source} != ''"},
+		{"name": "card", "path": "/v1/charges", "method": "post", "expected_error": {"code": "card_declined", "type": "card_error"}},
+		{"name": "decline", "path": "/v1/refunds", "method": "post", "params": {"reason": "${card:error.decline_code}"}}
+	]
+}`
+
+func TestConditionalSteps(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		paths = append(paths, req.URL.Path+" "+req.Form.Encode())
+
+		switch req.URL.Path {
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123", "default_source": null}`))
+		case "/v1/charges":
+			res.WriteHeader(http.StatusPaymentRequired)
+			res.Write([]byte(`{"error": {"type": "card_error", "code": "card_declined", "decline_code": "insufficient_funds"}}`))
+		default:
+			res.Write([]byte(`{"id": "obj_123"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, conditionsTestFixture)
+	require.NoError(t, err)
+
+	requestNames, err := fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"cust", "pm", "", "card", "decline"}, requestNames)
+	require.Equal(t, []string{
+		"/v1/customers ",
+		"/v1/payment_methods ",
+		"/v1/charges ",
+		"/v1/refunds reason=insufficient_funds",
+	}, paths)
+	require.True(t, fxt.Output().Steps["source"].Skipped)
+}
+
+func TestUnexpectedErrorCode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusPaymentRequired)
+		res.Write([]byte(`{"error": {"type": "card_error", "code": "expired_card"}}`))
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{"fixtures": [
+		{"name": "card", "path": "/v1/charges", "method": "post", "expected_error": {"code": "card_declined"}}
+	]}`)
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.Error(t, err)
+}
+
+func TestShouldRun(t *testing.T) {
+	fxt := Fixture{
+		responses: map[string]gjson.Result{
+			"cust": gjson.Parse(`{"id": "cus_123", "livemode": false, "default_source": null}`),
+		},
+	}
+
+	tests := []struct {
+		onlyIf string
+		run    bool
+	}{
+		{`${cust:id} == "cus_123"`, true},
+		{`${cust:id} != cus_123`, false},
+		{`${cust:livemode} == false`, true},
+		{`${cust:default_source} == ""`, true},
+		{`${cust:missing} != ''`, false},
+		{`"a == b" == "a == b"`, true},
+	}
+
+	for _, test := range tests {
+		run, err := fxt.shouldRun(fixture{Name: "step", OnlyIf: test.onlyIf})
+		require.NoError(t, err, test.onlyIf)
+		require.Equal(t, test.run, run, test.onlyIf)
+	}
+}
+
+func TestShouldRunErrors(t *testing.T) {
+	fxt := Fixture{
+		responses: map[string]gjson.Result{
+			"cust": gjson.Parse(`{"id": "cus_123"}`),
+		},
+	}
+
+	tests := map[string]string{
+		`${cust:id}`:                `Invalid only_if for fixture step: "${cust:id}" is not a comparison, expected <value> == <value> or <value> != <value>`,
+		`${cust:id} == "cus_123`:    `Invalid only_if for fixture step: "${cust:id} == \"cus_123" has an unterminated string`,
+		`== "cus_123"`:              `Invalid only_if for fixture step: "== \"cus_123\"" is missing a value to compare`,
+		`${cust:id} == a == b`:      `Invalid only_if for fixture step: "${cust:id} == a == b" has more than one comparison`,
+		`${charge:id} == "cus_123"`: "an undeclared fixture name was referenced",
+	}
+
+	for onlyIf, message := range tests {
+		_, err := fxt.shouldRun(fixture{Name: "step", OnlyIf: onlyIf})
+		require.Error(t, err, onlyIf)
+		require.Contains(t, err.Error(), message)
+	}
+}
@@ -0,0 +1,53 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CustomEvent validates the payload of a custom event and applies the
+// overrides to it, with `path.to.field=value` like
+// `data.object.reason=fraudulent`. An override with the value `-` removes the
+// field. The payload must have an id, a type and a data.object.
+func CustomEvent(payload []byte, overrides []string) ([]byte, error) {
+	var event map[string]interface{}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("Invalid custom event, the payload is not a JSON object: %v", err)
+	}
+
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid override %s, expected path.to.field=value", override)
+		}
+
+		path, err := parseFieldPath(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		mode := rewriteOverride
+		if parts[1] == removeSentinel {
+			mode = rewriteRemove
+		}
+		event = rewriteValue(event, path, parts[1], mode).(map[string]interface{})
+	}
+
+	var missing []string
+	if id, _ := event["id"].(string); id == "" {
+		missing = append(missing, "id")
+	}
+	if eventType, _ := event["type"].(string); eventType == "" {
+		missing = append(missing, "type")
+	}
+	data, _ := event["data"].(map[string]interface{})
+	if _, ok := data["object"].(map[string]interface{}); !ok {
+		missing = append(missing, "data.object")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("Invalid custom event, it is missing %s", strings.Join(missing, ", "))
+	}
+
+	return json.Marshal(event)
+}
@@ -0,0 +1,44 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const customEventPayload = `{
+	"id": "evt_123",
+	"object": "event",
+	"type": "charge.dispute.created",
+	"data": {"object": {"id": "dp_123", "amount": 1000, "reason": "general", "evidence": {"receipt": "file_123"}}}
+}`
+
+func TestCustomEvent(t *testing.T) {
+	payload, err := CustomEvent([]byte(customEventPayload), []string{
+		"data.object.reason=fraudulent",
+		"data.object.amount=2500",
+		"data.object.evidence.receipt=-",
+	})
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"id": "evt_123",
+		"object": "event",
+		"type": "charge.dispute.created",
+		"data": {"object": {"id": "dp_123", "amount": 2500, "reason": "fraudulent", "evidence": {}}}
+	}`, string(payload))
+}
+
+func TestCustomEventErrors(t *testing.T) {
+	_, err := CustomEvent([]byte(`[]`), nil)
+	require.Error(t, err)
+
+	_, err = CustomEvent([]byte(`{"type": "charge.succeeded", "data": {}}`), nil)
+	require.EqualError(t, err, "Invalid custom event, it is missing id, data.object")
+
+	_, err = CustomEvent([]byte(customEventPayload), []string{"type=-"})
+	require.EqualError(t, err, "Invalid custom event, it is missing type")
+
+	_, err = CustomEvent([]byte(customEventPayload), []string{"data.object.reason"})
+	require.EqualError(t, err, "Invalid override data.object.reason, expected path.to.field=value")
+}
@@ -0,0 +1,131 @@
+package fixtures
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/tidwall/gjson"
+)
+
+// A directory of fixture files runs them one after the other, in the order of
+// their names, like 01-products.json, 02-customers.json then
+// 03-subscriptions.json. An `order` file in the directory lists the files to
+// run instead, one per line. The fixtures of a directory share a RunContext,
+// so that a file references the steps of the previous ones, like ${cust:id}.
+
+// orderFile is the file listing the fixture files of a directory in the order
+// they run
+const orderFile = "order"
+
+// RunContext is the state the fixtures of a directory share
+type RunContext struct {
+	responses map[string]gjson.Result
+	statuses  map[string]int
+	headers   map[string]http.Header
+	vars      map[string]string
+	// steps are the names of the steps of the fixtures using the context
+	steps []string
+}
+
+// NewRunContext creates a context for fixtures run one after the other
+func NewRunContext() *RunContext {
+	return &RunContext{
+		responses: make(map[string]gjson.Result),
+		statuses:  make(map[string]int),
+		headers:   make(map[string]http.Header),
+		vars:      make(map[string]string),
+	}
+}
+
+// UseContext makes the fixture share the outputs of its steps and its
+// variables with the other fixtures using the context. It is called on each
+// fixture before running any of them, so that --only, --set and dry runs know
+// the steps of all of them.
+func (fxt *Fixture) UseContext(rc *RunContext) {
+	for name, response := range fxt.responses {
+		rc.responses[name] = response
+	}
+	for name, value := range fxt.vars {
+		rc.vars[name] = value
+	}
+
+	fxt.responses = rc.responses
+	fxt.statuses = rc.statuses
+	fxt.headers = rc.headers
+	fxt.vars = rc.vars
+
+	for _, data := range fxt.fixture.Fixtures {
+		rc.steps = append(rc.steps, data.Name)
+	}
+	fxt.context = rc
+}
+
+// DirectoryFiles returns the fixture files of the directory in the order they
+// run
+func DirectoryFiles(fs afero.Fs, dir string) ([]string, error) {
+	order := filepath.Join(dir, orderFile)
+	if exists, _ := afero.Exists(fs, order); exists {
+		return orderedFiles(fs, dir, order)
+	}
+
+	// ReadDir sorts the entries by name
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the fixtures directory %s: %v", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("The fixtures directory %s has no .json fixture files", dir)
+	}
+
+	return files, nil
+}
+
+// orderedFiles returns the files listed by the order file, skipping blank
+// lines and # comments
+func orderedFiles(fs afero.Fs, dir, order string) ([]string, error) {
+	content, err := afero.ReadFile(fs, order)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %v", order, err)
+	}
+
+	var files []string
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		file := line
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if exists, _ := afero.Exists(fs, file); !exists {
+			return nil, fmt.Errorf("The fixture file %s listed on line %d of %s does not exist", line, i+1, order)
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s lists no fixture files", order)
+	}
+
+	return files, nil
+}
+
+// FailedStep returns the step the last run of the fixture failed at, if it
+// failed while running one
+func (fxt *Fixture) FailedStep() string {
+	return fxt.failedStep
+}
@@ -0,0 +1,107 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "seed/02-customers.json", []byte(`{}`), 0644)
+	afero.WriteFile(fs, "seed/01-products.json", []byte(`{}`), 0644)
+	afero.WriteFile(fs, "seed/README.md", []byte(``), 0644)
+	fs.MkdirAll("seed/shared.json", 0755)
+
+	files, err := DirectoryFiles(fs, "seed")
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join("seed", "01-products.json"), filepath.Join("seed", "02-customers.json")}, files)
+
+	afero.WriteFile(fs, "seed/order", []byte("# customers first\n02-customers.json\n\n01-products.json\n"), 0644)
+	files, err = DirectoryFiles(fs, "seed")
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join("seed", "02-customers.json"), filepath.Join("seed", "01-products.json")}, files)
+
+	afero.WriteFile(fs, "seed/order", []byte("02-customers.json\n03-subscriptions.json\n"), 0644)
+	_, err = DirectoryFiles(fs, "seed")
+	require.EqualError(t, err, "The fixture file 03-subscriptions.json listed on line 2 of "+filepath.Join("seed", "order")+" does not exist")
+
+	_, err = DirectoryFiles(fs, "empty")
+	require.Error(t, err)
+}
+
+func TestRunContext(t *testing.T) {
+	params := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		switch req.URL.Path {
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+		case "/v1/subscriptions":
+			params["customer"] = req.PostForm.Get("customer")
+			params["price"] = req.PostForm.Get("items[0][price]")
+			res.Write([]byte(`{"id": "sub_123", "object": "subscription"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "seed/01-customers.json", []byte(`{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post"}]}`), 0644)
+	afero.WriteFile(fs, "seed/02-subscriptions.json", []byte(`{"fixtures": [{"name": "sub", "path": "/v1/subscriptions", "method": "post", "params": {"customer": "${cust:id}", "items": [{"price": "${var:price}"}]}}]}`), 0644)
+
+	rc := NewRunContext()
+	var loaded []*Fixture
+	for _, file := range []string{"seed/01-customers.json", "seed/02-subscriptions.json"} {
+		fxt, err := NewFixtureFromFile(fs, apiKey, "", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+		require.NoError(t, err)
+		fxt.Out = &bytes.Buffer{}
+		fxt.UseContext(rc)
+		loaded = append(loaded, fxt)
+	}
+
+	// the values set on any of the fixtures are shared
+	require.NoError(t, loaded[0].SetValues([]string{"price=price_123"}))
+
+	// the dry run of a later fixture has placeholders for the earlier steps
+	steps, err := loaded[1].DryRun()
+	require.NoError(t, err)
+	require.Equal(t, []string{"customer=<cust.id>", "items[0][price]=price_123"}, steps[0].Params)
+
+	for _, fxt := range loaded {
+		_, err := fxt.Execute(context.Background())
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[string]string{"customer": "cus_123", "price": "price_123"}, params)
+	require.Len(t, loaded[1].Output().Steps, 2)
+}
+
+func TestFailedStep(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/charges" {
+			res.WriteHeader(http.StatusPaymentRequired)
+			res.Write([]byte(`{"error": {"type": "card_error", "code": "card_declined", "message": "Your card was declined."}}`))
+			return
+		}
+		res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{
+		"fixtures": [
+			{"name": "cust", "path": "/v1/customers", "method": "post"},
+			{"name": "charge", "path": "/v1/charges", "method": "post"}
+		]
+	}`)
+	require.NoError(t, err)
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.Error(t, err)
+	require.Equal(t, "charge", fxt.FailedStep())
+}
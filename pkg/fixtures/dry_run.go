@@ -0,0 +1,159 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+)
+
+// A dry run resolves the requests of the fixture without making them. Steps
+// have no responses to reference, so references to them are shown as
+// placeholders like `<cust.id>`, and only_if conditions are not evaluated.
+
+// placeholderRegexp matches the placeholders of references to steps
+var placeholderRegexp = regexp.MustCompile(`<[^<>]+\.[^<>]+>`)
+
+// DryRunStep is the request a fixture step would make
+type DryRunStep struct {
+	Name           string   `json:"name"`
+	Method         string   `json:"method,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	Params         []string `json:"params,omitempty"`
+	Account        string   `json:"account,omitempty"`
+	IdempotencyKey string   `json:"idempotency_key,omitempty"`
+	APIVersion     string   `json:"api_version,omitempty"`
+	APIKeySource   string   `json:"api_key_source,omitempty"`
+	OnlyIf         string   `json:"only_if,omitempty"`
+	Skipped        bool     `json:"skipped,omitempty"`
+}
+
+// DryRun returns the requests the fixture would make, with their params
+// sorted and the values of secret environment variables masked
+func (fxt *Fixture) DryRun() ([]DryRunStep, error) {
+	if err := fxt.checkAPIVersions(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkSelection(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkUploads(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.resolveAPIKeys(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkVars(); err != nil {
+		return nil, err
+	}
+
+	fxt.dryRun = true
+	defer func() { fxt.dryRun = false }()
+
+	steps := make([]DryRunStep, 0, len(fxt.fixture.Fixtures))
+	for _, data := range fxt.fixture.Fixtures {
+		if !fxt.isSelected(data) {
+			steps = append(steps, DryRunStep{Name: data.Name, Skipped: true})
+			continue
+		}
+
+		prepared, err := fxt.prepareRequest(data)
+		if err != nil {
+			return nil, err
+		}
+
+		params := make([]string, len(prepared.params))
+		for i, param := range prepared.params {
+			params[i] = fxt.maskSecrets(param)
+		}
+		if prepared.upload != nil {
+			// as in curl -F file=@path
+			params = append(params, "file=@"+prepared.upload.file, "purpose="+prepared.upload.purpose)
+		}
+		// the params of a map are in no particular order, sort them so that
+		// dry runs can be diffed
+		sort.Strings(params)
+
+		steps = append(steps, DryRunStep{
+			Name:           data.Name,
+			Method:         prepared.method,
+			Path:           fxt.maskSecrets(prepared.path),
+			Params:         params,
+			Account:        prepared.account,
+			IdempotencyKey: fxt.maskSecrets(prepared.idempotencyKey),
+			APIVersion:     prepared.apiVersion,
+			APIKeySource:   apiKeySource(data),
+			OnlyIf:         data.OnlyIf,
+		})
+	}
+
+	return steps, nil
+}
+
+// PrintDryRun prints the steps of a dry run, as JSON if format is json
+func PrintDryRun(out io.Writer, steps []DryRunStep, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(map[string][]DryRunStep{"steps": steps}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	for _, step := range steps {
+		if step.Skipped {
+			fmt.Fprintf(out, "%s: skipped\n", step.Name)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s: %s %s\n", step.Name, step.Method, step.Path)
+		for _, param := range step.Params {
+			fmt.Fprintf(out, "    %s\n", param)
+		}
+		if step.Account != "" {
+			fmt.Fprintf(out, "    Stripe-Account: %s\n", step.Account)
+		}
+		if step.IdempotencyKey != "" {
+			fmt.Fprintf(out, "    Idempotency-Key: %s\n", step.IdempotencyKey)
+		}
+		if step.APIVersion != "" {
+			fmt.Fprintf(out, "    Stripe-Version: %s\n", step.APIVersion)
+		}
+		if step.APIKeySource != "" {
+			fmt.Fprintf(out, "    API key: %s\n", step.APIKeySource)
+		}
+		if step.OnlyIf != "" {
+			fmt.Fprintf(out, "    Only if: %s\n", step.OnlyIf)
+		}
+	}
+
+	return nil
+}
+
+// placeholder is shown in a dry run instead of the value of a reference to
+// a step
+func placeholder(query fixtureQuery) string {
+	return fmt.Sprintf("<%s.%s>", query.Name, query.Query)
+}
+
+func isPlaceholder(value string) bool {
+	return placeholderRegexp.MatchString(value)
+}
+
+// declaresStep returns whether the fixture has a step with the name
+func (fxt *Fixture) declaresStep(name string) bool {
+	for _, data := range fxt.fixture.Fixtures {
+		if data.Name == name {
+			return true
+		}
+	}
+
+	// The steps of the other fixtures of the directory
+	return fxt.context != nil && isNameIn(name, fxt.context.steps)
+}
@@ -0,0 +1,81 @@
+package fixtures
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const dryRunTestFixture = `
+{
+	"_meta": {"template_version": 0},
+	"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post", "params": {"description": "created by ${.env:DRY_RUN_USER}"}},
+		{"name": "connected", "path": "/v1/accounts", "method": "post", "params": {"type": "custom"}},
+		{"name": "charge", "path": "/v1/charges", "method": "post", "account": "${connected:id}", "params": {"customer": "${cust:id}", "amount": 2000}},
+		{"name": "get_cust", "path": "/v1/customers/${cust:id}", "method": "get", "only_if": "${cust:email} != \"\""},
+		{"name": "refund", "path": "/v1/refunds", "method": "post"}
+	]
+}`
+
+func TestDryRun(t *testing.T) {
+	os.Setenv("DRY_RUN_USER", "jenny")
+	defer os.Unsetenv("DRY_RUN_USER")
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, file, []byte(dryRunTestFixture), os.ModePerm)
+
+	// make no requests
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", "http://localhost:1", file, []string{"refund"}, []string{"charge:amount=3000"}, []string{}, []string{})
+	require.NoError(t, err)
+	fxt.IdempotencyKeyPrefix = "run1"
+
+	steps, err := fxt.DryRun()
+	require.NoError(t, err)
+	require.Equal(t, []DryRunStep{
+		{Name: "cust", Method: "POST", Path: "/v1/customers", Params: []string{"description=created by jenny"}, IdempotencyKey: "run1-cust"},
+		{Name: "connected", Method: "POST", Path: "/v1/accounts", Params: []string{"type=custom"}, IdempotencyKey: "run1-connected"},
+		{Name: "charge", Method: "POST", Path: "/v1/charges", Params: []string{"amount=3000", "customer=<cust.id>"}, Account: "<connected.id>", IdempotencyKey: "run1-charge"},
+		{Name: "get_cust", Method: "GET", Path: "/v1/customers/<cust.id>", Params: []string{}, OnlyIf: `${cust:email} != ""`},
+		{Name: "refund", Skipped: true},
+	}, steps)
+
+	var out bytes.Buffer
+	require.NoError(t, PrintDryRun(&out, steps, "default"))
+	require.Equal(t, `cust: POST /v1/customers
+    description=created by jenny
+    Idempotency-Key: run1-cust
+connected: POST /v1/accounts
+    type=custom
+    Idempotency-Key: run1-connected
+charge: POST /v1/charges
+    amount=3000
+    customer=<cust.id>
+    Stripe-Account: <connected.id>
+    Idempotency-Key: run1-charge
+get_cust: GET /v1/customers/<cust.id>
+    Only if: ${cust:email} != ""
+refund: skipped
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, PrintDryRun(&out, steps[3:], "json"))
+	require.JSONEq(t, `{"steps": [
+		{"name": "get_cust", "method": "GET", "path": "/v1/customers/<cust.id>", "only_if": "${cust:email} != \"\""},
+		{"name": "refund", "skipped": true}
+	]}`, out.String())
+}
+
+func TestDryRunUndeclaredReference(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", `{"fixtures": [
+		{"name": "charge", "path": "/v1/charges", "method": "post", "params": {"customer": "${cus:id}"}}
+	]}`)
+	require.NoError(t, err)
+
+	_, err = fxt.DryRun()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "an undeclared fixture name was referenced")
+}
@@ -0,0 +1,145 @@
+package fixtures
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Fixture params may reference environment variables with `${ENV:NAME}`, or
+// `${ENV:NAME|fallback}` to use a fallback when the variable is not set.
+// They are resolved once the fixture is loaded, before the queries
+// referencing previous responses, so that they can be used anywhere in the
+// params, including inside nested maps and arrays.
+
+// envInterpolationRegexp matches `${ENV:NAME}` and `${ENV:NAME|fallback}`
+var envInterpolationRegexp = regexp.MustCompile(`\$\{ENV:([A-Za-z_][A-Za-z0-9_]*)(\|[^}]*)?\}`)
+
+// maskedValue replaces the values of secret environment variables when
+// fixtures are displayed
+const maskedValue = "********"
+
+// envInterpolation resolves environment variables in fixture params
+type envInterpolation struct {
+	lookup func(string) (string, bool)
+
+	// missing is the set of variables without a value nor a fallback
+	missing map[string]bool
+	// secrets are the values of the variables whose name looks like the one
+	// of a secret, to be masked when displayed
+	secrets map[string]bool
+}
+
+// interpolateEnv resolves the environment variables referenced in the params
+// of every fixture. It fails listing all the variables that are not set and
+// have no fallback.
+func (fxt *Fixture) interpolateEnv() error {
+	loadDotEnv()
+
+	interpolation := &envInterpolation{
+		lookup:  os.LookupEnv,
+		missing: make(map[string]bool),
+		secrets: make(map[string]bool),
+	}
+
+	for i, data := range fxt.fixture.Fixtures {
+		if data.Params == nil {
+			continue
+		}
+
+		fxt.fixture.Fixtures[i].Params = interpolation.resolve(data.Params).(map[string]interface{})
+	}
+
+	if len(interpolation.missing) > 0 {
+		missing := make([]string, 0, len(interpolation.missing))
+		for name := range interpolation.missing {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+
+		return fmt.Errorf("The fixture references environment variables that are not set: %s", strings.Join(missing, ", "))
+	}
+
+	for secret := range interpolation.secrets {
+		fxt.secretValues = append(fxt.secretValues, secret)
+	}
+
+	return nil
+}
+
+// maskSecrets replaces the values of secret environment variables in s, like
+// API keys and tokens, so that they are not displayed
+func (fxt *Fixture) maskSecrets(s string) string {
+	for _, secret := range fxt.secretValues {
+		s = strings.ReplaceAll(s, secret, maskedValue)
+	}
+
+	return s
+}
+
+// resolve returns the value with the environment variables referenced in its
+// strings resolved, recursing into maps and arrays
+func (e *envInterpolation) resolve(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved[key] = e.resolve(item)
+		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved[i] = e.resolve(item)
+		}
+		return resolved
+	case string:
+		return envInterpolationRegexp.ReplaceAllStringFunc(v, e.replace)
+	default:
+		return value
+	}
+}
+
+func (e *envInterpolation) replace(match string) string {
+	groups := envInterpolationRegexp.FindStringSubmatch(match)
+	name, fallback := groups[1], groups[2]
+
+	value, ok := e.lookup(name)
+	if !ok || value == "" {
+		if fallback == "" {
+			e.missing[name] = true
+			return match
+		}
+		return strings.TrimPrefix(fallback, "|")
+	}
+
+	if isSecretName(name) {
+		e.secrets[value] = true
+	}
+
+	return value
+}
+
+// isSecretName reports whether the name of an environment variable suggests it
+// holds a secret
+func isSecretName(name string) bool {
+	name = strings.ToUpper(name)
+
+	return strings.Contains(name, "KEY") || strings.Contains(name, "SECRET") || strings.Contains(name, "TOKEN")
+}
+
+// loadDotEnv loads the variables of the .env file in the current directory, if
+// any, without overriding the ones already set
+func loadDotEnv() {
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = ""
+	}
+
+	godotenv.Load(path.Join(dir, ".env")) // #nosec G104
+}
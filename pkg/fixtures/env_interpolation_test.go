@@ -0,0 +1,70 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const envTestFixture = `
+{
+	"_meta": {
+		"template_version": 0
+	},
+	"fixtures": [
+		{
+			"name": "sub",
+			"path": "/v1/subscriptions",
+			"method": "post",
+			"params": {
+				"customer": "${cust:id}",
+				"items": [
+					{"price": "${ENV:FIXTURE_PRICE_ID}"}
+				],
+				"metadata": {
+					"email": "${ENV:FIXTURE_EMAIL|dev@example.com}",
+					"note": "key ${ENV:FIXTURE_API_KEY}"
+				}
+			}
+		}
+	]
+}`
+
+func TestInterpolateEnv(t *testing.T) {
+	t.Setenv("FIXTURE_PRICE_ID", "price_123")
+	t.Setenv("FIXTURE_API_KEY", "sk_test_secret")
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", envTestFixture)
+	require.NoError(t, err)
+
+	params := fxt.fixture.Fixtures[0].Params
+	require.Equal(t, "${cust:id}", params["customer"])
+	require.Equal(t, "price_123", params["items"].([]interface{})[0].(map[string]interface{})["price"])
+	require.Equal(t, "dev@example.com", params["metadata"].(map[string]interface{})["email"])
+	require.Equal(t, "key sk_test_secret", params["metadata"].(map[string]interface{})["note"])
+
+	content := fxt.GetFixtureFileContent()
+	require.Contains(t, content, "price_123")
+	require.Contains(t, content, "key ********")
+	require.False(t, strings.Contains(content, "sk_test_secret"))
+}
+
+func TestInterpolateEnvMissingVariables(t *testing.T) {
+	raw := `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post", "params": {
+		"email": "${ENV:FIXTURE_MISSING_B}",
+		"name": "${ENV:FIXTURE_MISSING_A}",
+		"phone": "${ENV:FIXTURE_MISSING_C|}"
+	}}]}`
+
+	_, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", raw)
+	require.EqualError(t, err, "The fixture references environment variables that are not set: FIXTURE_MISSING_A, FIXTURE_MISSING_B")
+}
+
+func TestIsSecretName(t *testing.T) {
+	require.True(t, isSecretName("STRIPE_API_KEY"))
+	require.True(t, isSecretName("webhook_secret"))
+	require.True(t, isSecretName("AUTH_TOKEN"))
+	require.False(t, isSecretName("PRICE_ID"))
+}
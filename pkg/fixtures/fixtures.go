@@ -4,33 +4,51 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/imdario/mergo"
 	"github.com/joho/godotenv"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/tidwall/gjson"
 
 	"github.com/stripe/stripe-cli/pkg/requests"
+	"github.com/stripe/stripe-cli/pkg/validators"
 )
 
 // SupportedVersions is the version number of the fixture template the CLI supports
 const SupportedVersions = 0
 
+// supportedMethods are the HTTP methods fixture steps can use
+var supportedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodDelete: true,
+}
+
 type metaFixture struct {
 	Version         int  `json:"template_version"`
 	ExcludeMetadata bool `json:"exclude_metadata"`
+	// APIVersion is sent as the Stripe-Version header of the requests of
+	// the fixture
+	APIVersion string `json:"api_version,omitempty"`
+	// Description is what the fixture does, listed by `stripe trigger --list`
+	Description string `json:"description,omitempty"`
 }
 
 type fixtureFile struct {
 	Meta     metaFixture       `json:"_meta"`
+	Include  []string          `json:"include,omitempty"`
 	Fixtures []fixture         `json:"fixtures"`
 	Env      map[string]string `json:"env"`
+	// Vars are the defaults of the variables the steps reference with
+	// ${var:name}, which --set overrides
+	Vars map[string]string `json:"vars,omitempty"`
 }
 
 type fixture struct {
@@ -39,8 +57,49 @@ type fixture struct {
 	Path              string                 `json:"path"`
 	Method            string                 `json:"method"`
 	Params            map[string]interface{} `json:"params"`
+	// Account is the connected account the request of the step is made on,
+	// instead of the fixture's StripeAccount. It may reference the output of
+	// a previous step.
+	Account string `json:"account,omitempty"`
+	// IdempotencyKey is sent as the Idempotency-Key header of the request of
+	// the step, after the fixture's IdempotencyKeyPrefix if any
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Repeat runs the step this many times, see expandRepeats
+	Repeat int `json:"repeat,omitempty"`
+	// ExpectedStatus is a status code the request of the step may fail with
+	// without failing the run, like 404 for a cleanup step deleting an object
+	// that may already be gone
+	ExpectedStatus int `json:"expected_status,omitempty"`
+	// ExpectedError is an error the request of the step may fail with
+	// without failing the run, see expectedError
+	ExpectedError *expectedError `json:"expected_error,omitempty"`
+	// OnlyIf is a condition the step only runs if it holds, see condition
+	OnlyIf string `json:"only_if,omitempty"`
+	// APIVersion is the API version of the request of the step, instead of
+	// the fixture's
+	APIVersion string `json:"api_version,omitempty"`
+	// Assert maps paths of fields of the response of the step to their
+	// expected values, see checkAssertions
+	Assert map[string]interface{} `json:"assert,omitempty"`
+	// Upload is the file the step uploads to the files API, see upload.go
+	Upload *upload `json:"upload,omitempty"`
+	// Profile is the config profile whose API key the request of the step
+	// is made with, instead of the fixture's, see api_keys.go
+	Profile string `json:"profile,omitempty"`
+	// APIKeyEnv is the environment variable holding the API key the request
+	// of the step is made with
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+
+	// repeatOf is the name of the repeated step this step is a run of
+	repeatOf string
+	// file is the fixture file declaring the step
+	file string
 }
 
+// headerQuerySuffix ends the name of the queries referencing a header of a
+// response, like ${cust:header:Request-Id}
+const headerQuerySuffix = ":header"
+
 type fixtureQuery struct {
 	Match        string // The substring that matched the query pattern regex
 	Name         string
@@ -53,17 +112,64 @@ type Fixture struct {
 	Fs            afero.Fs
 	APIKey        string
 	StripeAccount string
-	Skip          []string
-	Overrides     map[string]interface{}
-	Additions     map[string]interface{}
-	Removals      map[string]interface{}
-	BaseURL       string
+	// IdempotencyKeyPrefix derives the idempotency key of each POST request
+	// from the name of its step, so that running the fixture again with the
+	// same prefix returns the objects it created instead of creating new ones
+	IdempotencyKeyPrefix string
+	// ExpectedEvents are the types of the events the fixture is expected to
+	// trigger, written to the output file
+	ExpectedEvents []string
+	// IgnoreAssertions skips the assertions of the steps on their responses
+	IgnoreAssertions bool
+	// APIVersion is the API version of the requests of the fixture, instead
+	// of the one of its metadata. Steps with their own version keep it.
+	APIVersion string
+	Skip       []string
+	// Only runs just these steps, if set
+	Only      []string
+	Overrides map[string]interface{}
+	Additions map[string]interface{}
+	Removals  map[string]interface{}
+	BaseURL   string
+	// FilesBaseURL is the base URL of the file uploads of the fixture,
+	// stripe.DefaultFilesAPIBaseURL if empty
+	FilesBaseURL string
+	// ProfileAPIKey returns the API key of the profiles steps set, the test
+	// mode key of the profile in the config if nil
+	ProfileAPIKey func(profile string) (string, error)
+	// Out is where the progress of the run is printed, os.Stdout if nil
+	Out           io.Writer
 	responses     map[string]gjson.Result
 	fixture       fixtureFile
+	secretValues  []string
+	includedFiles []string
+	random        *randomValues
+	statuses      map[string]int
+	headers       map[string]http.Header
+	skipped       []string
+	dryRun        bool
+	remote        RemoteOptions
+	// now is the time the functions of the run are relative to
+	now     time.Time
+	created []CreatedObject
+	// stepKeys are the API keys of the steps that set their own
+	stepKeys map[string]string
+	// vars are the variables set with SetValues
+	vars map[string]string
+	// context is shared with the other fixtures of a directory, if any
+	context *RunContext
+	// failedStep is the step the last run failed at, if any
+	failedStep string
 }
 
 // NewFixtureFromFile creates a to later run steps for populating test data
 func NewFixtureFromFile(fs afero.Fs, apiKey, stripeAccount, baseURL, file string, skip, override, add, remove []string) (*Fixture, error) {
+	return NewFixture(fs, apiKey, stripeAccount, baseURL, file, skip, override, add, remove, RemoteOptions{})
+}
+
+// NewFixture creates a fixture from a file or a URL, fetching the files from
+// URLs as configured by remote
+func NewFixture(fs afero.Fs, apiKey, stripeAccount, baseURL, file string, skip, override, add, remove []string, remote RemoteOptions) (*Fixture, error) {
 	fxt := Fixture{
 		Fs:            fs,
 		APIKey:        apiKey,
@@ -71,30 +177,16 @@ func NewFixtureFromFile(fs afero.Fs, apiKey, stripeAccount, baseURL, file string
 		Skip:          skip,
 		BaseURL:       baseURL,
 		responses:     make(map[string]gjson.Result),
+		remote:        remote,
 	}
 
-	var filedata []byte
-	var err error
-
-	if _, ok := reverseMap()[file]; ok {
-		f, err := triggers.Open(file)
-		if err != nil {
-			return nil, err
-		}
-
-		filedata, err = ioutil.ReadAll(f)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		filedata, err = afero.ReadFile(fxt.Fs, file)
-		if err != nil {
-			return nil, err
-		}
+	fixture, err := fxt.loadFixtureFile(file, nil)
+	if err != nil {
+		return nil, err
 	}
+	fxt.fixture = fixture
 
-	err = json.Unmarshal(filedata, &fxt.fixture)
-	if err != nil {
+	if err := fxt.checkUniqueNames(); err != nil {
 		return nil, err
 	}
 
@@ -103,6 +195,18 @@ func NewFixtureFromFile(fs afero.Fs, apiKey, stripeAccount, baseURL, file string
 	fxt.Add(add)
 	fxt.Remove(remove)
 
+	if err := fxt.expandRepeats(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkMethods(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.interpolateEnv(); err != nil {
+		return nil, err
+	}
+
 	if fxt.fixture.Meta.Version > SupportedVersions {
 		return nil, fmt.Errorf("Fixture version not supported: %s", fmt.Sprint(fxt.fixture.Meta.Version))
 	}
@@ -121,11 +225,27 @@ func NewFixtureFromRawString(fs afero.Fs, apiKey, stripeAccount, baseURL, raw st
 		responses:     make(map[string]gjson.Result),
 	}
 
+	if err := ValidateFixture("--raw", []byte(raw)); err != nil {
+		return nil, err
+	}
+
 	err := json.Unmarshal([]byte(raw), &fxt.fixture)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := fxt.expandRepeats(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkMethods(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.interpolateEnv(); err != nil {
+		return nil, err
+	}
+
 	if fxt.fixture.Meta.Version > SupportedVersions {
 		return nil, fmt.Errorf("Fixture version not supported: %s", fmt.Sprint(fxt.fixture.Meta.Version))
 	}
@@ -133,92 +253,168 @@ func NewFixtureFromRawString(fs afero.Fs, apiKey, stripeAccount, baseURL, raw st
 	return &fxt, nil
 }
 
-// GetFixtureFileContent returns the file content of the given fixture file name
+// GetFixtureFileContent returns the file content of the given fixture file name,
+// with the values of secret environment variables masked
 func (fxt *Fixture) GetFixtureFileContent() string {
 	data, err := json.MarshalIndent(fxt.fixture, "", "  ")
 	if err != nil {
 		return ""
 	}
-	return string(data)
+	return fxt.maskSecrets(string(data))
 }
 
-// Override forcefully overrides fields with existing data on a fixture
+// Override forcefully overrides fields with existing data on a fixture. A
+// field overridden with `-` is removed.
 func (fxt *Fixture) Override(overrides []string) {
-	data := buildRewrites(overrides, false)
-	for _, f := range fxt.fixture.Fixtures {
-		if _, ok := data[f.Name]; ok {
-			if err := mergo.Merge(&f.Params, data[f.Name], mergo.WithOverride); err != nil {
-				fmt.Println(err)
-			}
-		}
-	}
+	fxt.rewrite(overrides, rewriteOverride)
 }
 
 // Add safely only adds any missing fields that do not already exist.
 // If the field is already on the fixture, it does not get copied
 // over. For that, `Override` should be used
 func (fxt *Fixture) Add(additions []string) {
-	// If the params is empty, initialize it before merging with added data
-	for i, data := range fxt.fixture.Fixtures {
-		if data.Method == "post" && data.Params == nil {
-			fxt.fixture.Fixtures[i].Params = make(map[string]interface{})
-		}
-	}
-
-	data := buildRewrites(additions, false)
-	for _, f := range fxt.fixture.Fixtures {
-		if _, ok := data[f.Name]; ok {
-			if err := mergo.Merge(&f.Params, data[f.Name]); err != nil {
-				fmt.Println(err)
-			}
-		}
-	}
+	fxt.rewrite(additions, rewriteAdd)
 }
 
 // Remove removes fields from the fixture
 func (fxt *Fixture) Remove(removals []string) {
-	data := buildRewrites(removals, true)
-	for _, f := range fxt.fixture.Fixtures {
-		if _, ok := data[f.Name]; ok {
-			for remove := range data[f.Name].(map[string]interface{}) {
-				delete(f.Params, remove)
-			}
-		}
-	}
+	fxt.rewrite(removals, rewriteRemove)
 }
 
 // Execute takes the parsed fixture file and runs through all the requests
 // defined to populate the user's account
 func (fxt *Fixture) Execute(ctx context.Context) ([]string, error) {
+	fxt.failedStep = ""
+
+	if err := fxt.checkAPIVersions(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkSelection(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkUploads(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.resolveAPIKeys(); err != nil {
+		return nil, err
+	}
+
+	if err := fxt.checkVars(); err != nil {
+		return nil, err
+	}
+
 	requestNames := make([]string, len(fxt.fixture.Fixtures))
 	for i, data := range fxt.fixture.Fixtures {
-		if isNameIn(data.Name, fxt.Skip) {
-			fmt.Printf("Skipping fixture for: %s\n", data.Name)
+		if !fxt.isSelected(data) {
+			fmt.Fprintf(fxt.out(), "Skipping fixture for: %s\n", data.Name)
+			fxt.skipped = append(fxt.skipped, data.Name)
 			continue
 		}
 
-		fmt.Printf("Setting up fixture for: %s\n", data.Name)
+		run, err := fxt.shouldRun(data)
+		if err != nil {
+			fxt.failedStep = data.Name
+			return nil, err
+		}
+		if !run {
+			fmt.Fprintf(fxt.out(), "Skipping fixture for: %s, its condition %s is false\n", data.Name, data.OnlyIf)
+			fxt.skipped = append(fxt.skipped, data.Name)
+			continue
+		}
+
+		fmt.Fprintf(fxt.out(), "Setting up fixture for: %s\n", data.Name)
 		requestNames[i] = data.Name
+		fxt.failedStep = data.Name
 
-		fmt.Printf("Running fixture for: %s\n", data.Name)
+		fmt.Fprintf(fxt.out(), "Running fixture for: %s\n", data.Name)
 		resp, err := fxt.makeRequest(ctx, data)
-		if err != nil && !errWasExpected(err, data.ExpectedErrorType) {
-			return nil, err
+		if err != nil {
+			if !errWasExpected(err, data) {
+				return nil, err
+			}
+			// Later steps may reference the error, like ${card:error.code}
+			resp = []byte(errorBody(err.(requests.RequestError)))
 		}
 
 		fxt.responses[data.Name] = gjson.ParseBytes(resp)
+
+		if err := fxt.checkAssertions(data, fxt.responses[data.Name]); err != nil {
+			return nil, err
+		}
+	}
+	fxt.failedStep = ""
+
+	if len(fxt.skipped) > 0 {
+		fmt.Fprintf(fxt.out(), "Skipped fixtures: %s\n", strings.Join(fxt.skipped, ", "))
 	}
 
 	return requestNames, nil
 }
 
-func errWasExpected(err error, expectedErrorType string) bool {
+func (fxt *Fixture) out() io.Writer {
+	if fxt.Out == nil {
+		return os.Stdout
+	}
+	return fxt.Out
+}
+
+func errWasExpected(err error, data fixture) bool {
 	if rerr, ok := err.(requests.RequestError); ok {
-		return rerr.ErrorType == expectedErrorType
+		if data.ExpectedStatus != 0 && rerr.StatusCode == data.ExpectedStatus {
+			return true
+		}
+		if data.ExpectedError != nil {
+			return data.ExpectedError.matches(rerr)
+		}
+		return rerr.ErrorType == data.ExpectedErrorType
 	}
 	return false
 }
 
+// checkMethods returns an error if a step uses an HTTP method fixtures don't
+// support
+func (fxt *Fixture) checkMethods() error {
+	for _, data := range fxt.fixture.Fixtures {
+		if !supportedMethods[strings.ToUpper(data.Method)] {
+			return fmt.Errorf("Fixture %s uses the unsupported method %s, supported methods are get, post and delete", data.Name, data.Method)
+		}
+		if data.Upload != nil && strings.ToUpper(data.Method) != http.MethodPost {
+			return fmt.Errorf("Fixture %s uploads a file and must use the post method", data.Name)
+		}
+	}
+
+	return nil
+}
+
+// apiVersion returns the API version of the request of the step, if any
+func (fxt *Fixture) apiVersion(data fixture) string {
+	switch {
+	case data.APIVersion != "":
+		return data.APIVersion
+	case fxt.APIVersion != "":
+		return fxt.APIVersion
+	default:
+		return fxt.fixture.Meta.APIVersion
+	}
+}
+
+// checkAPIVersions returns an error if a step has an invalid API version, so
+// that the fixture fails before making any request
+func (fxt *Fixture) checkAPIVersions() error {
+	for _, data := range fxt.fixture.Fixtures {
+		if version := fxt.apiVersion(data); version != "" {
+			if err := validators.APIVersion(version); err != nil {
+				return fmt.Errorf("Invalid API version for fixture %s: %v", data.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // UpdateEnv uses the results of the fixtures command just executed and
 // updates a local .env with the resulting data
 func (fxt *Fixture) UpdateEnv() error {
@@ -232,7 +428,8 @@ func (fxt *Fixture) UpdateEnv() error {
 func (fxt *Fixture) makeRequest(ctx context.Context, data fixture) ([]byte, error) {
 	var rp requests.RequestParameters
 
-	if data.Method == "post" && !fxt.fixture.Meta.ExcludeMetadata {
+	// Files have no metadata
+	if data.Method == "post" && !fxt.fixture.Meta.ExcludeMetadata && data.Upload == nil {
 		now := time.Now().String()
 		metadata := fmt.Sprintf("metadata[_created_by_fixture]=%s", now)
 		rp.AppendData([]string{metadata})
@@ -245,32 +442,112 @@ func (fxt *Fixture) makeRequest(ctx context.Context, data fixture) ([]byte, erro
 		Parameters:     rp,
 	}
 
-	path, err := fxt.parsePath(data)
-
+	prepared, err := fxt.prepareRequest(data)
 	if err != nil {
 		return make([]byte, 0), err
 	}
 
-	params, err := fxt.createParams(data.Params)
-
-	if err != nil {
-		return make([]byte, 0), err
+	params := &requests.RequestParameters{}
+	params.AppendData(prepared.params)
+	params.SetStripeAccount(prepared.account)
+	params.SetIdempotency(prepared.idempotencyKey)
+	params.SetVersion(prepared.apiVersion)
+
+	log.WithFields(log.Fields{
+		"prefix":         "fixtures.Fixture.makeRequest",
+		"api_version":    prepared.apiVersion,
+		"api_key_source": apiKeySource(data),
+	}).Debugf("Running fixture %s", data.Name)
+
+	var resp []byte
+	if prepared.upload != nil {
+		req.APIBaseURL = fxt.filesBaseURL()
+		resp, err = fxt.uploadFile(ctx, &req, data, prepared, params)
+	} else {
+		resp, err = req.MakeRequest(ctx, fxt.apiKeyOf(data), prepared.path, params, true)
+	}
+	fxt.recordResponse(data.Name, req.StatusCode, req.ResponseHeader)
+	if err == nil && prepared.method == http.MethodPost {
+		fxt.recordCreated(data.Name, prepared.account, prepared.path, resp)
+	}
+	if requestID := req.ResponseHeader.Get("Request-Id"); requestID != "" {
+		fmt.Fprintf(fxt.out(), "Request ID for %s: %s\n", data.Name, requestID)
 	}
 
-	return req.MakeRequest(ctx, fxt.APIKey, path, params, true)
+	return resp, err
 }
 
-func (fxt *Fixture) createParams(params interface{}) (*requests.RequestParameters, error) {
-	requestParams := requests.RequestParameters{}
-	parsed, err := fxt.parseInterface(params)
+// preparedRequest is the request of a fixture step, with its templates
+// resolved
+type preparedRequest struct {
+	method         string
+	path           string
+	params         []string
+	account        string
+	idempotencyKey string
+	apiVersion     string
+	// upload is the file the step uploads, if any
+	upload *preparedUpload
+}
+
+// prepareRequest resolves the templates of the path, params, account and
+// idempotency key of the step
+func (fxt *Fixture) prepareRequest(data fixture) (preparedRequest, error) {
+	prepared := preparedRequest{
+		method:     strings.ToUpper(data.Method),
+		account:    fxt.StripeAccount,
+		apiVersion: fxt.apiVersion(data),
+	}
+
+	if data.Upload != nil {
+		upload, err := fxt.prepareUpload(data)
+		if err != nil {
+			return prepared, err
+		}
+		prepared.upload = &upload
+
+		if data.Path == "" {
+			data.Path = uploadPath
+		}
+	}
+
+	path, err := fxt.parsePath(data)
 	if err != nil {
-		return &requestParams, err
+		return prepared, functionErrorOf(data, withParam(err, "path"))
 	}
-	requestParams.AppendData(parsed)
+	prepared.path = path
 
-	requestParams.SetStripeAccount(fxt.StripeAccount)
+	params, err := fxt.parseInterface(data.Params)
+	if err != nil {
+		return prepared, functionErrorOf(data, err)
+	}
+	prepared.params = params
 
-	return &requestParams, nil
+	if data.Account != "" {
+		account, err := fxt.parseQuery(data.Account)
+		if err != nil {
+			return prepared, err
+		}
+
+		// Dry runs can't check accounts created by previous steps
+		if !(fxt.dryRun && isPlaceholder(account)) {
+			if err := validators.AccountID(account); err != nil {
+				return prepared, fmt.Errorf("Invalid account for fixture %s: %v", data.Name, err)
+			}
+		}
+
+		prepared.account = account
+	}
+
+	if prepared.method == http.MethodPost {
+		key, err := fxt.idempotencyKey(data)
+		if err != nil {
+			return prepared, err
+		}
+		prepared.idempotencyKey = key
+	}
+
+	return prepared, nil
 }
 
 func getEnvVar(query fixtureQuery) (string, error) {
@@ -340,6 +617,35 @@ func (fxt *Fixture) updateEnv(env map[string]string) error {
 	return nil
 }
 
+// idempotencyKey returns the idempotency key of the step's request, which is
+// empty unless the step or the fixture sets one. The step's key may reference
+// previous responses.
+func (fxt *Fixture) idempotencyKey(data fixture) (string, error) {
+	key := data.IdempotencyKey
+	if r, containsQuery := matchFixtureQuery(key); containsQuery {
+		var err error
+		key = r.ReplaceAllStringFunc(key, func(query string) string {
+			value, queryErr := fxt.parseQuery(query)
+			if queryErr != nil {
+				err = queryErr
+			}
+			return value
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case fxt.IdempotencyKeyPrefix == "":
+		return key, nil
+	case key != "":
+		return fxt.IdempotencyKeyPrefix + "-" + key, nil
+	default:
+		return fxt.IdempotencyKeyPrefix + "-" + data.Name, nil
+	}
+}
+
 // isNameIn will search if the current fixture is in the skip list
 func isNameIn(name string, skip []string) bool {
 	for _, skipName := range skip {
@@ -349,73 +655,3 @@ func isNameIn(name string, skip []string) bool {
 	}
 	return false
 }
-
-// buildRewrites takes a slice of json queries and values then builds
-// them into a map to later be merged. We work through the entire
-// list at the same time because the user might pass in multiple
-// changes for the same fixture.
-//
-// The query supported is <fixture_name>:path.to.field=value
-func buildRewrites(changes []string, toRemove bool) map[string]interface{} {
-	builtChanges := make(map[string]interface{})
-	for _, change := range changes {
-		if change == "" {
-			continue
-		}
-		changeSplit := strings.SplitN(change, "=", 2)
-		path := changeSplit[0]
-
-		// When removing a field there will be no value so we set a default
-		// empty string or trying to get the split value from above
-		var value string
-		if !toRemove {
-			value = changeSplit[1]
-		}
-
-		pathSplit := strings.SplitN(path, ":", 2)
-		name := pathSplit[0]
-		keys := pathSplit[1]
-
-		keysSplit := strings.Split(keys, ".")
-
-		field, paths := pop(keysSplit)
-		keyMap := make(map[string]interface{})
-		keyMap[field] = value
-
-		keysReversed := reverse(paths)
-		for _, key := range keysReversed {
-			keyMap = map[string]interface{}{
-				key: keyMap,
-			}
-		}
-		_, ok := builtChanges[name]
-		if ok {
-			if err := mergo.Merge(&keyMap, builtChanges[name]); err != nil {
-				fmt.Println(err)
-			}
-		}
-
-		builtChanges[name] = keyMap
-	}
-
-	return builtChanges
-}
-
-// pop returns the last item and the rest of the list minus the last item
-// From: https://github.com/golang/go/wiki/SliceTricks#pop
-func pop(list []string) (string, []string) {
-	return list[len(list)-1], list[:len(list)-1]
-}
-
-// reverse reverses the list
-// From: https://github.com/golang/go/wiki/SliceTricks#reversing
-func reverse(list []string) []string {
-	reversed := make([]string, len(list))
-	copy(reversed, list)
-
-	for i := len(reversed)/2 - 1; i >= 0; i-- {
-		opp := len(reversed) - 1 - i
-		reversed[i], reversed[opp] = reversed[opp], reversed[i]
-	}
-	return reversed
-}
@@ -2,6 +2,7 @@ package fixtures
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -453,3 +454,277 @@ func TestExecuteReturnsRequestNames(t *testing.T) {
 	expectedResponseNames := []string{"cust_bender", "char_bender", "capt_bender"}
 	assert.Equal(t, expectedResponseNames, requestNames)
 }
+
+const accountTestFixture = `
+{
+	"_meta": {
+		"template_version": 0
+	},
+	"fixtures": [
+		{
+			"name": "connected",
+			"path": "/v1/accounts",
+			"method": "post",
+			"account": "acct_platform"
+		},
+		{
+			"name": "cust_connected",
+			"path": "/v1/customers",
+			"method": "post",
+			"account": "${connected:id}"
+		},
+		{
+			"name": "cust_default",
+			"path": "/v1/customers",
+			"method": "post"
+		}
+	]
+}`
+
+func TestMakeRequestWithStripeAccount(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	var accounts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		accounts = append(accounts, req.Header.Get("Stripe-Account"))
+
+		switch url := req.URL.String(); url {
+		case "/v1/accounts":
+			res.Write([]byte(`{"id": "acct_connected"}`))
+		case customersPath:
+			res.Write([]byte(`{"id": "cust_12345"}`))
+		default:
+			t.Errorf("Received an unexpected request URL: %s", req.URL.String())
+		}
+	}))
+
+	defer func() { ts.Close() }()
+
+	afero.WriteFile(fs, file, []byte(accountTestFixture), os.ModePerm)
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "acct_flag", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"acct_platform", "acct_connected", "acct_flag"}, accounts)
+}
+
+func TestMakeRequestWithInvalidStepAccount(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		t.Errorf("Received an unexpected request URL: %s", req.URL.String())
+	}))
+
+	defer func() { ts.Close() }()
+
+	raw := `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post", "account": "cus_123"}]}`
+	fxt, err := NewFixtureFromRawString(fs, apiKey, "", ts.URL, raw)
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "Invalid account for fixture cust: cus_123 is not a valid account ID, it must start with acct_")
+}
+
+const idempotencyTestFixture = `
+{
+	"_meta": {
+		"template_version": 0
+	},
+	"fixtures": [
+		{
+			"name": "cust",
+			"path": "/v1/customers",
+			"method": "post"
+		},
+		{
+			"name": "charge",
+			"path": "/v1/charges",
+			"method": "post",
+			"idempotency_key": "charge-for-${cust:id}",
+			"params": {
+				"customer": "${cust:id}"
+			}
+		},
+		{
+			"name": "get_cust",
+			"path": "/v1/customers/${cust:id}",
+			"method": "get"
+		}
+	]
+}`
+
+func TestMakeRequestWithIdempotencyKeyPrefix(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	// replays the response of requests with a known idempotency key, like the
+	// API does
+	replayed := make(map[string]string)
+	var keys []string
+	created := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get("Idempotency-Key")
+		keys = append(keys, key)
+
+		if response, ok := replayed[key]; ok {
+			res.Write([]byte(response))
+			return
+		}
+
+		created++
+		response := fmt.Sprintf(`{"id": "obj_%d"}`, created)
+		if key != "" {
+			replayed[key] = response
+		}
+		res.Write([]byte(response))
+	}))
+
+	defer func() { ts.Close() }()
+
+	afero.WriteFile(fs, file, []byte(idempotencyTestFixture), os.ModePerm)
+
+	for i := 0; i < 2; i++ {
+		fxt, err := NewFixtureFromFile(fs, apiKey, "", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+		require.NoError(t, err)
+		fxt.IdempotencyKeyPrefix = "run1"
+
+		_, err = fxt.Execute(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, "obj_1", fxt.responses["cust"].Get("id").String())
+		require.Equal(t, "obj_2", fxt.responses["charge"].Get("id").String())
+	}
+
+	// GET requests have no key
+	require.Equal(t, []string{"run1-cust", "run1-charge-for-obj_1", "", "run1-cust", "run1-charge-for-obj_1", ""}, keys)
+}
+
+const deleteTestFixture = `
+{
+	"_meta": {
+		"template_version": 0
+	},
+	"fixtures": [
+		{
+			"name": "cust",
+			"path": "/v1/customers",
+			"method": "post"
+		},
+		{
+			"name": "delete_cust",
+			"path": "/v1/customers/${cust:id}",
+			"method": "delete"
+		},
+		{
+			"name": "delete_cust_again",
+			"path": "/v1/customers/${cust:id}",
+			"method": "delete",
+			"expected_status": 404
+		}
+	]
+}`
+
+func TestMakeRequestWithDelete(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	var requests []string
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+
+		switch {
+		case req.Method == http.MethodPost:
+			res.Write([]byte(`{"id": "cus_123"}`))
+		case deleted:
+			res.WriteHeader(404)
+			res.Write([]byte(`{"error": {"type": "invalid_request_error"}}`))
+		default:
+			deleted = true
+			res.Write([]byte(`{"id": "cus_123", "deleted": true}`))
+		}
+	}))
+
+	defer func() { ts.Close() }()
+
+	afero.WriteFile(fs, file, []byte(deleteTestFixture), os.ModePerm)
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"POST /v1/customers", "DELETE /v1/customers/cus_123", "DELETE /v1/customers/cus_123"}, requests)
+	require.True(t, fxt.responses["delete_cust"].Get("deleted").Bool())
+
+	// without expected_status, the 404 fails the run
+	fxt, err = NewFixtureFromRawString(fs, apiKey, "", ts.URL, `{"fixtures": [{"name": "delete_cust", "path": "/v1/customers/cus_123", "method": "delete"}]}`)
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.Error(t, err)
+}
+
+func TestUnsupportedMethod(t *testing.T) {
+	_, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "put"}]}`)
+	require.EqualError(t, err, "Fixture cust uses the unsupported method put, supported methods are get, post and delete")
+}
+
+const apiVersionTestFixture = `
+{
+	"_meta": {
+		"template_version": 0,
+		"api_version": "2020-08-27"
+	},
+	"fixtures": [
+		{
+			"name": "cust",
+			"path": "/v1/customers",
+			"method": "post"
+		},
+		{
+			"name": "pi",
+			"path": "/v1/payment_intents",
+			"method": "post",
+			"api_version": "2024-09-30.acacia"
+		}
+	]
+}`
+
+func TestMakeRequestWithAPIVersion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	var versions []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		versions = append(versions, req.Header.Get("Stripe-Version"))
+		res.Write([]byte(`{"id": "obj_123"}`))
+	}))
+
+	defer func() { ts.Close() }()
+
+	afero.WriteFile(fs, file, []byte(apiVersionTestFixture), os.ModePerm)
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	fxt, err = NewFixtureFromFile(fs, apiKey, "", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+	require.NoError(t, err)
+	fxt.APIVersion = "2022-11-15"
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"2020-08-27", "2024-09-30.acacia", "2022-11-15", "2024-09-30.acacia"}, versions)
+
+	// an invalid version fails before any request
+	fxt, err = NewFixtureFromFile(fs, apiKey, "", ts.URL, file, []string{}, []string{}, []string{}, []string{})
+	require.NoError(t, err)
+	fxt.APIVersion = "latest"
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "Invalid API version for fixture cust: latest is not a valid API version, expected a date like 2020-08-27")
+	require.Len(t, versions, 4)
+}
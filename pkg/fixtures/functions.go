@@ -0,0 +1,364 @@
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fixture params may compute dates and numbers:
+//
+//	${timestamp:now}                  the current Unix timestamp
+//	${timestamp:+720h}                the timestamp 720 hours from now
+//	${timestamp:next_month+7d}        the timestamp 7 days after the start of next month
+//	${date:2006-01-02:+7d}            the date a week from now, in the layout of Go's time package
+//	${math:${price:unit_amount}*3}    arithmetic, with + - * / and parentheses
+//
+// A time is now, today or next_month, in UTC, optionally followed by an
+// offset made of numbers of weeks (w), days (d), hours (h), minutes (m) and
+// seconds (s), like -1d12h. Every function of a run uses the same now.
+
+// functionRegexp matches `${timestamp:...}` and `${date:...}`. ${math:...}
+// may nest references and is matched by findMathExpression instead.
+var functionRegexp = regexp.MustCompile(`\$\{(timestamp|date):([^}]*)\}`)
+
+var timeRegexp = regexp.MustCompile(`^(now|today|next_month)?(?:([+-])((?:\d+[wdhms])+))?$`)
+
+var offsetPartRegexp = regexp.MustCompile(`(\d+)([wdhms])`)
+
+var offsetUnits = map[string]time.Duration{
+	"w": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour,
+	"h": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+const mathPrefix = "${math:"
+
+// functionError is the error of a function in a param, which prepareRequest
+// reports with the name of the step
+type functionError struct {
+	param string
+	err   error
+}
+
+func (e *functionError) Error() string {
+	return e.err.Error()
+}
+
+// withParam sets the param of a function error, if err is one
+func withParam(err error, param string) error {
+	var fe *functionError
+	if errors.As(err, &fe) && fe.param == "" {
+		fe.param = param
+	}
+
+	return err
+}
+
+// functionErrorOf names the step and param of a function error, if err is
+// one
+func functionErrorOf(data fixture, err error) error {
+	var fe *functionError
+	if errors.As(err, &fe) {
+		return fmt.Errorf("Invalid param %s of fixture %s: %v", fe.param, data.Name, fe.err)
+	}
+
+	return err
+}
+
+// nowOf returns the time functions are relative to, which is the same for the
+// whole run
+func (fxt *Fixture) nowOf() time.Time {
+	if fxt.now.IsZero() {
+		fxt.now = time.Now()
+	}
+
+	return fxt.now.UTC()
+}
+
+// replaceFunctions evaluates the function expressions in s
+func (fxt *Fixture) replaceFunctions(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	s, err := fxt.replaceMath(s)
+	if err != nil {
+		return "", &functionError{err: err}
+	}
+
+	var evalErr error
+	replaced := functionRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		submatch := functionRegexp.FindStringSubmatch(match)
+
+		var value string
+		var err error
+		if submatch[1] == "timestamp" {
+			value, err = fxt.timestamp(submatch[2])
+		} else {
+			value, err = fxt.date(submatch[2])
+		}
+		if err != nil {
+			evalErr = &functionError{err: err}
+			return match
+		}
+
+		return value
+	})
+
+	return replaced, evalErr
+}
+
+func (fxt *Fixture) timestamp(spec string) (string, error) {
+	t, err := fxt.parseTime(spec)
+	if err != nil {
+		return "", fmt.Errorf("Invalid function ${timestamp:%s}: %v", spec, err)
+	}
+
+	return strconv.FormatInt(t.Unix(), 10), nil
+}
+
+// date formats a time with a layout, like `2006-01-02:+7d`, or the current
+// time with just a layout. Layouts may contain colons, only what follows the
+// last one is a time if it parses as one.
+func (fxt *Fixture) date(spec string) (string, error) {
+	layout, timeSpec := spec, "now"
+	if i := strings.LastIndex(spec, ":"); i >= 0 && timeRegexp.MatchString(spec[i+1:]) && spec[i+1:] != "" {
+		layout, timeSpec = spec[:i], spec[i+1:]
+	}
+
+	if layout == "" {
+		return "", fmt.Errorf("Invalid function ${date:%s}: expected a layout, like ${date:2006-01-02}", spec)
+	}
+
+	t, err := fxt.parseTime(timeSpec)
+	if err != nil {
+		return "", fmt.Errorf("Invalid function ${date:%s}: %v", spec, err)
+	}
+
+	return t.Format(layout), nil
+}
+
+func (fxt *Fixture) parseTime(spec string) (time.Time, error) {
+	match := timeRegexp.FindStringSubmatch(spec)
+	if spec == "" || match == nil {
+		return time.Time{}, errors.New("expected now, today, next_month or an offset like +720h or -7d")
+	}
+
+	now := fxt.nowOf()
+	var t time.Time
+	switch match[1] {
+	case "today":
+		t = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	case "next_month":
+		t = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		t = now
+	}
+
+	var offset time.Duration
+	for _, part := range offsetPartRegexp.FindAllStringSubmatch(match[3], -1) {
+		n, err := strconv.ParseInt(part[1], 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		offset += time.Duration(n) * offsetUnits[part[2]]
+	}
+	if match[2] == "-" {
+		offset = -offset
+	}
+
+	return t.Add(offset), nil
+}
+
+// replaceMath evaluates the ${math:...} expressions in s, after resolving the
+// references they contain
+func (fxt *Fixture) replaceMath(s string) (string, error) {
+	for {
+		start, end, ok := findMathExpression(s)
+		if !ok {
+			return s, nil
+		}
+
+		value, err := fxt.evaluateMath(s[start+len(mathPrefix) : end])
+		if err != nil {
+			return "", err
+		}
+
+		s = s[:start] + value + s[end+1:]
+	}
+}
+
+// findMathExpression returns the position of the first ${math:...}
+// expression of s and of its closing brace
+func findMathExpression(s string) (int, int, bool) {
+	start := strings.Index(s, mathPrefix)
+	if start < 0 {
+		return 0, 0, false
+	}
+
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return start, i, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+func (fxt *Fixture) evaluateMath(expression string) (string, error) {
+	resolved := expression
+	for strings.Contains(resolved, "${") {
+		start := strings.Index(resolved, "${")
+		end := strings.Index(resolved[start:], "}")
+		if end < 0 {
+			break
+		}
+		end += start
+
+		value, err := fxt.parseQuery(resolved[start : end+1])
+		if err != nil {
+			return "", err
+		}
+
+		resolved = resolved[:start] + value + resolved[end+1:]
+	}
+
+	// Dry runs don't know the values of previous steps
+	if fxt.dryRun && placeholderRegexp.MatchString(resolved) {
+		return fmt.Sprintf("<math:%s>", resolved), nil
+	}
+
+	parser := mathParser{input: strings.ReplaceAll(resolved, " ", "")}
+	result, err := parser.parse()
+	if err != nil {
+		return "", fmt.Errorf("Invalid function ${math:%s}: %v", expression, err)
+	}
+
+	if result == math.Trunc(result) && math.Abs(result) < 1e15 {
+		return strconv.FormatInt(int64(result), 10), nil
+	}
+
+	return strconv.FormatFloat(result, 'f', -1, 64), nil
+}
+
+// mathParser evaluates arithmetic expressions by recursive descent
+type mathParser struct {
+	input string
+	pos   int
+}
+
+func (p *mathParser) parse() (float64, error) {
+	result, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	return result, nil
+}
+
+// expression is a sum of terms
+func (p *mathParser) expression() (float64, error) {
+	result, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+
+	for p.pos < len(p.input) && (p.input[p.pos] == '+' || p.input[p.pos] == '-') {
+		op := p.input[p.pos]
+		p.pos++
+
+		operand, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+
+		if op == '+' {
+			result += operand
+		} else {
+			result -= operand
+		}
+	}
+
+	return result, nil
+}
+
+// term is a product of factors
+func (p *mathParser) term() (float64, error) {
+	result, err := p.factor()
+	if err != nil {
+		return 0, err
+	}
+
+	for p.pos < len(p.input) && (p.input[p.pos] == '*' || p.input[p.pos] == '/') {
+		op := p.input[p.pos]
+		p.pos++
+
+		operand, err := p.factor()
+		if err != nil {
+			return 0, err
+		}
+
+		if op == '*' {
+			result *= operand
+		} else {
+			if operand == 0 {
+				return 0, errors.New("division by zero")
+			}
+			result /= operand
+		}
+	}
+
+	return result, nil
+}
+
+// factor is a number, a negated factor or a parenthesized expression
+func (p *mathParser) factor() (float64, error) {
+	if p.pos >= len(p.input) {
+		return 0, errors.New("unexpected end of expression")
+	}
+
+	switch p.input[p.pos] {
+	case '-':
+		p.pos++
+		value, err := p.factor()
+		return -value, err
+	case '(':
+		p.pos++
+		value, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return 0, errors.New("missing )")
+		}
+		p.pos++
+		return value, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	return strconv.ParseFloat(p.input[start:p.pos], 64)
+}
@@ -0,0 +1,92 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestFunctions(t *testing.T) {
+	fxt := Fixture{
+		responses: map[string]gjson.Result{
+			"price": gjson.Parse(`{"unit_amount": 1250, "currency": "usd"}`),
+		},
+		now: time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC),
+	}
+
+	tests := map[string]string{
+		"${timestamp:now}":                 "1615734566",
+		"${timestamp:+720h}":               "1618326566",
+		"${timestamp:-1d}":                 "1615648166",
+		"${timestamp:today}":               "1615680000",
+		"${timestamp:next_month}":          "1617235200",
+		"${timestamp:next_month+1w2d}":     "1618012800",
+		"${date:2006-01-02}":               "2021-03-14",
+		"${date:2006-01-02:+7d}":           "2021-03-21",
+		"${date:2006-01-02T15:04:05Z:-1h}": "2021-03-14T14:09:26Z",
+		"${math:${price:unit_amount}*3}":   "3750",
+		"${math:(1 + 2) * -3}":             "-9",
+		"${math:10/4}":                     "2.5",
+		"trial ends ${date:Jan 2:+14d}":    "trial ends Mar 28",
+	}
+
+	for expression, expected := range tests {
+		value, err := fxt.parseQuery(expression)
+		require.NoError(t, err, expression)
+		require.Equal(t, expected, value, expression)
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	fxt := Fixture{now: time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC)}
+
+	tests := map[string]string{
+		"${timestamp:tomorrow}": "Invalid function ${timestamp:tomorrow}: expected now, today, next_month or an offset like +720h or -7d",
+		"${timestamp:+7y}":      "Invalid function ${timestamp:+7y}: expected now, today, next_month or an offset like +720h or -7d",
+		"${math:1/0}":           "Invalid function ${math:1/0}: division by zero",
+		"${math:2*(3+4}":        "Invalid function ${math:2*(3+4}: missing )",
+		"${math:2x}":            `Invalid function ${math:2x}: unexpected "x"`,
+	}
+
+	for expression, expected := range tests {
+		_, err := fxt.parseQuery(expression)
+		require.EqualError(t, err, expected, expression)
+	}
+}
+
+func TestFunctionErrorNamesStepAndParam(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(`{"id": "sub_123"}`))
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{
+		"fixtures": [
+			{"name": "subscription", "path": "/v1/subscriptions", "method": "post", "params": {"trial_end": "${timestamp:+3x}"}}
+		]
+	}`)
+	require.NoError(t, err)
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "Invalid param trial_end of fixture subscription: Invalid function ${timestamp:+3x}: expected now, today, next_month or an offset like +720h or -7d")
+}
+
+func TestFunctionsInDryRun(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", `{
+		"fixtures": [
+			{"name": "price", "path": "/v1/prices", "method": "post"},
+			{"name": "invoice_item", "path": "/v1/invoiceitems", "method": "post", "params": {"amount": "${math:${price:unit_amount}*3}"}}
+		]
+	}`)
+	require.NoError(t, err)
+
+	steps, err := fxt.DryRun()
+	require.NoError(t, err)
+	require.Equal(t, []string{"amount=<math:<price.unit_amount>*3>"}, steps[1].Params)
+}
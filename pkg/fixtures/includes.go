@@ -0,0 +1,142 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// A fixture file may include other fixture files with
+// `"include": ["./common/customer.json"]`, resolved relative to the including
+// file or URL. The steps of the included files run before the ones of the including
+// file, in the order they are included, and share the same names so that
+// `${cust:id}` references a step of an included file. The env of the
+// including file takes precedence over the one of the included files.
+
+// IncludedFiles returns the fixture files that were merged into the fixture,
+// in the order their steps run
+func (fxt *Fixture) IncludedFiles() []string {
+	return fxt.includedFiles
+}
+
+// loadFixtureFile reads and parses the fixture file, merging the files it
+// includes. including is the chain of files including this one, to detect
+// cycles.
+func (fxt *Fixture) loadFixtureFile(file string, including []string) (fixtureFile, error) {
+	var parsed fixtureFile
+
+	for i, includingFile := range including {
+		if includingFile == file {
+			cycle := append(append([]string{}, including[i:]...), file)
+			return parsed, fmt.Errorf("Fixture files include each other: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	filedata, err := fxt.readFixtureFile(file)
+	if err != nil {
+		return parsed, err
+	}
+
+	if err := ValidateFixture(file, filedata); err != nil {
+		return parsed, err
+	}
+
+	err = json.Unmarshal(filedata, &parsed)
+	if err != nil {
+		if len(including) > 0 {
+			return parsed, fmt.Errorf("Failed to parse included fixture file %s: %v", file, err)
+		}
+		return parsed, err
+	}
+
+	for i := range parsed.Fixtures {
+		parsed.Fixtures[i].file = file
+	}
+
+	if len(parsed.Include) == 0 {
+		return parsed, nil
+	}
+
+	merged := fixtureFile{
+		Meta: parsed.Meta,
+		Env:  make(map[string]string),
+		Vars: make(map[string]string),
+	}
+	chain := append(append([]string{}, including...), file)
+
+	for _, include := range parsed.Include {
+		includedFile, err := resolveInclude(file, include)
+		if err != nil {
+			return parsed, err
+		}
+
+		included, err := fxt.loadFixtureFile(includedFile, chain)
+		if err != nil {
+			return parsed, err
+		}
+
+		if included.Meta.Version > SupportedVersions {
+			return parsed, fmt.Errorf("Fixture version not supported: %s (included from %s)", fmt.Sprint(included.Meta.Version), file)
+		}
+
+		merged.Fixtures = append(merged.Fixtures, included.Fixtures...)
+		for key, value := range included.Env {
+			merged.Env[key] = value
+		}
+		for key, value := range included.Vars {
+			merged.Vars[key] = value
+		}
+		fxt.includedFiles = append(fxt.includedFiles, includedFile)
+	}
+
+	merged.Fixtures = append(merged.Fixtures, parsed.Fixtures...)
+	for key, value := range parsed.Env {
+		merged.Env[key] = value
+	}
+	for key, value := range parsed.Vars {
+		merged.Vars[key] = value
+	}
+
+	return merged, nil
+}
+
+// readFixtureFile reads a fixture file, either one of the triggers embedded in
+// the CLI, one from a URL or one from the file system
+func (fxt *Fixture) readFixtureFile(file string) ([]byte, error) {
+	if isRemoteFixture(file) {
+		return fxt.readRemoteFixture(file)
+	}
+
+	if _, ok := reverseMap()[filepath.ToSlash(file)]; ok {
+		f, err := triggers.Open(filepath.ToSlash(file))
+		if err != nil {
+			return nil, err
+		}
+
+		return ioutil.ReadAll(f)
+	}
+
+	return afero.ReadFile(fxt.Fs, file)
+}
+
+// checkUniqueNames fails when steps of the fixture and the files it includes
+// have the same name, as their responses would collide
+func (fxt *Fixture) checkUniqueNames() error {
+	if len(fxt.includedFiles) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(fxt.fixture.Fixtures))
+	for _, data := range fxt.fixture.Fixtures {
+		if names[data.Name] {
+			return fmt.Errorf("Fixture step %s is declared more than once across the included fixture files", data.Name)
+		}
+		names[data.Name] = true
+	}
+
+	return nil
+}
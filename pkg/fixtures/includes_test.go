@@ -0,0 +1,96 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFixtureFiles(t *testing.T, fs afero.Fs, files map[string]string) {
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(content), os.ModePerm))
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFixtureFiles(t, fs, map[string]string{
+		"fixtures/common/customer.json": `{
+			"include": ["./payment_method.json"],
+			"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post", "params": {"payment_method": "${pm:id}"}}],
+			"env": {"CUSTOMER": "${cust:id}", "SHARED": "included"}
+		}`,
+		"fixtures/common/payment_method.json": `{
+			"fixtures": [{"name": "pm", "path": "/v1/payment_methods", "method": "post"}]
+		}`,
+		"fixtures/charge.json": `{
+			"_meta": {"template_version": 0, "exclude_metadata": true},
+			"include": ["common/customer.json"],
+			"fixtures": [{"name": "charge", "path": "/v1/charges", "method": "post", "params": {"customer": "${cust:id}"}}],
+			"env": {"SHARED": "local"}
+		}`,
+	})
+
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		requests = append(requests, req.URL.Path+" "+req.Form.Encode())
+
+		switch req.URL.Path {
+		case "/v1/payment_methods":
+			res.Write([]byte(`{"id": "pm_123"}`))
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123"}`))
+		default:
+			res.Write([]byte(`{"id": "ch_123"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", ts.URL, "fixtures/charge.json", []string{}, []string{}, []string{}, []string{})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		filepath.Join("fixtures", "common", "payment_method.json"),
+		filepath.Join("fixtures", "common", "customer.json"),
+	}, fxt.IncludedFiles())
+	require.Equal(t, map[string]string{"CUSTOMER": "${cust:id}", "SHARED": "local"}, fxt.fixture.Env)
+
+	requestNames, err := fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"pm", "cust", "charge"}, requestNames)
+	require.Equal(t, []string{
+		"/v1/payment_methods ",
+		"/v1/customers payment_method=pm_123",
+		"/v1/charges customer=cus_123",
+	}, requests)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFixtureFiles(t, fs, map[string]string{
+		"a.json": `{"include": ["b.json"], "fixtures": []}`,
+		"b.json": `{"include": ["./a.json"], "fixtures": []}`,
+	})
+
+	_, err := NewFixtureFromFile(fs, apiKey, "", "", "a.json", []string{}, []string{}, []string{}, []string{})
+	require.EqualError(t, err, "Fixture files include each other: a.json -> b.json -> a.json")
+}
+
+func TestIncludeDuplicateStepNames(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFixtureFiles(t, fs, map[string]string{
+		"a.json": `{"include": ["b.json"], "fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post"}]}`,
+		"b.json": `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post"}]}`,
+	})
+
+	_, err := NewFixtureFromFile(fs, apiKey, "", "", "a.json", []string{}, []string{}, []string{}, []string{})
+	require.EqualError(t, err, "Fixture step cust is declared more than once across the included fixture files")
+}
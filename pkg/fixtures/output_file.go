@@ -0,0 +1,105 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// RunOutput is the content of the output file written once a fixture ran
+type RunOutput struct {
+	// ExpectedEvents are the types of the events the fixture is expected to
+	// trigger
+	ExpectedEvents []string `json:"expected_events"`
+	// Steps maps the name of each step that ran to its outcome
+	Steps map[string]StepOutput `json:"steps"`
+}
+
+// StepOutput is the outcome of a fixture step
+type StepOutput struct {
+	// ID is the ID of the object the step created or retrieved, if any
+	ID string `json:"id,omitempty"`
+	// Object is the type of the object, like customer
+	Object string `json:"object,omitempty"`
+	// Status is the HTTP status code of the step's response
+	Status int `json:"status,omitempty"`
+	// RequestID is the ID of the request of the step, to share with Stripe
+	// support
+	RequestID string `json:"request_id,omitempty"`
+	// Skipped is set if the step was skipped, with --skip or because of its
+	// only_if condition
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// Output returns the outcome of the steps that ran
+func (fxt *Fixture) Output() RunOutput {
+	output := RunOutput{
+		ExpectedEvents: fxt.ExpectedEvents,
+		Steps:          make(map[string]StepOutput),
+	}
+	if output.ExpectedEvents == nil {
+		output.ExpectedEvents = []string{}
+	}
+
+	for name, status := range fxt.statuses {
+		response := fxt.responses[name]
+		output.Steps[name] = StepOutput{
+			ID:        response.Get("id").String(),
+			Object:    response.Get("object").String(),
+			Status:    status,
+			RequestID: fxt.headers[name].Get("Request-Id"),
+		}
+	}
+
+	for _, name := range fxt.skipped {
+		output.Steps[name] = StepOutput{Skipped: true}
+	}
+
+	return output
+}
+
+// WriteOutputFile writes the outcome of the steps that ran to the file as
+// JSON. The file is replaced atomically, so that it is never read partially
+// written.
+func (fxt *Fixture) WriteOutputFile(file string) error {
+	data, err := json.MarshalIndent(fxt.Output(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := afero.TempFile(fxt.Fs, filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return fmt.Errorf("Failed to write the output file: %v", err)
+	}
+
+	_, err = tmp.Write(append(data, '\n'))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fxt.Fs.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		fxt.Fs.Remove(tmp.Name()) // #nosec G104
+		return fmt.Errorf("Failed to write the output file: %v", err)
+	}
+
+	return nil
+}
+
+// recordResponse records the status code and headers of the response of the
+// step
+func (fxt *Fixture) recordResponse(name string, status int, header http.Header) {
+	if fxt.statuses == nil {
+		fxt.statuses = make(map[string]int)
+		fxt.headers = make(map[string]http.Header)
+	}
+
+	fxt.statuses[name] = status
+	if header != nil {
+		fxt.headers[name] = header
+	}
+}
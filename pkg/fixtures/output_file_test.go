@@ -0,0 +1,91 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const outputFileTestFixture = `
+{
+	"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post"},
+		{"name": "charge", "path": "/v1/charges", "method": "post", "params": {"customer": "${cust:id}"}, "expected_error_type": "card_error"},
+		{"name": "skipped", "path": "/v1/refunds", "method": "post"}
+	]
+}`
+
+func TestWriteOutputFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+		default:
+			res.WriteHeader(http.StatusPaymentRequired)
+			res.Write([]byte(`{"error": {"type": "card_error"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	fs := afero.NewMemMapFs()
+	fxt, err := NewFixtureFromRawString(fs, apiKey, "", ts.URL, outputFileTestFixture)
+	require.NoError(t, err)
+	fxt.Skip = []string{"skipped"}
+	fxt.ExpectedEvents = []string{"customer.created"}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, fs.MkdirAll("out", 0755))
+	require.NoError(t, afero.WriteFile(fs, "out/ids.json", []byte("previous run"), 0644))
+	require.NoError(t, fxt.WriteOutputFile("out/ids.json"))
+
+	content, err := afero.ReadFile(fs, "out/ids.json")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"expected_events": ["customer.created"],
+		"steps": {
+			"cust": {"id": "cus_123", "object": "customer", "status": 200},
+			"charge": {"status": 402},
+			"skipped": {"skipped": true}
+		}
+	}`, string(content))
+
+	// the temporary file is renamed over the output file
+	files, err := afero.ReadDir(fs, "out")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestResponseHeaders(t *testing.T) {
+	var forms []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		forms = append(forms, req.Form.Encode())
+
+		res.Header().Set("Request-Id", "req_"+req.URL.Path[len("/v1/"):])
+		res.Write([]byte(`{"id": "obj_123"}`))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post"},
+		{"name": "charge", "path": "/v1/charges", "method": "post", "params": {"metadata": {"customer_request": "${cust:header:Request-Id}"}}}
+	]}`)
+	require.NoError(t, err)
+	fxt.Out = &out
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"", "metadata%5Bcustomer_request%5D=req_customers"}, forms)
+	require.Contains(t, out.String(), "Request ID for cust: req_customers\n")
+	require.Contains(t, out.String(), "Request ID for charge: req_charges\n")
+	require.Equal(t, "req_charges", fxt.Output().Steps["charge"].RequestID)
+}
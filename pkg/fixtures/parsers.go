@@ -64,9 +64,9 @@ func (fxt *Fixture) parsePath(http fixture) (string, error) {
 			newPath = append(newPath, value)
 		}
 
-		if len(pathParts)%2 == 0 {
-			newPath = append(newPath, pathParts[len(pathParts)-1])
-		}
+		// The path may continue after the last query, like
+		// /v1/customers/${cust:id}/sources
+		newPath = append(newPath, pathParts[len(pathParts)-1])
 
 		return path.Join(newPath...), nil
 	}
@@ -145,7 +145,7 @@ func (fxt *Fixture) parseMap(params map[string]interface{}, parent string, index
 			// responses, check and load those.
 			parsed, err := fxt.parseQuery(v.String())
 			if err != nil {
-				return make([]string, 0), err
+				return make([]string, 0), withParam(err, keyname)
 			}
 			data = append(data, fmt.Sprintf("%s=%s", keyname, parsed))
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -217,7 +217,7 @@ func (fxt *Fixture) parseArray(params []interface{}, parent string) ([]string, e
 			// A string can be a regular value or one we need to look up first, ex: ${product.id}
 			parsed, err := fxt.parseQuery(v.String())
 			if err != nil {
-				return make([]string, 0), err
+				return make([]string, 0), withParam(err, parent+"[]")
 			}
 			data = append(data, fmt.Sprintf("%s[]=%s", parent, parsed))
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -271,6 +271,21 @@ func findSimilarQueryNames(fxt *Fixture, name string) ([]string, bool) {
 // corresponding value in its place. The supported query format is:
 // 		$<name of fixture>:dot.path.to.field
 func (fxt *Fixture) parseQuery(queryString string) (string, error) {
+	queryString, err := fxt.replaceVars(queryString)
+	if err != nil {
+		return "", err
+	}
+
+	queryString, err = fxt.replaceRandomValues(queryString)
+	if err != nil {
+		return "", err
+	}
+
+	queryString, err = fxt.replaceFunctions(queryString)
+	if err != nil {
+		return "", err
+	}
+
 	value := queryString
 
 	if query, isQuery := toFixtureQuery(queryString); isQuery {
@@ -295,6 +310,27 @@ func (fxt *Fixture) parseQuery(queryString string) (string, error) {
 			return value, nil
 		}
 
+		// ${name:header:Request-Id} references a header of the response
+		if step := strings.TrimSuffix(name, headerQuerySuffix); step != name {
+			if header, ok := fxt.headers[step]; ok {
+				if headerValue := header.Get(query.Query); headerValue != "" {
+					return headerValue, nil
+				}
+				return value, nil
+			}
+			name = step
+		}
+
+		if err := fxt.skippedReference(name, query); err != nil {
+			return "", err
+		}
+
+		if fxt.dryRun && fxt.declaresStep(name) {
+			if _, ok := fxt.responses[name]; !ok {
+				return placeholder(query), nil
+			}
+		}
+
 		if _, ok := fxt.responses[name]; !ok {
 			// An undeclared fixture name is being referenced
 			var errorStrings []string
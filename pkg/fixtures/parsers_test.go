@@ -151,6 +151,21 @@ func TestParsePathOneParamWithTrailing(t *testing.T) {
 	assert.Equal(t, "/v1/charges/char_12345/capture", path)
 }
 
+func TestParsePathTwoParamsWithTrailing(t *testing.T) {
+	fxt := Fixture{
+		responses: map[string]gjson.Result{
+			"cust_bender": gjson.Parse(`{"id": "cust_12345"}`),
+			"card_bender": gjson.Parse(`{"id": "card_12345"}`),
+		},
+	}
+	http := fixture{
+		Path: "/v1/customers/${cust_bender:id}/sources/${card_bender:id}/verify",
+	}
+
+	path, _ := fxt.parsePath(http)
+	assert.Equal(t, "/v1/customers/cust_12345/sources/card_12345/verify", path)
+}
+
 func TestParseInterfaceFromRaw(t *testing.T) {
 	var rawFixtureData = []byte(`{
 		"salary": 1000000000,
@@ -0,0 +1,156 @@
+package fixtures
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fixture params may use random values so that each run creates distinct
+// objects:
+//
+//	${random:email}          a random email address at example.com
+//	${random:string:12}      a random lowercase alphanumeric string of 12 characters
+//	${random:int:100:5000}   a random integer between 100 and 5000, inclusive
+//	${uuid}                  a random version 4 UUID
+//
+// Each expression is generated once per run, so that using the same one in
+// several steps gives the same value. Runs with the same seed generate the
+// same values.
+
+// maxRandomStringLength bounds the length of ${random:string:N}
+const maxRandomStringLength = 1000
+
+// defaultRandomStringLength is the length of ${random:string}
+const defaultRandomStringLength = 12
+
+const randomStringAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// randomValueRegexp matches `${uuid}` and `${random:...}`
+var randomValueRegexp = regexp.MustCompile(`\$\{(uuid|random:[^}|]+)\}`)
+
+// randomValues generates the random values of a run. Each expression is
+// generated from the seed and the expression, so that the values don't depend
+// on the order params are parsed in.
+type randomValues struct {
+	seed   int64
+	values map[string]string
+}
+
+func newRandomValues(seed int64) *randomValues {
+	return &randomValues{
+		seed:   seed,
+		values: make(map[string]string),
+	}
+}
+
+// SetSeed makes the random values of the fixture reproducible. It must be
+// called before the fixture is executed.
+func (fxt *Fixture) SetSeed(seed int64) {
+	fxt.random = newRandomValues(seed)
+}
+
+// randomValuesOf returns the random values of the run, seeded with the time
+// unless SetSeed was called
+func (fxt *Fixture) randomValuesOf() *randomValues {
+	if fxt.random == nil {
+		fxt.random = newRandomValues(time.Now().UnixNano())
+	}
+
+	return fxt.random
+}
+
+// replaceRandomValues replaces the random value expressions in s
+func (fxt *Fixture) replaceRandomValues(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var err error
+	replaced := randomValueRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		expression := randomValueRegexp.FindStringSubmatch(match)[1]
+
+		value, generateErr := fxt.randomValuesOf().get(expression)
+		if generateErr != nil {
+			err = generateErr
+			return match
+		}
+
+		return value
+	})
+
+	return replaced, err
+}
+
+// get returns the value of the expression, generating it the first time
+func (r *randomValues) get(expression string) (string, error) {
+	if value, ok := r.values[expression]; ok {
+		return value, nil
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(expression))                                // #nosec G104 -- never fails
+	rng := rand.New(rand.NewSource(r.seed ^ int64(hash.Sum64()))) // #nosec G404 -- fixture data, not secrets
+
+	value, err := generateRandomValue(rng, expression)
+	if err != nil {
+		return "", err
+	}
+	r.values[expression] = value
+
+	return value, nil
+}
+
+func generateRandomValue(rng *rand.Rand, expression string) (string, error) {
+	if expression == "uuid" {
+		return randomUUID(rng), nil
+	}
+
+	args := strings.Split(strings.TrimPrefix(expression, "random:"), ":")
+
+	switch {
+	case args[0] == "email" && len(args) == 1:
+		return randomString(rng, 10) + "@example.com", nil
+	case args[0] == "string" && len(args) == 1:
+		return randomString(rng, defaultRandomStringLength), nil
+	case args[0] == "string" && len(args) == 2:
+		length, err := strconv.Atoi(args[1])
+		if err != nil || length < 1 || length > maxRandomStringLength {
+			return "", fmt.Errorf("Invalid random value ${%s}, the length must be between 1 and %d", expression, maxRandomStringLength)
+		}
+		return randomString(rng, length), nil
+	case args[0] == "int" && len(args) == 3:
+		min, minErr := strconv.ParseInt(args[1], 10, 64)
+		max, maxErr := strconv.ParseInt(args[2], 10, 64)
+		if minErr != nil || maxErr != nil || min > max || max-min+1 <= 0 {
+			return "", fmt.Errorf("Invalid random value ${%s}, expected ${random:int:min:max}", expression)
+		}
+		return strconv.FormatInt(min+rng.Int63n(max-min+1), 10), nil
+	default:
+		return "", fmt.Errorf("Unknown random value ${%s}, supported values are ${random:email}, ${random:string:length}, ${random:int:min:max} and ${uuid}", expression)
+	}
+}
+
+func randomString(rng *rand.Rand, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = randomStringAlphabet[rng.Intn(len(randomStringAlphabet))]
+	}
+
+	return string(b)
+}
+
+func randomUUID(rng *rand.Rand) string {
+	b := make([]byte, 16)
+	rng.Read(b) // #nosec G104 -- never fails
+
+	// version 4, variant 10
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,97 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const randomTestFixture = `
+{
+	"fixtures": [
+		{
+			"name": "cust",
+			"path": "/v1/customers",
+			"method": "post",
+			"params": {
+				"email": "${random:email}",
+				"name": "dev-${random:string:6}",
+				"metadata": {"ref": "${uuid}", "quantity": "${random:int:100:5000}"}
+			}
+		},
+		{
+			"name": "charge",
+			"path": "/v1/charges",
+			"method": "post",
+			"params": {
+				"receipt_email": "${random:email}"
+			}
+		}
+	]
+}`
+
+func runRandomTestFixture(t *testing.T, seed int64) []map[string]string {
+	var forms []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+
+		form := make(map[string]string)
+		for key := range req.Form {
+			form[key] = req.Form.Get(key)
+		}
+		forms = append(forms, form)
+
+		res.Write([]byte(`{"id": "obj_123"}`))
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, randomTestFixture)
+	require.NoError(t, err)
+	if seed != 0 {
+		fxt.SetSeed(seed)
+	}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	return forms
+}
+
+func TestRandomValues(t *testing.T) {
+	forms := runRandomTestFixture(t, 42)
+	require.Len(t, forms, 2)
+
+	cust := forms[0]
+	require.Regexp(t, `^[a-z0-9]{10}@example\.com$`, cust["email"])
+	require.Regexp(t, `^dev-[a-z0-9]{6}$`, cust["name"])
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, cust["metadata[ref]"])
+
+	quantity, err := strconv.Atoi(cust["metadata[quantity]"])
+	require.NoError(t, err)
+	require.True(t, quantity >= 100 && quantity <= 5000)
+
+	// the same expression has the same value in every step
+	require.Equal(t, cust["email"], forms[1]["receipt_email"])
+
+	// the same seed generates the same values
+	require.Equal(t, forms, runRandomTestFixture(t, 42))
+	require.NotEqual(t, cust["email"], runRandomTestFixture(t, 43)[0]["email"])
+}
+
+func TestRandomValuesErrors(t *testing.T) {
+	fxt := Fixture{}
+
+	for _, expression := range []string{"${random:color}", "${random:string:0}", "${random:int:10:1}", "${random:int:a:b}"} {
+		_, err := fxt.replaceRandomValues(expression)
+		require.Error(t, err, expression)
+	}
+
+	value, err := fxt.replaceRandomValues("${cust:id} and ${.env:PHONE}")
+	require.NoError(t, err)
+	require.Equal(t, "${cust:id} and ${.env:PHONE}", value)
+}
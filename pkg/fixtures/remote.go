@@ -0,0 +1,141 @@
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Fixture files may be fetched from http and https URLs. Fetched files are
+// cached, and used instead of fetching them again unless RemoteOptions.NoCache
+// is set. The includes of a fetched file are resolved against its URL.
+
+// MaxRemoteFixtureSize is the maximum size of a fixture file fetched from a
+// URL, in bytes
+const MaxRemoteFixtureSize = 5 * 1024 * 1024
+
+// remoteFixtureTimeout bounds the time to fetch a fixture file
+const remoteFixtureTimeout = 30 * time.Second
+
+// RemoteOptions configures how fixture files are fetched from URLs
+type RemoteOptions struct {
+	// CacheDir is the directory fetched files are cached in. They are not
+	// cached if it is empty.
+	CacheDir string
+	// NoCache fetches the files again even if they are cached
+	NoCache bool
+	// Client is the HTTP client used to fetch the files, a client with a
+	// timeout if nil
+	Client *http.Client
+	// AllowUploads lets the fixtures fetched from URLs upload local files,
+	// which they can't by default since anyone may have written them
+	AllowUploads bool
+}
+
+// isRemoteFixture returns whether the fixture file is a URL
+func isRemoteFixture(file string) bool {
+	return strings.HasPrefix(file, "https://") || strings.HasPrefix(file, "http://")
+}
+
+// resolveInclude returns the path or URL of a file included by another one
+func resolveInclude(file, include string) (string, error) {
+	if isRemoteFixture(include) {
+		return include, nil
+	}
+
+	if isRemoteFixture(file) {
+		base, err := url.Parse(file)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(filepath.ToSlash(include))
+		if err != nil {
+			return "", fmt.Errorf("Invalid include %s in %s: %v", include, file, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	return filepath.Clean(filepath.Join(filepath.Dir(file), include)), nil
+}
+
+// readRemoteFixture returns the fixture file at the URL, from the cache unless
+// NoCache is set
+func (fxt *Fixture) readRemoteFixture(fileURL string) ([]byte, error) {
+	var cached []byte
+	cacheFile := ""
+	if fxt.remote.CacheDir != "" {
+		sum := sha256.Sum256([]byte(fileURL))
+		cacheFile = filepath.Join(fxt.remote.CacheDir, hex.EncodeToString(sum[:])+".json")
+
+		if data, err := afero.ReadFile(fxt.Fs, cacheFile); err == nil {
+			if !fxt.remote.NoCache {
+				return data, nil
+			}
+			cached = data
+		}
+	}
+
+	data, err := fxt.fetchRemoteFixture(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheFile == "" {
+		return data, nil
+	}
+
+	if cached != nil && !bytes.Equal(checksum(cached), checksum(data)) {
+		fmt.Fprintf(fxt.out(), "Warning: the fixture file %s changed since it was cached (checksum %x, was %x)\n", fileURL, checksum(data), checksum(cached))
+	}
+
+	if err := fxt.Fs.MkdirAll(fxt.remote.CacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to cache the fixture file %s: %v", fileURL, err)
+	}
+	if err := afero.WriteFile(fxt.Fs, cacheFile, data, 0644); err != nil {
+		return nil, fmt.Errorf("Failed to cache the fixture file %s: %v", fileURL, err)
+	}
+
+	return data, nil
+}
+
+func (fxt *Fixture) fetchRemoteFixture(fileURL string) ([]byte, error) {
+	client := fxt.remote.Client
+	if client == nil {
+		client = &http.Client{Timeout: remoteFixtureTimeout}
+	}
+
+	resp, err := client.Get(fileURL) // #nosec G107 -- the URL is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch the fixture file %s: %v", fileURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to fetch the fixture file %s: %s", fileURL, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxRemoteFixtureSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch the fixture file %s: %v", fileURL, err)
+	}
+	if len(data) > MaxRemoteFixtureSize {
+		return nil, fmt.Errorf("The fixture file %s is larger than the limit of %d MB", fileURL, MaxRemoteFixtureSize/1024/1024)
+	}
+
+	return data, nil
+}
+
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:8]
+}
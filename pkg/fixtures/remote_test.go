@@ -0,0 +1,82 @@
+package fixtures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFixtureFromURL(t *testing.T) {
+	files := map[string]string{
+		"/org/fixtures/main/seed.json":            `{"include": ["common/customer.json"], "fixtures": [{"name": "charge", "path": "/v1/charges", "method": "post"}]}`,
+		"/org/fixtures/main/common/customer.json": `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post"}]}`,
+	}
+	fetched := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fetched++
+		content, ok := files[req.URL.Path]
+		if !ok {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		res.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	fs := afero.NewMemMapFs()
+	remote := RemoteOptions{CacheDir: "/config/fixtures"}
+
+	fxt, err := NewFixture(fs, apiKey, "", "", ts.URL+"/org/fixtures/main/seed.json", []string{}, []string{}, []string{}, []string{}, remote)
+	require.NoError(t, err)
+	require.Equal(t, []string{ts.URL + "/org/fixtures/main/common/customer.json"}, fxt.IncludedFiles())
+	require.Len(t, fxt.fixture.Fixtures, 2)
+	require.Equal(t, 2, fetched)
+
+	cached, err := afero.ReadDir(fs, "/config/fixtures")
+	require.NoError(t, err)
+	require.Len(t, cached, 2)
+
+	// the cached copies are used
+	_, err = NewFixture(fs, apiKey, "", "", ts.URL+"/org/fixtures/main/seed.json", []string{}, []string{}, []string{}, []string{}, remote)
+	require.NoError(t, err)
+	require.Equal(t, 2, fetched)
+
+	// unless the cache is bypassed
+	files["/org/fixtures/main/common/customer.json"] = `{"fixtures": [{"name": "cust", "path": "/v1/customers", "method": "post", "params": {"email": "a@example.com"}}]}`
+	remote.NoCache = true
+	fxt, err = NewFixture(fs, apiKey, "", "", ts.URL+"/org/fixtures/main/seed.json", []string{}, []string{}, []string{}, []string{}, remote)
+	require.NoError(t, err)
+	require.Equal(t, 4, fetched)
+	require.Equal(t, "a@example.com", fxt.fixture.Fixtures[0].Params["email"])
+}
+
+func TestNewFixtureFromURLErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/large.json" {
+			res.Write([]byte(strings.Repeat(" ", MaxRemoteFixtureSize+1)))
+			return
+		}
+		res.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	_, err := NewFixture(afero.NewMemMapFs(), apiKey, "", "", ts.URL+"/missing.json", []string{}, []string{}, []string{}, []string{}, RemoteOptions{})
+	require.EqualError(t, err, "Failed to fetch the fixture file "+ts.URL+"/missing.json: 404 Not Found")
+
+	_, err = NewFixture(afero.NewMemMapFs(), apiKey, "", "", ts.URL+"/large.json", []string{}, []string{}, []string{}, []string{}, RemoteOptions{})
+	require.EqualError(t, err, "The fixture file "+ts.URL+"/large.json is larger than the limit of 5 MB")
+}
+
+func TestResolveInclude(t *testing.T) {
+	include, err := resolveInclude("https://example.com/fixtures/seed.json", "../common/customer.json")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/common/customer.json", include)
+
+	include, err = resolveInclude("fixtures/seed.json", "https://example.com/customer.json")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/customer.json", include)
+}
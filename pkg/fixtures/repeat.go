@@ -0,0 +1,81 @@
+package fixtures
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A fixture step with `repeat: N` is run N times. Each run is named after the
+// step and its index, like `customers[3]`, so that later steps can reference
+// its response with `${customers[3]:id}`. `${index}` is replaced with the
+// index of the run in the params, path, account, idempotency key and only_if
+// condition of the step, which allows a repeated step to reference the run of
+// another one with the same index, like `${customers[${index}]:id}`.
+
+// MaxRepeat is the maximum number of times a fixture step can be repeated
+const MaxRepeat = 1000
+
+// indexPlaceholder is replaced with the index of the run of a repeated step
+const indexPlaceholder = "${index}"
+
+// expandRepeats replaces each repeated step by one step per run
+func (fxt *Fixture) expandRepeats() error {
+	expanded := make([]fixture, 0, len(fxt.fixture.Fixtures))
+
+	for _, data := range fxt.fixture.Fixtures {
+		if data.Repeat == 0 {
+			expanded = append(expanded, data)
+			continue
+		}
+
+		if data.Repeat < 0 || data.Repeat > MaxRepeat {
+			return fmt.Errorf("Fixture %s repeats %d times, it must repeat between 1 and %d times", data.Name, data.Repeat, MaxRepeat)
+		}
+
+		for i := 0; i < data.Repeat; i++ {
+			run := data
+			run.Name = fmt.Sprintf("%s[%d]", data.Name, i)
+			run.Repeat = 0
+			run.repeatOf = data.Name
+
+			index := strconv.Itoa(i)
+			run.Path = strings.ReplaceAll(data.Path, indexPlaceholder, index)
+			run.Account = strings.ReplaceAll(data.Account, indexPlaceholder, index)
+			run.IdempotencyKey = strings.ReplaceAll(data.IdempotencyKey, indexPlaceholder, index)
+			run.OnlyIf = strings.ReplaceAll(data.OnlyIf, indexPlaceholder, index)
+			if data.Params != nil {
+				run.Params = replaceIndex(data.Params, index).(map[string]interface{})
+			}
+
+			expanded = append(expanded, run)
+		}
+	}
+
+	fxt.fixture.Fixtures = expanded
+
+	return nil
+}
+
+// replaceIndex returns a copy of the value with the index placeholder replaced
+// in its strings, recursing into maps and arrays
+func replaceIndex(value interface{}, index string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		replaced := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			replaced[key] = replaceIndex(item, index)
+		}
+		return replaced
+	case []interface{}:
+		replaced := make([]interface{}, len(v))
+		for i, item := range v {
+			replaced[i] = replaceIndex(item, index)
+		}
+		return replaced
+	case string:
+		return strings.ReplaceAll(v, indexPlaceholder, index)
+	default:
+		return value
+	}
+}
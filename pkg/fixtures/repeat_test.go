@@ -0,0 +1,113 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const repeatTestFixture = `
+{
+	"_meta": {
+		"template_version": 0,
+		"exclude_metadata": true
+	},
+	"fixtures": [
+		{
+			"name": "customers",
+			"path": "/v1/customers",
+			"method": "post",
+			"repeat": 3,
+			"params": {
+				"email": "customer${index}@example.com"
+			}
+		},
+		{
+			"name": "subscriptions",
+			"path": "/v1/subscriptions",
+			"method": "post",
+			"repeat": 3,
+			"params": {
+				"customer": "${customers[${index}]:id}"
+			}
+		},
+		{
+			"name": "last",
+			"path": "/v1/customers/${customers[2]:id}",
+			"method": "post"
+		}
+	]
+}`
+
+func TestExecuteRepeatedSteps(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	customers := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch req.URL.Path {
+		case "/v1/customers":
+			requests = append(requests, "customer "+req.Form.Get("email"))
+			fmt.Fprintf(res, `{"id": "cus_%d"}`, customers)
+			customers++
+		case "/v1/subscriptions":
+			requests = append(requests, "subscription "+req.Form.Get("customer"))
+			res.Write([]byte(`{"id": "sub_123"}`))
+		default:
+			requests = append(requests, req.URL.Path)
+			res.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, repeatTestFixture)
+	require.NoError(t, err)
+
+	requestNames, err := fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"customers[0]", "customers[1]", "customers[2]",
+		"subscriptions[0]", "subscriptions[1]", "subscriptions[2]",
+		"last",
+	}, requestNames)
+	require.Equal(t, []string{
+		"customer customer0@example.com",
+		"customer customer1@example.com",
+		"customer customer2@example.com",
+		"subscription cus_0",
+		"subscription cus_1",
+		"subscription cus_2",
+		"/v1/customers/cus_2",
+	}, requests)
+}
+
+func TestExecuteSkipsEveryRunOfRepeatedSteps(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", repeatTestFixture)
+	require.NoError(t, err)
+
+	fxt.Skip = []string{"customers", "subscriptions"}
+	fxt.fixture.Fixtures = fxt.fixture.Fixtures[:6]
+
+	requestNames, err := fxt.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "", "", "", "", ""}, requestNames)
+}
+
+func TestExpandRepeatsBound(t *testing.T) {
+	raw := `{"fixtures": [{"name": "customers", "path": "/v1/customers", "method": "post", "repeat": 10000}]}`
+
+	_, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", raw)
+	require.EqualError(t, err, "Fixture customers repeats 10000 times, it must repeat between 1 and 1000 times")
+}
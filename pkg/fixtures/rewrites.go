@@ -0,0 +1,230 @@
+package fixtures
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rewrites change the params of fixtures from the command line, with
+// `<fixture_name>:path.to.field=value` for overrides and additions and
+// `<fixture_name>:path.to.field` for removals. Paths may use dots or
+// brackets, and brackets around a number index into an array:
+//
+//	checkout_session:line_items[0][price]=price_123
+//	checkout_session:line_items.0.price=price_123 (a key named "0")
+//
+// The intermediate maps and arrays a path goes through are created as needed.
+// Rewrites are applied in order, so the last override of a field wins.
+
+// removeSentinel is the value of an override removing the field
+const removeSentinel = "-"
+
+type rewriteMode int
+
+const (
+	rewriteOverride rewriteMode = iota
+	rewriteAdd
+	rewriteRemove
+)
+
+type rewrite struct {
+	name  string
+	path  []pathKey
+	value string
+}
+
+// pathKey is a key of a map, or an index of an array when index is not -1
+type pathKey struct {
+	key   string
+	index int
+}
+
+// rewrite applies the changes to the params of the fixtures they name.
+// Invalid changes are reported and ignored.
+func (fxt *Fixture) rewrite(changes []string, mode rewriteMode) {
+	for _, change := range changes {
+		if change == "" {
+			continue
+		}
+
+		rw, err := parseRewrite(change, mode != rewriteRemove)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		changeMode := mode
+		if mode == rewriteOverride && rw.value == removeSentinel {
+			changeMode = rewriteRemove
+		}
+
+		for i, data := range fxt.fixture.Fixtures {
+			if data.Name != rw.name {
+				continue
+			}
+
+			if data.Params == nil {
+				if changeMode == rewriteRemove {
+					continue
+				}
+				data.Params = make(map[string]interface{})
+			}
+
+			fxt.fixture.Fixtures[i].Params = rewriteValue(data.Params, rw.path, rw.value, changeMode).(map[string]interface{})
+		}
+	}
+}
+
+// parseRewrite parses `<fixture_name>:path.to.field=value`, or
+// `<fixture_name>:path.to.field` when withValue is false
+func parseRewrite(change string, withValue bool) (rewrite, error) {
+	var rw rewrite
+
+	field := change
+	if withValue {
+		parts := strings.SplitN(change, "=", 2)
+		if len(parts) != 2 {
+			return rw, fmt.Errorf("Invalid change %s, expected <fixture_name>:path.to.field=value", change)
+		}
+		field, rw.value = parts[0], parts[1]
+	}
+
+	parts := strings.SplitN(field, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return rw, fmt.Errorf("Invalid change %s, expected <fixture_name>:path.to.field", change)
+	}
+	rw.name = parts[0]
+
+	path, err := parseFieldPath(parts[1])
+	if err != nil {
+		return rw, err
+	}
+	rw.path = path
+
+	return rw, nil
+}
+
+// parseFieldPath splits a path like `line_items[0][price]` or `a.b.c` into its
+// keys
+func parseFieldPath(path string) ([]pathKey, error) {
+	var keys []pathKey
+
+	invalid := fmt.Errorf("Invalid field path %s", path)
+
+	rest := path
+	for rest != "" {
+		var key string
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, invalid
+			}
+			key = rest[1:end]
+			rest = rest[end+1:]
+
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 {
+				keys = append(keys, pathKey{index: index})
+				rest = strings.TrimPrefix(rest, ".")
+				continue
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key = rest[:end]
+			rest = strings.TrimPrefix(rest[end:], ".")
+		}
+
+		if key == "" {
+			return nil, invalid
+		}
+		keys = append(keys, pathKey{key: key, index: -1})
+	}
+
+	if len(keys) == 0 {
+		return nil, invalid
+	}
+
+	return keys, nil
+}
+
+// rewriteValue overrides, adds or removes the field at path in current and
+// returns the updated value
+func rewriteValue(current interface{}, path []pathKey, value string, mode rewriteMode) interface{} {
+	if len(path) == 0 {
+		if mode == rewriteAdd && current != nil {
+			return current
+		}
+		return convertLike(current, value)
+	}
+
+	key, rest := path[0], path[1:]
+
+	if key.index == -1 {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			if mode == rewriteRemove || (mode == rewriteAdd && current != nil) {
+				return current
+			}
+			m = make(map[string]interface{})
+		}
+
+		child, exists := m[key.key]
+		if mode == rewriteRemove {
+			if !exists {
+				return m
+			}
+			if len(rest) == 0 {
+				delete(m, key.key)
+				return m
+			}
+		}
+
+		m[key.key] = rewriteValue(child, rest, value, mode)
+		return m
+	}
+
+	a, ok := current.([]interface{})
+	if !ok {
+		if mode == rewriteRemove || (mode == rewriteAdd && current != nil) {
+			return current
+		}
+		a = []interface{}{}
+	}
+
+	if mode == rewriteRemove {
+		if key.index >= len(a) {
+			return a
+		}
+		if len(rest) == 0 {
+			return append(a[:key.index:key.index], a[key.index+1:]...)
+		}
+	}
+
+	for len(a) <= key.index {
+		a = append(a, nil)
+	}
+	a[key.index] = rewriteValue(a[key.index], rest, value, mode)
+
+	return a
+}
+
+// convertLike converts the value to the type of the number or boolean it
+// replaces, so that it keeps its type. Other values are strings.
+func convertLike(current interface{}, value string) interface{} {
+	switch current.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+
+	return value
+}
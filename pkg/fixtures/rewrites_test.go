@@ -0,0 +1,118 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []pathKey
+		err      bool
+	}{
+		{path: "name", expected: []pathKey{{key: "name", index: -1}}},
+		{path: "address.city", expected: []pathKey{{key: "address", index: -1}, {key: "city", index: -1}}},
+		{path: "metadata[order]", expected: []pathKey{{key: "metadata", index: -1}, {key: "order", index: -1}}},
+		{path: "line_items[0][price]", expected: []pathKey{{key: "line_items", index: -1}, {index: 0}, {key: "price", index: -1}}},
+		{path: "items[2].price.id", expected: []pathKey{{key: "items", index: -1}, {index: 2}, {key: "price", index: -1}, {key: "id", index: -1}}},
+		{path: "", err: true},
+		{path: "items[0", err: true},
+		{path: "a..b", err: true},
+		{path: "items[]", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			keys, err := parseFieldPath(test.path)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, keys)
+		})
+	}
+}
+
+func TestRewrites(t *testing.T) {
+	const params = `{
+		"amount": 100,
+		"capture": false,
+		"currency": "usd",
+		"line_items": [{"price": "price_1", "quantity": 1}, {"price": "price_2"}],
+		"metadata": {"order": "123"}
+	}`
+
+	tests := []struct {
+		name      string
+		overrides []string
+		additions []string
+		removals  []string
+		expected  string
+	}{
+		{
+			name:      "array element",
+			overrides: []string{"session:line_items[0][price]=price_123"},
+			expected:  `{"amount": 100, "capture": false, "currency": "usd", "line_items": [{"price": "price_123", "quantity": 1}, {"price": "price_2"}], "metadata": {"order": "123"}}`,
+		},
+		{
+			name:      "new array element",
+			overrides: []string{"session:line_items[3][price]=price_4"},
+			expected:  `{"amount": 100, "capture": false, "currency": "usd", "line_items": [{"price": "price_1", "quantity": 1}, {"price": "price_2"}, null, {"price": "price_4"}], "metadata": {"order": "123"}}`,
+		},
+		{
+			name:      "nested maps",
+			overrides: []string{"session:payment_intent_data.metadata.source=cli", "session:metadata[order]=456"},
+			expected:  `{"amount": 100, "capture": false, "currency": "usd", "line_items": [{"price": "price_1", "quantity": 1}, {"price": "price_2"}], "metadata": {"order": "456"}, "payment_intent_data": {"metadata": {"source": "cli"}}}`,
+		},
+		{
+			name:      "numeric and boolean values keep their type",
+			overrides: []string{"session:amount=3000", "session:capture=true", "session:currency=100", "session:line_items[0][quantity]=many"},
+			expected:  `{"amount": 3000, "capture": true, "currency": "100", "line_items": [{"price": "price_1", "quantity": "many"}, {"price": "price_2"}], "metadata": {"order": "123"}}`,
+		},
+		{
+			name:      "conflicting overrides on the same path",
+			overrides: []string{"session:metadata[order]=1", "session:metadata[order]=2", "session:currency=eur", "session:currency.code=jpy"},
+			expected:  `{"amount": 100, "capture": false, "currency": {"code": "jpy"}, "line_items": [{"price": "price_1", "quantity": 1}, {"price": "price_2"}], "metadata": {"order": "2"}}`,
+		},
+		{
+			name:      "remove sentinel",
+			overrides: []string{"session:metadata=-", "session:line_items[0]=-", "session:missing.field=-"},
+			expected:  `{"amount": 100, "capture": false, "currency": "usd", "line_items": [{"price": "price_2"}]}`,
+		},
+		{
+			name:      "additions only add missing fields",
+			additions: []string{"session:currency=eur", "session:line_items[1][quantity]=2", "session:metadata.order.id=1"},
+			expected:  `{"amount": 100, "capture": false, "currency": "usd", "line_items": [{"price": "price_1", "quantity": 1}, {"price": "price_2", "quantity": "2"}], "metadata": {"order": "123"}}`,
+		},
+		{
+			name:     "deep removals",
+			removals: []string{"session:line_items[0][quantity]", "session:metadata[order]", "session:missing[0]"},
+			expected: `{"amount": 100, "capture": false, "currency": "usd", "line_items": [{"price": "price_1"}, {"price": "price_2"}], "metadata": {}}`,
+		},
+		{
+			name:      "other fixtures and invalid changes are ignored",
+			overrides: []string{"other:amount=1", "session:amount", "session:items[=1"},
+			expected:  params,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fxt := Fixture{}
+			fxt.fixture.Fixtures = []fixture{{Name: "session"}}
+			require.NoError(t, json.Unmarshal([]byte(params), &fxt.fixture.Fixtures[0].Params))
+
+			fxt.Override(test.overrides)
+			fxt.Add(test.additions)
+			fxt.Remove(test.removals)
+
+			actual, err := json.Marshal(fxt.fixture.Fixtures[0].Params)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(actual))
+		})
+	}
+}
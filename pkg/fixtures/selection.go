@@ -0,0 +1,163 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// The steps a fixture runs can be selected with Skip and Only. Later steps may
+// still reference the output of a step that doesn't run if its values are set
+// with SetValues, as in `--set customer:id=cus_123`. SetValues also sets the
+// variables of the fixture, as in `--set price=price_123`.
+
+// isSelected returns whether the step runs given Skip and Only. The repeats of
+// a step are selected with it.
+func (fxt *Fixture) isSelected(data fixture) bool {
+	names := []string{data.Name}
+	if data.repeatOf != "" {
+		names = append(names, data.repeatOf)
+	}
+
+	for _, name := range names {
+		if isNameIn(name, fxt.Skip) {
+			return false
+		}
+	}
+
+	if len(fxt.Only) == 0 {
+		return true
+	}
+
+	for _, name := range names {
+		if isNameIn(name, fxt.Only) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSelection returns an error if Only names a step the fixture doesn't
+// declare, which would silently skip every step
+func (fxt *Fixture) checkSelection() error {
+	for _, name := range fxt.Only {
+		if !fxt.declaresStep(name) && !fxt.declaresRepeated(name) {
+			return fmt.Errorf("Fixture %s passed to --only is not declared", name)
+		}
+	}
+
+	return nil
+}
+
+// declaresRepeated returns whether the fixture has a step repeating the one
+// with the name
+func (fxt *Fixture) declaresRepeated(name string) bool {
+	for _, data := range fxt.fixture.Fixtures {
+		if data.repeatOf == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetValues sets variables, given as `<variable>=value`, and outputs of steps,
+// given as `<step>:path.to.field=value`, so that later steps can reference
+// them when the step doesn't run
+func (fxt *Fixture) SetValues(values []string) error {
+	outputs := make(map[string]map[string]interface{})
+	for _, value := range values {
+		assignment := strings.SplitN(value, "=", 2)
+		if len(assignment) != 2 {
+			return fmt.Errorf("Invalid value %s, expected <variable>=value or <fixture_name>:path.to.field=value", value)
+		}
+
+		nameAndPath := strings.SplitN(assignment[0], ":", 2)
+		if len(nameAndPath) == 1 {
+			if !varNameRegexp.MatchString(assignment[0]) {
+				return fmt.Errorf("Invalid value %s, expected <variable>=value or <fixture_name>:path.to.field=value", value)
+			}
+			fxt.setVar(assignment[0], assignment[1])
+			continue
+		}
+
+		name, path, fieldValue := nameAndPath[0], nameAndPath[1], assignment[1]
+		if name == "" || path == "" {
+			return fmt.Errorf("Invalid value %s, expected <variable>=value or <fixture_name>:path.to.field=value", value)
+		}
+
+		if !fxt.declaresStep(name) {
+			return fmt.Errorf("Invalid value %s, the fixture %s is not declared", value, name)
+		}
+
+		if _, ok := outputs[name]; !ok {
+			outputs[name] = make(map[string]interface{})
+		}
+
+		if err := setPath(outputs[name], strings.Split(path, "."), fieldValue); err != nil {
+			return fmt.Errorf("Invalid value %s: %v", value, err)
+		}
+	}
+
+	for name, output := range outputs {
+		data, err := json.Marshal(output)
+		if err != nil {
+			return err
+		}
+		fxt.responses[name] = gjson.ParseBytes(data)
+	}
+
+	return nil
+}
+
+// setPath sets the value at the path of nested maps, creating the maps along
+// the way
+func setPath(output map[string]interface{}, path []string, value string) error {
+	if len(path) == 1 {
+		if _, isMap := output[path[0]].(map[string]interface{}); isMap {
+			return fmt.Errorf("%s is already set to an object", path[0])
+		}
+		output[path[0]] = value
+		return nil
+	}
+
+	next, ok := output[path[0]]
+	if !ok {
+		next = make(map[string]interface{})
+		output[path[0]] = next
+	}
+
+	nested, isMap := next.(map[string]interface{})
+	if !isMap {
+		return fmt.Errorf("%s is already set to a value", path[0])
+	}
+
+	return setPath(nested, path[1:], value)
+}
+
+// skippedReference returns an error if the query references a field of the
+// step with the name, which didn't run and whose value wasn't set
+func (fxt *Fixture) skippedReference(name string, query fixtureQuery) error {
+	skipped := isNameIn(name, fxt.skipped)
+	if !skipped {
+		for _, data := range fxt.fixture.Fixtures {
+			if data.Name == name && !fxt.isSelected(data) {
+				skipped = true
+				break
+			}
+		}
+	}
+
+	if !skipped || query.DefaultValue != "" {
+		return nil
+	}
+
+	if response, ok := fxt.responses[name]; ok && response.Get(query.Query).Exists() {
+		return nil
+	}
+
+	return fmt.Errorf("%s references the fixture %s which was skipped, set the value with --set %s:%s=<value>", query.Match, name, name, query.Query)
+}
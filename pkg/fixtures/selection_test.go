@@ -0,0 +1,88 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const selectionTestFixture = `
+{
+	"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post"},
+		{"name": "pm", "path": "/v1/payment_methods/pm_card_visa/attach", "method": "post", "params": {"customer": "${cust:id}"}},
+		{"name": "pi", "path": "/v1/payment_intents", "method": "post", "params": {"customer": "${cust:id}", "payment_method": "${pm:id}"}}
+	]
+}`
+
+func TestExecuteWithOnly(t *testing.T) {
+	var paths []string
+	var customers []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		paths = append(paths, req.URL.Path)
+		customers = append(customers, req.Form.Get("customer"))
+		res.Write([]byte(`{"id": "pi_123"}`))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, selectionTestFixture)
+	require.NoError(t, err)
+	fxt.Out = &out
+	fxt.Only = []string{"pi"}
+	require.NoError(t, fxt.SetValues([]string{"cust:id=cus_existing", "pm:id=pm_existing"}))
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"/v1/payment_intents"}, paths)
+	require.Equal(t, []string{"cus_existing"}, customers)
+	require.Contains(t, out.String(), "Skipped fixtures: cust, pm\n")
+}
+
+func TestExecuteWithUnsetSkippedReference(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(`{"id": "pm_123"}`))
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, selectionTestFixture)
+	require.NoError(t, err)
+	fxt.Out = &bytes.Buffer{}
+	fxt.Skip = []string{"cust"}
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "${cust:id} references the fixture cust which was skipped, set the value with --set cust:id=<value>")
+
+	// the dry run catches it too
+	_, err = fxt.DryRun()
+	require.EqualError(t, err, "${cust:id} references the fixture cust which was skipped, set the value with --set cust:id=<value>")
+}
+
+func TestCheckSelection(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", selectionTestFixture)
+	require.NoError(t, err)
+	fxt.Only = []string{"pi", "charge"}
+
+	_, err = fxt.DryRun()
+	require.EqualError(t, err, "Fixture charge passed to --only is not declared")
+}
+
+func TestSetValues(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", selectionTestFixture)
+	require.NoError(t, err)
+
+	require.NoError(t, fxt.SetValues([]string{"cust:id=cus_123", "cust:invoice_settings.default_payment_method=pm_123"}))
+	require.Equal(t, "cus_123", fxt.responses["cust"].Get("id").String())
+	require.Equal(t, "pm_123", fxt.responses["cust"].Get("invoice_settings.default_payment_method").String())
+
+	require.EqualError(t, fxt.SetValues([]string{"cust.id=cus_123"}), "Invalid value cust.id=cus_123, expected <variable>=value or <fixture_name>:path.to.field=value")
+	require.EqualError(t, fxt.SetValues([]string{"cust:id"}), "Invalid value cust:id, expected <variable>=value or <fixture_name>:path.to.field=value")
+	require.EqualError(t, fxt.SetValues([]string{"charge:id=ch_123"}), "Invalid value charge:id=ch_123, the fixture charge is not declared")
+	require.EqualError(t, fxt.SetValues([]string{"cust:address=x", "cust:address.city=Paris"}), "Invalid value cust:address.city=Paris: address is already set to a value")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"io"
 	"sort"
 
 	"github.com/spf13/afero"
@@ -20,6 +21,7 @@ var Events = map[string]string{
 	"balance.available":                        "triggers/balance.available.json",
 	"charge.captured":                          "triggers/charge.captured.json",
 	"charge.dispute.created":                   "triggers/charge.disputed.created.json",
+	"charge.dispute.funds_withdrawn":           "triggers/charge.dispute.funds_withdrawn.json",
 	"charge.failed":                            "triggers/charge.failed.json",
 	"charge.refunded":                          "triggers/charge.refunded.json",
 	"charge.succeeded":                         "triggers/charge.succeeded.json",
@@ -33,6 +35,8 @@ var Events = map[string]string{
 	"customer.source.updated":                  "triggers/customer.source.updated.json",
 	"customer.subscription.created":            "triggers/customer.subscription.created.json",
 	"customer.subscription.deleted":            "triggers/customer.subscription.deleted.json",
+	"customer.subscription.paused":             "triggers/customer.subscription.paused.json",
+	"customer.subscription.resumed":            "triggers/customer.subscription.resumed.json",
 	"customer.subscription.updated":            "triggers/customer.subscription.updated.json",
 	"invoice.created":                          "triggers/invoice.created.json",
 	"invoice.finalized":                        "triggers/invoice.finalized.json",
@@ -50,6 +54,7 @@ var Events = map[string]string{
 	"payment_intent.canceled":                  "triggers/payment_intent.canceled.json",
 	"payment_method.attached":                  "triggers/payment_method.attached.json",
 	"payout.created":                           "triggers/payout.created.json",
+	"payout.failed":                            "triggers/payout.failed.json",
 	"payout.updated":                           "triggers/payout.updated.json",
 	"plan.created":                             "triggers/plan.created.json",
 	"plan.deleted":                             "triggers/plan.deleted.json",
@@ -57,6 +62,7 @@ var Events = map[string]string{
 	"product.created":                          "triggers/product.created.json",
 	"product.deleted":                          "triggers/product.deleted.json",
 	"product.updated":                          "triggers/product.updated.json",
+	"radar.early_fraud_warning.created":        "triggers/radar.early_fraud_warning.created.json",
 	"setup_intent.canceled":                    "triggers/setup_intent.canceled.json",
 	"setup_intent.created":                     "triggers/setup_intent.created.json",
 	"setup_intent.setup_failed":                "triggers/setup_intent.setup_failed.json",
@@ -122,50 +128,81 @@ func EventNames() []string {
 	return names
 }
 
-// Trigger triggers a Stripe event.
-func Trigger(ctx context.Context, event string, stripeAccount string, baseURL string, apiKey string, skip, override, add, remove []string, raw string) ([]string, error) {
-	var fixture *Fixture
-	var err error
-	fs := afero.NewOsFs()
-
+// Trigger triggers a Stripe event. The progress of the fixture is printed to
+// out, or to stdout if it is nil. The objects it creates are listed in a
+// manifest in manifestDir, if set.
+func Trigger(ctx context.Context, event string, stripeAccount string, baseURL string, filesBaseURL string, apiKey string, skip, only, override, add, remove, set []string, raw string, idempotencyKeyPrefix string, seed int64, apiVersion string, outputFile string, manifestDir string, out io.Writer) ([]string, error) {
 	// send event triggered
 	telemetryClient := stripe.GetTelemetryClient(ctx)
 	if telemetryClient != nil {
 		go telemetryClient.SendEvent(ctx, "Triggered Event", event)
 	}
 
-	if len(raw) == 0 {
-		if file, ok := Events[event]; ok {
-			fixture, err = BuildFromFixtureFile(fs, apiKey, stripeAccount, baseURL, file, skip, override, add, remove)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			exists, _ := afero.Exists(fs, event)
-			if !exists {
-				return nil, fmt.Errorf(fmt.Sprintf("The event ‘%s’ is not supported by the Stripe CLI.", event))
-			}
-
-			fixture, err = BuildFromFixtureFile(fs, apiKey, stripeAccount, baseURL, event, skip, override, add, remove)
-			if err != nil {
-				return nil, err
-			}
-		}
-	} else {
-		fixture, err = BuildFromFixtureString(fs, apiKey, stripeAccount, baseURL, raw)
-		if err != nil {
-			return nil, err
-		}
+	fixture, err := NewTriggerFixture(afero.NewOsFs(), event, stripeAccount, baseURL, apiKey, skip, override, add, remove, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture.IdempotencyKeyPrefix = idempotencyKeyPrefix
+	fixture.FilesBaseURL = filesBaseURL
+	fixture.Only = only
+	fixture.Out = out
+	fixture.APIVersion = apiVersion
+	if seed != 0 {
+		fixture.SetSeed(seed)
+	}
+	if err := fixture.SetValues(set); err != nil {
+		return nil, err
 	}
 
 	requestNames, err := fixture.Execute(ctx)
+
+	// The objects created before a failure are listed too
+	if manifestDir != "" {
+		if _, manifestErr := fixture.WriteManifest(manifestDir); manifestErr != nil && err == nil {
+			return nil, manifestErr
+		}
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf(fmt.Sprintf("Trigger failed: %s\n", err))
 	}
 
+	if outputFile != "" {
+		if err := fixture.WriteOutputFile(outputFile); err != nil {
+			return nil, err
+		}
+	}
+
 	return requestNames, nil
 }
 
+// NewTriggerFixture returns the fixture triggering the event, which is either
+// a supported event, the path to a fixture file or, if raw is set, ignored in
+// favor of the raw fixture
+func NewTriggerFixture(fs afero.Fs, event string, stripeAccount string, baseURL string, apiKey string, skip, override, add, remove []string, raw string) (*Fixture, error) {
+	if len(raw) != 0 {
+		return BuildFromFixtureString(fs, apiKey, stripeAccount, baseURL, raw)
+	}
+
+	if file, ok := Events[event]; ok {
+		fixture, err := BuildFromFixtureFile(fs, apiKey, stripeAccount, baseURL, file, skip, override, add, remove)
+		if err != nil {
+			return nil, err
+		}
+		fixture.ExpectedEvents = []string{event}
+
+		return fixture, nil
+	}
+
+	exists, _ := afero.Exists(fs, event)
+	if !exists {
+		return nil, fmt.Errorf(fmt.Sprintf("The event ‘%s’ is not supported by the Stripe CLI.", event))
+	}
+
+	return BuildFromFixtureFile(fs, apiKey, stripeAccount, baseURL, event, skip, override, add, remove)
+}
+
 func reverseMap() map[string]string {
 	reversed := make(map[string]string)
 	for name, file := range Events {
@@ -0,0 +1,78 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTriggers(t *testing.T) {
+	tests := map[string][]string{
+		"charge.dispute.funds_withdrawn": {
+			"POST /v1/charges",
+		},
+		"customer.subscription.paused": {
+			"POST /v1/customers",
+			"POST /v1/plans",
+			"POST /v1/subscriptions",
+			"POST /v1/subscriptions/sub_3",
+		},
+		"customer.subscription.resumed": {
+			"POST /v1/customers",
+			"POST /v1/plans",
+			"POST /v1/subscriptions",
+			"POST /v1/subscriptions/sub_3",
+			"POST /v1/customers/cus_1",
+			"POST /v1/subscriptions/sub_3/resume",
+		},
+		"payout.failed": {
+			"POST /v1/accounts",
+			"POST /v1/payment_intents",
+			"POST /v1/transfers",
+			"POST /v1/payouts acct_1",
+		},
+		"radar.early_fraud_warning.created": {
+			"POST /v1/payment_methods",
+			"POST /v1/payment_intents",
+		},
+	}
+
+	prefixes := map[string]string{
+		"/v1/accounts":      "acct",
+		"/v1/customers":     "cus",
+		"/v1/plans":         "plan",
+		"/v1/subscriptions": "sub",
+	}
+
+	for event, expected := range tests {
+		t.Run(event, func(t *testing.T) {
+			var requests []string
+			ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				require.NoError(t, req.ParseForm())
+				request := strings.TrimSpace(fmt.Sprintf("%s %s %s", req.Method, req.URL.Path, req.Header.Get("Stripe-Account")))
+				requests = append(requests, request)
+
+				prefix, ok := prefixes[req.URL.Path]
+				if !ok {
+					prefix = "obj"
+				}
+				res.Write([]byte(fmt.Sprintf(`{"id": "%s_%d"}`, prefix, len(requests))))
+			}))
+			defer ts.Close()
+
+			fxt, err := NewTriggerFixture(afero.NewOsFs(), event, "", ts.URL, apiKey, []string{}, []string{}, []string{}, []string{}, "")
+			require.NoError(t, err)
+			require.Equal(t, []string{event}, fxt.ExpectedEvents)
+
+			_, err = fxt.Execute(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, expected, requests)
+		})
+	}
+}
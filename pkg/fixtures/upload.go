@@ -0,0 +1,128 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/stripe/stripe-cli/pkg/requests"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+// A step may upload a file to the files API, like dispute evidence:
+//
+//	{
+//	  "name": "evidence",
+//	  "method": "post",
+//	  "upload": {"file": "./evidence.png", "purpose": "dispute_evidence"}
+//	}
+//
+// The file is sent as multipart/form-data to FilesBaseURL, on /v1/files unless
+// the step has a path, and later steps reference the uploaded file with
+// ${evidence:id}. Relative paths resolve against the fixture file declaring
+// the step.
+
+// uploadPath is the path of the uploads of steps without a path
+const uploadPath = "/v1/files"
+
+// maxUploadSize is the largest file the files API accepts
+var maxUploadSize int64 = 50 << 20
+
+type upload struct {
+	File    string `json:"file"`
+	Purpose string `json:"purpose"`
+}
+
+// preparedUpload is the upload of a step, with its file resolved
+type preparedUpload struct {
+	file    string
+	purpose string
+}
+
+// filesBaseURL returns the base URL of the uploads of the fixture
+func (fxt *Fixture) filesBaseURL() string {
+	if fxt.FilesBaseURL == "" {
+		return stripe.DefaultFilesAPIBaseURL
+	}
+
+	return fxt.FilesBaseURL
+}
+
+// prepareUpload resolves the file of the upload of the step against the
+// fixture file declaring it. Fixtures fetched from URLs can only upload files
+// when RemoteOptions.AllowUploads is set.
+func (fxt *Fixture) prepareUpload(data fixture) (preparedUpload, error) {
+	file := data.Upload.File
+	if isRemoteFixture(data.file) && !fxt.remote.AllowUploads {
+		return preparedUpload{}, fmt.Errorf("Fixture %s of the fixture URL %s uploads the local file %s, run it with --allow-remote-uploads to let fixtures from URLs upload files", data.Name, data.file, file)
+	}
+
+	if !filepath.IsAbs(file) {
+		if isRemoteFixture(data.file) {
+			return preparedUpload{}, fmt.Errorf("Fixture %s uploads %s relative to the fixture URL %s, fixtures from URLs can only upload files with absolute paths", data.Name, file, data.file)
+		}
+
+		resolved, err := resolveInclude(data.file, file)
+		if err != nil {
+			return preparedUpload{}, err
+		}
+		file = resolved
+	}
+
+	return preparedUpload{file: file, purpose: data.Upload.Purpose}, nil
+}
+
+// checkUploads returns an error if the file of an upload is missing or too
+// large, so that the fixture fails before making any request
+func (fxt *Fixture) checkUploads() error {
+	for _, data := range fxt.fixture.Fixtures {
+		if data.Upload == nil || !fxt.isSelected(data) {
+			continue
+		}
+
+		upload, err := fxt.prepareUpload(data)
+		if err != nil {
+			return err
+		}
+
+		info, err := fxt.Fs.Stat(upload.file)
+		if err != nil {
+			return fmt.Errorf("Failed to read the file uploaded by fixture %s: %v", data.Name, err)
+		}
+		if err := checkUploadSize(data, upload, info.Size()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkUploadSize(data fixture, upload preparedUpload, size int64) error {
+	if size > maxUploadSize {
+		return fmt.Errorf("The file %s uploaded by fixture %s is %.1f MB, larger than the %d MB the files API accepts", upload.file, data.Name, float64(size)/(1<<20), maxUploadSize>>20)
+	}
+
+	return nil
+}
+
+// uploadFile uploads the file of the step, with the params of the step as the
+// other fields of the form
+func (fxt *Fixture) uploadFile(ctx context.Context, req *requests.Base, data fixture, prepared preparedRequest, params *requests.RequestParameters) ([]byte, error) {
+	content, err := afero.ReadFile(fxt.Fs, prepared.upload.file)
+	if err != nil {
+		return make([]byte, 0), fmt.Errorf("Failed to read the file uploaded by fixture %s: %v", data.Name, err)
+	}
+	if err := checkUploadSize(data, *prepared.upload, int64(len(content))); err != nil {
+		return make([]byte, 0), err
+	}
+
+	params.AppendData([]string{"purpose=" + prepared.upload.purpose})
+
+	return req.MakeMultipartRequest(ctx, fxt.apiKeyOf(data), prepared.path, params, requests.MultipartFile{
+		Field:    "file",
+		Filename: filepath.Base(prepared.upload.file),
+		Content:  content,
+	}, true)
+}
@@ -0,0 +1,150 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const uploadFixture = `{
+	"fixtures": [
+		{
+			"name": "evidence",
+			"method": "post",
+			"upload": {"file": "./evidence.png", "purpose": "dispute_evidence"}
+		},
+		{
+			"name": "dispute",
+			"path": "/v1/disputes/dp_123",
+			"method": "post",
+			"params": {"evidence": {"receipt": "${evidence:id}"}}
+		}
+	]
+}`
+
+func TestUpload(t *testing.T) {
+	files := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/v1/files", req.URL.Path)
+		require.NoError(t, req.ParseMultipartForm(1<<20))
+		require.Equal(t, "dispute_evidence", req.FormValue("purpose"))
+		require.Empty(t, req.FormValue("metadata[_created_by_fixture]"))
+
+		file, header, err := req.FormFile("file")
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(file)
+		require.NoError(t, err)
+		require.Equal(t, "evidence.png", header.Filename)
+		require.Equal(t, "PNG", string(content))
+
+		res.Write([]byte(`{"id": "file_123", "object": "file"}`))
+	}))
+	defer files.Close()
+
+	var receipt string
+	api := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		receipt = req.PostForm.Get("evidence[receipt]")
+		res.Write([]byte(`{"id": "dp_123", "object": "dispute"}`))
+	}))
+	defer api.Close()
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/fixtures/dispute.json", []byte(uploadFixture), 0644)
+	afero.WriteFile(fs, "/fixtures/evidence.png", []byte("PNG"), 0644)
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", api.URL, "/fixtures/dispute.json", nil, nil, nil, nil)
+	require.NoError(t, err)
+	fxt.FilesBaseURL = files.URL
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "file_123", receipt)
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	defer func(size int64) { maxUploadSize = size }(maxUploadSize)
+	maxUploadSize = 2 << 20
+
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		t.Errorf("Unexpected request to %s", req.URL.Path)
+	}))
+	defer ts.Close()
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/fixtures/dispute.json", []byte(uploadFixture), 0644)
+	afero.WriteFile(fs, "/fixtures/evidence.png", make([]byte, 3<<20), 0644)
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", ts.URL, "/fixtures/dispute.json", nil, nil, nil, nil)
+	require.NoError(t, err)
+	fxt.FilesBaseURL = ts.URL
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "The file /fixtures/evidence.png uploaded by fixture evidence is 3.0 MB, larger than the 2 MB the files API accepts")
+}
+
+func TestUploadMissingFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/fixtures/dispute.json", []byte(uploadFixture), 0644)
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", "", "/fixtures/dispute.json", nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	_, err = fxt.DryRun()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Failed to read the file uploaded by fixture evidence")
+}
+
+func TestUploadDryRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/fixtures/dispute.json", []byte(uploadFixture), 0644)
+	afero.WriteFile(fs, "/fixtures/evidence.png", []byte("PNG"), 0644)
+
+	fxt, err := NewFixtureFromFile(fs, apiKey, "", "", "/fixtures/dispute.json", nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	steps, err := fxt.DryRun()
+	require.NoError(t, err)
+	require.Equal(t, "/v1/files", steps[0].Path)
+	require.Equal(t, []string{"file=@/fixtures/evidence.png", "purpose=dispute_evidence"}, steps[0].Params)
+	require.Equal(t, []string{"evidence[receipt]=<evidence.id>"}, steps[1].Params)
+}
+
+func TestUploadRequiresPost(t *testing.T) {
+	_, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", `{
+		"fixtures": [
+			{"name": "evidence", "method": "get", "upload": {"file": "/evidence.png", "purpose": "dispute_evidence"}}
+		]
+	}`)
+	require.EqualError(t, err, "Fixture evidence uploads a file and must use the post method")
+}
+
+func TestUploadFromRemoteFixture(t *testing.T) {
+	data := fixture{
+		Name:   "evidence",
+		file:   "https://example.com/fixtures/dispute.json",
+		Upload: &upload{File: "/home/user/.ssh/id_rsa", Purpose: "dispute_evidence"},
+	}
+
+	fxt := &Fixture{}
+	_, err := fxt.prepareUpload(data)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--allow-remote-uploads")
+
+	fxt.remote.AllowUploads = true
+	prepared, err := fxt.prepareUpload(data)
+	require.NoError(t, err)
+	require.Equal(t, "/home/user/.ssh/id_rsa", prepared.file)
+
+	data.Upload.File = "./evidence.png"
+	_, err = fxt.prepareUpload(data)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "absolute paths")
+}
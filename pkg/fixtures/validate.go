@@ -0,0 +1,360 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fixture files are validated before they are parsed, so that a typo in a
+// key or a value of the wrong type is reported with its location instead of
+// failing while the fixture runs. Problems are located with JSON pointers,
+// like /fixtures/1/params, and sorted by location so that the output is
+// stable.
+
+// ValidationError lists the problems of a fixture file
+type ValidationError struct {
+	File     string
+	Problems []ValidationProblem
+}
+
+// ValidationProblem is a problem at a location of a fixture file
+type ValidationProblem struct {
+	// Pointer is the JSON pointer of the invalid value
+	Pointer string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, fmt.Sprintf("  %s: %s", problem.Pointer, problem.Message))
+	}
+
+	return fmt.Sprintf("Invalid fixture file %s:\n%s", e.File, strings.Join(lines, "\n"))
+}
+
+// valueKind is the expected type of a value of the fixture format
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInteger
+	kindBool
+	kindObject
+	kindArray
+	kindAny
+)
+
+// schema describes the expected value at a location of a fixture file.
+// Objects with fields only accept those fields, while objects without accept
+// any key whose value matches values.
+type schema struct {
+	kind     valueKind
+	fields   map[string]*schema
+	required []string
+	// requiredUnless maps fields to the field that makes them optional when
+	// set
+	requiredUnless map[string]string
+	values         *schema
+	items          *schema
+}
+
+var stringSchema = &schema{kind: kindString}
+
+var fixtureFileSchema = &schema{
+	kind:     kindObject,
+	required: []string{"fixtures"},
+	fields: map[string]*schema{
+		"_meta": {
+			kind: kindObject,
+			fields: map[string]*schema{
+				"template_version": {kind: kindInteger},
+				"exclude_metadata": {kind: kindBool},
+				"api_version":      stringSchema,
+				"description":      stringSchema,
+			},
+		},
+		"include":  {kind: kindArray, items: stringSchema},
+		"env":      {kind: kindObject, values: stringSchema},
+		"vars":     {kind: kindObject, values: stringSchema},
+		"fixtures": {kind: kindArray, items: stepSchema},
+	},
+}
+
+var stepSchema = &schema{
+	kind:           kindObject,
+	required:       []string{"name", "method"},
+	requiredUnless: map[string]string{"path": "upload"},
+	fields: map[string]*schema{
+		"name":                stringSchema,
+		"path":                stringSchema,
+		"method":              stringSchema,
+		"params":              {kind: kindObject, values: &schema{kind: kindAny}},
+		"account":             stringSchema,
+		"idempotency_key":     stringSchema,
+		"repeat":              {kind: kindInteger},
+		"expected_error_type": stringSchema,
+		"expected_status":     {kind: kindInteger},
+		"expected_error": {
+			kind: kindObject,
+			fields: map[string]*schema{
+				"code": stringSchema,
+				"type": stringSchema,
+			},
+		},
+		"only_if":     stringSchema,
+		"api_version": stringSchema,
+		"assert":      {kind: kindObject, values: &schema{kind: kindAny}},
+		"profile":     stringSchema,
+		"api_key_env": stringSchema,
+		"upload": {
+			kind:     kindObject,
+			required: []string{"file", "purpose"},
+			fields: map[string]*schema{
+				"file":    stringSchema,
+				"purpose": stringSchema,
+			},
+		},
+	},
+}
+
+// maxValueLength bounds the length of the offending values quoted in problems
+const maxValueLength = 40
+
+// ValidateFixture validates the content of a fixture file. It returns a
+// *ValidationError listing the problems of a file of the wrong format.
+func ValidateFixture(file string, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			// the offset is past the offending byte
+			line, column := lineAndColumn(data, syntaxErr.Offset-1)
+			return fmt.Errorf("Invalid JSON in fixture file %s at line %d, column %d: %v", file, line, column, err)
+		}
+		return fmt.Errorf("Invalid JSON in fixture file %s: %v", file, err)
+	}
+
+	var problems []ValidationProblem
+	fixtureFileSchema.validate("", value, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{File: file, Problems: problems}
+	}
+
+	return nil
+}
+
+func (s *schema) validate(pointer string, value interface{}, problems *[]ValidationProblem) {
+	location := pointer
+	if location == "" {
+		location = "/"
+	}
+
+	problem := func(format string, args ...interface{}) {
+		*problems = append(*problems, ValidationProblem{Pointer: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.kind {
+	case kindAny:
+		return
+	case kindString:
+		if _, ok := value.(string); !ok {
+			problem("expected a string, got %s", describeValue(value))
+		}
+	case kindBool:
+		if _, ok := value.(bool); !ok {
+			problem("expected true or false, got %s", describeValue(value))
+		}
+	case kindInteger:
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			problem("expected an integer, got %s", describeValue(value))
+		}
+	case kindArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			problem("expected an array, got %s", describeValue(value))
+			return
+		}
+		for i, item := range items {
+			s.items.validate(fmt.Sprintf("%s/%d", pointer, i), item, problems)
+		}
+	case kindObject:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			problem("expected an object, got %s", describeValue(value))
+			return
+		}
+		s.validateObject(pointer, location, object, problems)
+	}
+}
+
+func (s *schema) validateObject(pointer, location string, object map[string]interface{}, problems *[]ValidationProblem) {
+	// Sorted, as the order of the keys in the file is lost
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := pointer + "/" + escapePointer(key)
+
+		if s.fields == nil {
+			s.values.validate(child, object[key], problems)
+			continue
+		}
+
+		field, ok := s.fields[key]
+		if !ok {
+			message := "unknown field"
+			if suggestion := s.suggestField(key); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			*problems = append(*problems, ValidationProblem{Pointer: child, Message: message})
+			continue
+		}
+
+		field.validate(child, object[key], problems)
+	}
+
+	for _, key := range s.required {
+		if _, ok := object[key]; !ok {
+			*problems = append(*problems, ValidationProblem{Pointer: location, Message: fmt.Sprintf("missing the required field %q", key)})
+		}
+	}
+
+	unless := make([]string, 0, len(s.requiredUnless))
+	for key := range s.requiredUnless {
+		unless = append(unless, key)
+	}
+	sort.Strings(unless)
+
+	for _, key := range unless {
+		_, ok := object[key]
+		_, optional := object[s.requiredUnless[key]]
+		if !ok && !optional {
+			*problems = append(*problems, ValidationProblem{Pointer: location, Message: fmt.Sprintf("missing the required field %q", key)})
+		}
+	}
+}
+
+// suggestField returns the field closest to the unknown key, if one is close
+// enough to be a typo
+func (s *schema) suggestField(key string) string {
+	names := make([]string, 0, len(s.fields))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 0
+	for _, name := range names {
+		distance := levenshtein(key, name)
+		if distance <= maxSuggestionDistance(name) && (best == "" || distance < bestDistance) {
+			best, bestDistance = name, distance
+		}
+	}
+
+	return best
+}
+
+func maxSuggestionDistance(name string) int {
+	if len(name) <= 4 {
+		return 1
+	}
+	if len(name) <= 8 {
+		return 2
+	}
+	return 3
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// describeValue returns the type and JSON of the value, shortened to
+// maxValueLength
+func describeValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	quoted := string(data)
+	if len(quoted) > maxValueLength {
+		quoted = quoted[:maxValueLength] + "..."
+	}
+
+	switch value.(type) {
+	case string:
+		return "the string " + quoted
+	case json.Number:
+		return "the number " + quoted
+	case bool:
+		return quoted
+	case nil:
+		return "null"
+	case []interface{}:
+		return "the array " + quoted
+	default:
+		return "the object " + quoted
+	}
+}
+
+// escapePointer escapes a key for a JSON pointer, as per RFC 6901
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// lineAndColumn returns the 1-based line and column of the byte offset
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	line, column := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column
+}
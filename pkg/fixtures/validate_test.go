@@ -0,0 +1,62 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFixture(t *testing.T) {
+	err := ValidateFixture("seed.json", []byte(`{
+		"_meta": {"template_version": "0"},
+		"fixtures": [
+			{"name": "cust", "path": "/v1/customers", "method": "post", "params": "abc"},
+			{"name": "pi", "path": "/v1/payment_intents", "method": "post", "exepcted_status": 402, "repeat": 1.5},
+			{"name": "charge", "method": "post", "expected_error": {"code": ["card_declined"]}}
+		],
+		"env": {"KEY": 123},
+		"includes": ["common.json"]
+	}`))
+
+	require.EqualError(t, err, `Invalid fixture file seed.json:
+  /_meta/template_version: expected an integer, got the string "0"
+  /env/KEY: expected a string, got the number 123
+  /fixtures/0/params: expected an object, got the string "abc"
+  /fixtures/1/exepcted_status: unknown field, did you mean "expected_status"?
+  /fixtures/1/repeat: expected an integer, got the number 1.5
+  /fixtures/2/expected_error/code: expected a string, got the array ["card_declined"]
+  /fixtures/2: missing the required field "path"
+  /includes: unknown field, did you mean "include"?`)
+
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok)
+	require.Len(t, validationErr.Problems, 8)
+}
+
+func TestValidateFixtureSyntaxError(t *testing.T) {
+	err := ValidateFixture("seed.json", []byte("{\n  \"fixtures\": [\n    {\"name\": \"cust\",}\n  ]\n}"))
+	require.EqualError(t, err, "Invalid JSON in fixture file seed.json at line 3, column 21: invalid character '}' looking for beginning of object key string")
+
+	err = ValidateFixture("seed.json", []byte(`[]`))
+	require.EqualError(t, err, "Invalid fixture file seed.json:\n  /: expected an object, got the array []")
+}
+
+func TestValidateIncludedFixture(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "seed.json", []byte(`{"include": ["common.json"], "fixtures": []}`), 0644)
+	afero.WriteFile(fs, "common.json", []byte(`{"fixtures": [{"name": "cust", "path": "/v1/customers", "metod": "post"}]}`), 0644)
+
+	_, err := NewFixtureFromFile(fs, apiKey, "", "", "seed.json", []string{}, []string{}, []string{}, []string{})
+	require.EqualError(t, err, `Invalid fixture file common.json:
+  /fixtures/0/metod: unknown field, did you mean "method"?
+  /fixtures/0: missing the required field "method"`)
+}
+
+func TestValidateTriggers(t *testing.T) {
+	for event, file := range Events {
+		data, err := triggers.ReadFile(file)
+		require.NoError(t, err, event)
+		require.NoError(t, ValidateFixture(file, data), event)
+	}
+}
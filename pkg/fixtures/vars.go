@@ -0,0 +1,140 @@
+package fixtures
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A fixture may declare variables in a top-level vars block, which params,
+// paths and the other templates of its steps reference with ${var:name}:
+//
+//	{
+//	  "vars": {"price": "price_123"},
+//	  "fixtures": [
+//	    {"name": "sub", "path": "/v1/subscriptions", "method": "post", "params": {"items": [{"price": "${var:price}"}]}}
+//	  ]
+//	}
+//
+// `--set price=price_456` overrides the value of the fixture, and sets
+// variables it doesn't declare. ${var:name|default} falls back to the default
+// when the variable is set nowhere. The vars of included files are merged
+// like their env.
+
+// varRegexp matches ${var:name} and ${var:name|default}
+var varRegexp = regexp.MustCompile(`\$\{var:([^|}]*)(?:\|([^}]*))?\}`)
+
+var varNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// setVar sets a variable given with --set, overriding its value in vars
+func (fxt *Fixture) setVar(name, value string) {
+	if fxt.vars == nil {
+		fxt.vars = make(map[string]string)
+	}
+
+	fxt.vars[name] = value
+}
+
+// varValue returns the value of the variable, set with --set or in vars
+func (fxt *Fixture) varValue(name string) (string, bool) {
+	if value, ok := fxt.vars[name]; ok {
+		return value, true
+	}
+
+	value, ok := fxt.fixture.Vars[name]
+
+	return value, ok
+}
+
+// replaceVars replaces the variable references in s
+func (fxt *Fixture) replaceVars(s string) (string, error) {
+	if !strings.Contains(s, "${var:") {
+		return s, nil
+	}
+
+	var err error
+	replaced := varRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		submatch := varRegexp.FindStringSubmatch(match)
+
+		if value, ok := fxt.varValue(submatch[1]); ok {
+			return value
+		}
+		if submatch[2] != "" {
+			return submatch[2]
+		}
+
+		err = &functionError{err: undefinedVarError(submatch[1])}
+		return match
+	})
+
+	return replaced, err
+}
+
+func undefinedVarError(name string) error {
+	return fmt.Errorf("the variable %s is not defined, declare it in vars or set it with --set %s=<value>", name, name)
+}
+
+// checkVars returns an error naming the step and param of the first
+// reference to an undefined variable, so that the fixture fails before
+// making any request
+func (fxt *Fixture) checkVars() error {
+	for _, data := range fxt.fixture.Fixtures {
+		if !fxt.isSelected(data) {
+			continue
+		}
+
+		templates := map[string]interface{}{
+			"path":            data.Path,
+			"account":         data.Account,
+			"idempotency_key": data.IdempotencyKey,
+			"only_if":         data.OnlyIf,
+		}
+		for key, value := range data.Params {
+			templates[key] = value
+		}
+
+		if param, name, found := fxt.findUndefinedVar(templates, ""); found {
+			return fmt.Errorf("Invalid param %s of fixture %s: %v", param, data.Name, undefinedVarError(name))
+		}
+	}
+
+	return nil
+}
+
+// findUndefinedVar returns the param and name of the first reference to an
+// undefined variable in value, in the order of the sorted keys of its maps
+func (fxt *Fixture) findUndefinedVar(value interface{}, param string) (string, string, bool) {
+	switch v := value.(type) {
+	case string:
+		for _, submatch := range varRegexp.FindAllStringSubmatch(v, -1) {
+			if _, ok := fxt.varValue(submatch[1]); !ok && submatch[2] == "" {
+				return param, submatch[1], true
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := key
+			if param != "" {
+				child = fmt.Sprintf("%s[%s]", param, key)
+			}
+			if p, name, found := fxt.findUndefinedVar(v[key], child); found {
+				return p, name, true
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if p, name, found := fxt.findUndefinedVar(item, fmt.Sprintf("%s[%d]", param, i)); found {
+				return p, name, true
+			}
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,100 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const varsFixture = `{
+	"vars": {"price": "price_default", "quantity": "1"},
+	"fixtures": [
+		{"name": "cust", "path": "/v1/customers", "method": "post", "params": {"description": "${var:tier|standard}"}},
+		{"name": "sub", "path": "/v1/subscriptions", "method": "post", "params": {"customer": "${cust:id}", "items": [{"price": "${var:price}", "quantity": "${var:quantity}"}]}},
+		{"name": "price", "path": "/v1/prices/${var:price}", "method": "get"}
+	]
+}`
+
+func TestVars(t *testing.T) {
+	params := make(map[string]string)
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		paths = append(paths, req.URL.Path)
+		for key := range req.PostForm {
+			params[key] = req.PostForm.Get(key)
+		}
+
+		switch req.URL.Path {
+		case "/v1/customers":
+			res.Write([]byte(`{"id": "cus_123", "object": "customer"}`))
+		default:
+			res.Write([]byte(`{"id": "sub_123", "object": "subscription"}`))
+		}
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, varsFixture)
+	require.NoError(t, err)
+	require.NoError(t, fxt.SetValues([]string{"price=price_123"}))
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"/v1/customers", "/v1/subscriptions", "/v1/prices/price_123"}, paths)
+	require.Equal(t, "standard", params["description"])
+	require.Equal(t, "cus_123", params["customer"])
+	require.Equal(t, "price_123", params["items[0][price]"])
+	require.Equal(t, "1", params["items[0][quantity]"])
+}
+
+func TestVarsUndefined(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		t.Errorf("Unexpected request to %s", req.URL.Path)
+	}))
+	defer ts.Close()
+
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", ts.URL, `{
+		"fixtures": [
+			{"name": "cust", "path": "/v1/customers", "method": "post"},
+			{"name": "sub", "path": "/v1/subscriptions", "method": "post", "params": {"items": [{"price": "${var:price}"}]}}
+		]
+	}`)
+	require.NoError(t, err)
+	fxt.Out = &bytes.Buffer{}
+
+	_, err = fxt.Execute(context.Background())
+	require.EqualError(t, err, "Invalid param items[0][price] of fixture sub: the variable price is not defined, declare it in vars or set it with --set price=<value>")
+
+	_, err = fxt.DryRun()
+	require.EqualError(t, err, "Invalid param items[0][price] of fixture sub: the variable price is not defined, declare it in vars or set it with --set price=<value>")
+
+	require.NoError(t, fxt.SetValues([]string{"price=price_123"}))
+	_, err = fxt.DryRun()
+	require.NoError(t, err)
+}
+
+func TestVarsDryRun(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", varsFixture)
+	require.NoError(t, err)
+
+	steps, err := fxt.DryRun()
+	require.NoError(t, err)
+	require.Equal(t, "/v1/prices/price_default", steps[2].Path)
+}
+
+func TestSetValuesVars(t *testing.T) {
+	fxt, err := NewFixtureFromRawString(afero.NewMemMapFs(), apiKey, "", "", varsFixture)
+	require.NoError(t, err)
+
+	require.NoError(t, fxt.SetValues([]string{"price=price_123", "url=https://example.com/?a=b"}))
+	require.Equal(t, map[string]string{"price": "price_123", "url": "https://example.com/?a=b"}, fxt.vars)
+
+	require.EqualError(t, fxt.SetValues([]string{"1price=price_123"}), "Invalid value 1price=price_123, expected <variable>=value or <fixture_name>:path.to.field=value")
+}
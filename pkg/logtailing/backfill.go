@@ -0,0 +1,177 @@
+package logtailing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+// When the websocket reconnects, the logs of the requests made while it was
+// disconnected are fetched from the request logs API and printed as
+// backfilled, before the logs of the new connection. The logs are
+// deduplicated by request ID, as the logs around the last one seen may be
+// both fetched and sent by the new connection.
+
+const (
+	// backfillPageSize is the number of logs fetched per page
+	backfillPageSize = 100
+	// backfillMaxPages caps the logs backfilled after a long disconnection
+	backfillMaxPages = 10
+	// backfillTimeout is the most the backfill holds the logs of the new
+	// connection
+	backfillTimeout = 10 * time.Second
+	// seenWindow is how long before the last log seen the IDs of the logs
+	// are kept for deduplication, in seconds
+	seenWindow = 5 * 60
+)
+
+// requestLogList is a page of the request logs API
+type requestLogList struct {
+	Data    []EventPayload `json:"data"`
+	HasMore bool           `json:"has_more"`
+}
+
+// onConnect backfills the logs missed since the last one seen when the
+// websocket connects again. The logs of the new connection wait for the
+// backfill, which closes the backfilled gate once it is done.
+func (t *Tailer) onConnect(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.connectedBefore || t.lastCreated == 0 {
+		t.connectedBefore = true
+		return
+	}
+
+	backfilled := make(chan struct{})
+	t.backfilled = backfilled
+	since := t.lastCreated
+
+	go func() {
+		defer close(backfilled)
+
+		backfillCtx, cancel := context.WithTimeout(ctx, backfillTimeout)
+		defer cancel()
+
+		if err := t.backfill(backfillCtx, since); err != nil {
+			t.send(websocket.WarningElement{
+				Warning: fmt.Sprintf("Failed to backfill the request logs missed while reconnecting: %v", err),
+			})
+		}
+	}()
+}
+
+// backfill prints the logs created since the time, oldest first
+func (t *Tailer) backfill(ctx context.Context, since int) error {
+	logs, err := t.listRequestLogs(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].CreatedAt < logs[j].CreatedAt
+	})
+
+	for _, payload := range logs {
+		payload.Backfilled = true
+		t.output(payload, "")
+	}
+
+	return nil
+}
+
+// listRequestLogs returns the logs created since the time, in seconds since
+// the epoch
+func (t *Tailer) listRequestLogs(ctx context.Context, since int) ([]EventPayload, error) {
+	baseURL := t.cfg.APIBaseURL
+	if baseURL == "" {
+		baseURL = stripe.DefaultAPIBaseURL
+	}
+	apiURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	client := &stripe.Client{BaseURL: apiURL, APIKey: t.cfg.Key}
+
+	var configure func(*http.Request)
+	if t.cfg.StripeAccount != "" {
+		configure = func(req *http.Request) {
+			req.Header.Set("Stripe-Account", t.cfg.StripeAccount)
+		}
+	}
+
+	var logs []EventPayload
+	startingAfter := ""
+	for page := 0; page < backfillMaxPages; page++ {
+		params := url.Values{}
+		params.Set("created[gte]", strconv.Itoa(since))
+		params.Set("limit", strconv.Itoa(backfillPageSize))
+		if startingAfter != "" {
+			params.Set("starting_after", startingAfter)
+		}
+
+		resp, err := client.PerformRequest(ctx, http.MethodGet, strings.TrimSuffix(requestLogsPath, "/"), params.Encode(), configure)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("status=%d, body=%s", resp.StatusCode, body)
+		}
+
+		var list requestLogList
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, list.Data...)
+		if !list.HasMore || len(list.Data) == 0 {
+			break
+		}
+		startingAfter = list.Data[len(list.Data)-1].RequestID
+	}
+
+	return logs, nil
+}
+
+// markSeen records the log, and returns false if it was already seen. It is
+// called with mu held.
+func (t *Tailer) markSeen(payload EventPayload) bool {
+	if payload.RequestID == "" {
+		return true
+	}
+	if _, ok := t.seen[payload.RequestID]; ok {
+		return false
+	}
+
+	t.seen[payload.RequestID] = payload.CreatedAt
+	if payload.CreatedAt > t.lastCreated {
+		t.lastCreated = payload.CreatedAt
+	}
+
+	// Forget the logs too old to be sent again
+	if len(t.seen) > 2*backfillPageSize*backfillMaxPages {
+		for id, created := range t.seen {
+			if created < t.lastCreated-seenWindow {
+				delete(t.seen, id)
+			}
+		}
+	}
+
+	return true
+}
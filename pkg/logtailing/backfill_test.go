@@ -0,0 +1,127 @@
+package logtailing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+type fakeWebSocketClient struct {
+	mu   sync.Mutex
+	acks []string
+}
+
+func (c *fakeWebSocketClient) SendMessage(msg *websocket.OutgoingMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.acks = append(c.acks, msg.EventAck.EventID)
+}
+
+func (c *fakeWebSocketClient) Stop() {}
+
+func requestLogMessage(id string, payload string) websocket.IncomingMessage {
+	return websocket.IncomingMessage{
+		RequestLogEvent: &websocket.RequestLogEvent{
+			EventPayload: payload,
+			RequestLogID: id,
+		},
+	}
+}
+
+func receivePayload(t *testing.T, outCh chan websocket.IElement) EventPayload {
+	select {
+	case element := <-outCh:
+		data, ok := element.(websocket.DataElement)
+		require.True(t, ok, "expected a log, got %#v", element)
+		return data.Data.(EventPayload)
+	default:
+		require.FailNow(t, "expected a log")
+		return EventPayload{}
+	}
+}
+
+func TestBackfillAfterReconnect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/v1/request_logs", req.URL.Path)
+		require.Equal(t, "Bearer sk_test_123", req.Header.Get("Authorization"))
+		require.Equal(t, "100", req.URL.Query().Get("created[gte]"))
+
+		// Newest first, like the API lists them, and with the last log seen
+		res.Write([]byte(`{"data": [
+			{"request_id": "req_3", "created_at": 120, "method": "POST", "url": "/v1/charges", "status": 200},
+			{"request_id": "req_2", "created_at": 110, "method": "GET", "url": "/v1/customers", "status": 200},
+			{"request_id": "req_1", "created_at": 100, "method": "POST", "url": "/v1/customers", "status": 200}
+		], "has_more": false}`))
+	}))
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	client := &fakeWebSocketClient{}
+	tailer := New(&Config{
+		APIBaseURL: ts.URL,
+		Filters:    &LogFilters{},
+		Key:        "sk_test_123",
+		OutCh:      outCh,
+	})
+	tailer.webSocketClient = client
+
+	// The first connection has nothing to backfill
+	tailer.onConnect(context.Background())
+	tailer.processRequestLogEvent(requestLogMessage("resp_1", `{"request_id": "req_1", "created_at": 100, "method": "POST", "url": "/v1/customers", "status": 200}`))
+	require.Equal(t, "req_1", receivePayload(t, outCh).RequestID)
+
+	tailer.onConnect(context.Background())
+	// The logs of the new connection wait for the backfill, and the ones
+	// already backfilled aren't printed again
+	tailer.processRequestLogEvent(requestLogMessage("resp_3", `{"request_id": "req_3", "created_at": 120, "method": "POST", "url": "/v1/charges", "status": 200}`))
+	tailer.processRequestLogEvent(requestLogMessage("resp_4", `{"request_id": "req_4", "created_at": 130, "method": "GET", "url": "/v1/charges", "status": 200}`))
+
+	backfilled := receivePayload(t, outCh)
+	require.Equal(t, "req_2", backfilled.RequestID)
+	require.True(t, backfilled.Backfilled)
+
+	backfilled = receivePayload(t, outCh)
+	require.Equal(t, "req_3", backfilled.RequestID)
+	require.True(t, backfilled.Backfilled)
+
+	live := receivePayload(t, outCh)
+	require.Equal(t, "req_4", live.RequestID)
+	require.False(t, live.Backfilled)
+
+	require.Empty(t, outCh)
+	require.Equal(t, []string{"resp_1", "resp_3", "resp_4"}, client.acks)
+}
+
+func TestBackfillFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	tailer := New(&Config{
+		APIBaseURL: ts.URL,
+		Filters:    &LogFilters{},
+		Key:        "sk_test_123",
+		OutCh:      outCh,
+	})
+	tailer.webSocketClient = &fakeWebSocketClient{}
+
+	tailer.onConnect(context.Background())
+	tailer.processRequestLogEvent(requestLogMessage("resp_1", `{"request_id": "req_1", "created_at": 100, "method": "POST", "url": "/v1/customers", "status": 200}`))
+	require.Equal(t, "req_1", receivePayload(t, outCh).RequestID)
+
+	tailer.onConnect(context.Background())
+	tailer.processRequestLogEvent(requestLogMessage("resp_2", `{"request_id": "req_2", "created_at": 110, "method": "GET", "url": "/v1/customers", "status": 200}`))
+
+	warning, ok := (<-outCh).(websocket.WarningElement)
+	require.True(t, ok)
+	require.Contains(t, warning.Warning, "Failed to backfill the request logs missed while reconnecting")
+	require.Equal(t, "req_2", receivePayload(t, outCh).RequestID)
+}
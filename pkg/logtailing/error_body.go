@@ -0,0 +1,157 @@
+package logtailing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+// requestLogsPath is the path of the details of a request, like its response
+const requestLogsPath = "/v1/request_logs/"
+
+// ErrorBodyFetcher fetches the errors the failed requests of the logs
+// responded with, for `stripe logs tail --show-error-body`. The fetches are
+// made one at a time, at most one per interval, and cached per request ID.
+type ErrorBodyFetcher struct {
+	client   *stripe.Client
+	account  string
+	interval time.Duration
+
+	// mu guards the cache, fetchMu the client and next
+	mu      sync.Mutex
+	cache   map[string]*errorBody
+	fetchMu sync.Mutex
+	next    time.Time
+}
+
+// errorBody is the error of a request, done once it was fetched
+type errorBody struct {
+	done chan struct{}
+	err  RedactedError
+	ok   bool
+}
+
+// NewErrorBodyFetcher creates a fetcher making at most one request per
+// interval with the client, on the connected account if set
+func NewErrorBodyFetcher(client *stripe.Client, account string, interval time.Duration) *ErrorBodyFetcher {
+	return &ErrorBodyFetcher{
+		client:   client,
+		account:  account,
+		interval: interval,
+		cache:    make(map[string]*errorBody),
+	}
+}
+
+// Fetch returns the error the request responded with, and false if it
+// couldn't be fetched. A request is only fetched once, even by concurrent
+// calls.
+func (f *ErrorBodyFetcher) Fetch(ctx context.Context, requestID string) (RedactedError, bool) {
+	f.mu.Lock()
+	body, cached := f.cache[requestID]
+	if !cached {
+		body = &errorBody{done: make(chan struct{})}
+		f.cache[requestID] = body
+	}
+	f.mu.Unlock()
+
+	if !cached {
+		body.err, body.ok = f.fetch(ctx, requestID)
+		close(body.done)
+	}
+
+	select {
+	case <-body.done:
+		return body.err, body.ok
+	case <-ctx.Done():
+		return RedactedError{}, false
+	}
+}
+
+func (f *ErrorBodyFetcher) fetch(ctx context.Context, requestID string) (RedactedError, bool) {
+	f.fetchMu.Lock()
+	defer f.fetchMu.Unlock()
+
+	if wait := time.Until(f.next); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return RedactedError{}, false
+		case <-time.After(wait):
+		}
+	}
+	f.next = time.Now().Add(f.interval)
+
+	var configure func(*http.Request)
+	if f.account != "" {
+		configure = func(req *http.Request) {
+			req.Header.Set("Stripe-Account", f.account)
+		}
+	}
+
+	resp, err := f.client.PerformRequest(ctx, http.MethodGet, requestLogsPath+requestID, "", configure)
+	if err != nil {
+		return RedactedError{}, false
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return RedactedError{}, false
+	}
+
+	return parseErrorBody(data)
+}
+
+// parseErrorBody returns the error of the details of a request, which has the
+// response either as the body it was sent as or already parsed
+func parseErrorBody(data []byte) (RedactedError, bool) {
+	var details struct {
+		Error        *RedactedError `json:"error"`
+		ResponseBody string         `json:"response_body"`
+	}
+	if err := json.Unmarshal(data, &details); err != nil {
+		return RedactedError{}, false
+	}
+
+	if details.Error == nil && details.ResponseBody != "" {
+		var body struct {
+			Error *RedactedError `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(details.ResponseBody), &body); err != nil {
+			return RedactedError{}, false
+		}
+		details.Error = body.Error
+	}
+
+	if details.Error == nil || (details.Error.Message == "" && details.Error.Code == "") {
+		return RedactedError{}, false
+	}
+
+	return *details.Error, true
+}
+
+// Describe returns the message and code of the error, like
+// `Your card was declined. (card_declined)`
+func (e RedactedError) Describe() string {
+	code := e.Code
+	switch {
+	case code == "":
+		code = e.DeclineCode
+	case e.DeclineCode != "":
+		code = fmt.Sprintf("%s, %s", e.Code, e.DeclineCode)
+	}
+
+	switch {
+	case e.Message == "":
+		return code
+	case code == "":
+		return e.Message
+	default:
+		return fmt.Sprintf("%s (%s)", e.Message, code)
+	}
+}
@@ -0,0 +1,59 @@
+package logtailing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+func TestErrorBodyFetcher(t *testing.T) {
+	var fetches int32
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		require.Equal(t, "Bearer sk_test_123", req.Header.Get("Authorization"))
+
+		switch req.URL.Path {
+		case "/v1/request_logs/req_declined":
+			res.Write([]byte(`{"id": "req_declined", "response_body": "{\"error\": {\"type\": \"card_error\", \"code\": \"card_declined\", \"decline_code\": \"insufficient_funds\", \"message\": \"Your card has insufficient funds.\"}}"}`))
+		case "/v1/request_logs/req_missing":
+			res.Write([]byte(`{"id": "req_missing", "error": {"type": "invalid_request_error", "code": "parameter_missing", "message": "Missing required param: amount."}}`))
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	fetcher := NewErrorBodyFetcher(&stripe.Client{BaseURL: baseURL, APIKey: "sk_test_123"}, "", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, ok := fetcher.Fetch(context.Background(), "req_declined")
+			require.True(t, ok)
+			require.Equal(t, "Your card has insufficient funds. (card_declined, insufficient_funds)", body.Describe())
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	body, ok := fetcher.Fetch(context.Background(), "req_missing")
+	require.True(t, ok)
+	require.Equal(t, "Missing required param: amount. (parameter_missing)", body.Describe())
+
+	_, ok = fetcher.Fetch(context.Background(), "req_unknown")
+	require.False(t, ok)
+	_, ok = fetcher.Fetch(context.Background(), "req_unknown")
+	require.False(t, ok)
+	require.Equal(t, int32(3), atomic.LoadInt32(&fetches))
+}
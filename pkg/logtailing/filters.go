@@ -0,0 +1,208 @@
+package logtailing
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// The filters are sent to Stripe, which only sends the request logs matching
+// them. The filters the payloads of the logs have the fields of are checked
+// again by the tailer, for the versions of the backend that don't apply them,
+// like the request path, API version, source and idempotency key filters. The
+// request ID filter is only checked by the tailer.
+// Each filter matches any of its values, and a log must match every filter,
+// except for the status codes and status code types, which a log matches
+// either of. The exclusions are only checked by the tailer, after the filters.
+
+// filterLabels are the names of the filters as printed, in order
+var filterLabels = []struct {
+	label  string
+	values func(*LogFilters) []string
+}{
+	{"account", func(f *LogFilters) []string { return f.FilterAccount }},
+	{"api version", func(f *LogFilters) []string { return f.FilterAPIVersion }},
+	{"ip address", func(f *LogFilters) []string { return f.FilterIPAddress }},
+	{"http method", func(f *LogFilters) []string { return f.FilterHTTPMethod }},
+	{"idempotency key", func(f *LogFilters) []string { return f.FilterIdempotencyKey }},
+	{"request id", func(f *LogFilters) []string { return f.FilterRequestID }},
+	{"request path", func(f *LogFilters) []string { return f.FilterRequestPath }},
+	{"request status", func(f *LogFilters) []string { return f.FilterRequestStatus }},
+	{"source", func(f *LogFilters) []string { return f.FilterSource }},
+}
+
+// Describe returns the filters that are set, like `http method GET and
+// request path /v1/charges or /v1/refunds`, or an empty string if none are
+func (f *LogFilters) Describe() string {
+	if f == nil {
+		return ""
+	}
+
+	var filters []string
+	for _, filter := range filterLabels {
+		if values := filter.values(f); len(values) > 0 {
+			filters = append(filters, filter.label+" "+strings.Join(values, " or "))
+		}
+	}
+
+	var statuses []string
+	if len(f.FilterStatusCode) > 0 {
+		statuses = append(statuses, "status code "+strings.Join(f.FilterStatusCode, " or "))
+	}
+	if len(f.FilterStatusCodeType) > 0 {
+		statuses = append(statuses, "status code type "+strings.Join(statusCodeTypes(f.FilterStatusCodeType), " or "))
+	}
+	if len(statuses) > 0 {
+		filters = append(filters, strings.Join(statuses, " or "))
+	}
+	if len(f.ExcludeStatusCode) > 0 {
+		filters = append(filters, "excluding status code "+strings.Join(f.ExcludeStatusCode, " or "))
+	}
+	if len(f.ExcludeRequestPath) > 0 {
+		filters = append(filters, "excluding request path "+strings.Join(f.ExcludeRequestPath, " or "))
+	}
+
+	return strings.Join(filters, " and ")
+}
+
+// CheckStatusCodes returns an error if a status code is both filtered and
+// excluded
+func (f *LogFilters) CheckStatusCodes() error {
+	for _, excluded := range f.ExcludeStatusCode {
+		for _, code := range f.FilterStatusCode {
+			if code == excluded {
+				return fmt.Errorf("The status code %s can't be both in --filter-status-code and --exclude-status-code", code)
+			}
+		}
+	}
+
+	return nil
+}
+
+// statusCodeTypes returns the status code types as given to the command, as
+// they are sent as the start of their range, like 400 for 4XX
+func statusCodeTypes(types []string) []string {
+	described := make([]string, 0, len(types))
+	for _, codeType := range types {
+		if len(codeType) == 3 {
+			codeType = codeType[:1] + "XX"
+		}
+		described = append(described, strings.ToUpper(codeType))
+	}
+
+	return described
+}
+
+// matches returns whether the log matches the filters of the fields of its
+// payload
+func (f *LogFilters) matches(payload EventPayload) bool {
+	if f == nil {
+		return true
+	}
+
+	return matchesAny(f.FilterAPIVersion, func(version string) bool {
+		// A version without a release, like 2024-09-30, matches its releases,
+		// like 2024-09-30.acacia. The logs without a version can't be
+		// checked.
+		return payload.APIVersion == "" || payload.APIVersion == version || strings.HasPrefix(payload.APIVersion, version+".")
+	}) && matchesAny(f.FilterHTTPMethod, func(method string) bool {
+		return strings.EqualFold(method, payload.Method)
+	}) && matchesAny(f.FilterIdempotencyKey, func(key string) bool {
+		// Unlike the other fields, most requests have no idempotency key
+		return key == payload.IdempotencyKey
+	}) && matchesAny(f.FilterRequestID, func(id string) bool {
+		return id == payload.RequestID
+	}) && matchesAny(f.FilterRequestPath, func(path string) bool {
+		// The URLs some logs don't show can't be checked
+		return payload.URL == "" || strings.HasPrefix(requestPath(payload.URL), path)
+	}) && matchesAny(f.FilterSource, func(source string) bool {
+		return payload.Source == "" || strings.EqualFold(source, payload.Source)
+	}) && f.matchesStatus(payload.Status)
+}
+
+// excludedRequestPath returns the excluded request path the log matches, if
+// any
+func (f *LogFilters) excludedRequestPath(payload EventPayload) (string, bool) {
+	if f == nil || payload.URL == "" {
+		return "", false
+	}
+
+	for _, pattern := range f.ExcludeRequestPath {
+		if matchesRequestPathPattern(pattern, requestPath(payload.URL)) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// RequestPathWarnings returns a warning for each filtered request path whose
+// logs are all excluded
+func (f *LogFilters) RequestPathWarnings() []string {
+	var warnings []string
+	for _, filtered := range f.FilterRequestPath {
+		for _, pattern := range f.ExcludeRequestPath {
+			if matchesRequestPathPattern(pattern, filtered) {
+				warnings = append(warnings, fmt.Sprintf("--exclude-request-path %s excludes the request logs of --filter-request-path %s", pattern, filtered))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// matchesRequestPathPattern returns whether the path starts with the pattern,
+// or matches it as a whole if it is a glob, like /v1/customers/*/sources
+func matchesRequestPathPattern(pattern string, requestPath string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.HasPrefix(requestPath, pattern)
+	}
+
+	matched, err := path.Match(pattern, requestPath)
+	return err == nil && matched
+}
+
+// matchesStatus returns whether the status is one of the status codes or in
+// one of the status code types, and isn't excluded
+func (f *LogFilters) matchesStatus(status int) bool {
+	code := strconv.Itoa(status)
+	matchesCode := func(filtered string) bool {
+		return filtered == code
+	}
+	matchesCodeType := func(codeType string) bool {
+		return codeType != "" && codeType[:1] == strconv.Itoa(status/100)
+	}
+
+	if len(f.ExcludeStatusCode) > 0 && matchesAny(f.ExcludeStatusCode, matchesCode) {
+		return false
+	}
+
+	if len(f.FilterStatusCode) == 0 && len(f.FilterStatusCodeType) == 0 {
+		return true
+	}
+
+	return (len(f.FilterStatusCode) > 0 && matchesAny(f.FilterStatusCode, matchesCode)) ||
+		(len(f.FilterStatusCodeType) > 0 && matchesAny(f.FilterStatusCodeType, matchesCodeType))
+}
+
+// matchesAny returns whether any of the values of a filter matches, or true
+// if the filter isn't set
+func matchesAny(values []string, match func(string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestPath returns the path of the URL of a log, without its query
+func requestPath(url string) string {
+	return strings.SplitN(url, "?", 2)[0]
+}
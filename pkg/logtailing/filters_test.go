@@ -0,0 +1,133 @@
+package logtailing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiltersMatches(t *testing.T) {
+	filters := &LogFilters{
+		FilterHTTPMethod:     []string{"post"},
+		FilterRequestPath:    []string{"/v1/payment_intents", "/v1/refunds"},
+		FilterStatusCodeType: []string{"400"},
+	}
+
+	require.True(t, filters.matches(EventPayload{Method: "POST", URL: "/v1/payment_intents/pi_123/confirm", Status: 402}))
+	require.True(t, filters.matches(EventPayload{Method: "POST", URL: "/v1/refunds?expand[]=charge", Status: 400}))
+	require.False(t, filters.matches(EventPayload{Method: "GET", URL: "/v1/payment_intents", Status: 400}))
+	require.False(t, filters.matches(EventPayload{Method: "POST", URL: "/v1/charges", Status: 400}))
+	require.False(t, filters.matches(EventPayload{Method: "POST", URL: "/v1/payment_intents", Status: 200}))
+
+	require.True(t, (&LogFilters{FilterStatusCode: []string{"404"}}).matches(EventPayload{Status: 404}))
+	require.True(t, (&LogFilters{}).matches(EventPayload{Method: "GET", URL: "/v1/charges", Status: 200}))
+
+	filters = &LogFilters{FilterAPIVersion: []string{"2020-08-27", "2024-09-30"}}
+	require.True(t, filters.matches(EventPayload{APIVersion: "2020-08-27"}))
+	require.True(t, filters.matches(EventPayload{APIVersion: "2024-09-30.acacia"}))
+	require.False(t, filters.matches(EventPayload{APIVersion: "2022-11-15"}))
+	require.False(t, filters.matches(EventPayload{APIVersion: "2024-09-300"}))
+
+	filters = &LogFilters{FilterSource: []string{"API", "CLI"}}
+	require.True(t, filters.matches(EventPayload{Source: "api"}))
+	require.True(t, filters.matches(EventPayload{Source: "CLI"}))
+	require.False(t, filters.matches(EventPayload{Source: "DASHBOARD"}))
+
+	filters = &LogFilters{FilterRequestID: []string{"req_123"}}
+	require.True(t, filters.matches(EventPayload{RequestID: "req_123"}))
+	require.False(t, filters.matches(EventPayload{RequestID: "req_456"}))
+
+	filters = &LogFilters{FilterIdempotencyKey: []string{"retry-123"}}
+	require.True(t, filters.matches(EventPayload{IdempotencyKey: "retry-123"}))
+	require.False(t, filters.matches(EventPayload{IdempotencyKey: "retry-456"}))
+	require.False(t, filters.matches(EventPayload{}))
+
+	var nilFilters *LogFilters
+	require.True(t, nilFilters.matches(EventPayload{Method: "GET"}))
+}
+
+func TestFiltersMatchesStatus(t *testing.T) {
+	// 4XX and 5XX but not 402
+	filters := &LogFilters{
+		FilterStatusCodeType: []string{"400", "500"},
+		ExcludeStatusCode:    []string{"402"},
+	}
+	require.True(t, filters.matches(EventPayload{Status: 400}))
+	require.True(t, filters.matches(EventPayload{Status: 503}))
+	require.False(t, filters.matches(EventPayload{Status: 402}))
+	require.False(t, filters.matches(EventPayload{Status: 200}))
+
+	// Either the codes or the types
+	filters = &LogFilters{
+		FilterStatusCode:     []string{"402", "429"},
+		FilterStatusCodeType: []string{"500"},
+	}
+	require.True(t, filters.matches(EventPayload{Status: 402}))
+	require.True(t, filters.matches(EventPayload{Status: 429}))
+	require.True(t, filters.matches(EventPayload{Status: 500}))
+	require.False(t, filters.matches(EventPayload{Status: 404}))
+	require.False(t, filters.matches(EventPayload{Status: 200}))
+
+	filters = &LogFilters{ExcludeStatusCode: []string{"200"}}
+	require.True(t, filters.matches(EventPayload{Status: 404}))
+	require.False(t, filters.matches(EventPayload{Status: 200}))
+}
+
+func TestFiltersExcludedRequestPath(t *testing.T) {
+	filters := &LogFilters{ExcludeRequestPath: []string{"/v1/balance", "/v1/customers/*/sources"}}
+
+	pattern, ok := filters.excludedRequestPath(EventPayload{URL: "/v1/balance?expand[]=instant_available"})
+	require.True(t, ok)
+	require.Equal(t, "/v1/balance", pattern)
+
+	pattern, ok = filters.excludedRequestPath(EventPayload{URL: "/v1/customers/cus_123/sources"})
+	require.True(t, ok)
+	require.Equal(t, "/v1/customers/*/sources", pattern)
+
+	_, ok = filters.excludedRequestPath(EventPayload{URL: "/v1/customers/cus_123/sources/card_123"})
+	require.False(t, ok)
+	_, ok = filters.excludedRequestPath(EventPayload{URL: "/v1/charges"})
+	require.False(t, ok)
+	_, ok = filters.excludedRequestPath(EventPayload{})
+	require.False(t, ok)
+}
+
+func TestFiltersRequestPathWarnings(t *testing.T) {
+	filters := &LogFilters{
+		FilterRequestPath:  []string{"/v1/balance", "/v1/customers"},
+		ExcludeRequestPath: []string{"/v1/balance", "/v1/customers/*/sources"},
+	}
+	require.Equal(t, []string{"--exclude-request-path /v1/balance excludes the request logs of --filter-request-path /v1/balance"}, filters.RequestPathWarnings())
+}
+
+func TestFiltersCheckStatusCodes(t *testing.T) {
+	filters := &LogFilters{
+		FilterStatusCode:  []string{"402", "429"},
+		ExcludeStatusCode: []string{"429"},
+	}
+	require.EqualError(t, filters.CheckStatusCodes(), "The status code 429 can't be both in --filter-status-code and --exclude-status-code")
+
+	filters = &LogFilters{
+		FilterStatusCodeType: []string{"400"},
+		ExcludeStatusCode:    []string{"402"},
+	}
+	require.NoError(t, filters.CheckStatusCodes())
+}
+
+func TestFiltersDescribe(t *testing.T) {
+	filters := &LogFilters{
+		FilterHTTPMethod:     []string{"POST"},
+		FilterRequestPath:    []string{"/v1/payment_intents", "/v1/refunds"},
+		FilterStatusCodeType: []string{"400"},
+	}
+	require.Equal(t, "http method POST and request path /v1/payment_intents or /v1/refunds and status code type 4XX", filters.Describe())
+
+	filters = &LogFilters{
+		FilterStatusCode:     []string{"429"},
+		FilterStatusCodeType: []string{"400", "500"},
+		ExcludeStatusCode:    []string{"402"},
+	}
+	require.Equal(t, "status code 429 or status code type 4XX or 5XX and excluding status code 402", filters.Describe())
+
+	require.Equal(t, "", (&LogFilters{}).Describe())
+}
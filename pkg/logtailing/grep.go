@@ -0,0 +1,57 @@
+package logtailing
+
+import (
+	"regexp"
+)
+
+// Grep matches the printed logs against a regular expression, for
+// `stripe logs tail --grep`. It matches anything in the line, like an ID in
+// the path of the request, which the filters can't.
+type Grep struct {
+	re *regexp.Regexp
+
+	// invert keeps the logs which don't match
+	invert bool
+	// raw matches the JSON payload of the log instead of its line
+	raw bool
+}
+
+// NewGrep compiles the RE2 regular expression of the grep
+func NewGrep(pattern string, invert bool, raw bool) (*Grep, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Grep{re: re, invert: invert, raw: raw}, nil
+}
+
+// Match returns whether the log with the line, without colors, and the JSON
+// payload is kept. A nil grep keeps every log.
+func (g *Grep) Match(line string, payload string) bool {
+	if g == nil {
+		return true
+	}
+
+	subject := line
+	if g.raw {
+		subject = payload
+	}
+
+	return g.re.MatchString(subject) != g.invert
+}
+
+// Highlight returns the line with the portions that match passed through
+// highlight. The lines kept by an inverted grep have nothing to highlight.
+func (g *Grep) Highlight(line string, highlight func(string) string) string {
+	if g == nil || g.invert {
+		return line
+	}
+
+	return g.re.ReplaceAllStringFunc(line, func(match string) string {
+		if match == "" {
+			return match
+		}
+		return highlight(match)
+	})
+}
@@ -0,0 +1,53 @@
+package logtailing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrepMatch(t *testing.T) {
+	line := "2021-03-14 15:09:26 [200] POST /v1/customers/cus_123 [req_123]"
+	payload := `{"request_id":"req_123","url":"/v1/customers/cus_123","api_version":"2020-08-27"}`
+
+	grep, err := NewGrep(`cus_\d+`, false, false)
+	require.NoError(t, err)
+	require.True(t, grep.Match(line, payload))
+	require.False(t, grep.Match("2021-03-14 15:09:26 [200] GET /v1/charges [req_456]", payload))
+
+	grep, err = NewGrep(`cus_\d+`, true, false)
+	require.NoError(t, err)
+	require.False(t, grep.Match(line, payload))
+	require.True(t, grep.Match("2021-03-14 15:09:26 [200] GET /v1/charges [req_456]", payload))
+
+	// The API version is only in the payload
+	grep, err = NewGrep(`2020-08-27`, false, true)
+	require.NoError(t, err)
+	require.True(t, grep.Match(line, payload))
+	require.False(t, grep.Match(line, `{"request_id":"req_123"}`))
+
+	var none *Grep
+	require.True(t, none.Match(line, payload))
+}
+
+func TestGrepHighlight(t *testing.T) {
+	highlight := func(s string) string { return "<" + s + ">" }
+
+	grep, err := NewGrep(`cus_\d+`, false, false)
+	require.NoError(t, err)
+	require.Equal(t, "POST /v1/customers/<cus_123> [req_123]", grep.Highlight("POST /v1/customers/cus_123 [req_123]", highlight))
+
+	// Empty matches aren't highlighted
+	grep, err = NewGrep(`x*`, false, false)
+	require.NoError(t, err)
+	require.Equal(t, "POST <x>", grep.Highlight("POST x", highlight))
+
+	grep, err = NewGrep(`cus_\d+`, true, false)
+	require.NoError(t, err)
+	require.Equal(t, "GET /v1/charges", grep.Highlight("GET /v1/charges", highlight))
+}
+
+func TestNewGrepInvalid(t *testing.T) {
+	_, err := NewGrep(`cus_(`, false, false)
+	require.EqualError(t, err, "error parsing regexp: missing closing ): `cus_(`")
+}
@@ -0,0 +1,53 @@
+package logtailing
+
+import (
+	"encoding/json"
+)
+
+// JSONLog is a request log as printed by `stripe logs tail --format json`, as
+// one compact JSON object per line
+type JSONLog struct {
+	// Created is when the request was made, in seconds since the epoch
+	Created  int  `json:"created"`
+	Livemode bool `json:"livemode"`
+	// Account is the connected account the request was made on, if any
+	Account    string `json:"account,omitempty"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	RequestID  string `json:"request_id"`
+	APIVersion string `json:"api_version,omitempty"`
+	// IdempotencyKey is the idempotency key of the request, if any
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Source is where the request came from, API, DASHBOARD or CLI
+	Source    string `json:"source,omitempty"`
+	ErrorType string `json:"error_type,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+	// Backfilled is set on the logs of the requests made while the tail was
+	// reconnecting
+	Backfilled bool `json:"backfilled,omitempty"`
+}
+
+// FormatJSON returns the log as a line of JSON, without colors
+func FormatJSON(payload EventPayload) (string, error) {
+	data, err := json.Marshal(JSONLog{
+		Created:        payload.CreatedAt,
+		Livemode:       payload.Livemode,
+		Account:        payload.Account,
+		Method:         payload.Method,
+		Path:           payload.URL,
+		Status:         payload.Status,
+		RequestID:      payload.RequestID,
+		APIVersion:     payload.APIVersion,
+		IdempotencyKey: payload.IdempotencyKey,
+		Source:         payload.Source,
+		ErrorType:      payload.Error.Type,
+		ErrorCode:      payload.Error.Code,
+		Backfilled:     payload.Backfilled,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,156 @@
+package logtailing
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// logFileBackups is how many rotated files a log file keeps, as file.1, the
+// most recent, to file.5
+const logFileBackups = 5
+
+// logFileFlushInterval is how often the buffered logs are written to the file
+const logFileFlushInterval = time.Second
+
+// LogFile writes request logs to a file as lines of JSON, like --format json
+// prints them. Once the file would grow past its max size, it is rotated to
+// file.1 and a new file is started, so that a line is never split across
+// files.
+type LogFile struct {
+	path    string
+	maxSize int64
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+	done   chan struct{}
+}
+
+// OpenLogFile opens the log file, appending to it if it exists, and rotates
+// it at maxSize bytes
+func OpenLogFile(path string, maxSize int64) (*LogFile, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("The max size of the log file must be positive, got %d", maxSize)
+	}
+
+	f := &LogFile{path: path, maxSize: maxSize, done: make(chan struct{})}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	go f.flushPeriodically()
+
+	return f, nil
+}
+
+func (f *LogFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("Failed to create the log file %s: %v", f.path, err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to create the log file %s: %v", f.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to create the log file %s: %v", f.path, err)
+	}
+
+	f.file = file
+	f.writer = bufio.NewWriter(file)
+	f.size = info.Size()
+
+	return nil
+}
+
+// Path returns the path of the log file
+func (f *LogFile) Path() string {
+	return f.path
+}
+
+// WritePayload writes the log to the file as a line of JSON
+func (f *LogFile) WritePayload(payload EventPayload) error {
+	line, err := FormatJSON(payload)
+	if err != nil {
+		return err
+	}
+
+	return f.writeLine(line)
+}
+
+func (f *LogFile) writeLine(line string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := int64(len(line) + 1)
+	// A line larger than the max size gets a file of its own
+	if f.size > 0 && f.size+n > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := f.writer.WriteString(line + "\n"); err != nil {
+		return fmt.Errorf("Failed to write to the log file %s: %v", f.path, err)
+	}
+	f.size += n
+
+	return nil
+}
+
+// rotate renames the file to file.1, shifting the previous backups, and
+// starts a new file
+func (f *LogFile) rotate() error {
+	if err := f.writer.Flush(); err != nil {
+		return fmt.Errorf("Failed to write to the log file %s: %v", f.path, err)
+	}
+	f.file.Close()
+
+	for i := logFileBackups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1)) // #nosec G104
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("Failed to rotate the log file %s: %v", f.path, err)
+	}
+
+	return f.open()
+}
+
+func (f *LogFile) flushPeriodically() {
+	ticker := time.NewTicker(logFileFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+			f.mu.Lock()
+			f.writer.Flush() // #nosec G104
+			f.mu.Unlock()
+		}
+	}
+}
+
+// Close writes the buffered logs and closes the file
+func (f *LogFile) Close() error {
+	close(f.done)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.writer.Flush(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("Failed to write to the log file %s: %v", f.path, err)
+	}
+
+	return f.file.Close()
+}
@@ -0,0 +1,66 @@
+package logtailing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readLogLines(t *testing.T, file string) []string {
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestLogFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "tail.log")
+
+	line, err := FormatJSON(EventPayload{Method: "POST", URL: "/v1/charges", RequestID: "req_0", Status: 200})
+	require.NoError(t, err)
+
+	// three lines fit in a file
+	f, err := OpenLogFile(path, int64(3*(len(line)+1)))
+	require.NoError(t, err)
+
+	for i := 0; i < 8; i++ {
+		require.NoError(t, f.WritePayload(EventPayload{Method: "POST", URL: "/v1/charges", RequestID: fmt.Sprintf("req_%d", i), Status: 200}))
+	}
+	require.NoError(t, f.Close())
+
+	require.Len(t, readLogLines(t, path+".2"), 3)
+	require.Len(t, readLogLines(t, path+".1"), 3)
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 2)
+
+	// every line is a whole JSON object
+	var log JSONLog
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &log))
+	require.Equal(t, "req_7", log.RequestID)
+}
+
+func TestLogFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tail.log")
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0600))
+
+	f, err := OpenLogFile(path, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, f.WritePayload(EventPayload{Method: "GET", URL: "/v1/customers", Status: 200}))
+	require.NoError(t, f.Close())
+
+	require.Len(t, readLogLines(t, path), 2)
+}
+
+func TestOpenLogFileFails(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte{}, 0600))
+
+	_, err := OpenLogFile(filepath.Join(file, "tail.log"), 1<<20)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Failed to create the log file")
+}
@@ -0,0 +1,221 @@
+package logtailing
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+const (
+	// statsWindow is the number of seconds the requests per minute are
+	// counted over
+	statsWindow = 60
+	// statsMaxPaths bounds the paths counted for the top paths. Once full, a
+	// new path replaces the least requested one, inheriting its count, so the
+	// paths requested the most are kept with counts over-estimated by at most
+	// the count of the path they replaced.
+	statsMaxPaths = 100
+	// statsTopPaths is the number of paths in a snapshot
+	statsTopPaths = 10
+	// statsBarWidth is the length of the bar of the most frequent status code
+	statsBarWidth = 30
+)
+
+// objectIDRegexp matches the path segments which are the ID of an object, like
+// cus_123, so that the requests on the objects of a kind are counted together
+var objectIDRegexp = regexp.MustCompile(`^[a-z]+_[0-9A-Za-z]*[0-9A-Z][0-9A-Za-z]*$`)
+
+// Stats aggregates the request logs for `stripe logs tail --stats`. The logs
+// have no latency, so there is none to aggregate.
+type Stats struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	started  time.Time
+	total    int
+	failed   int
+	statuses map[int]int
+	paths    map[string]int
+
+	// The requests of the last minute, per second
+	buckets       [statsWindow]int
+	bucketSeconds [statsWindow]int64
+}
+
+// StatusCount is the number of requests with a status code
+type StatusCount struct {
+	Status int
+	Count  int
+}
+
+// PathCount is the number of requests made on a path
+type PathCount struct {
+	Path  string
+	Count int
+}
+
+// StatsSnapshot is the aggregate of the request logs since the start of the
+// tail
+type StatsSnapshot struct {
+	Elapsed           time.Duration
+	Total             int
+	RequestsPerMinute int
+	// ErrorRate is the share of the requests with a 4xx or 5xx status
+	ErrorRate   float64
+	StatusCodes []StatusCount
+	TopPaths    []PathCount
+}
+
+// NewStats creates stats starting now
+func NewStats() *Stats {
+	return newStatsWithClock(time.Now)
+}
+
+func newStatsWithClock(now func() time.Time) *Stats {
+	return &Stats{
+		now:      now,
+		started:  now(),
+		statuses: make(map[int]int),
+		paths:    make(map[string]int),
+	}
+}
+
+// Add counts the log, when it is received
+func (s *Stats) Add(payload EventPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+	if payload.Status >= 400 {
+		s.failed++
+	}
+	s.statuses[payload.Status]++
+
+	second := s.now().Unix()
+	i := second % statsWindow
+	if s.bucketSeconds[i] != second {
+		s.bucketSeconds[i] = second
+		s.buckets[i] = 0
+	}
+	s.buckets[i]++
+
+	if payload.URL != "" {
+		s.addPath(normalizePath(payload.URL))
+	}
+}
+
+func (s *Stats) addPath(path string) {
+	if _, ok := s.paths[path]; ok || len(s.paths) < statsMaxPaths {
+		s.paths[path]++
+		return
+	}
+
+	minPath, minCount := "", 0
+	for p, count := range s.paths {
+		if minPath == "" || count < minCount || (count == minCount && p < minPath) {
+			minPath, minCount = p, count
+		}
+	}
+	delete(s.paths, minPath)
+	s.paths[path] = minCount + 1
+}
+
+// normalizePath replaces the IDs in the path, like /v1/customers/:id
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if objectIDRegexp.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// Snapshot returns the aggregate of the logs added so far
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	snapshot := StatsSnapshot{
+		Elapsed: now.Sub(s.started),
+		Total:   s.total,
+	}
+
+	for i, second := range s.bucketSeconds {
+		if now.Unix()-second < statsWindow {
+			snapshot.RequestsPerMinute += s.buckets[i]
+		}
+	}
+
+	if s.total > 0 {
+		snapshot.ErrorRate = float64(s.failed) / float64(s.total)
+	}
+
+	for status, count := range s.statuses {
+		snapshot.StatusCodes = append(snapshot.StatusCodes, StatusCount{Status: status, Count: count})
+	}
+	sort.Slice(snapshot.StatusCodes, func(i, j int) bool {
+		return snapshot.StatusCodes[i].Status < snapshot.StatusCodes[j].Status
+	})
+
+	for path, count := range s.paths {
+		snapshot.TopPaths = append(snapshot.TopPaths, PathCount{Path: path, Count: count})
+	}
+	sort.Slice(snapshot.TopPaths, func(i, j int) bool {
+		if snapshot.TopPaths[i].Count != snapshot.TopPaths[j].Count {
+			return snapshot.TopPaths[i].Count > snapshot.TopPaths[j].Count
+		}
+		return snapshot.TopPaths[i].Path < snapshot.TopPaths[j].Path
+	})
+	if len(snapshot.TopPaths) > statsTopPaths {
+		snapshot.TopPaths = snapshot.TopPaths[:statsTopPaths]
+	}
+
+	return snapshot
+}
+
+// Lines returns the snapshot as the lines printed by --stats, colored if
+// stdout supports it
+func (s StatsSnapshot) Lines() []string {
+	color := ansi.Color(os.Stdout)
+
+	lines := []string{
+		fmt.Sprintf("%s %d in %s, %d in the last minute, %.1f%% errors",
+			color.Bold("Requests"), s.Total, s.Elapsed.Round(time.Second), s.RequestsPerMinute, s.ErrorRate*100),
+	}
+
+	if len(s.StatusCodes) > 0 {
+		lines = append(lines, "", color.Bold("Status codes").String())
+
+		max := 0
+		for _, status := range s.StatusCodes {
+			if status.Count > max {
+				max = status.Count
+			}
+		}
+		for _, status := range s.StatusCodes {
+			width := status.Count * statsBarWidth / max
+			if width == 0 {
+				width = 1
+			}
+			lines = append(lines, fmt.Sprintf("  %d %s %d", ansi.ColorizeStatus(status.Status), strings.Repeat("█", width), status.Count))
+		}
+	}
+
+	if len(s.TopPaths) > 0 {
+		lines = append(lines, "", color.Bold("Top paths").String())
+		for _, path := range s.TopPaths {
+			lines = append(lines, fmt.Sprintf("  %6d %s", path.Count, path.Path))
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,66 @@
+package logtailing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	now := time.Unix(1615734566, 0)
+	stats := newStatsWithClock(func() time.Time { return now })
+
+	stats.Add(EventPayload{Status: 200, URL: "/v1/customers/cus_JX4bVd2GHjc1Ek"})
+	stats.Add(EventPayload{Status: 200, URL: "/v1/customers/cus_JX4qRsBNGa25zl"})
+	stats.Add(EventPayload{Status: 402, URL: "/v1/payment_intents"})
+
+	now = now.Add(90 * time.Second)
+	stats.Add(EventPayload{Status: 200, URL: "/v1/customers/cus_JX4bVd2GHjc1Ek"})
+
+	snapshot := stats.Snapshot()
+	require.Equal(t, 90*time.Second, snapshot.Elapsed)
+	require.Equal(t, 4, snapshot.Total)
+	// The first requests are more than a minute old
+	require.Equal(t, 1, snapshot.RequestsPerMinute)
+	require.Equal(t, 0.25, snapshot.ErrorRate)
+	require.Equal(t, []StatusCount{{Status: 200, Count: 3}, {Status: 402, Count: 1}}, snapshot.StatusCodes)
+	require.Equal(t, []PathCount{{Path: "/v1/customers/:id", Count: 3}, {Path: "/v1/payment_intents", Count: 1}}, snapshot.TopPaths)
+
+	require.Equal(t, []string{
+		"Requests 4 in 1m30s, 1 in the last minute, 25.0% errors",
+		"",
+		"Status codes",
+		"  200 ██████████████████████████████ 3",
+		"  402 ██████████ 1",
+		"",
+		"Top paths",
+		"       3 /v1/customers/:id",
+		"       1 /v1/payment_intents",
+	}, snapshot.Lines())
+}
+
+func TestStatsTopPathsBounded(t *testing.T) {
+	stats := NewStats()
+
+	// A path requested more than once per statsMaxPaths requests is kept
+	for i := 0; i < 50; i++ {
+		stats.Add(EventPayload{Status: 200, URL: "/v1/charges"})
+	}
+	for i := 0; i < 10*statsMaxPaths; i++ {
+		stats.Add(EventPayload{Status: 200, URL: fmt.Sprintf("/v1/path%d", i)})
+	}
+
+	require.Len(t, stats.paths, statsMaxPaths)
+
+	snapshot := stats.Snapshot()
+	require.Len(t, snapshot.TopPaths, statsTopPaths)
+	require.Equal(t, "/v1/charges", snapshot.TopPaths[0].Path)
+}
+
+func TestNormalizePath(t *testing.T) {
+	require.Equal(t, "/v1/customers/:id/sources/:id", normalizePath("/v1/customers/cus_JX4bVd2GHjc1Ek/sources/card_1IuwTy2eZvKYlo2C"))
+	require.Equal(t, "/v1/subscription_schedules", normalizePath("/v1/subscription_schedules"))
+	require.Equal(t, "/v1/payment_intents/:id/confirm", normalizePath("/v1/payment_intents/pi_1IuwTy2eZvKYlo2C/confirm"))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -19,13 +20,22 @@ const requestLogsWebSocketFeature = "request_logs"
 // LogFilters contains all of the potential user-provided filters for log tailing
 type LogFilters struct {
 	FilterAccount        []string `json:"filter_account,omitempty"`
+	FilterAPIVersion     []string `json:"filter_api_version,omitempty"`
 	FilterIPAddress      []string `json:"filter_ip_address,omitempty"`
 	FilterHTTPMethod     []string `json:"filter_http_method,omitempty"`
+	FilterIdempotencyKey []string `json:"filter_idempotency_key,omitempty"`
 	FilterRequestPath    []string `json:"filter_request_path,omitempty"`
 	FilterRequestStatus  []string `json:"filter_request_status,omitempty"`
 	FilterSource         []string `json:"filter_source,omitempty"`
 	FilterStatusCode     []string `json:"filter_status_code,omitempty"`
 	FilterStatusCodeType []string `json:"filter_status_code_type,omitempty"`
+
+	// FilterRequestID is checked by the tailer only too
+	FilterRequestID []string `json:"-"`
+
+	// The exclusions are checked by the tailer only
+	ExcludeRequestPath []string `json:"-"`
+	ExcludeStatusCode  []string `json:"-"`
 }
 
 // Config provides the configuration of a log tailer
@@ -38,38 +48,84 @@ type Config struct {
 	// Filters for API request logs
 	Filters *LogFilters
 
+	// ExitAfter is how long the tailer runs before it stops, if set
+	ExitAfter time.Duration
+
 	// Key is the API key used to authenticate with Stripe
 	Key string
 
 	// Info, error, etc. logger. Unrelated to API request logs.
 	Log *log.Logger
 
+	// LogFile is where the logs are written as JSON too, if set
+	LogFile *LogFile
+
+	// MaxEvents is the number of logs after which the tailer stops, if set
+	MaxEvents int
+
 	// Force use of unencrypted ws:// protocol instead of wss://
 	NoWSS bool
 
+	// StripeAccount is the connected account whose request logs are tailed,
+	// instead of the platform's
+	StripeAccount string
+
 	// OutCh is the channel to send logs and statuses to for processing in other packages
 	OutCh chan websocket.IElement
 }
 
+// webSocketClient is the part of the websocket client the tailer uses once
+// connected, faked in tests
+type webSocketClient interface {
+	SendMessage(msg *websocket.OutgoingMessage)
+	Stop()
+}
+
 // Tailer is the main interface for running the log tailing session
 type Tailer struct {
 	cfg *Config
 
 	stripeAuthClient *stripeauth.Client
-	webSocketClient  *websocket.Client
+	webSocketClient  webSocketClient
 
 	interruptCh chan os.Signal
+
+	// mu guards the logs seen and the counts of the logs
+	mu              sync.Mutex
+	seen            map[string]int
+	lastCreated     int
+	connectedBefore bool
+	// backfilled is closed once the backfill after the last reconnection is
+	// done, the logs of the new connection wait for it, see backfill.go
+	backfilled chan struct{}
+	// excluded counts the logs of each excluded request path
+	excluded map[string]int
+	// sent counts the logs sent to OutCh, and stop ends the run once they
+	// reach MaxEvents
+	sent int
+	stop context.CancelFunc
+
+	// sendMu is held for reading while sending to OutCh, and for writing by
+	// Run to close it once the sends in progress are done
+	sendMu sync.RWMutex
+	closed bool
 }
 
 // EventPayload is the mapping for fields in event payloads from request log tailing
 type EventPayload struct {
-	CreatedAt int           `json:"created_at"`
-	Livemode  bool          `json:"livemode"`
-	Method    string        `json:"method"`
-	RequestID string        `json:"request_id"`
-	Status    int           `json:"status"`
-	URL       string        `json:"url"`
-	Error     RedactedError `json:"error"`
+	Account        string        `json:"account"`
+	APIVersion     string        `json:"api_version"`
+	CreatedAt      int           `json:"created_at"`
+	IdempotencyKey string        `json:"idempotency_key"`
+	Livemode       bool          `json:"livemode"`
+	Method         string        `json:"method"`
+	RequestID      string        `json:"request_id"`
+	Source         string        `json:"source"`
+	Status         int           `json:"status"`
+	URL            string        `json:"url"`
+	Error          RedactedError `json:"error"`
+	// Backfilled is set on the logs fetched after a reconnection
+	Backfilled bool `json:"-"`
 }
 
 // RedactedError is the mapping for fields in error from an EventPayload
@@ -91,10 +147,13 @@ func New(cfg *Config) *Tailer {
 	return &Tailer{
 		cfg: cfg,
 		stripeAuthClient: stripeauth.NewClient(cfg.Key, &stripeauth.Config{
-			Log:        cfg.Log,
-			APIBaseURL: cfg.APIBaseURL,
+			Log:           cfg.Log,
+			APIBaseURL:    cfg.APIBaseURL,
+			StripeAccount: cfg.StripeAccount,
 		}),
 		interruptCh: make(chan os.Signal, 1),
+		seen:        make(map[string]int),
+		excluded:    make(map[string]int),
 	}
 }
 
@@ -102,7 +161,27 @@ const maxConnectAttempts = 3
 
 // Run sets the websocket connection
 func (t *Tailer) Run(ctx context.Context) error {
-	defer close(t.cfg.OutCh)
+	// The logs still being processed once the run ends are dropped
+	defer func() {
+		t.sendMu.Lock()
+		defer t.sendMu.Unlock()
+		t.closed = true
+		close(t.cfg.OutCh)
+	}()
+
+	// The bounded runs end like when the context is canceled
+	if t.cfg.ExitAfter > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.cfg.ExitAfter)
+		defer cancel()
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	t.mu.Lock()
+	t.stop = stop
+	t.mu.Unlock()
 
 	warned := false
 	nAttempts := 0
@@ -129,7 +208,7 @@ func (t *Tailer) Run(ctx context.Context) error {
 			warned = true
 		}
 
-		t.webSocketClient = websocket.NewClient(
+		client := websocket.NewClient(
 			session.WebSocketURL,
 			session.WebSocketID,
 			session.WebSocketAuthorizedFeature,
@@ -137,19 +216,21 @@ func (t *Tailer) Run(ctx context.Context) error {
 				EventHandler:      websocket.EventHandlerFunc(t.processRequestLogEvent),
 				Log:               t.cfg.Log,
 				NoWSS:             t.cfg.NoWSS,
+				OnConnect:         func() { t.onConnect(ctx) },
 				ReconnectInterval: time.Duration(session.ReconnectDelay) * time.Second,
 			},
 		)
+		t.webSocketClient = client
 
 		go func() {
-			<-t.webSocketClient.Connected()
+			<-client.Connected()
 			nAttempts = 0
-			t.cfg.OutCh <- websocket.StateElement{
+			t.send(websocket.StateElement{
 				State: websocket.Ready,
-			}
+			})
 		}()
 
-		go t.webSocketClient.Run(ctx)
+		go client.Run(ctx)
 		nAttempts++
 
 		select {
@@ -158,7 +239,7 @@ func (t *Tailer) Run(ctx context.Context) error {
 				State: websocket.Done,
 			}
 			return nil
-		case <-t.webSocketClient.NotifyExpired:
+		case <-client.NotifyExpired:
 			if nAttempts < maxConnectAttempts {
 				t.cfg.OutCh <- &websocket.StateElement{
 					State: websocket.Reconnecting,
@@ -200,13 +281,19 @@ func (t *Tailer) createSession(ctx context.Context) (*stripeauth.StripeCLISessio
 		// Try to authorize at least 5 times before failing. Sometimes we have random
 		// transient errors that we just need to retry for.
 		for i := 0; i <= 5; i++ {
-			session, err = t.stripeAuthClient.Authorize(ctx, t.cfg.DeviceName, requestLogsWebSocketFeature, &filters, nil)
+			session, err = t.stripeAuthClient.Authorize(ctx, t.cfg.DeviceName, requestLogsWebSocketFeature, &filters, nil, "")
 
 			if err == nil {
 				exitCh <- struct{}{}
 				return
 			}
 
+			// Retrying won't give access to the account
+			if _, ok := err.(stripeauth.ConnectedAccountError); ok {
+				exitCh <- struct{}{}
+				return
+			}
+
 			select {
 			case <-ctx.Done():
 				exitCh <- struct{}{}
@@ -244,19 +331,142 @@ func (t *Tailer) processRequestLogEvent(msg websocket.IncomingMessage) {
 	ackMessage := websocket.NewEventAck(requestLogEvent.RequestLogID, "")
 	t.webSocketClient.SendMessage(ackMessage)
 
+	// The backfilled logs are printed first
+	t.mu.Lock()
+	backfilled := t.backfilled
+	t.mu.Unlock()
+	if backfilled != nil {
+		<-backfilled
+	}
+
+	t.output(payload, requestLogEvent.EventPayload)
+}
+
+// output sends the log to OutCh, unless it is filtered out or was already
+// seen. The log is sent without holding mu, for a slow consumer of OutCh not
+// to hold up the tailer.
+func (t *Tailer) output(payload EventPayload, marshaled string) {
+	t.mu.Lock()
+	elements, stop := t.process(payload, marshaled)
+	t.mu.Unlock()
+
+	for _, element := range elements {
+		t.send(element)
+	}
+
+	if stop != nil {
+		stop()
+	}
+}
+
+// send sends the element to OutCh, unless Run closed it
+func (t *Tailer) send(element websocket.IElement) {
+	t.sendMu.RLock()
+	defer t.sendMu.RUnlock()
+
+	if t.closed {
+		return
+	}
+
+	t.cfg.OutCh <- element
+}
+
+// process returns the elements to send for the log, and the function to stop
+// the run with once the log reaches MaxEvents. It is called with mu held.
+func (t *Tailer) process(payload EventPayload, marshaled string) ([]websocket.IElement, context.CancelFunc) {
+	if t.cfg.MaxEvents > 0 && t.sent >= t.cfg.MaxEvents {
+		return nil, nil
+	}
+
 	// Don't show stripecli/sessions logs since they're generated by the CLI
 	if payload.URL == "/v1/stripecli/sessions" {
 		t.cfg.Log.Debug("Filtering out /v1/stripecli/sessions from logs")
-		return
+		return nil, nil
+	}
+
+	if !t.cfg.Filters.matches(payload) {
+		t.cfg.Log.Debugf("Filtering out %s %s, which doesn't match the filters", payload.Method, payload.URL)
+		return nil, nil
+	}
+
+	if !t.markSeen(payload) {
+		t.cfg.Log.Debugf("Filtering out %s, which was already shown", payload.RequestID)
+		return nil, nil
 	}
 
-	t.cfg.OutCh <- websocket.DataElement{
+	if pattern, ok := t.cfg.Filters.excludedRequestPath(payload); ok {
+		t.excluded[pattern]++
+		return nil, nil
+	}
+
+	if payload.Account == "" {
+		payload.Account = t.cfg.StripeAccount
+	}
+
+	var elements []websocket.IElement
+	if t.cfg.LogFile != nil {
+		if err := t.cfg.LogFile.WritePayload(payload); err != nil {
+			elements = append(elements, websocket.WarningElement{Warning: err.Error()})
+		}
+	}
+
+	if marshaled == "" {
+		if data, err := json.Marshal(payload); err == nil {
+			marshaled = string(data)
+		}
+	}
+
+	elements = append(elements, websocket.DataElement{
 		Data:      payload,
-		Marshaled: requestLogEvent.EventPayload,
+		Marshaled: marshaled,
+	})
+
+	t.sent++
+	if t.cfg.MaxEvents > 0 && t.sent >= t.cfg.MaxEvents {
+		return elements, t.stop
+	}
+
+	return elements, nil
+}
+
+// EventCount returns the number of logs sent to OutCh so far
+func (t *Tailer) EventCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.sent
+}
+
+// ExcludedRequestPaths returns the number of logs excluded by each of the
+// excluded request paths that excluded any
+func (t *Tailer) ExcludedRequestPaths() []PathCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var counts []PathCount
+	if t.cfg.Filters == nil {
+		return counts
+	}
+	for _, pattern := range t.cfg.Filters.ExcludeRequestPath {
+		if count := t.excluded[pattern]; count > 0 {
+			counts = append(counts, PathCount{Path: pattern, Count: count})
+		}
 	}
+
+	return counts
 }
 
 func jsonifyFilters(logFilters *LogFilters) (string, error) {
+	// Stripe would only send the logs matching both the status codes and the
+	// status code types, while a log matches either of them, so the tailer
+	// checks them on its own
+	if logFilters != nil && len(logFilters.FilterStatusCode) > 0 && len(logFilters.FilterStatusCodeType) > 0 {
+		sent := *logFilters
+		sent.FilterStatusCode = nil
+		sent.FilterStatusCodeType = nil
+		logFilters = &sent
+	}
+
 	bytes, err := json.Marshal(logFilters)
 	if err != nil {
 		return "", err
@@ -1,23 +1,29 @@
 package logtailing
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
 )
 
 func TestJsonifyFiltersAll(t *testing.T) {
 	filters := &LogFilters{
 		FilterAccount:        []string{"my-account"},
+		FilterAPIVersion:     []string{"my-api-version"},
 		FilterIPAddress:      []string{"my-ip-address"},
 		FilterHTTPMethod:     []string{"my-http-method"},
+		FilterIdempotencyKey: []string{"my-idempotency-key"},
 		FilterRequestPath:    []string{"my-request-path"},
 		FilterRequestStatus:  []string{"my-request-status"},
 		FilterSource:         []string{"my-source"},
-		FilterStatusCode:     []string{"my-status-code"},
 		FilterStatusCodeType: []string{"my-status-code-type"},
 	}
-	expected := `{"filter_account":["my-account"],"filter_ip_address":["my-ip-address"],"filter_http_method":["my-http-method"],"filter_request_path":["my-request-path"],"filter_request_status":["my-request-status"],"filter_source":["my-source"],"filter_status_code":["my-status-code"],"filter_status_code_type":["my-status-code-type"]}`
+	expected := `{"filter_account":["my-account"],"filter_api_version":["my-api-version"],"filter_ip_address":["my-ip-address"],"filter_http_method":["my-http-method"],"filter_idempotency_key":["my-idempotency-key"],"filter_request_path":["my-request-path"],"filter_request_status":["my-request-status"],"filter_source":["my-source"],"filter_status_code_type":["my-status-code-type"]}`
 	filtersStr, err := jsonifyFilters(filters)
 	require.NoError(t, err)
 	require.Equal(t, expected, filtersStr)
@@ -34,11 +40,28 @@ func TestJsonifyFiltersSome(t *testing.T) {
 	require.Equal(t, expected, filtersStr)
 }
 
+func TestJsonifyFiltersStatusCodes(t *testing.T) {
+	filters := &LogFilters{
+		FilterHTTPMethod:     []string{"my-http-method"},
+		FilterStatusCode:     []string{"my-status-code"},
+		FilterStatusCodeType: []string{"my-status-code-type"},
+		ExcludeStatusCode:    []string{"my-excluded-status-code"},
+	}
+	// Either the codes or the types match, which the tailer checks
+	expected := `{"filter_http_method":["my-http-method"]}`
+	filtersStr, err := jsonifyFilters(filters)
+	require.NoError(t, err)
+	require.Equal(t, expected, filtersStr)
+	require.Equal(t, []string{"my-status-code"}, filters.FilterStatusCode)
+}
+
 func TestJsonifyFiltersEmpty(t *testing.T) {
 	filters := &LogFilters{
 		FilterAccount:        []string{},
+		FilterAPIVersion:     []string{},
 		FilterIPAddress:      []string{},
 		FilterHTTPMethod:     []string{},
+		FilterIdempotencyKey: []string{},
 		FilterRequestPath:    []string{},
 		FilterRequestStatus:  []string{},
 		FilterSource:         []string{},
@@ -49,3 +72,81 @@ func TestJsonifyFiltersEmpty(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "{}", filtersStr)
 }
+
+func TestFormatJSON(t *testing.T) {
+	raw := `{"api_version":"2020-08-27","created_at":1615734566,"livemode":false,"method":"POST","request_id":"req_123","source":"API","status":402,"url":"/v1/charges","error":{"type":"card_error","charge":"ch_123","code":"card_declined","decline_code":"insufficient_funds","message":"Your card has insufficient funds.","param":""}}`
+
+	var payload EventPayload
+	require.NoError(t, json.Unmarshal([]byte(raw), &payload))
+
+	line, err := FormatJSON(payload)
+	require.NoError(t, err)
+	require.Equal(t, `{"created":1615734566,"livemode":false,"method":"POST","path":"/v1/charges","status":402,"request_id":"req_123","api_version":"2020-08-27","source":"API","error_type":"card_error","error_code":"card_declined"}`, line)
+
+	line, err = FormatJSON(EventPayload{CreatedAt: 1615734566, Method: "GET", RequestID: "req_456", Status: 200, URL: "/v1/customers"})
+	require.NoError(t, err)
+	require.Equal(t, `{"created":1615734566,"livemode":false,"method":"GET","path":"/v1/customers","status":200,"request_id":"req_456"}`, line)
+}
+
+func TestExcludedRequestPaths(t *testing.T) {
+	outCh := make(chan websocket.IElement, 10)
+	tailer := New(&Config{
+		Filters: &LogFilters{ExcludeRequestPath: []string{"/v1/balance", "/v1/customers"}},
+		OutCh:   outCh,
+	})
+	tailer.webSocketClient = &fakeWebSocketClient{}
+
+	tailer.processRequestLogEvent(requestLogMessage("resp_1", `{"request_id": "req_1", "created_at": 100, "method": "GET", "url": "/v1/balance", "status": 200}`))
+	tailer.processRequestLogEvent(requestLogMessage("resp_2", `{"request_id": "req_2", "created_at": 110, "method": "GET", "url": "/v1/balance", "status": 200}`))
+	tailer.processRequestLogEvent(requestLogMessage("resp_3", `{"request_id": "req_3", "created_at": 120, "method": "POST", "url": "/v1/charges", "status": 200}`))
+
+	require.Equal(t, "req_3", receivePayload(t, outCh).RequestID)
+	require.Empty(t, outCh)
+	require.Equal(t, []PathCount{{Path: "/v1/balance", Count: 2}}, tailer.ExcludedRequestPaths())
+}
+
+func TestMaxEvents(t *testing.T) {
+	outCh := make(chan websocket.IElement, 10)
+	tailer := New(&Config{
+		Filters:   &LogFilters{},
+		MaxEvents: 2,
+		OutCh:     outCh,
+	})
+	tailer.webSocketClient = &fakeWebSocketClient{}
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	tailer.stop = stop
+
+	tailer.processRequestLogEvent(requestLogMessage("resp_1", `{"request_id": "req_1", "created_at": 100, "method": "GET", "url": "/v1/charges", "status": 200}`))
+	require.NoError(t, ctx.Err())
+
+	tailer.processRequestLogEvent(requestLogMessage("resp_2", `{"request_id": "req_2", "created_at": 110, "method": "GET", "url": "/v1/charges", "status": 200}`))
+	require.Error(t, ctx.Err())
+
+	tailer.processRequestLogEvent(requestLogMessage("resp_3", `{"request_id": "req_3", "created_at": 120, "method": "GET", "url": "/v1/charges", "status": 200}`))
+
+	require.Equal(t, "req_1", receivePayload(t, outCh).RequestID)
+	require.Equal(t, "req_2", receivePayload(t, outCh).RequestID)
+	require.Empty(t, outCh)
+	require.Equal(t, 2, tailer.EventCount())
+}
+
+func TestOutputDoesNotHoldLockWhileSending(t *testing.T) {
+	outCh := make(chan websocket.IElement)
+	tailer := New(&Config{
+		Filters: &LogFilters{},
+		OutCh:   outCh,
+	})
+	tailer.webSocketClient = &fakeWebSocketClient{}
+
+	go tailer.processRequestLogEvent(requestLogMessage("resp_1", `{"request_id": "req_1", "created_at": 100, "method": "GET", "url": "/v1/charges", "status": 200}`))
+
+	// the tailer can be queried while the log waits for the consumer
+	require.Eventually(t, func() bool {
+		return tailer.EventCount() == 1
+	}, time.Second, time.Millisecond)
+
+	element := (<-outCh).(websocket.DataElement)
+	require.Equal(t, "req_1", element.Data.(EventPayload).RequestID)
+}
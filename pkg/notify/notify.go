@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var execCommand = exec.Command
+
+var lookPath = exec.LookPath
+
+// ErrUnavailable is returned when notifications can't be displayed, like in
+// headless environments
+var ErrUnavailable = errors.New("desktop notifications are not available")
+
+// windowsToastScript displays a toast with the PowerShell-quoted title and
+// message
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(%s)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode(%s)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('Stripe CLI').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// Available determines if desktop notifications can be displayed on the
+// operating system
+func Available() bool {
+	switch runtime.GOOS {
+	case "linux":
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return false
+		}
+		_, err := lookPath("notify-send")
+		return err == nil
+	case "windows":
+		_, err := lookPath("powershell")
+		return err == nil
+	case "darwin":
+		_, err := lookPath("osascript")
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// Send displays a desktop notification with the title and message, using the
+// notification service of the operating system. It waits for the command
+// displaying it to exit.
+func Send(title string, message string) error {
+	if !Available() {
+		return ErrUnavailable
+	}
+
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "linux":
+		cmd = execCommand("notify-send", "--app-name=Stripe CLI", "--", title, message)
+	case "windows":
+		script := fmt.Sprintf(windowsToastScript, powerShellString(title), powerShellString(message))
+		cmd = execCommand("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = execCommand("osascript", "-e", script)
+	}
+
+	return cmd.Run()
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+
+	return `"` + s + `"`
+}
+
+// powerShellString quotes s as a verbatim PowerShell string literal
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,17 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppleScriptString(t *testing.T) {
+	require.Equal(t, `"charge.failed returned 500"`, appleScriptString("charge.failed returned 500"))
+	require.Equal(t, `"say \"hi\" \\ bye"`, appleScriptString(`say "hi" \ bye`))
+}
+
+func TestPowerShellString(t *testing.T) {
+	require.Equal(t, "'charge.failed returned 500'", powerShellString("charge.failed returned 500"))
+	require.Equal(t, "'it''s $(whoami)'", powerShellString("it's $(whoami)"))
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+//
+// Private variables
+//
+
+// errNotReady is returned for custom events sent before a session is ready or
+// once the proxy is shutting down
+var errNotReady = errors.New("the proxy is not ready to forward events")
+
+//
+// Private functions
+//
+
+// deliverCustomEvent handles a custom event, sent by `stripe trigger --raw` to
+// the status server, like an event received from Stripe on the first session.
+// Custom events don't go through Stripe, so they are signed with the secret of
+// the session here.
+func (p *Proxy) deliverCustomEvent(payload []byte) error {
+	if len(p.sessions) == 0 || p.inflight.isDraining() {
+		return errNotReady
+	}
+
+	s := p.sessions[0]
+	secret := p.sessionSecret(s)
+	if secret == "" {
+		return errNotReady
+	}
+
+	evt, err := parseStripeEvent(string(payload))
+	if err != nil {
+		return fmt.Errorf("Invalid custom event: %v", err)
+	}
+	if evt.ID == "" || evt.Type == "" {
+		return errors.New("Invalid custom event, it has no id or type")
+	}
+
+	if len(p.sessions) > 1 {
+		evt.Profile = s.profile.Name
+		evt.ProfileAccount = s.profile.Account
+	}
+
+	// without a webhook ID, the responses of the endpoints aren't sent to
+	// Stripe
+	p.handleWebhookEvent(s, &websocket.WebhookEvent{
+		EventPayload: string(payload),
+		HTTPHeaders: map[string]string{
+			"Content-Type":     "application/json; charset=utf-8",
+			"User-Agent":       "Stripe/1.0 (+https://stripe.com/docs/webhooks)",
+			"Stripe-Signature": SignatureHeader(time.Now(), payload, secret),
+		},
+	}, evt)
+
+	return nil
+}
+
+// sessionSecret returns the webhook signing secret of the session once it is
+// ready
+func (p *Proxy) sessionSecret(s *listenSession) string {
+	if len(p.sessions) == 1 {
+		return p.status.readySecret()
+	}
+
+	p.readiness.mu.Lock()
+	defer p.readiness.mu.Unlock()
+
+	return p.readiness.secrets[s]
+}
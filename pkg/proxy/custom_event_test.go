@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestDeliverCustomEvent(t *testing.T) {
+	received := make(chan string, 1)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var timestamp int64
+		var signature string
+		_, err = fmt.Sscanf(r.Header.Get("Stripe-Signature"), "t=%d,v1=%s", &timestamp, &signature)
+		require.NoError(t, err)
+		require.Equal(t, ComputeSignature(time.Unix(timestamp, 0), body, "whsec_test"), signature)
+
+		received <- string(body)
+	}))
+	defer endpoint.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{endpoint.URL},
+		OutCh:       outCh,
+	})
+	require.NoError(t, err)
+
+	payload := []byte(`{"id": "evt_custom", "object": "event", "type": "charge.dispute.created", "request": {"id": null}, "data": {"object": {"reason": "fraudulent"}}}`)
+
+	// not ready before the session is
+	require.ErrorIs(t, p.deliverCustomEvent(payload), errNotReady)
+
+	p.status.setReady("whsec_test")
+	require.NoError(t, p.deliverCustomEvent(payload))
+
+	select {
+	case body := <-received:
+		require.Equal(t, string(payload), body)
+	case <-time.After(time.Second):
+		t.Fatal("the custom event was not forwarded")
+	}
+
+	evt := (<-outCh).(websocket.DataElement).Data.(StripeEvent)
+	require.Equal(t, "evt_custom", evt.ID)
+
+	require.Error(t, p.deliverCustomEvent([]byte(`{"object": "event"}`)))
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+//
+// Public types
+//
+
+// DuplicateEvent is sent in place of the event when an event with the same ID
+// was already received recently. Duplicates are not forwarded.
+type DuplicateEvent struct {
+	Event *StripeEvent
+}
+
+//
+// Public constants
+//
+
+const (
+	// DefaultDedupeSize is the default number of event IDs remembered by Dedupe
+	DefaultDedupeSize = 1000
+
+	// DefaultDedupeTTL is the default time event IDs are remembered by Dedupe
+	DefaultDedupeTTL = 10 * time.Minute
+)
+
+//
+// Private types
+//
+
+// dedupeCache is an LRU of the IDs of recently received events, which forgets
+// IDs after ttl. It is safe for concurrent use.
+type dedupeCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	// order holds the IDs from the most to the least recently seen
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type dedupeEntry struct {
+	id     string
+	seenAt time.Time
+}
+
+//
+// Private functions
+//
+
+func newDedupeCache(capacity int, ttl time.Duration) *dedupeCache {
+	return &dedupeCache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// seen records the event ID and reports whether it was already recorded
+// within the TTL
+func (c *dedupeCache) seen(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	if el, ok := c.entries[id]; ok {
+		entry := el.Value.(*dedupeEntry)
+		if now.Sub(entry.seenAt) < c.ttl {
+			c.order.MoveToFront(el)
+			return true
+		}
+
+		// expired, the event is treated as new
+		entry.seenAt = now
+		c.order.MoveToFront(el)
+		return false
+	}
+
+	c.entries[id] = c.order.PushFront(&dedupeEntry{id: id, seenAt: now})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dedupeEntry).id)
+	}
+
+	return false
+}
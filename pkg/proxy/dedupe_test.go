@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeCacheSuppressesDuplicatesWithinTTL(t *testing.T) {
+	now := time.Now()
+	c := newDedupeCache(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	require.False(t, c.seen("evt_1"))
+	require.True(t, c.seen("evt_1"))
+
+	now = now.Add(2 * time.Minute)
+	require.False(t, c.seen("evt_1"))
+	require.True(t, c.seen("evt_1"))
+}
+
+func TestDedupeCacheEvictsLeastRecentlySeen(t *testing.T) {
+	c := newDedupeCache(2, time.Minute)
+
+	require.False(t, c.seen("evt_1"))
+	require.False(t, c.seen("evt_2"))
+	require.True(t, c.seen("evt_1"))
+
+	// evt_2 is the least recently seen and gets evicted
+	require.False(t, c.seen("evt_3"))
+	require.Equal(t, 2, c.order.Len())
+
+	require.True(t, c.seen("evt_1"))
+	require.False(t, c.seen("evt_2"))
+}
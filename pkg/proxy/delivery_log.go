@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Public types
+//
+
+// DeliveryRecord is a line of the delivery log written with the LogFile
+// option. It describes the outcome of forwarding an event to an endpoint.
+type DeliveryRecord struct {
+	Time      time.Time `json:"time"`
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	URL       string    `json:"url"`
+	// StatusCode is the status code returned by the endpoint, or 0 if the
+	// request failed
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+//
+// Public constants
+//
+
+// DefaultLogMaxSize is the size in bytes past which the delivery log is
+// rotated
+const DefaultLogMaxSize = 10 * 1024 * 1024
+
+// DefaultLogMaxFiles is the number of rotated delivery logs kept
+const DefaultLogMaxFiles = 5
+
+//
+// Private constants
+//
+
+// deliveryLogBufferSize is the number of records waiting to be written after
+// which further records are dropped rather than slowing down deliveries
+const deliveryLogBufferSize = 1024
+
+//
+// Private types
+//
+
+// deliveryLog appends delivery records to a file as JSON Lines from a
+// dedicated goroutine, and rotates the file once it grows past maxSize: the
+// current file is renamed to path.1, path.1 to path.2, and so on up to
+// path.<maxFiles>. It is safe for concurrent use.
+type deliveryLog struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+
+	mu      sync.RWMutex
+	closed  bool
+	records chan DeliveryRecord
+	done    chan struct{}
+	dropped int64
+	err     error
+}
+
+//
+// Private functions
+//
+
+func newDeliveryLog(path string, maxSize int64, maxFiles int) (*deliveryLog, error) {
+	l := &deliveryLog{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		records:  make(chan DeliveryRecord, deliveryLogBufferSize),
+		done:     make(chan struct{}),
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	go l.run()
+
+	return l, nil
+}
+
+// write queues the record without blocking. Records are dropped when the
+// writer falls behind or after the log was closed.
+func (l *deliveryLog) write(record DeliveryRecord) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return
+	}
+
+	select {
+	case l.records <- record:
+	default:
+		atomic.AddInt64(&l.dropped, 1)
+	}
+}
+
+// close writes the queued records and closes the file. It returns the first
+// error encountered while writing, if any.
+func (l *deliveryLog) close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.records)
+	}
+	l.mu.Unlock()
+
+	<-l.done
+
+	if dropped := atomic.LoadInt64(&l.dropped); dropped > 0 && l.err == nil {
+		l.err = fmt.Errorf("dropped %d records because the log could not keep up", dropped)
+	}
+
+	return l.err
+}
+
+func (l *deliveryLog) run() {
+	defer close(l.done)
+
+	for record := range l.records {
+		if err := l.append(record); err != nil && l.err == nil {
+			l.err = err
+		}
+	}
+
+	if l.file != nil {
+		if err := l.file.Close(); err != nil && l.err == nil {
+			l.err = err
+		}
+	}
+}
+
+func (l *deliveryLog) append(record DeliveryRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if l.file == nil {
+		return nil
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+
+	return err
+}
+
+func (l *deliveryLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+func (l *deliveryLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+
+	// Renaming over an existing file fails on Windows
+	if err := os.Remove(l.rotatedPath(l.maxFiles)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(l.rotatedPath(i), l.rotatedPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(l.path, l.rotatedPath(1)); err != nil {
+		return err
+	}
+
+	return l.open()
+}
+
+func (l *deliveryLog) rotatedPath(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
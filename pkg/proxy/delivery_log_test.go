@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readDeliveryRecords(t *testing.T, path string) []DeliveryRecord {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []DeliveryRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record DeliveryRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	return records
+}
+
+func TestDeliveryLogRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.log")
+
+	l, err := newDeliveryLog(path, 200, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		l.write(DeliveryRecord{EventID: "evt_123", EventType: "charge.succeeded", URL: "http://localhost/hooks", StatusCode: 200})
+	}
+	require.NoError(t, l.close())
+
+	require.FileExists(t, path+".1")
+	require.FileExists(t, path+".2")
+	require.NoFileExists(t, path+".3")
+
+	info, err := os.Stat(path + ".1")
+	require.NoError(t, err)
+	require.LessOrEqual(t, info.Size(), int64(200))
+
+	records := readDeliveryRecords(t, path+".1")
+	require.NotEmpty(t, records)
+	require.Equal(t, "evt_123", records[0].EventID)
+	require.Equal(t, 200, records[0].StatusCode)
+}
+
+func TestDeliveryLogAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.log")
+
+	for i := 0; i < 2; i++ {
+		l, err := newDeliveryLog(path, DefaultLogMaxSize, DefaultLogMaxFiles)
+		require.NoError(t, err)
+		l.write(DeliveryRecord{EventID: "evt_123", Error: "connection refused"})
+		require.NoError(t, l.close())
+	}
+
+	records := readDeliveryRecords(t, path)
+	require.Len(t, records, 2)
+	require.Equal(t, "connection refused", records[1].Error)
+}
+
+func TestDeliveryLogDropsRecordsWhenFull(t *testing.T) {
+	// Without a writer goroutine the buffer fills up, and writes must still
+	// return immediately
+	l := &deliveryLog{records: make(chan DeliveryRecord, 1), done: make(chan struct{})}
+
+	l.write(DeliveryRecord{EventID: "evt_1"})
+	l.write(DeliveryRecord{EventID: "evt_2"})
+
+	require.Equal(t, int64(1), l.dropped)
+}
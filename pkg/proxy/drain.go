@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Public types
+//
+
+// DrainResult describes the deliveries that were in flight when the proxy was
+// stopped
+type DrainResult struct {
+	// Drained is the number of deliveries that completed before the drain
+	// timeout
+	Drained int
+	// Abandoned is the number of deliveries canceled at the drain timeout
+	Abandoned int
+}
+
+//
+// Private variables
+//
+
+// drainPollInterval is how often in-flight deliveries are checked while
+// draining
+var drainPollInterval = 10 * time.Millisecond
+
+// cancelGracePeriod is how long canceled deliveries are given to report their
+// failure before the proxy stops
+var cancelGracePeriod = time.Second
+
+//
+// Private types
+//
+
+// inflightDeliveries tracks the deliveries that have been started but have
+// not finished reporting their outcome yet. It is safe for concurrent use.
+type inflightDeliveries struct {
+	count    int64
+	draining int32
+
+	// ctx is canceled to abort the deliveries still in flight at the drain
+	// timeout
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+//
+// Private functions
+//
+
+func newInflightDeliveries() *inflightDeliveries {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &inflightDeliveries{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (d *inflightDeliveries) add() {
+	atomic.AddInt64(&d.count, 1)
+}
+
+func (d *inflightDeliveries) done() {
+	atomic.AddInt64(&d.count, -1)
+}
+
+func (d *inflightDeliveries) inflight() int {
+	return int(atomic.LoadInt64(&d.count))
+}
+
+// isDraining reports whether the proxy is shutting down, in which case new
+// events must not be processed
+func (d *inflightDeliveries) isDraining() bool {
+	return atomic.LoadInt32(&d.draining) == 1
+}
+
+// drain stops the processing of new events and waits up to timeout for the
+// deliveries in flight to complete. The deliveries still in flight after
+// timeout are canceled.
+func (d *inflightDeliveries) drain(timeout time.Duration) DrainResult {
+	atomic.StoreInt32(&d.draining, 1)
+
+	inflight := d.inflight()
+	if inflight == 0 {
+		return DrainResult{}
+	}
+
+	if d.wait(timeout) {
+		return DrainResult{Drained: inflight}
+	}
+
+	abandoned := d.inflight()
+	d.cancel()
+
+	// give canceled deliveries a chance to report, so that nothing is sent to
+	// the proxy's output channel once it is closed
+	d.wait(cancelGracePeriod)
+
+	return DrainResult{
+		Drained:   inflight - abandoned,
+		Abandoned: abandoned,
+	}
+}
+
+// wait returns true once there are no deliveries in flight, or false if there
+// are still some after timeout
+func (d *inflightDeliveries) wait(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for d.inflight() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func newDrainTestProxy(t *testing.T, handler http.HandlerFunc) (*Proxy, chan websocket.IElement) {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{ts.URL},
+		OutCh:       outCh,
+	})
+	require.NoError(t, err)
+
+	return p, outCh
+}
+
+func TestDrainWaitsForInflightDeliveries(t *testing.T) {
+	p, outCh := newDrainTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+	result := p.inflight.drain(5 * time.Second)
+	require.Equal(t, DrainResult{Drained: 1}, result)
+	require.True(t, p.inflight.isDraining())
+
+	el := (<-outCh).(websocket.DataElement)
+	resp := el.Data.(EndpointResponse)
+	require.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+}
+
+func TestDrainAbandonsSlowDeliveries(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	p, outCh := newDrainTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+	result := p.inflight.drain(50 * time.Millisecond)
+	require.Equal(t, DrainResult{Abandoned: 1}, result)
+
+	// the canceled delivery reports its failure before the drain returns
+	require.Equal(t, 0, p.inflight.inflight())
+	el := (<-outCh).(websocket.ErrorElement)
+	require.IsType(t, FailedToPostError{}, el.Error)
+}
+
+func TestDrainWithoutInflightDeliveries(t *testing.T) {
+	d := newInflightDeliveries()
+
+	require.Equal(t, DrainResult{}, d.drain(time.Second))
+	require.True(t, d.isDraining())
+}
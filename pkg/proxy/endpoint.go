@@ -2,10 +2,17 @@ package proxy
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"path"
 	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -27,6 +34,24 @@ type EndpointConfig struct {
 
 	// OutCh is the channel to send data and statuses to for processing in other packages
 	OutCh chan websocket.IElement
+
+	// retrier retries failed deliveries. Deliveries are not retried when nil.
+	retrier *retrier
+
+	// headersFile holds custom headers loaded from a file, which are
+	// overridden by the client's own headers
+	headersFile *headersFile
+
+	// signingSecrets re-sign deliveries instead of the session secret, when set
+	signingSecrets []string
+
+	// command, when set, is run for each event instead of sending HTTP
+	// requests
+	command *eventCommand
+
+	// ctx aborts the deliveries in flight when canceled. Deliveries are never
+	// aborted when nil.
+	ctx context.Context
 }
 
 // EndpointResponseHandler handles a response from the endpoint.
@@ -59,6 +84,10 @@ type EndpointClient struct {
 	// URL the client sends POST requests to
 	URL string
 
+	// requestURL is the URL of the HTTP requests, which differs from URL for
+	// endpoints listening on a Unix domain socket
+	requestURL string
+
 	headers map[string]string
 
 	connect bool
@@ -81,6 +110,12 @@ func (c *EndpointClient) SupportsEventType(connect bool, eventType string) bool
 		return true
 	}
 
+	for event := range c.events {
+		if eventTypeMatches(event, eventType) {
+			return true
+		}
+	}
+
 	return false
 }
 
@@ -90,17 +125,128 @@ func (c *EndpointClient) Post(evtCtx eventContext, body string, headers map[stri
 		"prefix": "proxy.EndpointClient.Post",
 	}).Debug("Forwarding event to local endpoint")
 
-	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewBuffer([]byte(body)))
+	resp, err := c.send(evtCtx, body, headers)
+
+	if r := c.cfg.retrier; r != nil && r.shouldRetry(resp, err) {
+		if r.acquire() {
+			resp, err = c.retry(evtCtx, resp, err, body, headers)
+			r.release()
+		} else {
+			c.cfg.Log.WithFields(log.Fields{
+				"prefix": "proxy.EndpointClient.Post",
+			}).Warnf("Too many deliveries are already being retried, not retrying %s", evtCtx.event.ID)
+		}
+	}
+
 	if err != nil {
+		err = c.describeSendError(err)
+		c.cfg.OutCh <- websocket.ErrorElement{
+			Error: FailedToPostError{Err: err},
+		}
 		return err
 	}
 
+	defer resp.Body.Close()
+
+	c.cfg.ResponseHandler.ProcessResponse(evtCtx, c.URL, resp)
+
+	return nil
+}
+
+// retry re-sends a failed delivery with exponential backoff until it succeeds
+// or the maximum number of retries is reached, and returns the last attempt's
+// result.
+func (c *EndpointClient) retry(evtCtx eventContext, resp *http.Response, err error, body string, headers map[string]string) (*http.Response, error) {
+	r := c.cfg.retrier
+
+	for attempt := 1; attempt <= r.maxRetries && r.shouldRetry(resp, err); attempt++ {
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			// read the body so that the connection can be reused
+			io.Copy(ioutil.Discard, resp.Body) // #nosec G104
+			resp.Body.Close()
+		}
+
+		delay := r.backoff(attempt)
+
+		c.cfg.Log.WithFields(log.Fields{
+			"prefix":   "proxy.EndpointClient.Post",
+			"event_id": evtCtx.event.ID,
+		}).Infof("Forwarding to %s failed (%s), retrying in %s [attempt %d/%d]", c.URL, reason, delay.Round(time.Millisecond), attempt, r.maxRetries)
+
+		select {
+		case <-c.context().Done():
+			return nil, c.context().Err()
+		case <-time.After(delay):
+		}
+
+		resp, err = c.send(evtCtx, body, headers)
+	}
+
+	return resp, err
+}
+
+// describeSendError makes timeouts and refused connections, the most common
+// ways for a delivery to fail, explicit
+func (c *EndpointClient) describeSendError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("timed out after %s waiting for a response from %s: %w", c.cfg.HTTPClient.Timeout, c.URL, err)
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("connection refused by %s, is your server running? %w", c.URL, err)
+	}
+
+	return err
+}
+
+// customHeaders merges the headers from the headers file, if any, with the
+// client's own headers
+func (c *EndpointClient) customHeaders() map[string]string {
+	if c.cfg.headersFile == nil {
+		return c.headers
+	}
+
+	merged := make(map[string]string)
+	for k, v := range c.cfg.headersFile.get() {
+		merged[k] = v
+	}
+	for k, v := range c.headers {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// context returns the context deliveries are made with
+func (c *EndpointClient) context() context.Context {
+	if c.cfg.ctx == nil {
+		return context.Background()
+	}
+
+	return c.cfg.ctx
+}
+
+func (c *EndpointClient) send(evtCtx eventContext, body string, headers map[string]string) (*http.Response, error) {
+	if c.cfg.command != nil {
+		return c.cfg.command.run(c.context(), evtCtx.event, body)
+	}
+
+	req, err := http.NewRequestWithContext(c.context(), http.MethodPost, c.requestURL, bytes.NewBuffer([]byte(body)))
+	if err != nil {
+		return nil, err
+	}
+
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
 
 	// add custom headers
-	for k, v := range c.headers {
+	for k, v := range c.customHeaders() {
 		if strings.ToLower(k) == "host" {
 			req.Host = v
 		} else {
@@ -108,19 +254,12 @@ func (c *EndpointClient) Post(evtCtx eventContext, body string, headers map[stri
 		}
 	}
 
-	resp, err := c.cfg.HTTPClient.Do(req)
-	if err != nil {
-		c.cfg.OutCh <- websocket.ErrorElement{
-			Error: FailedToPostError{Err: err},
-		}
-		return err
+	// re-sign the payload, replacing the signature made with the session secret
+	if len(c.cfg.signingSecrets) > 0 {
+		req.Header.Set("Stripe-Signature", SignatureHeader(time.Now(), []byte(body), c.cfg.signingSecrets...))
 	}
 
-	defer resp.Body.Close()
-
-	c.cfg.ResponseHandler.ProcessResponse(evtCtx, c.URL, resp)
-
-	return nil
+	return c.cfg.HTTPClient.Do(req)
 }
 
 //
@@ -150,12 +289,18 @@ func NewEndpointClient(url string, headers []string, connect bool, events []stri
 		cfg.ResponseHandler = EndpointResponseHandlerFunc(func(eventContext, string, *http.Response) {})
 	}
 
+	requestURL := url
+	if _, socketRequestURL, ok := parseUnixSocketURL(url); ok {
+		requestURL = socketRequestURL
+	}
+
 	return &EndpointClient{
-		URL:     url,
-		headers: convertToMapAndSanitize(headers),
-		connect: connect,
-		events:  convertToMap(events),
-		cfg:     cfg,
+		URL:        url,
+		requestURL: requestURL,
+		headers:    convertToMapAndSanitize(headers),
+		connect:    connect,
+		events:     convertToMap(events),
+		cfg:        cfg,
 	}
 }
 
@@ -164,7 +309,7 @@ func NewEndpointClient(url string, headers []string, connect bool, events []stri
 //
 
 const (
-	defaultTimeout = 30 * time.Second
+	defaultTimeout = DefaultForwardTimeout
 )
 
 //
@@ -180,6 +325,17 @@ func convertToMap(events []string) map[string]bool {
 	return eventsMap
 }
 
+// eventTypeMatches reports whether eventType matches the glob pattern, e.g.
+// `invoice.*` matches `invoice.paid`. Malformed patterns never match.
+func eventTypeMatches(pattern string, eventType string) bool {
+	if pattern == eventType {
+		return true
+	}
+
+	matched, err := path.Match(pattern, eventType)
+	return err == nil && matched
+}
+
 func convertToMapAndSanitize(headers []string) map[string]string {
 	reg := regexp.MustCompile("[\x00-\x1f]+")
 
@@ -6,8 +6,11 @@ import (
 	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
 )
 
 func TestClientHandler(t *testing.T) {
@@ -134,3 +137,153 @@ func TestClientHandler_Redirects(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestSupportsEventTypeGlob(t *testing.T) {
+	client := NewEndpointClient("http://localhost", []string{}, false, []string{"invoice.*"}, nil)
+
+	require.True(t, client.SupportsEventType(false, "invoice.paid"))
+	require.False(t, client.SupportsEventType(false, "charge.succeeded"))
+	require.False(t, client.SupportsEventType(true, "invoice.paid"))
+}
+
+func TestClientHandler_Retries(t *testing.T) {
+	n := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	r := newRetrier(5)
+	r.baseDelay = time.Millisecond
+
+	statusCode := 0
+	client := NewEndpointClient(
+		ts.URL,
+		[]string{},
+		false,
+		[]string{"*"},
+		&EndpointConfig{
+			ResponseHandler: EndpointResponseHandlerFunc(func(evtCtx eventContext, forwardURL string, resp *http.Response) {
+				statusCode = resp.StatusCode
+			}),
+			retrier: r,
+		},
+	)
+
+	evtCtx := eventContext{event: &StripeEvent{ID: "evt_123"}}
+
+	err := client.Post(evtCtx, "{}", map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, http.StatusOK, statusCode)
+}
+
+func TestClientHandler_RetriesExhausted(t *testing.T) {
+	n := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	r := newRetrier(2)
+	r.baseDelay = time.Millisecond
+
+	statusCode := 0
+	client := NewEndpointClient(
+		ts.URL,
+		[]string{},
+		false,
+		[]string{"*"},
+		&EndpointConfig{
+			ResponseHandler: EndpointResponseHandlerFunc(func(evtCtx eventContext, forwardURL string, resp *http.Response) {
+				statusCode = resp.StatusCode
+			}),
+			retrier: r,
+		},
+	)
+
+	evtCtx := eventContext{event: &StripeEvent{ID: "evt_123"}}
+
+	err := client.Post(evtCtx, "{}", map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, http.StatusInternalServerError, statusCode)
+}
+
+func TestClientHandler_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	outCh := make(chan websocket.IElement, 1)
+	client := NewEndpointClient(
+		ts.URL,
+		[]string{},
+		false,
+		[]string{"*"},
+		&EndpointConfig{
+			HTTPClient: &http.Client{Timeout: 10 * time.Millisecond},
+			OutCh:      outCh,
+		},
+	)
+
+	err := client.Post(eventContext{event: &StripeEvent{ID: "evt_123"}}, "{}", map[string]string{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out after 10ms waiting for a response from "+ts.URL)
+
+	ee := (<-outCh).(websocket.ErrorElement)
+	require.IsType(t, FailedToPostError{}, ee.Error)
+}
+
+func TestClientHandler_ConnectionRefused(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	client := NewEndpointClient(
+		url,
+		[]string{},
+		false,
+		[]string{"*"},
+		&EndpointConfig{
+			OutCh: make(chan websocket.IElement, 1),
+		},
+	)
+
+	err := client.Post(eventContext{event: &StripeEvent{ID: "evt_123"}}, "{}", map[string]string{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "connection refused by "+url)
+}
+
+func TestClientHandler_SigningSecrets(t *testing.T) {
+	var signature string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("Stripe-Signature")
+	}))
+	defer ts.Close()
+
+	client := NewEndpointClient(
+		ts.URL,
+		[]string{},
+		false,
+		[]string{"*"},
+		&EndpointConfig{
+			signingSecrets: []string{"whsec_vault"},
+		},
+	)
+
+	err := client.Post(eventContext{event: &StripeEvent{ID: "evt_123"}}, `{"id":"evt_123"}`, map[string]string{"Stripe-Signature": "t=1,v1=session"})
+	require.NoError(t, err)
+
+	require.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, signature)
+	require.NotContains(t, signature, "session")
+}
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+//
+// Public types
+//
+
+// EndpointOverride customizes how the events of a webhook endpoint configured
+// on the account are forwarded, when using UseConfiguredWebhooks
+type EndpointOverride struct {
+	// Headers are added to the forwarded requests, replacing the headers of
+	// the same name given with ForwardHeaders or ForwardConnectHeaders
+	Headers map[string]string `yaml:"headers"`
+	// Connect overrides whether the endpoint receives Connect events, which is
+	// otherwise deduced from the endpoint
+	Connect *bool `yaml:"connect"`
+	// ForwardURL is the URL events are forwarded to, instead of the
+	// endpoint's path appended to ForwardURLs or ForwardConnectURLs
+	ForwardURL string `yaml:"forward_url"`
+	// SkipVerify overrides Config.SkipVerify for this endpoint
+	SkipVerify *bool `yaml:"skip_verify"`
+}
+
+//
+// Public functions
+//
+
+// LoadEndpointsConfig reads a YAML or JSON file mapping the URLs of webhook
+// endpoints configured on the account to their EndpointOverride. Unknown keys
+// are rejected so that typos don't go unnoticed.
+func LoadEndpointsConfig(path string) (map[string]EndpointOverride, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]EndpointOverride)
+	if err := yaml.UnmarshalStrict(contents, &overrides); err != nil {
+		return nil, fmt.Errorf("Failed to parse the endpoints config %s: %v", path, err)
+	}
+
+	return overrides, nil
+}
+
+//
+// Private functions
+//
+
+// headers returns the override's headers in the `Name: Value` format of
+// forwarded headers, sorted by name
+func (o EndpointOverride) headers() []string {
+	names := make([]string, 0, len(o.Headers))
+	for name := range o.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]string, 0, len(names))
+	for _, name := range names {
+		headers = append(headers, fmt.Sprintf("%s: %s", name, o.Headers[name]))
+	}
+
+	return headers
+}
+
+// unusedEndpointOverrides returns the URLs of the overrides that don't match
+// any of the endpoints, sorted
+func unusedEndpointOverrides(overrides map[string]EndpointOverride, endpointURLs []string) []string {
+	used := convertToMap(endpointURLs)
+
+	var unused []string
+	for url := range overrides {
+		if !used[url] {
+			unused = append(unused, url)
+		}
+	}
+	sort.Strings(unused)
+
+	return unused
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/requests"
+)
+
+func writeEndpointsConfig(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoadEndpointsConfig(t *testing.T) {
+	yamlPath := writeEndpointsConfig(t, "endpoints.yaml", `
+https://planetexpress.com/hooks:
+  headers:
+    Authorization: Bearer sk_local
+  skip_verify: true
+https://planetexpress.com/connect-hooks:
+  connect: false
+  forward_url: http://localhost:5000/connect
+`)
+	jsonPath := writeEndpointsConfig(t, "endpoints.json", `{
+  "https://planetexpress.com/hooks": {"headers": {"Authorization": "Bearer sk_local"}, "skip_verify": true},
+  "https://planetexpress.com/connect-hooks": {"connect": false, "forward_url": "http://localhost:5000/connect"}
+}`)
+
+	yes, no := true, false
+	expected := map[string]EndpointOverride{
+		"https://planetexpress.com/hooks": {
+			Headers:    map[string]string{"Authorization": "Bearer sk_local"},
+			SkipVerify: &yes,
+		},
+		"https://planetexpress.com/connect-hooks": {
+			Connect:    &no,
+			ForwardURL: "http://localhost:5000/connect",
+		},
+	}
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		overrides, err := LoadEndpointsConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, overrides)
+	}
+}
+
+func TestLoadEndpointsConfigRejectsUnknownKeys(t *testing.T) {
+	path := writeEndpointsConfig(t, "endpoints.yaml", `
+https://planetexpress.com/hooks:
+  header:
+    Authorization: Bearer sk_local
+`)
+
+	_, err := LoadEndpointsConfig(path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "field header not found")
+}
+
+func TestBuildEndpointRoutesWithOverrides(t *testing.T) {
+	yes, no := true, false
+	endpointList := requests.WebhookEndpointList{
+		Data: []requests.WebhookEndpoint{
+			{URL: "https://planetexpress.com/hooks", EnabledEvents: []string{"*"}, Status: "enabled"},
+			{URL: "https://planetexpress.com/connect-hooks", Application: "ca_123", EnabledEvents: []string{"*"}, Status: "enabled"},
+			{URL: "https://planetexpress.com/other", EnabledEvents: []string{"*"}, Status: "enabled"},
+		},
+	}
+	overrides := map[string]EndpointOverride{
+		"https://planetexpress.com/hooks": {
+			Headers:    map[string]string{"Authorization": "Bearer sk_local"},
+			SkipVerify: &yes,
+		},
+		"https://planetexpress.com/connect-hooks": {
+			Connect:    &no,
+			ForwardURL: "http://localhost:5000/connect",
+		},
+	}
+
+	output, err := buildEndpointRoutes(endpointList, []string{"http://localhost"}, []string{"http://localhost"}, []string{"Host: hostname"}, []string{"Host: connecthostname"}, overrides, true)
+	require.NoError(t, err)
+	require.Equal(t, []EndpointRoute{
+		{
+			URL:            "http://localhost/hooks",
+			ForwardHeaders: []string{"Host: hostname", "Authorization: Bearer sk_local"},
+			EventTypes:     []string{"*"},
+			Status:         "enabled",
+			SkipVerify:     &yes,
+		},
+		{
+			URL:            "http://localhost:5000/connect",
+			ForwardHeaders: []string{"Host: hostname"},
+			EventTypes:     []string{"*"},
+			Status:         "enabled",
+		},
+		{
+			URL:            "http://localhost/other",
+			ForwardHeaders: []string{"Host: hostname"},
+			EventTypes:     []string{"*"},
+			Status:         "enabled",
+		},
+	}, output)
+}
+
+func TestNewEndpointClientSkipVerifyOverride(t *testing.T) {
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{"https://localhost:4242"},
+	})
+	require.NoError(t, err)
+
+	yes := true
+	client := p.newEndpointClient(EndpointRoute{URL: "https://localhost:4242", SkipVerify: &yes})
+	transport := client.cfg.HTTPClient.Transport.(*http.Transport)
+	require.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+
+	// the proxy's configuration is left untouched
+	require.False(t, p.tlsConfig.InsecureSkipVerify)
+}
+
+func TestInitEndpointsConfigRequiresConfiguredWebhooks(t *testing.T) {
+	_, err := Init(context.Background(), &Config{
+		ForwardURLs:         []string{"http://localhost:4242"},
+		EndpointsConfigFile: "endpoints.yaml",
+	})
+	require.EqualError(t, err, "endpoints_config requires use_configured_webhooks")
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//
+// Public types
+//
+
+// EventPatternExpansion lists the event types matched by a glob pattern
+type EventPatternExpansion struct {
+	Pattern    string
+	EventTypes []string
+}
+
+//
+// Public functions
+//
+
+// MatchingEventTypes returns the known event types matched by the glob
+// pattern, sorted
+func MatchingEventTypes(pattern string) []string {
+	var matches []string
+
+	for event := range validEvents {
+		if event != "*" && eventTypeMatches(pattern, event) {
+			matches = append(matches, event)
+		}
+	}
+
+	sort.Strings(matches)
+
+	return matches
+}
+
+// ExpandEventPatterns replaces the glob patterns, like customer.subscription.*,
+// with the known event types they match. "*" and event types without wildcards
+// are kept as is. A pattern matching no known event type is an error.
+func ExpandEventPatterns(events []string) ([]string, []EventPatternExpansion, error) {
+	var expanded []string
+	var expansions []EventPatternExpansion
+
+	seen := make(map[string]bool)
+	add := func(event string) {
+		if !seen[event] {
+			seen[event] = true
+			expanded = append(expanded, event)
+		}
+	}
+
+	for _, event := range events {
+		if event == "*" || !isEventPattern(event) {
+			add(event)
+			continue
+		}
+
+		matches := MatchingEventTypes(event)
+		if len(matches) == 0 {
+			return nil, nil, fmt.Errorf("%s doesn't match any event type. For a list of all possible events, see: https://stripe.com/docs/api/events/types", event)
+		}
+
+		for _, match := range matches {
+			add(match)
+		}
+		expansions = append(expansions, EventPatternExpansion{Pattern: event, EventTypes: matches})
+	}
+
+	return expanded, expansions, nil
+}
+
+//
+// Private functions
+//
+
+func isEventPattern(event string) bool {
+	return strings.ContainsAny(event, "*?[")
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchingEventTypes(t *testing.T) {
+	require.Equal(t, []string{
+		"customer.subscription.created",
+		"customer.subscription.deleted",
+		"customer.subscription.paused",
+		"customer.subscription.pending_update_applied",
+		"customer.subscription.pending_update_expired",
+		"customer.subscription.resumed",
+		"customer.subscription.trial_will_end",
+		"customer.subscription.updated",
+	}, MatchingEventTypes("customer.subscription.*"))
+
+	require.Empty(t, MatchingEventTypes("nothing.*"))
+}
+
+func TestExpandEventPatterns(t *testing.T) {
+	expanded, expansions, err := ExpandEventPatterns([]string{"charge.captured", "charge.dispute.*", "charge.dispute.closed"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"charge.captured",
+		"charge.dispute.closed",
+		"charge.dispute.created",
+		"charge.dispute.funds_reinstated",
+		"charge.dispute.funds_withdrawn",
+		"charge.dispute.updated",
+	}, expanded)
+	require.Len(t, expansions, 1)
+	require.Equal(t, "charge.dispute.*", expansions[0].Pattern)
+	require.Len(t, expansions[0].EventTypes, 5)
+
+	expanded, expansions, err = ExpandEventPatterns([]string{"*"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, expanded)
+	require.Empty(t, expansions)
+
+	_, _, err = ExpandEventPatterns([]string{"charge.*", "nothing.*"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "nothing.*")
+}
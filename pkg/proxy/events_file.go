@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//
+// Private types
+//
+
+type eventsFileEntry struct {
+	line  int
+	event string
+}
+
+//
+// Private functions
+//
+
+// readEventsFile reads a file listing one event type per line. Blank lines
+// and lines starting with `#` are ignored, as is anything following a `#`.
+func readEventsFile(path string) ([]eventsFileEntry, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]eventsFileEntry, 0)
+
+	line := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+		if idx := strings.Index(text, "#"); idx != -1 {
+			text = text[:idx]
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		entries = append(entries, eventsFileEntry{line: line, event: text})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// mergeEvents appends the events of the events file to the events list,
+// removing duplicates
+func mergeEvents(events []string, entries []eventsFileEntry) []string {
+	merged := make([]string, 0, len(events)+len(entries))
+	seen := make(map[string]bool)
+
+	add := func(event string) {
+		if !seen[event] {
+			seen[event] = true
+			merged = append(merged, event)
+		}
+	}
+
+	for _, event := range events {
+		add(event)
+	}
+
+	for _, entry := range entries {
+		add(entry.event)
+	}
+
+	return merged
+}
+
+// unknownEventsFileEntries describes the entries of the events file that are
+// not valid event types, e.g. `line 3 (charge.sucseeded)`
+func unknownEventsFileEntries(entries []eventsFileEntry) []string {
+	unknown := make([]string, 0)
+
+	for _, entry := range entries {
+		if _, found := validEvents[entry.event]; !found {
+			unknown = append(unknown, fmt.Sprintf("line %d (%s)", entry.line, entry.event))
+		}
+	}
+
+	return unknown
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEventsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.txt")
+	contents := `# billing
+invoice.paid
+invoice.payment_failed  # retried by dunning
+
+charge.sucseeded
+`
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+
+	entries, err := readEventsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []eventsFileEntry{
+		{line: 2, event: "invoice.paid"},
+		{line: 3, event: "invoice.payment_failed"},
+		{line: 5, event: "charge.sucseeded"},
+	}, entries)
+
+	require.Equal(t, []string{"line 5 (charge.sucseeded)"}, unknownEventsFileEntries(entries))
+	require.Equal(t, []string{"charge.succeeded", "invoice.paid", "invoice.payment_failed", "charge.sucseeded"}, mergeEvents([]string{"charge.succeeded", "invoice.paid"}, entries))
+}
+
+func TestInitWithEventsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("invoice.paid\ninvoice.paid\nnot.an_event\n"), 0600))
+
+	var out bytes.Buffer
+	logger := log.New()
+	logger.Out = &out
+
+	p, err := Init(context.Background(), &Config{
+		Events:     []string{"charge.succeeded"},
+		EventsFile: path,
+		Log:        logger,
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"charge.succeeded": true, "invoice.paid": true, "not.an_event": true}, p.events)
+	require.Contains(t, out.String(), "line 3 (not.an_event)")
+
+	emptyPath := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, ioutil.WriteFile(emptyPath, []byte("# nothing\n"), 0600))
+
+	_, err = Init(context.Background(), &Config{EventsFile: emptyPath})
+	require.Error(t, err)
+}
@@ -51,8 +51,10 @@ var validEvents = map[string]bool{
 	"customer.source.updated":                      true,
 	"customer.subscription.created":                true,
 	"customer.subscription.deleted":                true,
+	"customer.subscription.paused":                 true,
 	"customer.subscription.pending_update_applied": true,
 	"customer.subscription.pending_update_expired": true,
+	"customer.subscription.resumed":                true,
 	"customer.subscription.trial_will_end":         true,
 	"customer.subscription.updated":                true,
 	"customer.tax_id.created":                      true,
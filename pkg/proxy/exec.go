@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+//
+// Private constants
+//
+
+// execMethod is the method of the responses of commands, in place of POST
+const execMethod = "EXEC"
+
+//
+// Private types
+//
+
+// eventCommand runs a shell command for each event, with the event payload
+// written to its stdin. At most a given number of commands run at the same
+// time, further events wait for a command to exit.
+type eventCommand struct {
+	command string
+	slots   chan struct{}
+
+	// timeout is how long commands may run before being killed, no limit
+	// when 0
+	timeout time.Duration
+}
+
+//
+// Private functions
+//
+
+func newEventCommand(command string, concurrency int, timeout time.Duration) *eventCommand {
+	return &eventCommand{
+		command: command,
+		slots:   make(chan struct{}, concurrency),
+		timeout: timeout,
+	}
+}
+
+// url is the URL commands are reported with, e.g. `exec:./handle-event.sh`
+func (c *eventCommand) url() *url.URL {
+	return &url.URL{Scheme: "exec", Opaque: c.command}
+}
+
+// run runs the command for the event. Its result is made into a response
+// like endpoints', with a 200 status when the command succeeds and a 500
+// status when it exits with a non-zero code, whose body is the command's
+// output.
+func (c *eventCommand) run(ctx context.Context, evt *StripeEvent, body string) (*http.Response, error) {
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	cmd := shellCommand(c.command)
+	cmd.Stdin = strings.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"STRIPE_EVENT_ID="+evt.ID,
+		"STRIPE_EVENT_TYPE="+evt.Type,
+	)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	statusCode := http.StatusOK
+	status := "200 OK"
+
+	err := runCommand(ctx, cmd)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		statusCode = http.StatusInternalServerError
+		status = fmt.Sprintf("%d %s", statusCode, exitErr)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", c.command, err)
+	}
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     status,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(&output),
+		Request: &http.Request{
+			Method: execMethod,
+			URL:    c.url(),
+		},
+	}, nil
+}
+
+// runCommand runs the command until it exits, or kills it along with the
+// processes it started once the context is done
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+	defer close(exited)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			killCommand(cmd) // #nosec G104
+		case <-exited:
+		}
+	}()
+
+	return cmd.Wait()
+}
@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func skipExecOnWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test commands require a Unix shell")
+	}
+}
+
+func TestEventCommandRun(t *testing.T) {
+	skipExecOnWindows(t)
+
+	path := filepath.Join(t.TempDir(), "event.json")
+	command := newEventCommand(`cat > `+path+` && echo "$STRIPE_EVENT_TYPE $STRIPE_EVENT_ID"`, 1, 0)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	resp, err := command.run(context.Background(), evt, `{"id":"evt_123"}`)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "EXEC", resp.Request.Method)
+	require.Equal(t, "exec:cat > "+path+` && echo "$STRIPE_EVENT_TYPE $STRIPE_EVENT_ID"`, resp.Request.URL.String())
+
+	output, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "charge.succeeded evt_123\n", string(output))
+
+	stdin, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"id":"evt_123"}`, string(stdin))
+}
+
+func TestEventCommandRunFailure(t *testing.T) {
+	skipExecOnWindows(t)
+
+	command := newEventCommand("echo invalid signature >&2; exit 3", 1, 0)
+
+	resp, err := command.run(context.Background(), &StripeEvent{ID: "evt_123"}, "{}")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Equal(t, "500 exit status 3", resp.Status)
+
+	output, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "invalid signature\n", string(output))
+}
+
+func TestEventCommandTimeoutKillsChildren(t *testing.T) {
+	skipExecOnWindows(t)
+
+	// the background sleep keeps the output open unless it is killed too
+	command := newEventCommand("sleep 10 & wait", 1, 100*time.Millisecond)
+
+	start := time.Now()
+	resp, err := command.run(context.Background(), &StripeEvent{ID: "evt_123"}, "{}")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestEventCommandLimitsConcurrency(t *testing.T) {
+	skipExecOnWindows(t)
+
+	command := newEventCommand("sleep 0.2", 2, 0)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := command.run(context.Background(), &StripeEvent{}, "{}")
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// the 4 commands run in 2 rounds of 2
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestForwardEventRunsCommand(t *testing.T) {
+	skipExecOnWindows(t)
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ExecCommand:    "exit 1",
+		ForwardTimeout: time.Second,
+		OutCh:          outCh,
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	destinations := p.destinationsFor(evt)
+	require.Len(t, destinations, 1)
+
+	p.forwardEvent(eventContext{event: evt}, destinations, "{}", map[string]string{})
+
+	el := (<-outCh).(websocket.DataElement)
+	resp := el.Data.(EndpointResponse)
+	require.Equal(t, http.StatusInternalServerError, resp.Resp.StatusCode)
+	require.Equal(t, "exec:exit 1", resp.Resp.Request.URL.String())
+}
+
+func TestExecDeliveriesAreNotRetried(t *testing.T) {
+	skipExecOnWindows(t)
+
+	path := filepath.Join(t.TempDir(), "runs")
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ExecCommand:    "echo run >> " + path + "; exit 1",
+		ForwardTimeout: time.Second,
+		RetryMax:       3,
+		OutCh:          outCh,
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+	el := (<-outCh).(websocket.DataElement)
+	require.Equal(t, http.StatusInternalServerError, el.Data.(EndpointResponse).Resp.StatusCode)
+
+	runs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "run\n", string(runs))
+}
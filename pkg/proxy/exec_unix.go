@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package proxy
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs the command with sh, so that it can have arguments, pipes
+// or redirections. It runs in a process group of its own, for killCommand to
+// kill the processes it started too.
+func shellCommand(command string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	return cmd
+}
+
+// killCommand kills the process group of the command
+func killCommand(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package proxy
+
+import "os/exec"
+
+// shellCommand runs the command with cmd, so that it can have arguments,
+// pipes or redirections
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+// killCommand kills the command
+func killCommand(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//
+// Private types
+//
+
+// headersFile holds the custom headers loaded from a file with one
+// `Name: Value` pair per line. Unlike headers passed on the command line,
+// values are used verbatim and may contain commas. The file can be reloaded
+// while events are being forwarded.
+type headersFile struct {
+	path string
+
+	mu      sync.RWMutex
+	headers map[string]string
+}
+
+//
+// Private functions
+//
+
+func loadHeadersFile(path string) (*headersFile, error) {
+	h := &headersFile{path: path}
+
+	if err := h.reload(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// reload re-reads the headers from the file. The previous headers are kept if
+// the file cannot be read or parsed.
+func (h *headersFile) reload() error {
+	headers, err := readHeadersFile(h.path)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.headers = headers
+
+	return nil
+}
+
+func (h *headersFile) get() map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.headers
+}
+
+func readHeadersFile(path string) (map[string]string, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reg := regexp.MustCompile("[\x00-\x1f]+")
+	headers := make(map[string]string)
+
+	line := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		splitHeader := strings.SplitN(reg.ReplaceAllString(text, ""), ":", 2)
+		if len(splitHeader) != 2 || strings.TrimSpace(splitHeader[0]) == "" {
+			return nil, fmt.Errorf("Invalid header on line %d of %s, expected the format \"Name: Value\"", line, path)
+		}
+
+		headers[strings.TrimSpace(splitHeader[0])] = strings.TrimSpace(splitHeader[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers.txt")
+	contents := `# local auth
+Authorization: Bearer eyJhbGciOi,JIUzI1NiJ9
+Cookie: a=1, b=2
+
+X-Empty:
+`
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+
+	h, err := loadHeadersFile(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"Authorization": "Bearer eyJhbGciOi,JIUzI1NiJ9",
+		"Cookie":        "a=1, b=2",
+		"X-Empty":       "",
+	}, h.get())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("Authorization: Bearer rotated\n"), 0600))
+	require.NoError(t, h.reload())
+	require.Equal(t, map[string]string{"Authorization": "Bearer rotated"}, h.get())
+
+	// invalid files keep the previous headers
+	require.NoError(t, ioutil.WriteFile(path, []byte("Authorization: Bearer rotated\nnot a header\n"), 0600))
+	require.EqualError(t, h.reload(), "Invalid header on line 2 of "+path+", expected the format \"Name: Value\"")
+	require.Equal(t, map[string]string{"Authorization": "Bearer rotated"}, h.get())
+}
+
+func TestCustomHeadersMergesHeadersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("Authorization: from file\nCookie: a=1, b=2\n"), 0600))
+
+	h, err := loadHeadersFile(path)
+	require.NoError(t, err)
+
+	client := NewEndpointClient("http://localhost", []string{"Authorization: from flag"}, false, []string{"*"}, &EndpointConfig{
+		headersFile: h,
+	})
+
+	require.Equal(t, map[string]string{
+		"Authorization": "from flag",
+		"Cookie":        "a=1, b=2",
+	}, client.customHeaders())
+}
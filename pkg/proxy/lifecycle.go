@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+//
+// Public types
+//
+
+// LifecycleEvent describes a step of the life of the proxy or of an event. They
+// are sent when Format is JSON, to be printed as JSON Lines.
+type LifecycleEvent struct {
+	// Type is one of "ready", "event_received", "thin_event_expanded",
+	// "forward_attempt", "forward_result" or "error"
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Secret is the webhook signing secret, set on "ready"
+	Secret string `json:"secret,omitempty"`
+	// APIVersion is the API version events are formatted with, set on "ready"
+	APIVersion string `json:"api_version,omitempty"`
+
+	EventID   string `json:"event_id,omitempty"`
+	EventType string `json:"event_type,omitempty"`
+	// Event is the event payload, set on "event_received"
+	Event json.RawMessage `json:"event,omitempty"`
+	// Paused is true when the event was received while forwarding is paused
+	Paused bool `json:"paused,omitempty"`
+	// Duplicate is true when an event with the same ID was received recently,
+	// in which case it is not forwarded
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Filtered is true when the metadata of the event's object doesn't match
+	// FilterMetadata, in which case it is not forwarded
+	Filtered bool `json:"filtered,omitempty"`
+	// Replayed is true for recent events fetched from the API when the
+	// session is ready, rather than received from Stripe
+	Replayed bool `json:"replayed,omitempty"`
+	// Thin is true for v2 thin events, whose RelatedObject is the object the
+	// event is about
+	Thin          bool           `json:"thin,omitempty"`
+	RelatedObject *RelatedObject `json:"related_object,omitempty"`
+	// RelatedObjectData is the related object of a thin event, set on
+	// "thin_event_expanded"
+	RelatedObjectData json.RawMessage `json:"related_object_data,omitempty"`
+
+	// URL is the endpoint the event is forwarded to
+	URL string `json:"url,omitempty"`
+	// StatusCode is the status code of the endpoint's response, 0 when the
+	// request failed
+	StatusCode int   `json:"status_code,omitempty"`
+	LatencyMs  int64 `json:"latency_ms,omitempty"`
+	// Error describes why the request failed or the proxy stopped
+	Error string `json:"error,omitempty"`
+
+	// Profile and ProfileAccount are the name and account ID of the profile
+	// the event was received for, when listening with several profiles
+	Profile        string `json:"profile,omitempty"`
+	ProfileAccount string `json:"profile_account,omitempty"`
+}
+
+//
+// Private constants
+//
+
+const (
+	lifecycleReady          = "ready"
+	lifecycleEventReceived  = "event_received"
+	lifecycleForwardAttempt = "forward_attempt"
+	lifecycleForwardResult  = "forward_result"
+	lifecycleError          = "error"
+
+	lifecycleThinEventExpanded = "thin_event_expanded"
+)
+
+//
+// Private functions
+//
+
+func (p *Proxy) emitsLifecycle() bool {
+	return strings.ToUpper(p.cfg.Format) == outputFormatJSON
+}
+
+// emitLifecycle sends the lifecycle event, timestamped now, when lifecycle
+// events are enabled
+func (p *Proxy) emitLifecycle(event LifecycleEvent) {
+	if !p.emitsLifecycle() {
+		return
+	}
+
+	event.Time = time.Now().UTC()
+
+	p.cfg.OutCh <- websocket.DataElement{
+		Data: event,
+	}
+}
+
+// compactPayload returns the payload without insignificant whitespace so that
+// it fits on a single line
+func compactPayload(payload string) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(payload)); err != nil {
+		return nil
+	}
+
+	return buf.Bytes()
+}
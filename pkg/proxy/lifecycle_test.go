@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestForwardEventEmitsLifecycle(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{ts.URL},
+		Format:      "json",
+		OutCh:       outCh,
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+	var lifecycle []LifecycleEvent
+	for len(lifecycle) < 2 {
+		if de, ok := (<-outCh).(websocket.DataElement); ok {
+			if event, ok := de.Data.(LifecycleEvent); ok {
+				lifecycle = append(lifecycle, event)
+			}
+		}
+	}
+
+	require.Equal(t, lifecycleForwardAttempt, lifecycle[0].Type)
+	require.Equal(t, ts.URL, lifecycle[0].URL)
+	require.Equal(t, "evt_123", lifecycle[0].EventID)
+
+	require.Equal(t, lifecycleForwardResult, lifecycle[1].Type)
+	require.Equal(t, http.StatusOK, lifecycle[1].StatusCode)
+	require.Empty(t, lifecycle[1].Error)
+}
+
+func TestEmitLifecycleDisabled(t *testing.T) {
+	outCh := make(chan websocket.IElement, 1)
+	p, err := Init(context.Background(), &Config{OutCh: outCh})
+	require.NoError(t, err)
+
+	p.emitLifecycle(LifecycleEvent{Type: lifecycleReady})
+	require.Empty(t, outCh)
+}
+
+func TestCompactPayload(t *testing.T) {
+	require.Equal(t, `{"id":"evt_123","object":"event"}`, string(compactPayload("{\n  \"id\": \"evt_123\",\n  \"object\": \"event\"\n}")))
+	require.Nil(t, compactPayload("not json"))
+}
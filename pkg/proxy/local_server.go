@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+//
+// Private types
+//
+
+// localServer is an HTTP server exposing information about the proxy, such as
+// its status or metrics
+type localServer struct {
+	listener net.Listener
+	server   *http.Server
+}
+
+//
+// Private functions
+//
+
+// newLocalServer binds the server to the port so that an unavailable port is
+// reported before the proxy starts
+func newLocalServer(port int, name string, handler http.Handler) (*localServer, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start the %s server on port %d, is it already in use? %v", name, port, err)
+	}
+
+	return &localServer{
+		listener: listener,
+		server: &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}, nil
+}
+
+func (s *localServer) serve() {
+	// Serve always returns an error, http.ErrServerClosed on shutdown
+	s.server.Serve(s.listener) // #nosec G104
+}
+
+func (s *localServer) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.server.Shutdown(ctx) // #nosec G104
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// Public types
+//
+
+// FilteredEvent is sent in place of the event when it is not forwarded because
+// its object's metadata doesn't match FilterMetadata, if PrintFiltered is set
+type FilteredEvent struct {
+	Event *StripeEvent
+}
+
+//
+// Private functions
+//
+
+// parseMetadataFilters parses `key=value` filters into a map
+func parseMetadataFilters(filters []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(filters))
+
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid metadata filter %s, expected key=value", filter)
+		}
+
+		parsed[parts[0]] = parts[1]
+	}
+
+	return parsed, nil
+}
+
+// matchesMetadata returns true if the metadata of the event's object has all
+// the filtered keys set to their filtered values. Events without an object
+// or metadata, like thin events, never match.
+func matchesMetadata(evt *StripeEvent, filters map[string]string) bool {
+	object, ok := evt.Data["object"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for key, value := range filters {
+		if actual, ok := metadata[key].(string); !ok || actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterMetadata returns true if the event should not be forwarded because its
+// object's metadata doesn't match the metadata being filtered on
+func (p *Proxy) filterMetadata(evt *StripeEvent) bool {
+	if len(p.metadataFilters) == 0 || matchesMetadata(evt, p.metadataFilters) {
+		return false
+	}
+
+	p.cfg.Log.WithFields(log.Fields{
+		"prefix":   "proxy.Proxy.filterMetadata",
+		"event_id": evt.ID,
+	}).Debugf("Received event whose metadata doesn't match the filters, ignoring")
+
+	return true
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadataFilters(t *testing.T) {
+	filters, err := parseMetadataFilters([]string{"tenant=alice", "env=dev=1", "empty="})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"tenant": "alice", "env": "dev=1", "empty": ""}, filters)
+
+	_, err = parseMetadataFilters([]string{"tenant"})
+	require.EqualError(t, err, "Invalid metadata filter tenant, expected key=value")
+
+	_, err = parseMetadataFilters([]string{"=alice"})
+	require.Error(t, err)
+}
+
+func TestMatchesMetadata(t *testing.T) {
+	filters := map[string]string{"tenant": "alice", "env": "dev"}
+
+	evt := func(data map[string]interface{}) *StripeEvent {
+		return &StripeEvent{Data: data}
+	}
+
+	require.True(t, matchesMetadata(evt(map[string]interface{}{
+		"object": map[string]interface{}{
+			"metadata": map[string]interface{}{"tenant": "alice", "env": "dev", "other": "x"},
+		},
+	}), filters))
+
+	require.False(t, matchesMetadata(evt(map[string]interface{}{
+		"object": map[string]interface{}{
+			"metadata": map[string]interface{}{"tenant": "alice"},
+		},
+	}), filters))
+
+	require.False(t, matchesMetadata(evt(map[string]interface{}{
+		"object": map[string]interface{}{
+			"metadata": map[string]interface{}{"tenant": "bob", "env": "dev"},
+		},
+	}), filters))
+
+	require.False(t, matchesMetadata(evt(map[string]interface{}{
+		"object": map[string]interface{}{},
+	}), filters))
+
+	require.False(t, matchesMetadata(evt(map[string]interface{}{"object": "ch_123"}), filters))
+	require.False(t, matchesMetadata(evt(nil), filters))
+}
+
+func TestInitRejectsInvalidMetadataFilter(t *testing.T) {
+	_, err := Init(context.Background(), &Config{FilterMetadata: []string{"tenant"}})
+	require.EqualError(t, err, "Invalid metadata filter tenant, expected key=value")
+}
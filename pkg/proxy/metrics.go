@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//
+// Private variables
+//
+
+// forwardLatencyBuckets are the upper bounds, in seconds, of the forward
+// latency histogram buckets
+var forwardLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var connectionStates = []string{stateConnecting, stateReady, stateReconnecting, stateDone}
+
+//
+// Private types
+//
+
+// proxyMetrics collects metrics in the Prometheus data model. It is safe for
+// concurrent use.
+type proxyMetrics struct {
+	mu sync.Mutex
+
+	eventsReceived map[string]int
+	forwardResults map[string]int
+
+	// latencyBuckets counts the latencies lower than or equal to each of
+	// forwardLatencyBuckets, non-cumulatively
+	latencyBuckets []int
+	latencyCount   int
+	latencySum     float64
+}
+
+//
+// Private functions
+//
+
+func newProxyMetrics() *proxyMetrics {
+	return &proxyMetrics{
+		eventsReceived: make(map[string]int),
+		forwardResults: make(map[string]int),
+		latencyBuckets: make([]int, len(forwardLatencyBuckets)),
+	}
+}
+
+func (m *proxyMetrics) eventReceived(eventType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.eventsReceived[eventType]++
+}
+
+func (m *proxyMetrics) forwardResult(statusCode int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.forwardResults[statusClass(statusCode)]++
+
+	seconds := latency.Seconds()
+	for i, bound := range forwardLatencyBuckets {
+		if seconds <= bound {
+			m.latencyBuckets[i]++
+			break
+		}
+	}
+	m.latencyCount++
+	m.latencySum += seconds
+}
+
+// write writes the metrics in the Prometheus text exposition format
+func (m *proxyMetrics) write(w io.Writer, status Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeHeader(w, "stripe_listen_events_received_total", "counter", "Events received from Stripe, by event type.")
+	for _, eventType := range sortedKeys(m.eventsReceived) {
+		fmt.Fprintf(w, "stripe_listen_events_received_total{event_type=%q} %d\n", eventType, m.eventsReceived[eventType])
+	}
+
+	writeHeader(w, "stripe_listen_forward_results_total", "counter", "Deliveries to local endpoints, by status class.")
+	for _, class := range sortedKeys(m.forwardResults) {
+		fmt.Fprintf(w, "stripe_listen_forward_results_total{status_class=%q} %d\n", class, m.forwardResults[class])
+	}
+
+	writeHeader(w, "stripe_listen_forward_latency_seconds", "histogram", "Time local endpoints took to respond.")
+	cumulative := 0
+	for i, bound := range forwardLatencyBuckets {
+		cumulative += m.latencyBuckets[i]
+		fmt.Fprintf(w, "stripe_listen_forward_latency_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "stripe_listen_forward_latency_seconds_bucket{le=\"+Inf\"} %d\n", m.latencyCount)
+	fmt.Fprintf(w, "stripe_listen_forward_latency_seconds_sum %s\n", strconv.FormatFloat(m.latencySum, 'g', -1, 64))
+	fmt.Fprintf(w, "stripe_listen_forward_latency_seconds_count %d\n", m.latencyCount)
+
+	writeHeader(w, "stripe_listen_websocket_reconnects_total", "counter", "Reconnections to Stripe, including session renewals.")
+	fmt.Fprintf(w, "stripe_listen_websocket_reconnects_total %d\n", status.Reconnects)
+
+	writeHeader(w, "stripe_listen_connection_state", "gauge", "Current state of the connection to Stripe, 1 for the current state.")
+	for _, state := range connectionStates {
+		value := 0
+		if state == status.State {
+			value = 1
+		}
+		fmt.Fprintf(w, "stripe_listen_connection_state{state=%q} %d\n", state, value)
+	}
+}
+
+// newMetricsServer serves the metrics on /metrics
+func newMetricsServer(port int, metrics *proxyMetrics, status func() Status) (*localServer, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.write(w, status())
+	})
+
+	return newLocalServer(port, "metrics", mux)
+}
+
+func writeHeader(w io.Writer, name string, metricType string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
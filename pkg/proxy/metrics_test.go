@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsServer(t *testing.T) {
+	metrics := newProxyMetrics()
+	metrics.eventReceived("charge.succeeded")
+	metrics.eventReceived("charge.succeeded")
+	metrics.eventReceived("invoice.paid")
+	metrics.forwardResult(http.StatusOK, 20*time.Millisecond)
+	metrics.forwardResult(http.StatusInternalServerError, 3*time.Second)
+	metrics.forwardResult(0, 0)
+
+	server, err := newMetricsServer(0, metrics, func() Status {
+		return Status{State: stateReady, Reconnects: 2}
+	})
+	require.NoError(t, err)
+	go server.serve()
+	defer server.shutdown()
+
+	resp, err := http.Get("http://" + server.listener.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"# TYPE stripe_listen_events_received_total counter",
+		`stripe_listen_events_received_total{event_type="charge.succeeded"} 2`,
+		`stripe_listen_events_received_total{event_type="invoice.paid"} 1`,
+		"# TYPE stripe_listen_forward_results_total counter",
+		`stripe_listen_forward_results_total{status_class="2xx"} 1`,
+		`stripe_listen_forward_results_total{status_class="5xx"} 1`,
+		`stripe_listen_forward_results_total{status_class="failed"} 1`,
+		"# TYPE stripe_listen_forward_latency_seconds histogram",
+		`stripe_listen_forward_latency_seconds_bucket{le="0.005"} 1`,
+		`stripe_listen_forward_latency_seconds_bucket{le="0.025"} 2`,
+		`stripe_listen_forward_latency_seconds_bucket{le="5"} 3`,
+		`stripe_listen_forward_latency_seconds_bucket{le="+Inf"} 3`,
+		"stripe_listen_forward_latency_seconds_count 3",
+		"stripe_listen_websocket_reconnects_total 2",
+		"# TYPE stripe_listen_connection_state gauge",
+		`stripe_listen_connection_state{state="ready"} 1`,
+		`stripe_listen_connection_state{state="reconnecting"} 0`,
+	} {
+		require.Contains(t, string(body), line+"\n")
+	}
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/template"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+)
+
+//
+// Public types
+//
+
+// OutputTemplateData is the data the output template is rendered with, once
+// for each received event and once for each delivery result
+type OutputTemplateData struct {
+	// Kind is "event" for received events, "paused" for events received while
+	// forwarding is paused and "response" for delivery results
+	Kind string
+
+	EventID    string
+	Type       string
+	Account    string
+	APIVersion string
+	Livemode   bool
+	RequestID  string
+	// Created is the time the event was created, in seconds since the epoch
+	Created int64
+	// EventURL is the URL of the event in the Dashboard
+	EventURL string
+
+	// Time is when the line is rendered, e.g. {{.Time.Unix}} for an epoch
+	// timestamp
+	Time time.Time
+
+	// ForwardURL and ForwardStatus are the URL and the status code of a
+	// delivery result, and empty for received events
+	ForwardURL    string
+	ForwardStatus int
+
+	// Buffered is the number of events waiting to be forwarded on resume, and
+	// Dropped is true when a paused event won't be forwarded because the
+	// buffer is full
+	Buffered int
+	Dropped  bool
+}
+
+// OutputLine is sent in place of events and endpoint responses when an
+// output template is configured
+type OutputLine struct {
+	Line string
+	// Body is the beginning of the response body of failed deliveries, when
+	// PrintFailedResponses is set
+	Body          []byte
+	BodyTruncated bool
+}
+
+//
+// Public functions
+//
+
+// ParseOutputTemplate parses a text/template rendered with
+// OutputTemplateData, using the color functions of ansi.TemplateFuncs. The
+// template is executed once with empty data so that references to unknown
+// fields are reported right away, along with their position.
+func ParseOutputTemplate(text string, w io.Writer) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(ansi.TemplateFuncs(w)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid output template: %v", err)
+	}
+
+	if err := tmpl.Execute(ioutil.Discard, OutputTemplateData{}); err != nil {
+		return nil, fmt.Errorf("Invalid output template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+//
+// Private constants
+//
+
+const (
+	outputKindEvent    = "event"
+	outputKindPaused   = "paused"
+	outputKindResponse = "response"
+)
+
+//
+// Private functions
+//
+
+func newOutputTemplateData(kind string, evt *StripeEvent) OutputTemplateData {
+	requestID := evt.Request.ID
+	if requestID == "" {
+		if req, err := ExtractRequestData(evt.RequestData); err == nil {
+			requestID = req.ID
+		}
+	}
+
+	return OutputTemplateData{
+		Kind:       kind,
+		EventID:    evt.ID,
+		Type:       evt.Type,
+		Account:    evt.Account,
+		APIVersion: evt.APIVersion,
+		Livemode:   evt.Livemode,
+		RequestID:  requestID,
+		Created:    int64(evt.Created),
+		EventURL:   evt.URLForEventID(),
+		Time:       time.Now(),
+	}
+}
+
+// renderOutput renders the output template, with any execution error in
+// place of the line
+func (p *Proxy) renderOutput(data OutputTemplateData) string {
+	var buf bytes.Buffer
+	if err := p.outputTemplate.Execute(&buf, data); err != nil {
+		return fmt.Sprintf("Failed to render the output template: %v", err)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestParseOutputTemplateErrors(t *testing.T) {
+	_, err := ParseOutputTemplate("{{.EventID", &bytes.Buffer{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid output template: template: output:1:")
+
+	_, err = ParseOutputTemplate("{{.EventID}}\n{{.Status}}", &bytes.Buffer{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "output:2:2")
+	require.Contains(t, err.Error(), "can't evaluate field Status")
+
+	_, err = ParseOutputTemplate("{{blink .Type}}", &bytes.Buffer{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `function "blink" not defined`)
+}
+
+func TestParseOutputTemplate(t *testing.T) {
+	tmpl, err := ParseOutputTemplate("{{.Created}} {{bold .Type}} {{.EventID}} {{.Account}} {{.RequestID}}{{if .ForwardStatus}} {{status .ForwardStatus}}{{end}}", &bytes.Buffer{})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{
+		ID:          "evt_123",
+		Type:        "charge.succeeded",
+		Account:     "acct_123",
+		Created:     1700000000,
+		RequestData: map[string]interface{}{"id": "req_123"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, newOutputTemplateData(outputKindEvent, evt)))
+	require.Equal(t, "1700000000 charge.succeeded evt_123 acct_123 req_123", buf.String())
+
+	data := newOutputTemplateData(outputKindResponse, evt)
+	data.ForwardStatus = 200
+	buf.Reset()
+	require.NoError(t, tmpl.Execute(&buf, data))
+	require.Equal(t, "1700000000 charge.succeeded evt_123 acct_123 req_123 200", buf.String())
+}
+
+func TestProcessEndpointResponseRendersOutputTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs:    []string{ts.URL},
+		OutputTemplate: "{{.Kind}} {{.EventID}} {{.ForwardStatus}} {{.ForwardURL}}",
+		OutCh:          outCh,
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+	line := (<-outCh).(websocket.DataElement).Data.(OutputLine)
+	require.Equal(t, "response evt_123 202 "+ts.URL, line.Line)
+}
+
+func TestInitRejectsInvalidOutputTemplate(t *testing.T) {
+	_, err := Init(context.Background(), &Config{OutputTemplate: "{{.EventId}}"})
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "Invalid output template"))
+}
+
+func TestPausedEventRendersOutputTemplate(t *testing.T) {
+	forwarded := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded <- struct{}{}
+	}))
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs:     []string{ts.URL},
+		OutputTemplate:  "{{.Kind}} {{.EventID}} {{.Buffered}}",
+		PauseBufferSize: 10,
+		DrainTimeout:    time.Second,
+		OutCh:           outCh,
+	})
+	require.NoError(t, err)
+
+	p.Pause()
+	payload := `{"id": "evt_123", "object": "event", "type": "charge.succeeded", "request": {"id": null}}`
+	evt, err := parseStripeEvent(payload)
+	require.NoError(t, err)
+	p.handleWebhookEvent(p.sessions[0], &websocket.WebhookEvent{EventPayload: payload}, evt)
+
+	line := (<-outCh).(websocket.DataElement).Data.(OutputLine)
+	require.Equal(t, "paused evt_123 1", line.Line)
+
+	// the buffered event is forwarded when the proxy stops
+	p.drainDeliveries()
+	select {
+	case <-forwarded:
+	default:
+		t.Fatal("the buffered event was not forwarded")
+	}
+	require.False(t, p.Paused())
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import "sync"
+
+//
+// Public types
+//
+
+// PausedEvent is sent in place of the event when it is received while
+// forwarding is paused
+type PausedEvent struct {
+	Event *StripeEvent
+	// Buffered is the number of events waiting to be forwarded on resume,
+	// including this one unless it was dropped
+	Buffered int
+	// Dropped is true when the event won't be forwarded because the buffer is
+	// full or disabled
+	Dropped bool
+}
+
+//
+// Private types
+//
+
+// heldDelivery is an event held back while forwarding is paused
+type heldDelivery struct {
+	evtCtx       eventContext
+	destinations []*EndpointClient
+	payload      string
+	headers      map[string]string
+}
+
+// pauseState holds back deliveries while forwarding is paused, buffering up to
+// capacity of them. It is safe for concurrent use.
+type pauseState struct {
+	mu       sync.Mutex
+	paused   bool
+	capacity int
+	held     []heldDelivery
+}
+
+//
+// Private functions
+//
+
+func newPauseState(capacity int) *pauseState {
+	return &pauseState{capacity: capacity}
+}
+
+// hold buffers the delivery if forwarding is paused. It returns false when the
+// delivery should go ahead.
+func (s *pauseState) hold(delivery heldDelivery) (held bool, buffered int, dropped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return false, 0, false
+	}
+
+	if len(s.held) >= s.capacity {
+		return true, len(s.held), true
+	}
+
+	s.held = append(s.held, delivery)
+
+	return true, len(s.held), false
+}
+
+func (s *pauseState) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = true
+}
+
+// resume forwards the buffered deliveries, oldest first, and resumes
+// forwarding. Deliveries are forwarded with the lock held so that events
+// received meanwhile are forwarded after them.
+func (s *pauseState) resume(forward func(heldDelivery)) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.held {
+		forward(delivery)
+	}
+
+	n := len(s.held)
+	s.paused = false
+	s.held = nil
+
+	return n
+}
+
+func (s *pauseState) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused
+}
+
+func (s *pauseState) buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.held)
+}
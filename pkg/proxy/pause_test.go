@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseStateHold(t *testing.T) {
+	s := newPauseState(2)
+
+	held, _, _ := s.hold(heldDelivery{payload: "0"})
+	require.False(t, held)
+
+	s.pause()
+	require.True(t, s.isPaused())
+
+	held, buffered, dropped := s.hold(heldDelivery{payload: "1"})
+	require.True(t, held)
+	require.Equal(t, 1, buffered)
+	require.False(t, dropped)
+
+	s.hold(heldDelivery{payload: "2"})
+
+	held, buffered, dropped = s.hold(heldDelivery{payload: "3"})
+	require.True(t, held)
+	require.Equal(t, 2, buffered)
+	require.True(t, dropped)
+	require.Equal(t, 2, s.buffered())
+
+	var deliveries []heldDelivery
+	n := s.resume(func(delivery heldDelivery) {
+		deliveries = append(deliveries, delivery)
+	})
+	require.Equal(t, 2, n)
+	require.False(t, s.isPaused())
+	require.Equal(t, 0, s.buffered())
+	require.Len(t, deliveries, 2)
+	require.Equal(t, "1", deliveries[0].payload)
+	require.Equal(t, "2", deliveries[1].payload)
+}
+
+func TestPauseStateWithoutBuffer(t *testing.T) {
+	s := newPauseState(0)
+	s.pause()
+
+	held, buffered, dropped := s.hold(heldDelivery{payload: "1"})
+	require.True(t, held)
+	require.Equal(t, 0, buffered)
+	require.True(t, dropped)
+	require.Equal(t, 0, s.resume(func(heldDelivery) {
+		require.FailNow(t, "no delivery should be buffered")
+	}))
+}
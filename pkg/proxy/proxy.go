@@ -10,15 +10,20 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/stripe/stripe-cli/pkg/ansi"
 	"github.com/stripe/stripe-cli/pkg/config"
+	"github.com/stripe/stripe-cli/pkg/notify"
 	"github.com/stripe/stripe-cli/pkg/requests"
 	"github.com/stripe/stripe-cli/pkg/stripe"
 	"github.com/stripe/stripe-cli/pkg/stripeauth"
@@ -45,12 +50,35 @@ type EndpointRoute struct {
 
 	// Status is whether or not the endpoint is enabled.
 	Status string
+
+	// SkipVerify overrides Config.SkipVerify for this endpoint, when set.
+	SkipVerify *bool
+}
+
+// EventRoute forwards the event types matching Pattern to URL. Pattern is a
+// glob, e.g. `invoice.*`.
+type EventRoute struct {
+	Pattern string
+	URL     string
 }
 
 // EndpointResponse describes the response to a Stripe event from an endpoint
 type EndpointResponse struct {
 	Event *StripeEvent
 	Resp  *http.Response
+	// Body is the beginning of the response body of non-2xx responses, when
+	// PrintFailedResponses is set
+	Body []byte
+	// BodyTruncated is true when the body was longer than Body
+	BodyTruncated bool
+}
+
+// EndpointsSummary describes the responses to a Stripe event that was forwarded
+// to more than one endpoint. StatusCodes holds one entry per destination, in the
+// order the destinations were configured, with 0 meaning the request failed.
+type EndpointsSummary struct {
+	Event       *StripeEvent
+	StatusCodes []int
 }
 
 // FailedToReadResponseError describes a failure to read the response from an endpoint
@@ -71,34 +99,170 @@ type Config struct {
 	// URL to which requests are sent
 	APIBaseURL string
 
-	// URL to which events are forwarded to
-	ForwardURL string
+	// URLs to which events are forwarded to
+	ForwardURLs []string
 	// Headers to inject when forwarding events
 	ForwardHeaders []string
-	// URL to which Connect events are forwarded to
-	ForwardConnectURL string
+	// URLs to which Connect events are forwarded to
+	ForwardConnectURLs []string
 	// Headers to inject when forwarding Connect events
 	ForwardConnectHeaders []string
+	// ForwardHeadersFile is the path of a file with one `Name: Value` header per
+	// line to inject when forwarding events
+	ForwardHeadersFile string
 	// UseConfiguredWebhooks loads webhooks config from user's account
 	UseConfiguredWebhooks bool
+	// EndpointsConfigFile is the path of a YAML or JSON file customizing how
+	// events are forwarded for each of the webhook endpoints loaded with
+	// UseConfiguredWebhooks. See LoadEndpointsConfig.
+	EndpointsConfigFile string
+	// NoForwardQuery drops the query string of the webhook endpoints loaded
+	// with UseConfiguredWebhooks, instead of appending it to forwarded URLs
+	NoForwardQuery bool
 
 	// EndpointsRoutes is a mapping of local webhook endpoint urls to the events they consume
 	EndpointRoutes []EndpointRoute
+	// EventRoutes sends events matching a pattern to a dedicated URL. Events not
+	// matching any route are forwarded to ForwardURLs instead
+	EventRoutes []EventRoute
 	// List of events to listen and proxy
 	Events []string
+	// EventsFile is the path of a file listing one event type per line, merged
+	// with Events
+	EventsFile string
+	// FilterAccounts restricts the events processed to those belonging to one of
+	// the given connected accounts. All events are processed when empty.
+	FilterAccounts []string
+	// FilterMetadata are `key=value` pairs the metadata of the events' object
+	// must all match for the events to be forwarded
+	FilterMetadata []string
+	// PrintFiltered prints the events not forwarded because of FilterMetadata
+	PrintFiltered bool
+	// SkipEvents are event types, or glob patterns, dropped after they are
+	// received. They apply after Events.
+	SkipEvents []string
+	// OnlyLivemode drops test mode events. Conflicts with OnlyTestmode.
+	OnlyLivemode bool
+	// OnlyTestmode drops live mode events. Conflicts with OnlyLivemode.
+	OnlyTestmode bool
 
 	// WebSocketFeature is the feature specified for the websocket connection
 	WebSocketFeature string
 	// Indicates whether to print full JSON objects to stdout
 	PrintJSON bool
 
-	// Specifies the format to print to stdout.
+	// OutputTemplate is a text/template rendered with OutputTemplateData for
+	// each event and delivery result, sent as OutputLine. See
+	// ParseOutputTemplate.
+	OutputTemplate string
+
+	// Specifies the format to print to stdout. With JSON, LifecycleEvents are
+	// sent for every step of the life of events.
 	Format string
 
 	// Indicates whether to filter events formatted with the default or latest API version
 	UseLatestAPIVersion bool
+	// APIVersion pins the API version events are rendered at, instead of the
+	// account's default or latest version
+	APIVersion string
 	// Indicates whether to skip certificate verification when forwarding webhooks to HTTPS endpoints
 	SkipVerify bool
+	// SkipVerifyHosts skips certificate verification only when forwarding to
+	// these hosts, given as `host` to match any port, or `host:port`
+	SkipVerifyHosts []string
+	// ClientCertFile and ClientKeyFile are the paths of the PEM encoded client
+	// certificate and key presented to HTTPS endpoints requiring mutual TLS
+	ClientCertFile string
+	ClientKeyFile  string
+	// CACertFile is the path of a PEM encoded CA certificate used to verify
+	// HTTPS endpoints instead of the system's root CAs
+	CACertFile string
+	// MaxConcurrentDeliveries bounds the number of events being forwarded at the
+	// same time, further events are queued. Unbounded when 0.
+	MaxConcurrentDeliveries int
+	// RecordTo is the path of a file to which received events are appended as
+	// JSON Lines. Events are not recorded when empty.
+	RecordTo string
+	// RecordOnly records events without forwarding them. Requires RecordTo.
+	RecordOnly bool
+	// LogFile is the path of a file to which the outcome of each delivery is
+	// appended as JSON Lines, regardless of the output's verbosity
+	LogFile string
+	// LogMaxSize is the size in bytes past which the log file is rotated.
+	// Defaults to DefaultLogMaxSize.
+	LogMaxSize int64
+	// LogMaxFiles is the number of rotated log files kept. Defaults to
+	// DefaultLogMaxFiles.
+	LogMaxFiles int
+	// RetryMax is the maximum number of times a delivery failing with a connection
+	// error or a 5xx response is retried. Retries are disabled when 0.
+	RetryMax int
+	// ReconnectInitialDelay is the delay before reconnecting to Stripe after the
+	// websocket connection is lost. Subsequent attempts back off exponentially.
+	ReconnectInitialDelay time.Duration
+	// ReconnectMaxDelay caps the delay between two reconnection attempts
+	ReconnectMaxDelay time.Duration
+	// StatusPort is the port of an HTTP server exposing the readiness and the
+	// status of the proxy on /ready and /status. Disabled when 0.
+	StatusPort int
+	// MetricsPort is the port of an HTTP server exposing Prometheus metrics on
+	// /metrics. Disabled when 0.
+	MetricsPort int
+	// PauseBufferSize is the number of events buffered while forwarding is
+	// paused, to be forwarded on resume. Further events are not forwarded.
+	PauseBufferSize int
+	// PrintFailedResponses captures the beginning of the response body of
+	// non-2xx responses in EndpointResponse
+	PrintFailedResponses bool
+	// ExpandThinEvents fetches the related object of thin events from the API
+	// when they are received
+	ExpandThinEvents bool
+	// ForwardTimeout is how long to wait for local endpoints to respond. There
+	// is no timeout when 0.
+	ForwardTimeout time.Duration
+	// SigningSecrets are used to sign forwarded events instead of the session's
+	// webhook signing secret, with one signature per secret
+	SigningSecrets []string
+	// Dedupe skips forwarding events whose ID was received within DedupeTTL,
+	// remembering up to DedupeSize IDs
+	Dedupe     bool
+	DedupeSize int
+	DedupeTTL  time.Duration
+	// ReplayRecent is the number of the most recent events matching the
+	// listened event types that are fetched from the API and forwarded once the
+	// session is ready, oldest first. Live events are forwarded after them.
+	ReplayRecent int
+	// MaxEventsPerSecond bounds the rate at which events are forwarded. Events
+	// received faster are queued. Unbounded when 0.
+	MaxEventsPerSecond float64
+	// NotifyOnFailure displays a desktop notification for each delivery that
+	// fails or receives a non-2xx response, when notifications are available
+	NotifyOnFailure bool
+	// FailFast stops the proxy with an error after the first delivery that
+	// fails or receives a non-2xx response
+	FailFast bool
+	// Profiles receives the events of several profiles at once, over one
+	// session each, instead of the events of Key
+	Profiles []Profile
+	// StrictProfiles stops the proxy when the session of any of the Profiles
+	// fails, rather than when all of them failed
+	StrictProfiles bool
+	// ExecCommand is a shell command run for each event, with the event
+	// written to its stdin and its ID and type in the STRIPE_EVENT_ID and
+	// STRIPE_EVENT_TYPE environment variables. It is run in addition to
+	// forwarding the event to endpoints. Commands are not run when empty.
+	ExecCommand string
+	// ExecConcurrency bounds the number of commands running at the same time,
+	// 4 when 0
+	ExecConcurrency int
+	// Summary records the deliveries for DeliverySummary, which is empty
+	// otherwise
+	Summary bool
+
+	// DrainTimeout is how long to wait for the deliveries in flight to complete
+	// when the proxy is stopped, before abandoning them. They are abandoned
+	// right away when 0.
+	DrainTimeout time.Duration
 	// The logger used to log messages to stdin/err
 	Log *log.Logger
 	// Force use of unencrypted ws:// protocol instead of wss://
@@ -114,93 +278,136 @@ type Config struct {
 type Proxy struct {
 	cfg *Config
 
-	endpointClients  []*EndpointClient
-	stripeAuthClient *stripeauth.Client
-	webSocketClient  *websocket.Client
+	endpointClients []*EndpointClient
+	routeClients    []*EndpointClient
+	commandClients  []*EndpointClient
+	retrier         *retrier
+	tlsConfig       *tls.Config
+	headersFile     *headersFile
+	stats           *deliveryStats
+	pool            *workerPool
+	limiter         *rateLimiter
+	recorder        *eventRecorder
+	deliveryLog     *deliveryLog
+	status          *sessionStatus
+	pause           *pauseState
+	statusServer    *localServer
+	metrics         *proxyMetrics
+	metricsServer   *localServer
+	inflight        *inflightDeliveries
+	dedupe          *dedupeCache
+	replayGate      *replayGate
+	failed          chan error
+	outputTemplate  *template.Template
+	sessions        []*listenSession
+	readiness       *sessionsReadiness
 
 	// Events is the supported event types for the command
 	events map[string]bool
+
+	// accounts is the set of connected accounts events are accepted from
+	accounts map[string]bool
+	// metadataFilters is the metadata the events' object must match
+	metadataFilters map[string]string
+
+	// livemodeFiltered counts the events dropped by OnlyLivemode or OnlyTestmode
+	livemodeFiltered int64
+
+	// drainResult describes the deliveries in flight when Run returned
+	drainResult DrainResult
 }
 
 const maxConnectAttempts = 3
 
+// defaultExecConcurrency is the default limit of commands running at the same
+// time
+const defaultExecConcurrency = 4
+
+// DefaultForwardTimeout is the default time to wait for local endpoints to
+// respond
+const DefaultForwardTimeout = 30 * time.Second
+
 // Run sets the websocket connection and starts the Goroutines to forward
 // incoming events to the local endpoint.
 func (p *Proxy) Run(ctx context.Context) error {
 	defer close(p.cfg.OutCh)
+	defer p.closeRecorder()
+	defer p.closeDeliveryLog()
 
-	p.cfg.OutCh <- websocket.StateElement{
-		State: websocket.Loading,
+	if p.pool != nil {
+		defer p.pool.close()
 	}
 
-	nAttempts := 0
-
-	for nAttempts < maxConnectAttempts {
-		session, err := p.createSession(ctx)
+	if p.statusServer != nil {
+		go p.statusServer.serve()
+		defer p.statusServer.shutdown()
+	}
 
-		if err != nil {
-			p.cfg.OutCh <- websocket.ErrorElement{
-				Error: fmt.Errorf("Error while authenticating with Stripe: %v", err),
-			}
-			return err
-		}
+	if p.metricsServer != nil {
+		go p.metricsServer.serve()
+		defer p.metricsServer.shutdown()
+	}
 
-		p.webSocketClient = websocket.NewClient(
-			session.WebSocketURL,
-			session.WebSocketID,
-			session.WebSocketAuthorizedFeature,
-			&websocket.Config{
-				Log:               p.cfg.Log,
-				NoWSS:             p.cfg.NoWSS,
-				ReconnectInterval: time.Duration(session.ReconnectDelay) * time.Second,
-				EventHandler:      websocket.EventHandlerFunc(p.processWebhookEvent),
-			},
-		)
+	p.cfg.OutCh <- websocket.StateElement{
+		State: websocket.Loading,
+	}
 
-		go func() {
-			<-p.webSocketClient.Connected()
-			nAttempts = 0
+	sessionsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-			displayedAPIVersion := ""
-			if p.cfg.UseLatestAPIVersion && session.LatestVersion != "" {
-				displayedAPIVersion = "You are using Stripe API Version [" + session.LatestVersion + "]. "
-			} else if !p.cfg.UseLatestAPIVersion && session.DefaultVersion != "" {
-				displayedAPIVersion = "You are using Stripe API Version [" + session.DefaultVersion + "]. "
-			}
+	type sessionResult struct {
+		session *listenSession
+		err     error
+	}
+	results := make(chan sessionResult, len(p.sessions))
 
-			p.cfg.OutCh <- websocket.StateElement{
-				State: websocket.Ready,
-				Data:  []string{displayedAPIVersion, session.Secret},
-			}
-		}()
+	for _, s := range p.sessions {
+		go func(s *listenSession) {
+			results <- sessionResult{session: s, err: p.runSession(sessionsCtx, s)}
+		}(s)
+	}
 
-		go p.webSocketClient.Run(ctx)
-		nAttempts++
+	running := len(p.sessions)
 
+	for running > 0 {
 		select {
 		case <-ctx.Done():
-			p.cfg.OutCh <- &websocket.StateElement{
-				State: websocket.Done,
+			return p.stop()
+		case err := <-p.failed:
+			p.drainDeliveries()
+			p.cfg.OutCh <- websocket.ErrorElement{
+				Error: err,
+			}
+			return err
+		case result := <-results:
+			running--
+			if result.err == nil {
+				// the sessions return once canceled, possibly before
+				// ctx.Done is selected
+				if ctx.Err() != nil {
+					return p.stop()
+				}
+				continue
 			}
-			return nil
-		case <-p.webSocketClient.NotifyExpired:
-			if nAttempts < maxConnectAttempts {
-				p.cfg.OutCh <- &websocket.StateElement{
-					State: websocket.Reconnecting,
+
+			// the other profiles keep receiving events unless StrictProfiles is set
+			if len(p.sessions) == 1 || p.cfg.StrictProfiles || running == 0 {
+				err := result.err
+				if len(p.sessions) > 1 {
+					err = fmt.Errorf("%s: %v", result.session.profile.Name, err)
 				}
-			} else {
-				err := fmt.Errorf("Session expired. Terminating after %d failed attempts to reauthorize", nAttempts)
+				p.drainDeliveries()
 				p.cfg.OutCh <- websocket.ErrorElement{
 					Error: err,
 				}
 				return err
 			}
+
+			p.sessionFailed(result.session, result.err)
 		}
 	}
 
-	if p.webSocketClient != nil {
-		p.webSocketClient.Stop()
-	}
+	p.drainDeliveries()
 
 	log.WithFields(log.Fields{
 		"prefix": "proxy.Proxy.Run",
@@ -209,6 +416,102 @@ func (p *Proxy) Run(ctx context.Context) error {
 	return nil
 }
 
+// stop drains the deliveries once the proxy is canceled, and reports that it
+// is done
+func (p *Proxy) stop() error {
+	p.drainDeliveries()
+	p.cfg.OutCh <- &websocket.StateElement{
+		State: websocket.Done,
+	}
+
+	return nil
+}
+
+// drainDeliveries forwards the events buffered while paused and waits for the
+// deliveries in flight before Run returns, so that none of them is sent to
+// OutCh once it is closed
+func (p *Proxy) drainDeliveries() {
+	// the events buffered while paused were acknowledged, and would be lost
+	if n := p.pause.buffered(); n > 0 {
+		p.cfg.Log.Infof("Forwarding the %d events buffered while paused", n)
+		p.Resume()
+	}
+
+	if n := p.inflight.inflight(); n > 0 && p.cfg.DrainTimeout > 0 {
+		p.cfg.Log.Infof("Waiting up to %s for %d in-flight deliveries to complete", p.cfg.DrainTimeout, n)
+	}
+	p.drainResult = p.inflight.drain(p.cfg.DrainTimeout)
+	p.status.setState(stateDone)
+}
+
+// DeliverySummary returns statistics about the deliveries made to local
+// endpoints so far
+func (p *Proxy) DeliverySummary() DeliverySummary {
+	return p.stats.summary()
+}
+
+// DrainResult returns the number of deliveries that were drained and
+// abandoned when the proxy was stopped. It is only meaningful once Run has
+// returned.
+func (p *Proxy) DrainResult() DrainResult {
+	return p.drainResult
+}
+
+// Status returns the current status of the proxy
+func (p *Proxy) Status() Status {
+	return p.status.status()
+}
+
+// Pause stops forwarding events to local endpoints. Events are still received
+// and buffered up to PauseBufferSize until Resume is called.
+func (p *Proxy) Pause() {
+	p.pause.pause()
+}
+
+// Resume forwards the buffered events, oldest first, and resumes forwarding.
+// It returns the number of buffered events.
+func (p *Proxy) Resume() int {
+	return p.pause.resume(func(delivery heldDelivery) {
+		p.forwardEvent(delivery.evtCtx, delivery.destinations, delivery.payload, delivery.headers)
+	})
+}
+
+// Paused returns whether forwarding is paused
+func (p *Proxy) Paused() bool {
+	return p.pause.isPaused()
+}
+
+// BufferedEvents returns the number of events waiting to be forwarded on
+// resume
+func (p *Proxy) BufferedEvents() int {
+	return p.pause.buffered()
+}
+
+// FlushRecording writes the events recorded with RecordTo that are still
+// buffered. Run flushes them when it returns; this is for exits that don't
+// wait for it.
+func (p *Proxy) FlushRecording() {
+	if p.recorder == nil {
+		return
+	}
+
+	if err := p.recorder.flush(); err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix": "proxy.Proxy.FlushRecording",
+		}).Warnf("Failed to flush recorded events: %v", err)
+	}
+}
+
+// ReloadHeadersFile re-reads the headers file. Events forwarded afterwards use
+// the new headers.
+func (p *Proxy) ReloadHeadersFile() error {
+	if p.headersFile == nil {
+		return errors.New("no headers file was provided")
+	}
+
+	return p.headersFile.reload()
+}
+
 // GetSessionSecret creates a session and returns the webhook signing secret.
 func GetSessionSecret(ctx context.Context, deviceName, key, baseURL string) (string, error) {
 	p, err := Init(ctx, &Config{
@@ -225,7 +528,7 @@ func GetSessionSecret(ctx context.Context, deviceName, key, baseURL string) (str
 		return "", err
 	}
 
-	session, err := p.createSession(ctx)
+	session, err := p.createSession(ctx, p.sessions[0])
 	if err != nil {
 		log.WithFields(log.Fields{
 			"prefix": "proxy.Proxy.GetSessionSecret",
@@ -236,7 +539,7 @@ func GetSessionSecret(ctx context.Context, deviceName, key, baseURL string) (str
 	return session.Secret, nil
 }
 
-func (p *Proxy) createSession(ctx context.Context) (*stripeauth.StripeCLISession, error) {
+func (p *Proxy) createSession(ctx context.Context, s *listenSession) (*stripeauth.StripeCLISession, error) {
 	var session *stripeauth.StripeCLISession
 
 	var err error
@@ -248,13 +551,15 @@ func (p *Proxy) createSession(ctx context.Context) (*stripeauth.StripeCLISession
 		// transient errors that we just need to retry for.
 		for i := 0; i <= 5; i++ {
 			devURLMap := stripeauth.DeviceURLMap{
-				ForwardURL:        p.cfg.ForwardURL,
-				ForwardConnectURL: p.cfg.ForwardConnectURL,
+				ForwardURL:        firstOrEmpty(p.cfg.ForwardURLs),
+				ForwardConnectURL: firstOrEmpty(p.cfg.ForwardConnectURLs),
 			}
 
-			session, err = p.stripeAuthClient.Authorize(ctx, p.cfg.DeviceName, p.cfg.WebSocketFeature, nil, &devURLMap)
+			session, err = s.stripeAuthClient.Authorize(ctx, p.cfg.DeviceName, p.cfg.WebSocketFeature, nil, &devURLMap, p.cfg.APIVersion)
 
-			if err == nil {
+			// Retrying won't make Stripe accept the API version
+			var versionErr stripeauth.InvalidAPIVersionError
+			if err == nil || errors.As(err, &versionErr) {
 				exitCh <- struct{}{}
 				return
 			}
@@ -274,8 +579,17 @@ func (p *Proxy) createSession(ctx context.Context) (*stripeauth.StripeCLISession
 	return session, err
 }
 
-func (p *Proxy) filterWebhookEvent(msg *websocket.WebhookEvent) bool {
-	if msg.Endpoint.APIVersion != nil && !p.cfg.UseLatestAPIVersion {
+func (p *Proxy) filterWebhookEvent(msg *websocket.WebhookEvent, evt *StripeEvent) bool {
+	if p.cfg.APIVersion != "" && getAPIVersionString(msg.Endpoint.APIVersion) != p.cfg.APIVersion {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":      "proxy.Proxy.filterWebhookEvent",
+			"api_version": getAPIVersionString(msg.Endpoint.APIVersion),
+		}).Debugf("Received event with a different API version than the pinned one, ignoring")
+
+		return true
+	}
+
+	if p.cfg.APIVersion == "" && msg.Endpoint.APIVersion != nil && !p.cfg.UseLatestAPIVersion {
 		p.cfg.Log.WithFields(log.Fields{
 			"prefix":      "proxy.Proxy.filterWebhookEvent",
 			"api_version": getAPIVersionString(msg.Endpoint.APIVersion),
@@ -284,7 +598,7 @@ func (p *Proxy) filterWebhookEvent(msg *websocket.WebhookEvent) bool {
 		return true
 	}
 
-	if msg.Endpoint.APIVersion == nil && p.cfg.UseLatestAPIVersion {
+	if p.cfg.APIVersion == "" && msg.Endpoint.APIVersion == nil && p.cfg.UseLatestAPIVersion {
 		p.cfg.Log.WithFields(log.Fields{
 			"prefix": "proxy.Proxy.filterWebhookEvent",
 		}).Debugf("Received event with default API version, ignoring")
@@ -292,9 +606,68 @@ func (p *Proxy) filterWebhookEvent(msg *websocket.WebhookEvent) bool {
 		return true
 	}
 
+	if p.skipsEventType(evt.Type) {
+		// only count the events that would have been processed otherwise
+		if p.events["*"] || p.events[evt.Type] {
+			p.stats.skip(evt.Type)
+		}
+
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":     "proxy.Proxy.filterWebhookEvent",
+			"event_id":   evt.ID,
+			"event_type": evt.Type,
+		}).Debugf("Received event type being skipped, ignoring")
+
+		return true
+	}
+
+	if (p.cfg.OnlyLivemode && !evt.Livemode) || (p.cfg.OnlyTestmode && evt.Livemode) {
+		filtered := atomic.AddInt64(&p.livemodeFiltered, 1)
+
+		mode := "test"
+		if evt.Livemode {
+			mode = "live"
+		}
+
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":   "proxy.Proxy.filterWebhookEvent",
+			"event_id": evt.ID,
+			"filtered": filtered,
+		}).Debugf("Received %s mode event, ignoring", mode)
+
+		return true
+	}
+
 	return false
 }
 
+// skipsEventType returns true if the event type matches one of SkipEvents
+func (p *Proxy) skipsEventType(eventType string) bool {
+	for _, pattern := range p.cfg.SkipEvents {
+		if eventTypeMatches(pattern, eventType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterAccount returns true if the event should be ignored because it does not
+// belong to one of the connected accounts being filtered on.
+func (p *Proxy) filterAccount(evt *StripeEvent) bool {
+	if len(p.accounts) == 0 || p.accounts[evt.Account] {
+		return false
+	}
+
+	p.cfg.Log.WithFields(log.Fields{
+		"prefix":   "proxy.Proxy.filterAccount",
+		"event_id": evt.ID,
+		"account":  evt.Account,
+	}).Debugf("Received event for an account not being filtered on, ignoring")
+
+	return true
+}
+
 // This function outputs the event payload in the format specified.
 // Currently only supports JSON.
 func (p *Proxy) formatOutput(format string, eventPayload string) string {
@@ -314,7 +687,7 @@ func (p *Proxy) formatOutput(format string, eventPayload string) string {
 	}
 }
 
-func (p *Proxy) processWebhookEvent(msg websocket.IncomingMessage) {
+func (p *Proxy) processWebhookEvent(s *listenSession, msg websocket.IncomingMessage) {
 	if msg.WebhookEvent == nil {
 		p.cfg.Log.Debug("WebSocket specified for Webhooks received non-webhook event")
 		return
@@ -322,29 +695,27 @@ func (p *Proxy) processWebhookEvent(msg websocket.IncomingMessage) {
 
 	webhookEvent := msg.WebhookEvent
 
+	// leave the event unacknowledged so that Stripe sends it again later
+	if p.inflight.isDraining() {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":     "proxy.Proxy.processWebhookEvent",
+			"webhook_id": webhookEvent.WebhookID,
+		}).Debug("Shutting down, ignoring webhook event")
+		return
+	}
+
 	p.cfg.Log.WithFields(log.Fields{
 		"prefix":                   "proxy.Proxy.processWebhookEvent",
 		"webhook_id":               webhookEvent.WebhookID,
 		"webhook_converesation_id": webhookEvent.WebhookConversationID,
 	}).Debugf("Processing webhook event")
 
-	var evt StripeEvent
-
-	err := json.Unmarshal([]byte(webhookEvent.EventPayload), &evt)
-	if err != nil {
-		p.cfg.Log.Debug("Received malformed event from Stripe, ignoring")
-		return
-	}
-
-	req, err := ExtractRequestData(evt.RequestData)
-
+	evt, err := parseStripeEvent(webhookEvent.EventPayload)
 	if err != nil {
 		p.cfg.Log.Debug("Received malformed event from Stripe, ignoring")
 		return
 	}
 
-	evt.Request = req
-
 	p.cfg.Log.WithFields(log.Fields{
 		"prefix":                  "proxy.Proxy.processWebhookEvent",
 		"webhook_id":              webhookEvent.WebhookID,
@@ -356,38 +727,334 @@ func (p *Proxy) processWebhookEvent(msg websocket.IncomingMessage) {
 
 	// at this point the message is valid so we can acknowledge it
 	ackMessage := websocket.NewEventAck(webhookEvent.WebhookID, webhookEvent.WebhookConversationID)
-	p.webSocketClient.SendMessage(ackMessage)
+	s.webSocketClient.SendMessage(ackMessage)
+
+	if len(p.sessions) > 1 {
+		evt.Profile = s.profile.Name
+		evt.ProfileAccount = s.profile.Account
+	}
 
-	if p.filterWebhookEvent(webhookEvent) {
+	// events received while recent events are replayed are forwarded after them
+	if p.replayGate != nil && p.replayGate.hold(func() { p.handleWebhookEvent(s, webhookEvent, evt) }) {
+		return
+	}
+
+	p.handleWebhookEvent(s, webhookEvent, evt)
+}
+
+// handleWebhookEvent prints and forwards an acknowledged webhook event
+func (p *Proxy) handleWebhookEvent(s *listenSession, webhookEvent *websocket.WebhookEvent, evt StripeEvent) {
+	if p.filterWebhookEvent(webhookEvent, &evt) || p.filterAccount(&evt) {
 		return
 	}
 
 	evtCtx := eventContext{
+		session:               s,
 		webhookID:             webhookEvent.WebhookID,
 		webhookConversationID: webhookEvent.WebhookConversationID,
 		event:                 &evt,
+		receivedAt:            time.Now(),
 	}
 
 	if p.events["*"] || p.events[evt.Type] {
-		p.cfg.OutCh <- websocket.DataElement{
-			Data:      evt,
-			Marshaled: p.formatOutput(outputFormatJSON, webhookEvent.EventPayload),
+		p.status.eventReceived(evtCtx.receivedAt)
+		p.metrics.eventReceived(evt.Type)
+
+		received := LifecycleEvent{
+			Type:           lifecycleEventReceived,
+			EventID:        evt.ID,
+			EventType:      evt.Type,
+			Event:          compactPayload(webhookEvent.EventPayload),
+			Thin:           evt.IsThin(),
+			RelatedObject:  evt.RelatedObject,
+			Profile:        evt.Profile,
+			ProfileAccount: evt.ProfileAccount,
+		}
+
+		// fetch the related object once the event has been printed
+		if p.cfg.ExpandThinEvents && evt.IsThin() {
+			defer func() { go p.expandThinEvent(context.Background(), s.profile.Key, &evt) }()
+		}
+
+		if p.filterMetadata(&evt) {
+			received.Filtered = true
+			p.emitLifecycle(received)
+			if p.cfg.PrintFiltered {
+				p.cfg.OutCh <- websocket.DataElement{
+					Data:      FilteredEvent{Event: &evt},
+					Marshaled: p.formatOutput(outputFormatJSON, webhookEvent.EventPayload),
+				}
+			}
+			return
+		}
+
+		if p.dedupe != nil && p.dedupe.seen(evt.ID) {
+			received.Duplicate = true
+			p.emitLifecycle(received)
+			p.cfg.OutCh <- websocket.DataElement{
+				Data:      DuplicateEvent{Event: &evt},
+				Marshaled: p.formatOutput(outputFormatJSON, webhookEvent.EventPayload),
+			}
+			return
+		}
+
+		if !p.cfg.RecordOnly {
+			held, buffered, dropped := p.pause.hold(heldDelivery{
+				evtCtx:       evtCtx,
+				destinations: p.destinationsFor(&evt),
+				payload:      webhookEvent.EventPayload,
+				headers:      webhookEvent.HTTPHeaders,
+			})
+			if held {
+				received.Paused = true
+				p.emitLifecycle(received)
+				if p.outputTemplate != nil {
+					data := newOutputTemplateData(outputKindPaused, &evt)
+					data.Buffered = buffered
+					data.Dropped = dropped
+					p.cfg.OutCh <- websocket.DataElement{
+						Data: OutputLine{Line: p.renderOutput(data)},
+					}
+				} else {
+					p.cfg.OutCh <- websocket.DataElement{
+						Data:      PausedEvent{Event: &evt, Buffered: buffered, Dropped: dropped},
+						Marshaled: p.formatOutput(outputFormatJSON, webhookEvent.EventPayload),
+					}
+				}
+				return
+			}
+		}
+
+		p.emitLifecycle(received)
+		if p.outputTemplate != nil {
+			p.cfg.OutCh <- websocket.DataElement{
+				Data: OutputLine{Line: p.renderOutput(newOutputTemplateData(outputKindEvent, &evt))},
+			}
+		} else {
+			p.cfg.OutCh <- websocket.DataElement{
+				Data:      evt,
+				Marshaled: p.formatOutput(outputFormatJSON, webhookEvent.EventPayload),
+			}
+		}
+
+		if p.cfg.RecordOnly {
+			p.recordEvent(evtCtx, webhookEvent.EventPayload, "", 0, 0)
+			return
+		}
+
+		// the raw payload is forwarded so that the signature stays valid
+		p.forwardEvent(evtCtx, p.destinationsFor(&evt), webhookEvent.EventPayload, webhookEvent.HTTPHeaders)
+	}
+}
+
+// destinationsFor returns the endpoints the event should be forwarded to. Event
+// routes take precedence: the regular endpoints only receive events that did
+// not match any route. Commands receive every event.
+func (p *Proxy) destinationsFor(evt *StripeEvent) []*EndpointClient {
+	var destinations []*EndpointClient
+	for _, endpoint := range p.routeClients {
+		if endpoint.SupportsEventType(evt.IsConnect(), evt.Type) {
+			destinations = append(destinations, endpoint)
 		}
+	}
 
+	if len(destinations) == 0 {
 		for _, endpoint := range p.endpointClients {
 			if endpoint.SupportsEventType(evt.IsConnect(), evt.Type) {
-				// TODO: handle errors returned by endpointClients
-				go endpoint.Post(
-					evtCtx,
-					webhookEvent.EventPayload,
-					webhookEvent.HTTPHeaders,
-				)
+				destinations = append(destinations, endpoint)
+			}
+		}
+	}
+
+	// commands run for every event, on top of the endpoints
+	for _, endpoint := range p.commandClients {
+		if endpoint.SupportsEventType(evt.IsConnect(), evt.Type) {
+			destinations = append(destinations, endpoint)
+		}
+	}
+
+	return destinations
+}
+
+// forwardEvent posts the event to every destination, once MaxEventsPerSecond
+// allows it
+func (p *Proxy) forwardEvent(evtCtx eventContext, destinations []*EndpointClient, payload string, headers map[string]string) {
+	if p.limiter == nil || len(destinations) == 0 {
+		p.deliverEvent(evtCtx, destinations, payload, headers)
+		return
+	}
+
+	// the event is in flight while queued, so that it is still forwarded when
+	// the proxy stops
+	p.inflight.add()
+	p.limiter.submit(func() {
+		defer p.inflight.done()
+		p.deliverEvent(evtCtx, destinations, payload, headers)
+	})
+}
+
+// deliverEvent posts the event to every destination concurrently. When the
+// event fans out to more than one destination, a summary of each destination's
+// status code is sent by the last delivery to complete.
+func (p *Proxy) deliverEvent(evtCtx eventContext, destinations []*EndpointClient, payload string, headers map[string]string) {
+	evtCtx.deliveries = newDeliveryStatuses(len(destinations))
+
+	if len(destinations) == 0 {
+		p.recordEvent(evtCtx, payload, "", 0, 0)
+	}
+
+	pending := int32(len(destinations))
+
+	for i, endpoint := range destinations {
+		destCtx := evtCtx
+		destCtx.destination = i
+
+		p.inflight.add()
+
+		deliver := func(endpoint *EndpointClient, destCtx eventContext) {
+			defer p.inflight.done()
+
+			p.emitLifecycle(LifecycleEvent{
+				Type:           lifecycleForwardAttempt,
+				EventID:        destCtx.event.ID,
+				EventType:      destCtx.event.Type,
+				URL:            endpoint.URL,
+				Profile:        destCtx.event.Profile,
+				ProfileAccount: destCtx.event.ProfileAccount,
+			})
+
+			destCtx.sentAt = time.Now()
+
+			err := endpoint.Post(destCtx, payload, headers)
+
+			latency := time.Since(destCtx.sentAt)
+			statusCode := destCtx.deliveries.code(destCtx.destination)
+
+			result := LifecycleEvent{
+				Type:           lifecycleForwardResult,
+				EventID:        destCtx.event.ID,
+				EventType:      destCtx.event.Type,
+				URL:            endpoint.URL,
+				StatusCode:     statusCode,
+				LatencyMs:      latency.Milliseconds(),
+				Profile:        destCtx.event.Profile,
+				ProfileAccount: destCtx.event.ProfileAccount,
+			}
+			if err != nil {
+				result.Error = err.Error()
+				p.sendWebhookResponse(destCtx, endpoint.URL, 0, "", map[string]string{}, result.Error)
+			}
+			p.emitLifecycle(result)
+
+			eventDone := atomic.AddInt32(&pending, -1) == 0
+			if p.cfg.Summary {
+				p.stats.record(destCtx.event.ID, endpoint.URL, statusCode, latency, eventDone)
+			}
+			p.metrics.forwardResult(statusCode, latency)
+			p.recordEvent(destCtx, payload, endpoint.URL, statusCode, latency)
+			p.logDelivery(result)
+
+			if err != nil || statusCode < 200 || statusCode >= 300 {
+				if p.cfg.NotifyOnFailure {
+					go p.notifyFailure(result)
+				}
+				if p.cfg.FailFast {
+					p.failDelivery(result)
+				}
+			}
+
+			if eventDone && len(destinations) > 1 {
+				p.cfg.OutCh <- websocket.DataElement{
+					Data: EndpointsSummary{
+						Event:       destCtx.event,
+						StatusCodes: destCtx.deliveries.codes(),
+					},
+				}
 			}
 		}
+
+		if p.pool != nil {
+			endpoint, destCtx := endpoint, destCtx
+			p.pool.submit(endpoint, func() { deliver(endpoint, destCtx) })
+		} else {
+			go deliver(endpoint, destCtx)
+		}
+	}
+}
+
+// recordEvent appends the event and the outcome of its delivery to the
+// recording file, if recording is enabled
+func (p *Proxy) recordEvent(evtCtx eventContext, payload string, forwardURL string, statusCode int, latency time.Duration) {
+	if p.recorder == nil {
+		return
+	}
+
+	err := p.recorder.record(RecordedEvent{
+		ReceivedAt: evtCtx.receivedAt,
+		ForwardURL: forwardURL,
+		StatusCode: statusCode,
+		LatencyMs:  latency.Milliseconds(),
+		Event:      json.RawMessage(payload),
+	})
+	if err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":   "proxy.Proxy.recordEvent",
+			"event_id": evtCtx.event.ID,
+		}).Warnf("Failed to record event: %v", err)
+	}
+}
+
+// logDelivery keeps the result of a delivery for the status server, and
+// appends it to the log file, if enabled
+func (p *Proxy) logDelivery(result LifecycleEvent) {
+	record := DeliveryRecord{
+		Time:       time.Now(),
+		EventID:    result.EventID,
+		EventType:  result.EventType,
+		URL:        result.URL,
+		StatusCode: result.StatusCode,
+		LatencyMs:  result.LatencyMs,
+		Error:      result.Error,
+	}
+
+	p.status.deliveryDone(record)
+
+	if p.deliveryLog == nil {
+		return
+	}
+
+	p.deliveryLog.write(record)
+}
+
+func (p *Proxy) closeDeliveryLog() {
+	if p.deliveryLog == nil {
+		return
+	}
+
+	if err := p.deliveryLog.close(); err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix": "proxy.Proxy.closeDeliveryLog",
+		}).Warnf("Failed to write the delivery log: %v", err)
+	}
+}
+
+func (p *Proxy) closeRecorder() {
+	if p.recorder == nil {
+		return
+	}
+
+	if err := p.recorder.close(); err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix": "proxy.Proxy.closeRecorder",
+		}).Warnf("Failed to flush recorded events: %v", err)
 	}
 }
 
 func (p *Proxy) processEndpointResponse(evtCtx eventContext, forwardURL string, resp *http.Response) {
+	if evtCtx.deliveries != nil {
+		evtCtx.deliveries.record(evtCtx.destination, resp.StatusCode)
+	}
+
 	buf, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		p.cfg.OutCh <- websocket.ErrorElement{
@@ -398,11 +1065,35 @@ func (p *Proxy) processEndpointResponse(evtCtx eventContext, forwardURL string,
 
 	body := truncate(string(buf), maxBodySize, true)
 
-	p.cfg.OutCh <- websocket.DataElement{
-		Data: EndpointResponse{
-			Event: evtCtx.event,
-			Resp:  resp,
-		},
+	endpointResponse := EndpointResponse{
+		Event: evtCtx.event,
+		Resp:  resp,
+	}
+
+	if p.cfg.PrintFailedResponses && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		endpointResponse.Body = buf
+		if len(buf) > maxFailedResponseBodySize {
+			endpointResponse.Body = buf[:maxFailedResponseBodySize]
+			endpointResponse.BodyTruncated = true
+		}
+	}
+
+	if p.outputTemplate != nil {
+		data := newOutputTemplateData(outputKindResponse, evtCtx.event)
+		data.ForwardURL = forwardURL
+		data.ForwardStatus = resp.StatusCode
+
+		p.cfg.OutCh <- websocket.DataElement{
+			Data: OutputLine{
+				Line:          p.renderOutput(data),
+				Body:          endpointResponse.Body,
+				BodyTruncated: endpointResponse.BodyTruncated,
+			},
+		}
+	} else {
+		p.cfg.OutCh <- websocket.DataElement{
+			Data: endpointResponse,
+		}
 	}
 
 	idx := 0
@@ -417,16 +1108,60 @@ func (p *Proxy) processEndpointResponse(evtCtx eventContext, forwardURL string,
 		}
 	}
 
-	if p.webSocketClient != nil {
-		msg := websocket.NewWebhookResponse(
-			evtCtx.webhookID,
-			evtCtx.webhookConversationID,
-			forwardURL,
-			resp.StatusCode,
-			body,
-			headers,
-		)
-		p.webSocketClient.SendMessage(msg)
+	p.sendWebhookResponse(evtCtx, forwardURL, resp.StatusCode, body, headers, "")
+}
+
+// sendWebhookResponse reports the outcome of a delivery back to Stripe, along
+// with the error when the endpoint didn't respond
+func (p *Proxy) sendWebhookResponse(evtCtx eventContext, forwardURL string, status int, body string, headers map[string]string, deliveryErr string) {
+	// replayed events were not received over the websocket
+	if evtCtx.session == nil || evtCtx.session.webSocketClient == nil || evtCtx.webhookID == "" {
+		return
+	}
+
+	msg := websocket.NewWebhookResponse(
+		evtCtx.webhookID,
+		evtCtx.webhookConversationID,
+		forwardURL,
+		status,
+		body,
+		headers,
+	)
+	msg.Error = truncate(deliveryErr, maxBodySize, true)
+	if !evtCtx.sentAt.IsZero() {
+		msg.LatencyMs = time.Since(evtCtx.sentAt).Milliseconds()
+	}
+
+	evtCtx.session.webSocketClient.SendMessage(msg)
+}
+
+// failDelivery stops the proxy after the first failed delivery, when FailFast
+// is set
+func (p *Proxy) failDelivery(result LifecycleEvent) {
+	err := fmt.Errorf("Failed to deliver %s to %s: %s", result.EventID, result.URL, result.Error)
+	if result.Error == "" {
+		err = fmt.Errorf("Failed to deliver %s to %s: the endpoint responded with status %d", result.EventID, result.URL, result.StatusCode)
+	}
+
+	select {
+	case p.failed <- err:
+	default:
+	}
+}
+
+// notifyFailure displays a desktop notification of a failed delivery. Failing
+// to display it doesn't affect the delivery.
+func (p *Proxy) notifyFailure(result LifecycleEvent) {
+	message := fmt.Sprintf("%s to %s failed: %s", result.EventType, result.URL, result.Error)
+	if result.Error == "" {
+		message = fmt.Sprintf("%s to %s returned %d", result.EventType, result.URL, result.StatusCode)
+	}
+
+	if err := notify.Send("Webhook delivery failed", message); err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":   "proxy.Proxy.notifyFailure",
+			"event_id": result.EventID,
+		}).Debugf("Failed to display a notification: %v", err)
 	}
 }
 
@@ -441,31 +1176,71 @@ func Init(ctx context.Context, cfg *Config) (*Proxy, error) {
 	}
 
 	// validate forward-urls args
-	if cfg.UseConfiguredWebhooks && len(cfg.ForwardURL) > 0 {
-		if strings.HasPrefix(cfg.ForwardURL, "/") {
-			return nil, errors.New("forward_to cannot be a relative path when loading webhook endpoints from the API")
+	if cfg.UseConfiguredWebhooks && len(cfg.ForwardURLs) > 0 {
+		for _, forwardURL := range cfg.ForwardURLs {
+			if strings.HasPrefix(forwardURL, "/") {
+				return nil, errors.New("forward_to cannot be a relative path when loading webhook endpoints from the API")
+			}
 		}
-		if strings.HasPrefix(cfg.ForwardConnectURL, "/") {
-			return nil, errors.New("forward_connect_to cannot be a relative path when loading webhook endpoints from the API")
+		for _, forwardConnectURL := range cfg.ForwardConnectURLs {
+			if strings.HasPrefix(forwardConnectURL, "/") {
+				return nil, errors.New("forward_connect_to cannot be a relative path when loading webhook endpoints from the API")
+			}
 		}
-	} else if cfg.UseConfiguredWebhooks && len(cfg.ForwardURL) == 0 {
+	} else if cfg.UseConfiguredWebhooks && len(cfg.ForwardURLs) == 0 {
 		return nil, errors.New("load_from_webhooks_api requires a location to forward to with forward_to")
 	}
 
+	for _, forwardURL := range append(append([]string{}, cfg.ForwardURLs...), cfg.ForwardConnectURLs...) {
+		if socketPath, _, ok := parseUnixSocketURL(forwardURL); ok && socketPath == "" {
+			return nil, fmt.Errorf("%s is missing the path of the socket, expected the format unix:///path/to/app.sock:/webhook", forwardURL)
+		}
+	}
+
+	for _, pattern := range cfg.SkipEvents {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Invalid skipped event pattern \"%s\": %v", pattern, err)
+		}
+	}
+
+	if cfg.OnlyLivemode && cfg.OnlyTestmode {
+		return nil, errors.New("only_livemode and only_testmode cannot be used together")
+	}
+
+	for _, event := range cfg.Events {
+		if _, found := validEvents[event]; !found {
+			cfg.Log.Infof("Warning: You're attempting to listen for \"%s\", which isn't a valid event\n", event)
+		}
+	}
+
+	if cfg.EventsFile != "" {
+		entries, err := readEventsFile(cfg.EventsFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the events file: %v", err)
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("The events file %s doesn't list any events", cfg.EventsFile)
+		}
+
+		if unknown := unknownEventsFileEntries(entries); len(unknown) > 0 {
+			cfg.Log.Infof("Warning: %s lists event types which aren't valid events: %s\n", cfg.EventsFile, strings.Join(unknown, ", "))
+		}
+
+		cfg.Events = mergeEvents(cfg.Events, entries)
+	}
+
 	// if no events are passed, listen for all events
 	if len(cfg.Events) == 0 {
 		cfg.Events = []string{"*"}
-	} else {
-		for _, event := range cfg.Events {
-			if _, found := validEvents[event]; !found {
-				cfg.Log.Infof("Warning: You're attempting to listen for \"%s\", which isn't a valid event\n", event)
-			}
-		}
+	}
+
+	if err := validateEventRoutes(cfg.EventRoutes, cfg.Events); err != nil {
+		return nil, err
 	}
 
 	// build from --forward-to urls if --forward-connect-to was not provided
-	if len(cfg.ForwardConnectURL) == 0 {
-		cfg.ForwardConnectURL = cfg.ForwardURL
+	if len(cfg.ForwardConnectURLs) == 0 {
+		cfg.ForwardConnectURLs = cfg.ForwardURLs
 	}
 	if len(cfg.ForwardConnectHeaders) == 0 {
 		cfg.ForwardConnectHeaders = cfg.ForwardHeaders
@@ -473,71 +1248,289 @@ func Init(ctx context.Context, cfg *Config) (*Proxy, error) {
 
 	// build endpoint routes
 	var endpointRoutes []EndpointRoute
+	if cfg.EndpointsConfigFile != "" && !cfg.UseConfiguredWebhooks {
+		return nil, errors.New("endpoints_config requires use_configured_webhooks")
+	}
+
 	if cfg.UseConfiguredWebhooks {
+		var overrides map[string]EndpointOverride
+		if cfg.EndpointsConfigFile != "" {
+			var err error
+			overrides, err = LoadEndpointsConfig(cfg.EndpointsConfigFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// build from user's API config
 		endpoints := getEndpointsFromAPI(ctx, cfg.Key, cfg.APIBaseURL)
 		if len(endpoints.Data) == 0 {
 			return nil, errors.New("You have not defined any webhook endpoints on your account. Go to the Stripe Dashboard to add some: https://dashboard.stripe.com/test/webhooks")
 		}
+		// each endpoint has its own secret, so one set of secrets can't be right
+		// for all of them
+		if len(cfg.SigningSecrets) > 0 && len(endpoints.Data) > 1 {
+			return nil, fmt.Errorf("signing_secrets cannot be used with the %d webhook endpoints configured on your account, since each has its own secret", len(endpoints.Data))
+		}
+		endpointURLs := make([]string, 0, len(endpoints.Data))
+		for _, endpoint := range endpoints.Data {
+			endpointURLs = append(endpointURLs, endpoint.URL)
+		}
+		if unused := unusedEndpointOverrides(overrides, endpointURLs); len(unused) > 0 {
+			cfg.Log.Infof("Warning: %s configures endpoints which aren't configured on your account: %s\n", cfg.EndpointsConfigFile, strings.Join(unused, ", "))
+		}
+
 		var err error
-		endpointRoutes, err = buildEndpointRoutes(endpoints, parseURL(cfg.ForwardURL), parseURL(cfg.ForwardConnectURL), cfg.ForwardHeaders, cfg.ForwardConnectHeaders)
+		endpointRoutes, err = buildEndpointRoutes(endpoints, parseURLs(cfg.ForwardURLs), parseURLs(cfg.ForwardConnectURLs), cfg.ForwardHeaders, cfg.ForwardConnectHeaders, overrides, !cfg.NoForwardQuery)
 		if err != nil {
 			return nil, err
 		}
-	} else if len(cfg.ForwardURL) > 0 {
+	} else if len(cfg.ForwardURLs) > 0 {
 		// non-connect endpoints
-		endpointRoutes = append(endpointRoutes, EndpointRoute{
-			URL:            parseURL(cfg.ForwardURL),
-			ForwardHeaders: cfg.ForwardHeaders,
-			Connect:        false,
-			EventTypes:     cfg.Events,
-		})
+		for _, forwardURL := range cfg.ForwardURLs {
+			endpointRoutes = append(endpointRoutes, EndpointRoute{
+				URL:            parseURL(forwardURL),
+				ForwardHeaders: cfg.ForwardHeaders,
+				Connect:        false,
+				EventTypes:     cfg.Events,
+			})
+		}
 
 		// connect endpoints
-		endpointRoutes = append(endpointRoutes, EndpointRoute{
-			URL:            parseURL(cfg.ForwardConnectURL),
-			ForwardHeaders: cfg.ForwardConnectHeaders,
-			Connect:        true,
-			EventTypes:     cfg.Events,
-		})
+		for _, forwardConnectURL := range cfg.ForwardConnectURLs {
+			endpointRoutes = append(endpointRoutes, EndpointRoute{
+				URL:            parseURL(forwardConnectURL),
+				ForwardHeaders: cfg.ForwardConnectHeaders,
+				Connect:        true,
+				EventTypes:     cfg.Events,
+			})
+		}
 	}
 
 	p := &Proxy{
-		cfg: cfg,
-		stripeAuthClient: stripeauth.NewClient(cfg.Key, &stripeauth.Config{
-			Log:        cfg.Log,
-			APIBaseURL: cfg.APIBaseURL,
-		}),
-		events: convertToMap(cfg.Events),
+		cfg:      cfg,
+		events:   convertToMap(cfg.Events),
+		accounts: convertToMap(cfg.FilterAccounts),
+		stats:    newDeliveryStats(),
+		status:   newSessionStatus(),
+		inflight: newInflightDeliveries(),
+		metrics:  newProxyMetrics(),
+		pause:    newPauseState(cfg.PauseBufferSize),
+		failed:   make(chan error, 1),
+	}
+
+	profiles := cfg.Profiles
+	if len(profiles) == 0 {
+		profiles = []Profile{{Key: cfg.Key}}
+	}
+	for _, profile := range profiles {
+		p.sessions = append(p.sessions, &listenSession{
+			profile: profile,
+			stripeAuthClient: stripeauth.NewClient(profile.Key, &stripeauth.Config{
+				Log:        cfg.Log,
+				APIBaseURL: cfg.APIBaseURL,
+			}),
+		})
+	}
+	p.readiness = newSessionsReadiness(len(p.sessions))
+
+	metadataFilters, err := parseMetadataFilters(cfg.FilterMetadata)
+	if err != nil {
+		return nil, err
+	}
+	p.metadataFilters = metadataFilters
+
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.tlsConfig = tlsConfig
+
+	if cfg.ForwardHeadersFile != "" {
+		headersFile, err := loadHeadersFile(cfg.ForwardHeadersFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the headers file: %v", err)
+		}
+		p.headersFile = headersFile
+	}
+
+	if cfg.MaxConcurrentDeliveries > 0 {
+		p.pool = newWorkerPool(cfg.MaxConcurrentDeliveries, cfg.Log)
+	}
+
+	if cfg.NotifyOnFailure && !notify.Available() {
+		cfg.Log.WithFields(log.Fields{
+			"prefix": "proxy.Proxy.Init",
+		}).Debug("Desktop notifications are not available, failed deliveries won't be notified")
+	}
+
+	if cfg.MaxEventsPerSecond > 0 {
+		p.limiter = newRateLimiter(p.inflight.ctx, cfg.MaxEventsPerSecond, 1, cfg.Log)
+	}
+
+	if cfg.OutputTemplate != "" {
+		tmpl, err := ParseOutputTemplate(cfg.OutputTemplate, os.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		p.outputTemplate = tmpl
+	}
+
+	if cfg.Dedupe {
+		size, ttl := cfg.DedupeSize, cfg.DedupeTTL
+		if size <= 0 {
+			size = DefaultDedupeSize
+		}
+		if ttl <= 0 {
+			ttl = DefaultDedupeTTL
+		}
+		p.dedupe = newDedupeCache(size, ttl)
+	}
+
+	if cfg.ReplayRecent > 0 {
+		p.replayGate = newReplayGate(len(p.sessions))
+	}
+
+	if cfg.RetryMax > 0 {
+		p.retrier = newRetrier(cfg.RetryMax)
+	}
+
+	if cfg.RecordOnly && cfg.RecordTo == "" {
+		return nil, errors.New("record_only requires a file to record to with record_to")
+	}
+
+	if cfg.RecordTo != "" {
+		recorder, err := newEventRecorder(cfg.RecordTo)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open the file to record events to: %v", err)
+		}
+		p.recorder = recorder
+	}
+
+	if cfg.LogFile != "" {
+		if cfg.LogMaxSize <= 0 {
+			cfg.LogMaxSize = DefaultLogMaxSize
+		}
+		if cfg.LogMaxFiles <= 0 {
+			cfg.LogMaxFiles = DefaultLogMaxFiles
+		}
+
+		deliveryLog, err := newDeliveryLog(cfg.LogFile, cfg.LogMaxSize, cfg.LogMaxFiles)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open the log file: %v", err)
+		}
+		p.deliveryLog = deliveryLog
 	}
 
 	for _, route := range endpointRoutes {
 		// append to endpointClients
-		p.endpointClients = append(p.endpointClients, NewEndpointClient(
-			route.URL,
-			route.ForwardHeaders,
-			route.Connect,
-			route.EventTypes,
-			&EndpointConfig{
-				HTTPClient: &http.Client{
-					CheckRedirect: func(req *http.Request, via []*http.Request) error {
-						return http.ErrUseLastResponse
-					},
-					Timeout: defaultTimeout,
-					Transport: &http.Transport{
-						TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.SkipVerify},
-					},
-				},
-				Log:             p.cfg.Log,
-				ResponseHandler: EndpointResponseHandlerFunc(p.processEndpointResponse),
-				OutCh:           p.cfg.OutCh,
-			},
-		))
+		p.endpointClients = append(p.endpointClients, p.newEndpointClient(route))
+	}
+
+	for _, route := range buildEventRoutes(cfg.EventRoutes, cfg.ForwardHeaders, cfg.ForwardConnectHeaders) {
+		p.routeClients = append(p.routeClients, p.newEndpointClient(route))
+	}
+
+	if cfg.ExecCommand != "" {
+		concurrency := cfg.ExecConcurrency
+		if concurrency <= 0 {
+			concurrency = defaultExecConcurrency
+		}
+
+		// one client for each of normal and Connect events, sharing the limit of
+		// concurrent commands
+		command := newEventCommand(cfg.ExecCommand, concurrency, cfg.ForwardTimeout)
+		for _, connect := range []bool{false, true} {
+			p.commandClients = append(p.commandClients, p.newCommandClient(command, connect))
+		}
+	}
+
+	// The listeners are opened last, for none of the steps above to leave
+	// them open when they fail
+	if cfg.StatusPort != 0 {
+		statusServer, err := newStatusServer(cfg.StatusPort, p.Status, p.status.recentDeliveries, p.deliverCustomEvent, cfg.Log)
+		if err != nil {
+			p.closeRecorder()
+			p.closeDeliveryLog()
+			return nil, err
+		}
+		p.statusServer = statusServer
+	}
+
+	if cfg.MetricsPort != 0 {
+		metricsServer, err := newMetricsServer(cfg.MetricsPort, p.metrics, p.Status)
+		if err != nil {
+			if p.statusServer != nil {
+				p.statusServer.listener.Close()
+			}
+			p.closeRecorder()
+			p.closeDeliveryLog()
+			return nil, err
+		}
+		p.metricsServer = metricsServer
 	}
 
 	return p, nil
 }
 
+// newCommandClient returns the client running the command of --exec for the
+// events. Its deliveries are not retried, for a failed command not to be run
+// again with the side effects it already had.
+func (p *Proxy) newCommandClient(command *eventCommand, connect bool) *EndpointClient {
+	return NewEndpointClient(
+		command.url().String(),
+		nil,
+		connect,
+		p.cfg.Events,
+		&EndpointConfig{
+			Log:             p.cfg.Log,
+			ResponseHandler: EndpointResponseHandlerFunc(p.processEndpointResponse),
+			OutCh:           p.cfg.OutCh,
+			command:         command,
+			ctx:             p.inflight.ctx,
+		},
+	)
+}
+
+func (p *Proxy) newEndpointClient(route EndpointRoute) *EndpointClient {
+	skipVerify := p.tlsConfig.InsecureSkipVerify || skipsVerifyForHost(p.cfg.SkipVerifyHosts, route.URL)
+	if route.SkipVerify != nil {
+		skipVerify = *route.SkipVerify
+	}
+
+	// Each transport has a copy of its own, since net/http sets the
+	// NextProtos of the configuration when it negotiates HTTP/2
+	tlsConfig := p.tlsConfig.Clone()
+	tlsConfig.InsecureSkipVerify = skipVerify
+
+	if skipVerify && strings.HasPrefix(route.URL, "https://") && !isLocalURL(route.URL) {
+		p.cfg.Log.Warnf("Certificate verification is disabled for %s, which is not a local endpoint. Anyone between you and it can read and alter the events forwarded to it", route.URL)
+	}
+
+	return NewEndpointClient(
+		route.URL,
+		route.ForwardHeaders,
+		route.Connect,
+		route.EventTypes,
+		&EndpointConfig{
+			HTTPClient: &http.Client{
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+				Timeout:   p.cfg.ForwardTimeout,
+				Transport: newEndpointTransport(route.URL, tlsConfig),
+			},
+			Log:             p.cfg.Log,
+			ResponseHandler: EndpointResponseHandlerFunc(p.processEndpointResponse),
+			OutCh:           p.cfg.OutCh,
+			retrier:         p.retrier,
+			headersFile:     p.headersFile,
+			signingSecrets:  p.cfg.SigningSecrets,
+			ctx:             p.inflight.ctx,
+		},
+	)
+}
+
 // ExtractRequestData takes an interface with request data from a Stripe event payload
 // and properly parses it into a StripeRequest struct before returning it
 func ExtractRequestData(data interface{}) (StripeRequest, error) {
@@ -581,9 +1574,62 @@ func ExtractRequestData(data interface{}) (StripeRequest, error) {
 //
 
 type eventContext struct {
+	// session is the session the event was received over
+	session               *listenSession
 	webhookID             string
 	webhookConversationID string
 	event                 *StripeEvent
+	receivedAt            time.Time
+	// sentAt is when the event was first sent to the destination
+	sentAt time.Time
+
+	// destination is the index of the endpoint this context was forwarded to
+	// within deliveries
+	destination int
+	deliveries  *deliveryStatuses
+}
+
+// deliveryStatuses collects the status code returned by each destination an
+// event was forwarded to. It is shared by the goroutines posting the event.
+type deliveryStatuses struct {
+	mu          sync.Mutex
+	statusCodes []int
+}
+
+func newDeliveryStatuses(n int) *deliveryStatuses {
+	return &deliveryStatuses{
+		statusCodes: make([]int, n),
+	}
+}
+
+func (d *deliveryStatuses) record(destination int, statusCode int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if destination >= 0 && destination < len(d.statusCodes) {
+		d.statusCodes[destination] = statusCode
+	}
+}
+
+func (d *deliveryStatuses) code(destination int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if destination < 0 || destination >= len(d.statusCodes) {
+		return 0
+	}
+
+	return d.statusCodes[destination]
+}
+
+func (d *deliveryStatuses) codes() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	codes := make([]int, len(d.statusCodes))
+	copy(codes, d.statusCodes)
+
+	return codes
 }
 
 //
@@ -597,6 +1643,10 @@ const (
 	maxHeaderValueSize = 200
 )
 
+// maxFailedResponseBodySize is how much of the body of failed responses is
+// captured with PrintFailedResponses
+const maxFailedResponseBodySize = 4 * 1024
+
 const outputFormatJSON = "JSON"
 
 //
@@ -641,6 +1691,10 @@ func isUTF8ContinuationByte(b byte) bool {
 // parseURL parses the potentially incomplete URL provided in the configuration
 // and returns a full URL
 func parseURL(url string) string {
+	if isUnixSocketURL(url) {
+		return url
+	}
+
 	_, err := strconv.Atoi(url)
 	if err == nil {
 		// If the input is just a number, assume it's a port number
@@ -660,6 +1714,24 @@ func parseURL(url string) string {
 	return url
 }
 
+// parseURLs applies parseURL to every URL in the list
+func parseURLs(urls []string) []string {
+	parsed := make([]string, 0, len(urls))
+	for _, u := range urls {
+		parsed = append(parsed, parseURL(u))
+	}
+
+	return parsed
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
 func getEndpointsFromAPI(ctx context.Context, secretKey, apiBaseURL string) requests.WebhookEndpointList {
 	if apiBaseURL == "" {
 		apiBaseURL = stripe.DefaultAPIBaseURL
@@ -668,7 +1740,7 @@ func getEndpointsFromAPI(ctx context.Context, secretKey, apiBaseURL string) requ
 	return requests.WebhookEndpointsList(ctx, apiBaseURL, "2019-03-14", secretKey, &config.Profile{})
 }
 
-func buildEndpointRoutes(endpoints requests.WebhookEndpointList, forwardURL, forwardConnectURL string, forwardHeaders []string, forwardConnectHeaders []string) ([]EndpointRoute, error) {
+func buildEndpointRoutes(endpoints requests.WebhookEndpointList, forwardURLs, forwardConnectURLs []string, forwardHeaders []string, forwardConnectHeaders []string, overrides map[string]EndpointOverride, forwardQuery bool) ([]EndpointRoute, error) {
 	endpointRoutes := make([]EndpointRoute, 0)
 
 	for _, endpoint := range endpoints.Data {
@@ -679,54 +1751,162 @@ func buildEndpointRoutes(endpoints requests.WebhookEndpointList, forwardURL, for
 
 		u, err := url.Parse(endpoint.URL)
 		// Silently skip over invalid paths
-		if err == nil {
-			// Since webhooks in the dashboard may have a more generic url, only extract
-			// the path. We'll use this with `localhost` or with the `--forward-to` flag
-			if endpoint.Application == "" {
-				url, err := buildForwardURL(forwardURL, u)
-				if err != nil {
-					return nil, err
-				}
-				endpointRoutes = append(endpointRoutes, EndpointRoute{
-					URL:            url,
-					ForwardHeaders: forwardHeaders,
-					Connect:        false,
-					EventTypes:     endpoint.EnabledEvents,
-					Status:         endpoint.Status,
-				})
-			} else {
-				url, err := buildForwardURL(forwardConnectURL, u)
+		if err != nil {
+			continue
+		}
+
+		override := overrides[endpoint.URL]
+
+		connect := endpoint.Application != ""
+		if override.Connect != nil {
+			connect = *override.Connect
+		}
+
+		baseURLs, headers := forwardURLs, forwardHeaders
+		if connect {
+			baseURLs, headers = forwardConnectURLs, forwardConnectHeaders
+		}
+		if len(override.Headers) > 0 {
+			headers = append(append([]string{}, headers...), override.headers()...)
+		}
+
+		// Since webhooks in the dashboard may have a more generic url, only extract
+		// the path and query. We'll use this with `localhost` or with the
+		// `--forward-to` flag
+		var urls []string
+		if override.ForwardURL != "" {
+			urls = []string{override.ForwardURL}
+		} else {
+			for _, baseURL := range baseURLs {
+				url, err := buildForwardURL(baseURL, u, forwardQuery)
 				if err != nil {
 					return nil, err
 				}
-				endpointRoutes = append(endpointRoutes, EndpointRoute{
-					URL:            url,
-					ForwardHeaders: forwardConnectHeaders,
-					Connect:        true,
-					EventTypes:     endpoint.EnabledEvents,
-				})
+				urls = append(urls, url)
 			}
 		}
+
+		for _, url := range urls {
+			route := EndpointRoute{
+				URL:            url,
+				ForwardHeaders: headers,
+				Connect:        connect,
+				EventTypes:     endpoint.EnabledEvents,
+				SkipVerify:     override.SkipVerify,
+			}
+			if !connect {
+				route.Status = endpoint.Status
+			}
+			endpointRoutes = append(endpointRoutes, route)
+		}
 	}
 
 	return endpointRoutes, nil
 }
 
-func buildForwardURL(forwardURL string, destination *url.URL) (string, error) {
+// buildEventRoutes builds a normal and a Connect endpoint route for each event
+// route, both limited to the event types matching the route's pattern.
+func buildEventRoutes(eventRoutes []EventRoute, forwardHeaders []string, forwardConnectHeaders []string) []EndpointRoute {
+	endpointRoutes := make([]EndpointRoute, 0, 2*len(eventRoutes))
+
+	for _, route := range eventRoutes {
+		endpointRoutes = append(endpointRoutes, EndpointRoute{
+			URL:            parseURL(route.URL),
+			ForwardHeaders: forwardHeaders,
+			Connect:        false,
+			EventTypes:     []string{route.Pattern},
+		})
+		endpointRoutes = append(endpointRoutes, EndpointRoute{
+			URL:            parseURL(route.URL),
+			ForwardHeaders: forwardConnectHeaders,
+			Connect:        true,
+			EventTypes:     []string{route.Pattern},
+		})
+	}
+
+	return endpointRoutes
+}
+
+// validateEventRoutes ensures that every route can receive events given the
+// list of events being listened for.
+func validateEventRoutes(eventRoutes []EventRoute, events []string) error {
+	listensToAll := false
+	for _, event := range events {
+		if event == "*" {
+			listensToAll = true
+		}
+	}
+
+	for _, route := range eventRoutes {
+		if _, err := path.Match(route.Pattern, ""); err != nil {
+			return fmt.Errorf("Invalid route pattern \"%s\": %v", route.Pattern, err)
+		}
+
+		if listensToAll {
+			continue
+		}
+
+		matched := false
+		for _, event := range events {
+			if eventTypeMatches(route.Pattern, event) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("Route \"%s\" does not match any of the events being listened for: %s", route.Pattern, strings.Join(events, ", "))
+		}
+	}
+
+	return nil
+}
+
+func buildForwardURL(forwardURL string, destination *url.URL, forwardQuery bool) (string, error) {
+	query := ""
+	if forwardQuery {
+		query = destination.RawQuery
+	}
+
+	if socketPath, requestURL, ok := parseUnixSocketURL(forwardURL); ok {
+		r, err := url.Parse(requestURL)
+		if err != nil {
+			return "", fmt.Errorf("Provided forward url cannot be parsed: %s", forwardURL)
+		}
+
+		return buildUnixSocketURL(socketPath, strings.TrimSuffix(r.Path, "/")+destination.Path+joinQueries(r.RawQuery, query)), nil
+	}
+
 	f, err := url.Parse(forwardURL)
 	if err != nil {
 		return "", fmt.Errorf("Provided forward url cannot be parsed: %s", forwardURL)
 	}
 
 	return fmt.Sprintf(
-		"%s://%s%s%s",
+		"%s://%s%s%s%s",
 		f.Scheme,
 		f.Host,
 		strings.TrimSuffix(f.Path, "/"), // avoids having a double "//"
 		destination.Path,
+		joinQueries(f.RawQuery, query),
 	), nil
 }
 
+// joinQueries joins the raw query strings, keeping their order, into a query
+// string starting with "?", or an empty string if both are empty
+func joinQueries(first string, second string) string {
+	switch {
+	case first == "" && second == "":
+		return ""
+	case first == "":
+		return "?" + second
+	case second == "":
+		return "?" + first
+	default:
+		return "?" + first + "&" + second
+	}
+}
+
 func getAPIVersionString(str *string) string {
 	var APIVersion string
 
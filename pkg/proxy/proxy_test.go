@@ -2,8 +2,15 @@ package proxy
 
 import (
 	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -28,11 +35,80 @@ func TestFilterWebhookEvent(t *testing.T) {
 		},
 	}
 
-	require.False(t, proxyUseDefault.filterWebhookEvent(evtDefault))
-	require.True(t, proxyUseDefault.filterWebhookEvent(evtLatest))
+	require.False(t, proxyUseDefault.filterWebhookEvent(evtDefault, &StripeEvent{}))
+	require.True(t, proxyUseDefault.filterWebhookEvent(evtLatest, &StripeEvent{}))
 
-	require.True(t, proxyUseLatest.filterWebhookEvent(evtDefault))
-	require.False(t, proxyUseLatest.filterWebhookEvent(evtLatest))
+	require.True(t, proxyUseLatest.filterWebhookEvent(evtDefault, &StripeEvent{}))
+	require.False(t, proxyUseLatest.filterWebhookEvent(evtLatest, &StripeEvent{}))
+}
+
+func TestFilterWebhookEventPinnedAPIVersion(t *testing.T) {
+	proxyPinned, _ := Init(context.Background(), &Config{APIVersion: "2023-10-16"})
+
+	pinned := "2023-10-16"
+	other := "2019-05-04"
+
+	require.False(t, proxyPinned.filterWebhookEvent(&websocket.WebhookEvent{Endpoint: websocket.WebhookEndpoint{APIVersion: &pinned}}, &StripeEvent{}))
+	require.True(t, proxyPinned.filterWebhookEvent(&websocket.WebhookEvent{Endpoint: websocket.WebhookEndpoint{APIVersion: &other}}, &StripeEvent{}))
+	require.True(t, proxyPinned.filterWebhookEvent(&websocket.WebhookEvent{}, &StripeEvent{}))
+}
+
+func TestFilterWebhookEventLivemode(t *testing.T) {
+	proxyAll, _ := Init(context.Background(), &Config{})
+	proxyLive, _ := Init(context.Background(), &Config{OnlyLivemode: true})
+	proxyTest, _ := Init(context.Background(), &Config{OnlyTestmode: true})
+
+	msg := &websocket.WebhookEvent{}
+	liveEvt := &StripeEvent{Livemode: true}
+	testEvt := &StripeEvent{Livemode: false}
+
+	require.False(t, proxyAll.filterWebhookEvent(msg, liveEvt))
+	require.False(t, proxyAll.filterWebhookEvent(msg, testEvt))
+
+	require.False(t, proxyLive.filterWebhookEvent(msg, liveEvt))
+	require.True(t, proxyLive.filterWebhookEvent(msg, testEvt))
+
+	require.True(t, proxyTest.filterWebhookEvent(msg, liveEvt))
+	require.False(t, proxyTest.filterWebhookEvent(msg, testEvt))
+	require.Equal(t, int64(1), proxyTest.livemodeFiltered)
+
+	_, err := Init(context.Background(), &Config{OnlyLivemode: true, OnlyTestmode: true})
+	require.Error(t, err)
+}
+
+func TestFilterWebhookEventSkipEvents(t *testing.T) {
+	p, err := Init(context.Background(), &Config{
+		Events:     []string{"charge.succeeded", "charge.dispute.created", "balance.available"},
+		SkipEvents: []string{"balance.available", "charge.dispute.*"},
+	})
+	require.NoError(t, err)
+
+	msg := &websocket.WebhookEvent{}
+
+	require.False(t, p.filterWebhookEvent(msg, &StripeEvent{Type: "charge.succeeded"}))
+	require.True(t, p.filterWebhookEvent(msg, &StripeEvent{Type: "balance.available"}))
+	require.True(t, p.filterWebhookEvent(msg, &StripeEvent{Type: "charge.dispute.created"}))
+	require.True(t, p.filterWebhookEvent(msg, &StripeEvent{Type: "charge.dispute.closed"}))
+	require.True(t, p.filterWebhookEvent(msg, &StripeEvent{Type: "balance.available"}))
+
+	// charge.dispute.closed isn't listened for, so it isn't counted
+	require.Equal(t, map[string]int{"balance.available": 2, "charge.dispute.created": 1}, p.DeliverySummary().Skipped)
+
+	_, err = Init(context.Background(), &Config{SkipEvents: []string{"["}})
+	require.Error(t, err)
+}
+
+func TestFilterAccount(t *testing.T) {
+	proxyAll, _ := Init(context.Background(), &Config{})
+	proxyFiltered, _ := Init(context.Background(), &Config{FilterAccounts: []string{"acct_123", "acct_456"}})
+
+	require.False(t, proxyAll.filterAccount(&StripeEvent{Account: "acct_789"}))
+	require.False(t, proxyAll.filterAccount(&StripeEvent{}))
+
+	require.False(t, proxyFiltered.filterAccount(&StripeEvent{Account: "acct_123"}))
+	require.False(t, proxyFiltered.filterAccount(&StripeEvent{Account: "acct_456"}))
+	require.True(t, proxyFiltered.filterAccount(&StripeEvent{Account: "acct_789"}))
+	require.True(t, proxyFiltered.filterAccount(&StripeEvent{}))
 }
 
 func TestTruncate(t *testing.T) {
@@ -74,7 +150,7 @@ func TestBuildEndpointRoutes(t *testing.T) {
 		Data: []requests.WebhookEndpoint{endpointNormal, endpointConnect, endpointDisabled},
 	}
 
-	output, err := buildEndpointRoutes(endpointList, localURL, localURL, []string{"Host: hostname"}, []string{"Host: connecthostname"})
+	output, err := buildEndpointRoutes(endpointList, []string{localURL}, []string{localURL}, []string{"Host: hostname"}, []string{"Host: connecthostname"}, nil, true)
 	require.NoError(t, err)
 	require.Equal(t, 2, len(output))
 	require.Equal(t, "http://localhost/hooks", output[0].URL)
@@ -87,6 +163,225 @@ func TestBuildEndpointRoutes(t *testing.T) {
 	require.Equal(t, []string{"*"}, output[1].EventTypes)
 }
 
+func TestBuildEndpointRoutesMultipleForwardURLs(t *testing.T) {
+	endpointList := requests.WebhookEndpointList{
+		Data: []requests.WebhookEndpoint{
+			{
+				URL:           "https://planetexpress.com/hooks",
+				EnabledEvents: []string{"*"},
+				Status:        "enabled",
+			},
+		},
+	}
+
+	output, err := buildEndpointRoutes(endpointList, []string{"http://localhost:3000", "http://localhost:4000"}, []string{"http://localhost:3000"}, nil, nil, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(output))
+	require.Equal(t, "http://localhost:3000/hooks", output[0].URL)
+	require.Equal(t, "http://localhost:4000/hooks", output[1].URL)
+}
+
+func TestForwardEventFansOut(t *testing.T) {
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer okServer.Close()
+
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failServer.Close()
+
+	outCh := make(chan websocket.IElement)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{okServer.URL, failServer.URL},
+		OutCh:       outCh,
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	evtCtx := eventContext{event: evt}
+
+	var destinations []*EndpointClient
+	for _, endpoint := range p.endpointClients {
+		if endpoint.SupportsEventType(false, evt.Type) {
+			destinations = append(destinations, endpoint)
+		}
+	}
+	require.Equal(t, 2, len(destinations))
+
+	go p.forwardEvent(evtCtx, destinations, "{}", map[string]string{})
+
+	for el := range outCh {
+		de, ok := el.(websocket.DataElement)
+		if !ok {
+			continue
+		}
+		if summary, ok := de.Data.(EndpointsSummary); ok {
+			require.Equal(t, "evt_123", summary.Event.ID)
+			require.Equal(t, []int{http.StatusOK, http.StatusInternalServerError}, summary.StatusCodes)
+			break
+		}
+	}
+}
+
+func TestForwardEventRecords(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{ts.URL},
+		RecordTo:    path,
+		OutCh:       outCh,
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), `{"id":"evt_123"}`, map[string]string{})
+
+	// the response is sent to the output channel before the delivery is recorded
+	<-outCh
+	require.Eventually(t, func() bool {
+		p.recorder.mu.Lock()
+		defer p.recorder.mu.Unlock()
+		return p.recorder.w.Buffered() > 0
+	}, time.Second, time.Millisecond)
+	p.closeRecorder()
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var recorded RecordedEvent
+	require.NoError(t, json.Unmarshal(contents, &recorded))
+	require.Equal(t, ts.URL, recorded.ForwardURL)
+	require.Equal(t, http.StatusAccepted, recorded.StatusCode)
+	require.Equal(t, `{"id":"evt_123"}`, string(recorded.Event))
+}
+
+func TestProcessEndpointResponseCapturesFailedBody(t *testing.T) {
+	outCh := make(chan websocket.IElement, 2)
+	p, err := Init(context.Background(), &Config{
+		PrintFailedResponses: true,
+		OutCh:                outCh,
+	})
+	require.NoError(t, err)
+
+	evtCtx := eventContext{event: &StripeEvent{ID: "evt_123"}}
+
+	p.processEndpointResponse(evtCtx, "", &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"error":"bad signature"}`)),
+	})
+	resp := (<-outCh).(websocket.DataElement).Data.(EndpointResponse)
+	require.Equal(t, `{"error":"bad signature"}`, string(resp.Body))
+	require.False(t, resp.BodyTruncated)
+
+	p.processEndpointResponse(evtCtx, "", &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Body:       ioutil.NopCloser(strings.NewReader(strings.Repeat("a", maxFailedResponseBodySize+1))),
+	})
+	resp = (<-outCh).(websocket.DataElement).Data.(EndpointResponse)
+	require.Len(t, resp.Body, maxFailedResponseBodySize)
+	require.True(t, resp.BodyTruncated)
+
+	p.processEndpointResponse(evtCtx, "", &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("ok")),
+	})
+	resp = (<-outCh).(websocket.DataElement).Data.(EndpointResponse)
+	require.Nil(t, resp.Body)
+}
+
+func TestRecordOnlyRequiresRecordTo(t *testing.T) {
+	_, err := Init(context.Background(), &Config{RecordOnly: true})
+	require.Error(t, err)
+}
+
+func TestForwardEventFailFast(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{ts.URL},
+		FailFast:    true,
+		OutCh:       make(chan websocket.IElement, 10),
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+	select {
+	case err := <-p.failed:
+		require.EqualError(t, err, "Failed to deliver evt_123 to "+ts.URL+": the endpoint responded with status 500")
+	case <-time.After(time.Second):
+		require.Fail(t, "the failed delivery was not reported")
+	}
+}
+
+func TestForwardEventWithoutFailFast(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{ts.URL},
+		OutCh:       make(chan websocket.IElement, 10),
+	})
+	require.NoError(t, err)
+
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+	p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+	require.True(t, p.inflight.wait(time.Second))
+
+	require.Empty(t, p.failed)
+}
+
+func TestDestinationsForEventRoutes(t *testing.T) {
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{"http://localhost:3000/events"},
+		EventRoutes: []EventRoute{
+			{Pattern: "invoice.*", URL: "localhost:4000/billing"},
+			{Pattern: "checkout.session.*", URL: "localhost:5000/checkout"},
+		},
+	})
+	require.NoError(t, err)
+
+	destinations := p.destinationsFor(&StripeEvent{Type: "invoice.paid"})
+	require.Equal(t, 1, len(destinations))
+	require.Equal(t, "http://localhost:4000/billing", destinations[0].URL)
+
+	destinations = p.destinationsFor(&StripeEvent{Type: "checkout.session.completed", Account: "acct_123"})
+	require.Equal(t, 1, len(destinations))
+	require.Equal(t, "http://localhost:5000/checkout", destinations[0].URL)
+
+	destinations = p.destinationsFor(&StripeEvent{Type: "charge.succeeded"})
+	require.Equal(t, 1, len(destinations))
+	require.Equal(t, "http://localhost:3000/events", destinations[0].URL)
+
+	p, err = Init(context.Background(), &Config{
+		EventRoutes: []EventRoute{{Pattern: "invoice.*", URL: "localhost:4000/billing"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(p.destinationsFor(&StripeEvent{Type: "charge.succeeded"})))
+}
+
+func TestValidateEventRoutes(t *testing.T) {
+	routes := []EventRoute{{Pattern: "invoice.*", URL: "localhost:4000"}}
+
+	require.NoError(t, validateEventRoutes(routes, []string{"*"}))
+	require.NoError(t, validateEventRoutes(routes, []string{"charge.succeeded", "invoice.paid"}))
+	require.Error(t, validateEventRoutes(routes, []string{"charge.succeeded"}))
+	require.Error(t, validateEventRoutes([]EventRoute{{Pattern: "invoice.[", URL: "localhost:4000"}}, []string{"*"}))
+}
+
 func TestBuildForwardURL(t *testing.T) {
 	f, err := url.Parse("http://example.com/foo/bar.php")
 	require.NoError(t, err)
@@ -103,7 +398,7 @@ func TestBuildForwardURL(t *testing.T) {
 	for _, pair := range expectedInputPairs {
 		expected := pair[0]
 		input := pair[1]
-		forwardURL, err := buildForwardURL(input, f)
+		forwardURL, err := buildForwardURL(input, f, true)
 		require.NoError(t, err)
 		require.Equal(t, expected, forwardURL)
 	}
@@ -123,12 +418,39 @@ func TestBuildForwardURL(t *testing.T) {
 	for _, pair := range expectedInputPairs {
 		expected := pair[0]
 		input := pair[1]
-		forwardURL, err := buildForwardURL(input, f)
+		forwardURL, err := buildForwardURL(input, f, true)
 		require.NoError(t, err)
 		require.Equal(t, expected, forwardURL)
 	}
 }
 
+func TestBuildForwardURLQuery(t *testing.T) {
+	withQuery, err := url.Parse("https://example.com/hooks?tenant=abc")
+	require.NoError(t, err)
+	withoutQuery, err := url.Parse("https://example.com/hooks")
+	require.NoError(t, err)
+
+	tests := []struct {
+		forwardURL   string
+		destination  *url.URL
+		forwardQuery bool
+		expected     string
+	}{
+		{"http://localhost:3000", withQuery, true, "http://localhost:3000/hooks?tenant=abc"},
+		{"http://localhost:3000", withQuery, false, "http://localhost:3000/hooks"},
+		{"http://localhost:3000", withoutQuery, true, "http://localhost:3000/hooks"},
+		{"http://localhost:3000/stripe?env=dev", withQuery, true, "http://localhost:3000/stripe/hooks?env=dev&tenant=abc"},
+		{"http://localhost:3000/stripe?env=dev", withQuery, false, "http://localhost:3000/stripe/hooks?env=dev"},
+		{"http://localhost:3000/stripe?env=dev", withoutQuery, true, "http://localhost:3000/stripe/hooks?env=dev"},
+		{"unix:///tmp/app.sock:/stripe", withQuery, true, "unix:///tmp/app.sock:/stripe/hooks?tenant=abc"},
+	}
+	for _, test := range tests {
+		forwardURL, err := buildForwardURL(test.forwardURL, test.destination, test.forwardQuery)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, forwardURL)
+	}
+}
+
 func TestParseUrl(t *testing.T) {
 	require.Equal(t, "http://example.com/foo", parseURL("http://example.com/foo"))
 	require.Equal(t, "https://example.com/foo", parseURL("https://example.com/foo"))
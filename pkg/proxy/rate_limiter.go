@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// Private types
+//
+
+// rateLimiter paces the forwarding of events with a token bucket. Events
+// received faster than the rate are queued and forwarded in the order they
+// arrived, none are dropped. It is safe for concurrent use.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	log   *log.Logger
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	// ctx is canceled to forward the queued events right away, like when the
+	// proxy stops
+	ctx context.Context
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	queue  []func()
+	notify chan struct{}
+}
+
+//
+// Private functions
+//
+
+// newRateLimiter returns a limiter forwarding up to rate events per second,
+// after an initial burst of up to burst events
+func newRateLimiter(ctx context.Context, rate float64, burst int, logger *log.Logger) *rateLimiter {
+	l := &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		log:    logger,
+		now:    time.Now,
+		after:  time.After,
+		ctx:    ctx,
+		tokens: float64(burst),
+		notify: make(chan struct{}, 1),
+	}
+	l.last = l.now()
+
+	go l.dispatch()
+
+	return l
+}
+
+// submit queues forward until the rate allows it. It never blocks.
+func (l *rateLimiter) submit(forward func()) {
+	l.mu.Lock()
+	l.queue = append(l.queue, forward)
+	queued := len(l.queue)
+	l.mu.Unlock()
+
+	if queued > 1 {
+		l.log.WithFields(log.Fields{
+			"prefix": "proxy.rateLimiter",
+			"queued": queued,
+		}).Debugf("%d events are waiting to be forwarded at %g events per second", queued, l.rate)
+	}
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch forwards the queued events one at a time, in order, each as soon as
+// a token is available
+func (l *rateLimiter) dispatch() {
+	for range l.notify {
+		for {
+			l.mu.Lock()
+			if len(l.queue) == 0 {
+				l.mu.Unlock()
+				break
+			}
+			forward := l.queue[0]
+			l.mu.Unlock()
+
+			if delay := l.reserve(); delay > 0 {
+				select {
+				case <-l.after(delay):
+				case <-l.ctx.Done():
+				}
+			}
+
+			l.mu.Lock()
+			l.queue = l.queue[1:]
+			l.mu.Unlock()
+
+			forward()
+		}
+	}
+}
+
+// reserve takes a token from the bucket and returns how long to wait before
+// it is available. Tokens may be borrowed ahead, so that consecutive
+// reservations are spaced by the rate.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
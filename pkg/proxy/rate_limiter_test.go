@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock advances its time by the delays waited on instead of sleeping
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func newTestRateLimiter(ctx context.Context, rate float64, burst int, clock *fakeClock) *rateLimiter {
+	l := newRateLimiter(ctx, rate, burst, &log.Logger{Out: ioutil.Discard})
+	l.now = clock.Now
+	l.after = clock.After
+	l.last = clock.Now()
+
+	return l
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	l := newTestRateLimiter(context.Background(), 2, 1, clock)
+
+	require.Equal(t, time.Duration(0), l.reserve())
+	require.Equal(t, 500*time.Millisecond, l.reserve())
+	require.Equal(t, time.Second, l.reserve())
+
+	// the borrowed tokens are paid back before new ones accumulate
+	clock.Advance(2 * time.Second)
+	require.Equal(t, time.Duration(0), l.reserve())
+	require.Equal(t, 500*time.Millisecond, l.reserve())
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	l := newTestRateLimiter(context.Background(), 10, 3, clock)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, time.Duration(0), l.reserve())
+	}
+	require.Equal(t, 100*time.Millisecond, l.reserve())
+}
+
+func TestRateLimiterPacesEventsInOrder(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	l := newTestRateLimiter(context.Background(), 4, 1, clock)
+	start := clock.Now()
+
+	var mu sync.Mutex
+	var forwarded []int
+	var elapsed []time.Duration
+	for i := 0; i < 5; i++ {
+		i := i
+		l.submit(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			forwarded = append(forwarded, i)
+			elapsed = append(elapsed, clock.Now().Sub(start))
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(forwarded) == 5
+	}, time.Second, time.Millisecond)
+
+	require.Equal(t, []int{0, 1, 2, 3, 4}, forwarded)
+	require.Equal(t, []time.Duration{
+		0,
+		250 * time.Millisecond,
+		500 * time.Millisecond,
+		750 * time.Millisecond,
+		time.Second,
+	}, elapsed)
+}
+
+func TestRateLimiterForwardsQueuedEventsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	l := newRateLimiter(ctx, 0.001, 1, &log.Logger{Out: ioutil.Discard})
+
+	var mu sync.Mutex
+	forwarded := 0
+	for i := 0; i < 3; i++ {
+		l.submit(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			forwarded++
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return forwarded == 3
+	}, time.Second, time.Millisecond)
+}
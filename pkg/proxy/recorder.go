@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+//
+// Public types
+//
+
+// RecordedEvent is a line of a recording written with the RecordTo option. It
+// describes an event received by the proxy and, if it was forwarded, the
+// response from the local endpoint.
+type RecordedEvent struct {
+	// ReceivedAt is when the proxy received the event from Stripe
+	ReceivedAt time.Time `json:"received_at"`
+
+	// ForwardURL is the URL the event was forwarded to, if any
+	ForwardURL string `json:"forward_url,omitempty"`
+
+	// StatusCode is the status code returned by the endpoint, or 0 if the
+	// request failed or the event was not forwarded
+	StatusCode int `json:"status_code,omitempty"`
+
+	// LatencyMs is how long the endpoint took to respond, in milliseconds
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+
+	// Event is the event payload as sent by Stripe
+	Event json.RawMessage `json:"event"`
+}
+
+//
+// Private types
+//
+
+// eventRecorder appends recorded events to a file as JSON Lines. Events are
+// buffered until flush or close. It is safe for concurrent use.
+type eventRecorder struct {
+	mu     sync.Mutex
+	file   *os.File
+	w      *bufio.Writer
+	closed bool
+}
+
+//
+// Private functions
+//
+
+func newEventRecorder(path string) (*eventRecorder, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventRecorder{
+		file: file,
+		w:    bufio.NewWriter(file),
+	}, nil
+}
+
+func (r *eventRecorder) record(evt RecordedEvent) error {
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Deliveries may still complete after the proxy shut down
+	if r.closed {
+		return nil
+	}
+
+	if _, err := r.w.Write(line); err != nil {
+		return err
+	}
+
+	return r.w.WriteByte('\n')
+}
+
+// flush writes the buffered events to the file, for exits that skip close
+func (r *eventRecorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	return r.w.Flush()
+}
+
+// close flushes any buffered events and closes the file
+func (r *eventRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+
+	return r.file.Close()
+}
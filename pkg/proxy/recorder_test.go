@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	recorder, err := newEventRecorder(path)
+	require.NoError(t, err)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := recorder.record(RecordedEvent{
+				ReceivedAt: time.Now(),
+				ForwardURL: "http://localhost:4242/webhook",
+				StatusCode: 200,
+				LatencyMs:  12,
+				Event:      json.RawMessage(`{"id":"evt_123"}`),
+			})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, recorder.close())
+
+	// records after closing are dropped
+	require.NoError(t, recorder.record(RecordedEvent{Event: json.RawMessage(`{}`)}))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var evt RecordedEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &evt))
+		require.Equal(t, 200, evt.StatusCode)
+		require.Equal(t, `{"id":"evt_123"}`, string(evt.Event))
+		lines++
+	}
+	require.Equal(t, 10, lines)
+}
+
+func TestEventRecorderBuffersUntilFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	recorder, err := newEventRecorder(path)
+	require.NoError(t, err)
+	defer recorder.close()
+
+	require.NoError(t, recorder.record(RecordedEvent{Event: json.RawMessage(`{"id":"evt_123"}`)}))
+
+	// the event stays buffered
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	require.NoError(t, recorder.flush())
+
+	data, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"evt_123"`)
+}
@@ -0,0 +1,222 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// Public types
+//
+
+// ReplayConfig provides the configuration for replaying recorded events
+type ReplayConfig struct {
+	// URL to which events are delivered
+	ForwardURL string
+	// Headers to inject when delivering events
+	ForwardHeaders []string
+	// Secret is the endpoint secret used to sign the events
+	Secret string
+	// Events limits the replayed events to these types. All events are
+	// replayed when empty.
+	Events []string
+	// Speed is the rate at which the original timing between events is
+	// respected, e.g. 2 replays events twice as fast as they were received.
+	// Events are replayed as fast as possible when 0.
+	Speed float64
+	// Indicates whether to skip certificate verification when delivering to HTTPS endpoints
+	SkipVerify bool
+	// The logger used to log messages to stdin/err
+	Log *log.Logger
+
+	// OnDelivery is called after each delivery attempt
+	OnDelivery func(ReplayDelivery)
+}
+
+// ReplayDelivery describes the outcome of replaying a single event
+type ReplayDelivery struct {
+	Event      StripeEvent
+	StatusCode int
+	Err        error
+}
+
+// Success returns true if the endpoint accepted the event with a 2xx response
+func (d ReplayDelivery) Success() bool {
+	return d.Err == nil && d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+//
+// Public functions
+//
+
+// ReadRecordedEvents reads a recording written with the RecordTo option.
+// Events forwarded to several endpoints were recorded once per endpoint, only
+// their first occurrence is returned.
+func ReadRecordedEvents(r io.Reader) ([]RecordedEvent, error) {
+	recorded := make([]RecordedEvent, 0)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var evt RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			return nil, fmt.Errorf("Invalid recorded event on line %d: %v", line, err)
+		}
+
+		var stripeEvt StripeEvent
+		if err := json.Unmarshal(evt.Event, &stripeEvt); err != nil {
+			return nil, fmt.Errorf("Invalid event payload on line %d: %v", line, err)
+		}
+
+		if seen[stripeEvt.ID] {
+			continue
+		}
+		seen[stripeEvt.ID] = true
+
+		recorded = append(recorded, evt)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// Replay delivers the recorded events to the configured endpoint, signing
+// each of them with the endpoint secret. It returns the number of deliveries
+// that did not receive a 2xx response.
+func Replay(ctx context.Context, recorded []RecordedEvent, cfg *ReplayConfig) (int, error) {
+	if cfg.Log == nil {
+		cfg.Log = &log.Logger{Out: ioutil.Discard}
+	}
+
+	if cfg.OnDelivery == nil {
+		cfg.OnDelivery = func(ReplayDelivery) {}
+	}
+
+	forwardURL := parseURL(cfg.ForwardURL)
+	headers := convertToMapAndSanitize(cfg.ForwardHeaders)
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: defaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.SkipVerify},
+		},
+	}
+
+	failed := 0
+
+	var previous time.Time
+
+	for _, rec := range recorded {
+		var evt StripeEvent
+		if err := json.Unmarshal(rec.Event, &evt); err != nil {
+			return failed, err
+		}
+
+		if !matchesAnyEventType(cfg.Events, evt.Type) {
+			continue
+		}
+
+		if cfg.Speed > 0 && !previous.IsZero() && rec.ReceivedAt.After(previous) {
+			wait := time.Duration(float64(rec.ReceivedAt.Sub(previous)) / cfg.Speed)
+
+			select {
+			case <-ctx.Done():
+				return failed, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		previous = rec.ReceivedAt
+
+		delivery := ReplayDelivery{Event: evt}
+		delivery.StatusCode, delivery.Err = replayEvent(ctx, client, forwardURL, headers, cfg.Secret, rec.Event)
+
+		if !delivery.Success() {
+			failed++
+		}
+
+		cfg.Log.WithFields(log.Fields{
+			"prefix":      "proxy.Replay",
+			"event_id":    evt.ID,
+			"status_code": delivery.StatusCode,
+		}).Debug("Replayed event")
+
+		cfg.OnDelivery(delivery)
+	}
+
+	return failed, nil
+}
+
+//
+// Private functions
+//
+
+func replayEvent(ctx context.Context, client *http.Client, forwardURL string, headers map[string]string, secret string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, forwardURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "Stripe/1.0 (+https://stripe.com/docs/webhooks)")
+	req.Header.Set("Stripe-Signature", SignatureHeader(time.Now(), payload, secret))
+
+	for k, v := range headers {
+		if strings.ToLower(k) == "host" {
+			req.Host = v
+		} else {
+			req.Header.Set(k, v)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// drain the body so the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body) // #nosec G104
+
+	return resp.StatusCode, nil
+}
+
+// matchesAnyEventType returns true if eventType matches one of the patterns,
+// or if there are no patterns
+func matchesAnyEventType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if pattern == "*" || eventTypeMatches(pattern, eventType) {
+			return true
+		}
+	}
+
+	return false
+}
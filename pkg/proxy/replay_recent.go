@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+	"github.com/stripe/stripe-cli/pkg/requests"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+//
+// Public types
+//
+
+// ReplayedEvent is sent in place of the event for the recent events fetched
+// from the API and forwarded when the session is ready
+type ReplayedEvent struct {
+	Event *StripeEvent
+}
+
+//
+// Private constants
+//
+
+// replayPageSize is the number of events fetched per page of /v1/events
+const replayPageSize = 100
+
+// replayMaxPages bounds the number of pages of /v1/events looked through to
+// find recent events matching the listened event types
+const replayMaxPages = 10
+
+//
+// Private types
+//
+
+// replayedPayload is a recent event fetched from the API
+type replayedPayload struct {
+	event   StripeEvent
+	payload string
+}
+
+// replayGate holds back live events while recent events are being replayed,
+// so that they are forwarded after them. It is released once each session
+// replayed its recent events. It is safe for concurrent use.
+type replayGate struct {
+	mu      sync.Mutex
+	pending int
+	closed  bool
+	held    []func()
+}
+
+//
+// Private functions
+//
+
+func newReplayGate(sessions int) *replayGate {
+	return &replayGate{pending: sessions}
+}
+
+// hold queues process until the gate is released. It returns false when the
+// gate was already released and process should run right away.
+func (g *replayGate) hold(process func()) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.closed {
+		g.held = append(g.held, process)
+	}
+
+	return !g.closed
+}
+
+// release processes the held events in the order they were received, once
+// it was called for every session. Events received meanwhile are queued
+// behind them until none are left.
+func (g *replayGate) release() {
+	g.mu.Lock()
+	g.pending--
+	pending := g.pending
+	g.mu.Unlock()
+
+	if pending > 0 {
+		return
+	}
+
+	for {
+		g.mu.Lock()
+		held := g.held
+		g.held = nil
+		if len(held) == 0 {
+			g.closed = true
+		}
+		g.mu.Unlock()
+
+		if len(held) == 0 {
+			return
+		}
+
+		for _, process := range held {
+			process()
+		}
+	}
+}
+
+// replayRecentEvents forwards the most recent ReplayRecent events matching the
+// listened event types, oldest first, then releases the live events received
+// in the meantime
+func (p *Proxy) replayRecentEvents(ctx context.Context, s *listenSession, apiVersion string, secret string) {
+	defer p.replayGate.release()
+
+	events, err := p.fetchRecentEvents(ctx, s.profile.Key, apiVersion)
+	if err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix": "proxy.Proxy.replayRecentEvents",
+		}).Warnf("Failed to fetch recent events to replay: %v", err)
+		return
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		p.replayEvent(s, events[i], secret)
+	}
+}
+
+// fetchRecentEvents pages through /v1/events for the most recent events that
+// would be forwarded if they were received now, most recent first
+func (p *Proxy) fetchRecentEvents(ctx context.Context, key string, apiVersion string) ([]replayedPayload, error) {
+	apiBaseURL := p.cfg.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = stripe.DefaultAPIBaseURL
+	}
+
+	events := make([]replayedPayload, 0, p.cfg.ReplayRecent)
+	startingAfter := ""
+
+	for page := 0; page < replayMaxPages && len(events) < p.cfg.ReplayRecent; page++ {
+		list, err := requests.EventsList(ctx, apiBaseURL, apiVersion, key, startingAfter, replayPageSize, &config.Profile{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, payload := range list.Data {
+			evt, err := parseStripeEvent(string(payload))
+			if err != nil {
+				return nil, err
+			}
+			startingAfter = evt.ID
+
+			if !(p.events["*"] || p.events[evt.Type]) || p.skipsEventType(evt.Type) || p.filterAccount(&evt) || p.filterMetadata(&evt) {
+				continue
+			}
+
+			events = append(events, replayedPayload{event: evt, payload: string(payload)})
+			if len(events) == p.cfg.ReplayRecent {
+				break
+			}
+		}
+
+		if !list.HasMore {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// replayEvent forwards a recent event like an event received from Stripe,
+// signed with the session secret
+func (p *Proxy) replayEvent(s *listenSession, replayed replayedPayload, secret string) {
+	evt := replayed.event
+	if len(p.sessions) > 1 {
+		evt.Profile = s.profile.Name
+		evt.ProfileAccount = s.profile.Account
+	}
+
+	evtCtx := eventContext{
+		session:    s,
+		event:      &evt,
+		receivedAt: time.Now(),
+	}
+
+	p.status.eventReceived(evtCtx.receivedAt)
+	p.metrics.eventReceived(evt.Type)
+
+	// live events with the same ID are skipped after the replay
+	if p.dedupe != nil {
+		p.dedupe.seen(evt.ID)
+	}
+
+	p.emitLifecycle(LifecycleEvent{
+		Type:           lifecycleEventReceived,
+		EventID:        evt.ID,
+		EventType:      evt.Type,
+		Event:          compactPayload(replayed.payload),
+		Replayed:       true,
+		Profile:        evt.Profile,
+		ProfileAccount: evt.ProfileAccount,
+	})
+	if p.outputTemplate != nil {
+		p.cfg.OutCh <- websocket.DataElement{
+			Data: OutputLine{Line: p.renderOutput(newOutputTemplateData(outputKindEvent, &evt))},
+		}
+	} else {
+		p.cfg.OutCh <- websocket.DataElement{
+			Data:      ReplayedEvent{Event: &evt},
+			Marshaled: p.formatOutput(outputFormatJSON, replayed.payload),
+		}
+	}
+
+	if p.cfg.RecordOnly {
+		p.recordEvent(evtCtx, replayed.payload, "", 0, 0)
+		return
+	}
+
+	headers := map[string]string{
+		"Content-Type":     "application/json; charset=utf-8",
+		"User-Agent":       "Stripe/1.0 (+https://stripe.com/docs/webhooks)",
+		"Stripe-Signature": SignatureHeader(time.Now(), []byte(replayed.payload), secret),
+	}
+
+	p.forwardEvent(evtCtx, p.destinationsFor(&evt), replayed.payload, headers)
+}
@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestReplayGate(t *testing.T) {
+	gate := newReplayGate(1)
+
+	var processed []int
+	require.True(t, gate.hold(func() { processed = append(processed, 1) }))
+	require.True(t, gate.hold(func() {
+		processed = append(processed, 2)
+		// received while the held events are processed
+		require.True(t, gate.hold(func() { processed = append(processed, 3) }))
+	}))
+	require.Empty(t, processed)
+
+	gate.release()
+	require.Equal(t, []int{1, 2, 3}, processed)
+
+	require.False(t, gate.hold(func() { processed = append(processed, 4) }))
+	require.Equal(t, []int{1, 2, 3}, processed)
+}
+
+func TestReplayGateWaitsForEverySession(t *testing.T) {
+	gate := newReplayGate(2)
+
+	processed := false
+	require.True(t, gate.hold(func() { processed = true }))
+
+	gate.release()
+	require.False(t, processed)
+	require.True(t, gate.hold(func() {}))
+
+	gate.release()
+	require.True(t, processed)
+}
+
+func TestReplayRecentEvents(t *testing.T) {
+	// events are listed most recent first
+	pages := map[string]string{
+		"": `{"has_more": true, "data": [
+			{"id": "evt_5", "object": "event", "type": "charge.succeeded", "request": {"id": null}},
+			{"id": "evt_4", "object": "event", "type": "customer.created", "request": {"id": null}},
+			{"id": "evt_3", "object": "event", "type": "charge.succeeded", "request": {"id": null}}
+		]}`,
+		"evt_3": `{"has_more": false, "data": [
+			{"id": "evt_2", "object": "event", "type": "charge.failed", "request": {"id": null}},
+			{"id": "evt_1", "object": "event", "type": "charge.succeeded", "request": {"id": null}}
+		]}`,
+	}
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/events", r.URL.Path)
+		require.Equal(t, "Bearer sk_test_123", r.Header.Get("Authorization"))
+		require.Equal(t, "2023-10-16", r.Header.Get("Stripe-Version"))
+
+		page, ok := pages[r.URL.Query().Get("starting_after")]
+		require.True(t, ok)
+		w.Write([]byte(page))
+	}))
+	defer api.Close()
+
+	var mu sync.Mutex
+	var delivered []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var evt StripeEvent
+		require.NoError(t, json.Unmarshal(body, &evt))
+		var timestamp int64
+		var signature string
+		_, err = fmt.Sscanf(r.Header.Get("Stripe-Signature"), "t=%d,v1=%s", &timestamp, &signature)
+		require.NoError(t, err)
+		require.Equal(t, ComputeSignature(time.Unix(timestamp, 0), body, "whsec_test"), signature)
+
+		mu.Lock()
+		delivered = append(delivered, evt.ID)
+		mu.Unlock()
+	}))
+	defer endpoint.Close()
+
+	outCh := make(chan websocket.IElement, 20)
+	p, err := Init(context.Background(), &Config{
+		Key:                     "sk_test_123",
+		APIBaseURL:              api.URL,
+		ForwardURLs:             []string{endpoint.URL},
+		Events:                  []string{"charge.succeeded", "charge.failed"},
+		SkipEvents:              []string{"charge.failed"},
+		ReplayRecent:            3,
+		MaxConcurrentDeliveries: 1,
+		OutCh:                   outCh,
+	})
+	require.NoError(t, err)
+
+	var live []string
+	require.True(t, p.replayGate.hold(func() { live = append(live, "evt_6") }))
+
+	p.replayRecentEvents(context.Background(), p.sessions[0], "2023-10-16", "whsec_test")
+	require.Equal(t, []string{"evt_6"}, live)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(delivered) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"evt_1", "evt_3", "evt_5"}, delivered)
+
+	replayed := (<-outCh).(websocket.DataElement).Data.(ReplayedEvent)
+	require.Equal(t, "evt_1", replayed.Event.ID)
+}
+
+func TestReplayRecentEventsAPIError(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"type": "invalid_request_error"}}`)
+	}))
+	defer api.Close()
+
+	p, err := Init(context.Background(), &Config{
+		APIBaseURL:   api.URL,
+		ReplayRecent: 5,
+		OutCh:        make(chan websocket.IElement, 10),
+	})
+	require.NoError(t, err)
+
+	held := false
+	p.replayGate.hold(func() { held = true })
+
+	// the live events are released even though the replay failed
+	p.replayRecentEvents(context.Background(), p.sessions[0], "", "whsec_test")
+	require.True(t, held)
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const recording = `{"received_at":"2021-01-01T00:00:00Z","forward_url":"http://localhost:3000","status_code":200,"event":{"id":"evt_1","type":"invoice.paid"}}
+{"received_at":"2021-01-01T00:00:00Z","forward_url":"http://localhost:4000","status_code":500,"event":{"id":"evt_1","type":"invoice.paid"}}
+
+{"received_at":"2021-01-01T00:00:01Z","event":{"id":"evt_2","type":"charge.succeeded"}}
+`
+
+func TestReadRecordedEvents(t *testing.T) {
+	recorded, err := ReadRecordedEvents(strings.NewReader(recording))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(recorded))
+	require.Equal(t, 200, recorded[0].StatusCode)
+	require.Equal(t, `{"id":"evt_2","type":"charge.succeeded"}`, string(recorded[1].Event))
+
+	_, err = ReadRecordedEvents(strings.NewReader("not json\n"))
+	require.EqualError(t, err, "Invalid recorded event on line 1: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func TestReplay(t *testing.T) {
+	received := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = append(received, string(body))
+
+		require.True(t, strings.HasPrefix(r.Header.Get("Stripe-Signature"), "t="))
+		require.Equal(t, "value", r.Header.Get("custom"))
+
+		if strings.Contains(string(body), "charge.succeeded") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	recorded, err := ReadRecordedEvents(strings.NewReader(recording))
+	require.NoError(t, err)
+
+	deliveries := make([]ReplayDelivery, 0)
+	failed, err := Replay(context.Background(), recorded, &ReplayConfig{
+		ForwardURL:     ts.URL,
+		ForwardHeaders: []string{"custom: value"},
+		Secret:         "whsec_123",
+		OnDelivery: func(d ReplayDelivery) {
+			deliveries = append(deliveries, d)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, failed)
+	require.Equal(t, 2, len(received))
+	require.Equal(t, "evt_1", deliveries[0].Event.ID)
+	require.True(t, deliveries[0].Success())
+	require.Equal(t, http.StatusBadRequest, deliveries[1].StatusCode)
+	require.False(t, deliveries[1].Success())
+
+	received = received[:0]
+	failed, err = Replay(context.Background(), recorded, &ReplayConfig{
+		ForwardURL:     ts.URL,
+		ForwardHeaders: []string{"custom: value"},
+		Events:         []string{"invoice.*"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, failed)
+	require.Equal(t, 1, len(received))
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+//
+// Private constants
+//
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+
+	// maxQueuedRetries bounds the number of deliveries that can be retrying at
+	// the same time. Deliveries failing while the queue is full are not retried.
+	maxQueuedRetries = 100
+)
+
+//
+// Private types
+//
+
+// retrier retries failed deliveries with exponential backoff and jitter.
+type retrier struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+
+	queue chan struct{}
+}
+
+//
+// Private functions
+//
+
+func newRetrier(maxRetries int) *retrier {
+	return &retrier{
+		maxRetries: maxRetries,
+		baseDelay:  retryBaseDelay,
+		maxDelay:   retryMaxDelay,
+		queue:      make(chan struct{}, maxQueuedRetries),
+	}
+}
+
+// shouldRetry returns true for connection errors and 5xx responses
+func (r *retrier) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before the given retry attempt (starting at 1).
+// The delay doubles with each attempt up to maxDelay, and a random jitter of
+// up to half the delay is subtracted to avoid retrying in lockstep.
+func (r *retrier) backoff(attempt int) time.Duration {
+	delay := r.baseDelay
+	for i := 1; i < attempt && delay < r.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > r.maxDelay {
+		delay = r.maxDelay
+	}
+
+	// #nosec G404 -- jitter does not need a cryptographically secure source
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+
+	return delay - jitter
+}
+
+// acquire reserves a spot in the retry queue without blocking. It returns
+// false if the queue is full.
+func (r *retrier) acquire() bool {
+	select {
+	case r.queue <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *retrier) release() {
+	<-r.queue
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrierShouldRetry(t *testing.T) {
+	r := newRetrier(3)
+
+	require.True(t, r.shouldRetry(nil, errors.New("connection refused")))
+	require.True(t, r.shouldRetry(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	require.False(t, r.shouldRetry(&http.Response{StatusCode: http.StatusOK}, nil))
+	require.False(t, r.shouldRetry(&http.Response{StatusCode: http.StatusBadRequest}, nil))
+}
+
+func TestRetrierBackoff(t *testing.T) {
+	r := newRetrier(10)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := r.backoff(attempt)
+		require.LessOrEqual(t, delay, r.maxDelay)
+		require.Greater(t, delay, time.Duration(0))
+	}
+
+	// the third retry waits 4x the base delay, minus up to half of it in jitter
+	require.GreaterOrEqual(t, r.backoff(3), 2*r.baseDelay)
+}
+
+func TestRetrierQueueIsBounded(t *testing.T) {
+	r := newRetrier(1)
+
+	for i := 0; i < maxQueuedRetries; i++ {
+		require.True(t, r.acquire())
+	}
+	require.False(t, r.acquire())
+
+	r.release()
+	require.True(t, r.acquire())
+}
@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-cli/pkg/stripeauth"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+//
+// Public types
+//
+
+// Profile is one of the profiles events are received for when listening with
+// several profiles at once, each over its own session
+type Profile struct {
+	// Name is the name of the profile, printed along with its events
+	Name string
+	// Key is the API key the session of the profile is created with
+	Key string
+	// Account is the ID of the profile's account
+	Account string
+}
+
+// SessionFailed is sent when the session of one of several profiles could not
+// be established or was lost. Events are still received for the other
+// profiles unless StrictProfiles is set.
+type SessionFailed struct {
+	Profile Profile
+	Err     error
+}
+
+//
+// Private types
+//
+
+// listenSession is a websocket session events are received over
+type listenSession struct {
+	profile          Profile
+	stripeAuthClient *stripeauth.Client
+	webSocketClient  *websocket.Client
+
+	// replayOnce replays recent events the first time the session is ready,
+	// or releases the replay gate if it fails before
+	replayOnce sync.Once
+}
+
+// sessionsReadiness tracks which sessions are ready so that the Ready state is
+// first sent once all of them are ready or failed
+type sessionsReadiness struct {
+	mu        sync.Mutex
+	total     int
+	settled   map[*listenSession]bool
+	secrets   map[*listenSession]string
+	announced bool
+	// apiVersion is the API version displayed in the Ready state
+	apiVersion string
+}
+
+//
+// Private functions
+//
+
+// runSession authorizes the session and keeps it connected, reauthorizing when
+// it expires, until ctx is done or it fails
+func (p *Proxy) runSession(ctx context.Context, s *listenSession) error {
+	nAttempts := 0
+
+	for nAttempts < maxConnectAttempts {
+		session, err := p.createSession(ctx, s)
+
+		if err != nil {
+			return fmt.Errorf("Error while authenticating with Stripe: %v", err)
+		}
+
+		s.webSocketClient = websocket.NewClient(
+			session.WebSocketURL,
+			session.WebSocketID,
+			session.WebSocketAuthorizedFeature,
+			&websocket.Config{
+				Log:                   p.cfg.Log,
+				NoWSS:                 p.cfg.NoWSS,
+				ReconnectInterval:     time.Duration(session.ReconnectDelay) * time.Second,
+				ReconnectInitialDelay: p.cfg.ReconnectInitialDelay,
+				ReconnectMaxDelay:     p.cfg.ReconnectMaxDelay,
+				EventHandler: websocket.EventHandlerFunc(func(msg websocket.IncomingMessage) {
+					p.processWebhookEvent(s, msg)
+				}),
+			},
+		)
+		p.status.setClient(s.profile.Name, s.webSocketClient)
+
+		go func(webSocketClient *websocket.Client) {
+			<-webSocketClient.Connected()
+			nAttempts = 0
+
+			displayedAPIVersion := ""
+			if p.cfg.APIVersion != "" {
+				displayedAPIVersion = "You are using Stripe API Version [" + p.cfg.APIVersion + "]. "
+			} else if p.cfg.UseLatestAPIVersion && session.LatestVersion != "" {
+				displayedAPIVersion = "You are using Stripe API Version [" + session.LatestVersion + "]. "
+			} else if !p.cfg.UseLatestAPIVersion && session.DefaultVersion != "" {
+				displayedAPIVersion = "You are using Stripe API Version [" + session.DefaultVersion + "]. "
+			}
+
+			p.status.setReady(session.Secret)
+
+			apiVersion := session.DefaultVersion
+			if p.cfg.APIVersion != "" {
+				apiVersion = p.cfg.APIVersion
+			} else if p.cfg.UseLatestAPIVersion {
+				apiVersion = session.LatestVersion
+			}
+			p.emitLifecycle(LifecycleEvent{
+				Type:           lifecycleReady,
+				Secret:         session.Secret,
+				APIVersion:     apiVersion,
+				Profile:        s.profile.Name,
+				ProfileAccount: s.profile.Account,
+			})
+
+			p.sessionReady(s, displayedAPIVersion, session.Secret)
+
+			if p.replayGate != nil {
+				s.replayOnce.Do(func() { p.replayRecentEvents(ctx, s, apiVersion, session.Secret) })
+			}
+		}(s.webSocketClient)
+
+		go s.webSocketClient.Run(ctx)
+		nAttempts++
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.webSocketClient.NotifyExpired:
+			if nAttempts < maxConnectAttempts {
+				p.status.setState(stateReconnecting)
+				p.cfg.OutCh <- &websocket.StateElement{
+					State: websocket.Reconnecting,
+				}
+			} else {
+				return fmt.Errorf("Session expired. Terminating after %d failed attempts to reauthorize", nAttempts)
+			}
+		}
+	}
+
+	if s.webSocketClient != nil {
+		s.webSocketClient.Stop()
+	}
+
+	return nil
+}
+
+func newSessionsReadiness(total int) *sessionsReadiness {
+	return &sessionsReadiness{
+		total:   total,
+		settled: make(map[*listenSession]bool),
+		secrets: make(map[*listenSession]string),
+	}
+}
+
+// settle marks the session as ready with its secret, or as failed when the
+// secret is empty. It returns the sessions to list in the Ready state: all the
+// ready ones once every session settled, then each one that is ready again
+// after reconnecting.
+func (r *sessionsReadiness) settle(s *listenSession, secret string, sessions []*listenSession) []*listenSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settled[s] = true
+	if secret != "" {
+		r.secrets[s] = secret
+	}
+
+	if r.announced {
+		if secret == "" {
+			return nil
+		}
+		return []*listenSession{s}
+	}
+
+	if len(r.settled) < r.total {
+		return nil
+	}
+
+	r.announced = true
+
+	var ready []*listenSession
+	for _, session := range sessions {
+		if _, ok := r.secrets[session]; ok {
+			ready = append(ready, session)
+		}
+	}
+
+	return ready
+}
+
+// sessionReady sends the Ready state with the session's secret. When listening
+// with several profiles, it is first sent once all the sessions are ready or
+// failed, listing the name, account and secret of each profile after the
+// first secret.
+func (p *Proxy) sessionReady(s *listenSession, displayedAPIVersion string, secret string) {
+	if len(p.sessions) == 1 {
+		p.cfg.OutCh <- websocket.StateElement{
+			State: websocket.Ready,
+			Data:  []string{displayedAPIVersion, secret},
+		}
+		return
+	}
+
+	p.readiness.mu.Lock()
+	p.readiness.apiVersion = displayedAPIVersion
+	p.readiness.mu.Unlock()
+
+	p.announceReady(p.readiness.settle(s, secret, p.sessions))
+}
+
+// sessionFailed reports the failure of one of several sessions
+func (p *Proxy) sessionFailed(s *listenSession, err error) {
+	p.emitLifecycle(LifecycleEvent{
+		Type:           lifecycleError,
+		Profile:        s.profile.Name,
+		ProfileAccount: s.profile.Account,
+		Error:          err.Error(),
+	})
+	p.cfg.OutCh <- websocket.DataElement{
+		Data: SessionFailed{Profile: s.profile, Err: err},
+	}
+
+	if p.replayGate != nil {
+		s.replayOnce.Do(p.replayGate.release)
+	}
+
+	// the other sessions may have been waiting on this one
+	p.announceReady(p.readiness.settle(s, "", p.sessions))
+}
+
+func (p *Proxy) announceReady(ready []*listenSession) {
+	if len(ready) == 0 {
+		return
+	}
+
+	p.readiness.mu.Lock()
+	data := []string{p.readiness.apiVersion, p.readiness.secrets[ready[0]]}
+	for _, session := range ready {
+		data = append(data, session.profile.Name, session.profile.Account, p.readiness.secrets[session])
+	}
+	p.readiness.mu.Unlock()
+
+	p.cfg.OutCh <- websocket.StateElement{
+		State: websocket.Ready,
+		Data:  data,
+	}
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionsReadinessWaitsForEverySession(t *testing.T) {
+	work := &listenSession{profile: Profile{Name: "work"}}
+	personal := &listenSession{profile: Profile{Name: "personal"}}
+	sessions := []*listenSession{work, personal}
+
+	readiness := newSessionsReadiness(len(sessions))
+
+	require.Empty(t, readiness.settle(personal, "whsec_personal", sessions))
+	require.Equal(t, sessions, readiness.settle(work, "whsec_work", sessions))
+
+	// sessions that reconnect are announced on their own
+	require.Equal(t, []*listenSession{personal}, readiness.settle(personal, "whsec_personal", sessions))
+}
+
+func TestSessionsReadinessSkipsFailedSessions(t *testing.T) {
+	work := &listenSession{profile: Profile{Name: "work"}}
+	personal := &listenSession{profile: Profile{Name: "personal"}}
+	sessions := []*listenSession{work, personal}
+
+	readiness := newSessionsReadiness(len(sessions))
+
+	require.Empty(t, readiness.settle(work, "whsec_work", sessions))
+	require.Equal(t, []*listenSession{work}, readiness.settle(personal, "", sessions))
+	require.Empty(t, readiness.settle(personal, "", sessions))
+}
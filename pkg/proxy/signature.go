@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+//
+// Public functions
+//
+
+// ComputeSignature computes the webhook signature of payload for the given
+// timestamp and endpoint secret.
+func ComputeSignature(t time.Time, payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d", t.Unix())))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeader returns the value of a Stripe-Signature header signing
+// payload with the given endpoint secrets, with one signature per secret like
+// Stripe does while a secret is being rolled.
+func SignatureHeader(t time.Time, payload []byte, secrets ...string) string {
+	header := fmt.Sprintf("t=%d", t.Unix())
+	for _, secret := range secrets {
+		header += ",v1=" + ComputeSignature(t, payload, secret)
+	}
+
+	return header
+}
@@ -0,0 +1,28 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureHeader(t *testing.T) {
+	timestamp := time.Unix(1614556800, 0)
+	payload := []byte(`{"id":"evt_123"}`)
+
+	header := SignatureHeader(timestamp, payload, "whsec_test_secret")
+
+	require.Equal(t, "t=1614556800,v1="+ComputeSignature(timestamp, payload, "whsec_test_secret"), header)
+	require.NotEqual(t, ComputeSignature(timestamp, payload, "whsec_other_secret"), ComputeSignature(timestamp, payload, "whsec_test_secret"))
+	require.Len(t, ComputeSignature(timestamp, payload, "whsec_test_secret"), 64)
+}
+
+func TestSignatureHeaderMultipleSecrets(t *testing.T) {
+	timestamp := time.Unix(1614556800, 0)
+	payload := []byte(`{"id":"evt_123"}`)
+
+	header := SignatureHeader(timestamp, payload, "whsec_old", "whsec_new")
+
+	require.Equal(t, "t=1614556800,v1="+ComputeSignature(timestamp, payload, "whsec_old")+",v1="+ComputeSignature(timestamp, payload, "whsec_new"), header)
+}
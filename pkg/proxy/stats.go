@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+//
+// Public types
+//
+
+// DeliveryLatency is the time an endpoint took to respond to an event
+type DeliveryLatency struct {
+	EventID string
+	URL     string
+	Latency time.Duration
+}
+
+// DeliverySummary summarizes the deliveries made to local endpoints
+type DeliverySummary struct {
+	// Events is the number of distinct events forwarded
+	Events int
+	// Deliveries is the number of requests made to endpoints. It is greater
+	// than Events when events are forwarded to several endpoints.
+	Deliveries int
+	// StatusClasses counts deliveries by status class (2xx, 3xx, 4xx, 5xx),
+	// with failed requests counted as "failed"
+	StatusClasses map[string]int
+
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+
+	// Slowest lists the slowest deliveries, slowest first
+	Slowest []DeliveryLatency
+
+	// Skipped counts the events dropped by SkipEvents, by event type
+	Skipped map[string]int
+}
+
+//
+// Private constants
+//
+
+const (
+	maxSlowestDeliveries = 5
+	// maxLatencySamples bounds the latencies kept for the percentiles. Past
+	// it, the percentiles are of a uniform sample of the deliveries.
+	maxLatencySamples = 10000
+)
+
+//
+// Private types
+//
+
+// deliveryStats records the outcome of every delivery, in a bounded amount of
+// memory however long the session. It is safe for concurrent use.
+type deliveryStats struct {
+	mu         sync.Mutex
+	events     int
+	deliveries int
+	// samples is a reservoir sample of the latencies of the deliveries
+	samples       []time.Duration
+	slowest       []DeliveryLatency
+	statusClasses map[string]int
+	skipped       map[string]int
+}
+
+//
+// Private functions
+//
+
+func newDeliveryStats() *deliveryStats {
+	return &deliveryStats{
+		statusClasses: make(map[string]int),
+		skipped:       make(map[string]int),
+	}
+}
+
+func (s *deliveryStats) skip(eventType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skipped[eventType]++
+}
+
+// record records a delivery, with eventDone set by the last delivery of its
+// event so that the events are counted once
+func (s *deliveryStats) record(eventID string, url string, statusCode int, latency time.Duration, eventDone bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if eventDone {
+		s.events++
+	}
+	s.deliveries++
+	s.statusClasses[statusClass(statusCode)]++
+
+	if len(s.samples) < maxLatencySamples {
+		s.samples = append(s.samples, latency)
+	} else if i := rand.Intn(s.deliveries); i < maxLatencySamples { // #nosec G404
+		s.samples[i] = latency
+	}
+
+	s.recordSlowest(DeliveryLatency{EventID: eventID, URL: url, Latency: latency})
+}
+
+// recordSlowest keeps the delivery if it is one of the slowest, slowest
+// first and earliest first among equals
+func (s *deliveryStats) recordSlowest(delivery DeliveryLatency) {
+	i := sort.Search(len(s.slowest), func(i int) bool {
+		return s.slowest[i].Latency < delivery.Latency
+	})
+	if i >= maxSlowestDeliveries {
+		return
+	}
+
+	s.slowest = append(s.slowest, DeliveryLatency{})
+	copy(s.slowest[i+1:], s.slowest[i:])
+	s.slowest[i] = delivery
+
+	if len(s.slowest) > maxSlowestDeliveries {
+		s.slowest = s.slowest[:maxSlowestDeliveries]
+	}
+}
+
+func (s *deliveryStats) summary() DeliverySummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := DeliverySummary{
+		Events:        s.events,
+		Deliveries:    s.deliveries,
+		StatusClasses: make(map[string]int),
+		Skipped:       make(map[string]int),
+	}
+
+	for class, count := range s.statusClasses {
+		summary.StatusClasses[class] = count
+	}
+
+	for eventType, count := range s.skipped {
+		summary.Skipped[eventType] = count
+	}
+
+	if len(s.samples) == 0 {
+		return summary
+	}
+
+	sorted := make([]time.Duration, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	summary.P50 = percentile(sorted, 50)
+	summary.P95 = percentile(sorted, 95)
+	summary.P99 = percentile(sorted, 99)
+
+	summary.Slowest = make([]DeliveryLatency, len(s.slowest))
+	copy(summary.Slowest, s.slowest)
+
+	return summary
+}
+
+// percentile returns the nearest-rank percentile of latencies sorted in
+// ascending order
+func percentile(sorted []time.Duration, p int) time.Duration {
+	// nearest rank, 1-indexed
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+func statusClass(statusCode int) string {
+	if statusCode == 0 {
+		return "failed"
+	}
+
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
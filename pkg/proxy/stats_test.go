@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryStatsSummary(t *testing.T) {
+	stats := newDeliveryStats()
+
+	require.Equal(t, 0, stats.summary().Deliveries)
+
+	for i := 1; i <= 100; i++ {
+		statusCode := 200
+		if i%10 == 0 {
+			statusCode = 500
+		}
+		if i == 100 {
+			statusCode = 0
+		}
+
+		stats.record(fmt.Sprintf("evt_%d", i), "http://localhost", statusCode, time.Duration(i)*time.Millisecond, i != 1)
+	}
+	// same event delivered to another endpoint
+	stats.record("evt_1", "http://localhost:4000", 404, time.Millisecond, true)
+
+	summary := stats.summary()
+	require.Equal(t, 100, summary.Events)
+	require.Equal(t, 101, summary.Deliveries)
+	require.Equal(t, map[string]int{"2xx": 90, "4xx": 1, "5xx": 9, "failed": 1}, summary.StatusClasses)
+	require.Equal(t, 50*time.Millisecond, summary.P50)
+	require.Equal(t, 95*time.Millisecond, summary.P95)
+	require.Equal(t, 99*time.Millisecond, summary.P99)
+	require.Len(t, summary.Slowest, maxSlowestDeliveries)
+	require.Equal(t, "evt_100", summary.Slowest[0].EventID)
+	require.Equal(t, "evt_96", summary.Slowest[4].EventID)
+}
+
+func TestDeliveryStatsBounded(t *testing.T) {
+	stats := newDeliveryStats()
+
+	for i := 0; i < 3*maxLatencySamples; i++ {
+		stats.record("evt_123", "http://localhost", 200, time.Duration(i%100)*time.Millisecond, true)
+	}
+
+	require.Len(t, stats.samples, maxLatencySamples)
+	require.Len(t, stats.slowest, maxSlowestDeliveries)
+
+	summary := stats.summary()
+	require.Equal(t, 3*maxLatencySamples, summary.Deliveries)
+	require.InDelta(t, 50*time.Millisecond, summary.P50, float64(10*time.Millisecond))
+	require.Equal(t, 99*time.Millisecond, summary.Slowest[0].Latency)
+}
@@ -0,0 +1,244 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+//
+// Public types
+//
+
+// Status describes the state of a running proxy
+type Status struct {
+	// State is one of "connecting", "ready", "reconnecting" or "done"
+	State string `json:"state"`
+	// Ready is true once the websocket session is established and the webhook
+	// signing secret is known
+	Ready bool `json:"ready"`
+	// EventsReceived is the number of events received that matched the
+	// configured event types and accounts
+	EventsReceived int `json:"events_received"`
+	// LastEventAt is when the last event was received
+	LastEventAt *time.Time `json:"last_event_at"`
+	// Reconnects counts both websocket reconnections and session renewals
+	Reconnects int `json:"reconnects"`
+}
+
+//
+// Private constants
+//
+
+// recentDeliveriesSize is the number of deliveries kept for /deliveries
+const recentDeliveriesSize = 100
+
+// maxCustomEventSize is the largest custom event accepted on /events
+const maxCustomEventSize = 1 << 20
+
+const (
+	stateConnecting   = "connecting"
+	stateReady        = "ready"
+	stateReconnecting = "reconnecting"
+	stateDone         = "done"
+)
+
+//
+// Private types
+//
+
+// sessionStatus tracks the state of the proxy. It is safe for concurrent use.
+type sessionStatus struct {
+	mu             sync.Mutex
+	state          string
+	secret         string
+	eventsReceived int
+	lastEventAt    time.Time
+	reconnects     int
+	clients        map[string]*websocket.Client
+	deliveries     []DeliveryRecord
+}
+
+//
+// Private functions
+//
+
+func newSessionStatus() *sessionStatus {
+	return &sessionStatus{state: stateConnecting, clients: map[string]*websocket.Client{}}
+}
+
+func (s *sessionStatus) setState(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state
+}
+
+func (s *sessionStatus) setReady(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = stateReady
+	s.secret = secret
+}
+
+// readySecret returns the webhook signing secret while the session is ready
+func (s *sessionStatus) readySecret() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != stateReady {
+		return ""
+	}
+
+	return s.secret
+}
+
+// setClient sets the websocket client of a new session of the profile.
+// Replacing a previous client of the same profile counts as a reconnection,
+// on top of those made by that client, while the sessions of other profiles
+// keep their own.
+func (s *sessionStatus) setClient(profile string, client *websocket.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, ok := s.clients[profile]; ok {
+		s.reconnects += previous.Reconnects() + 1
+	}
+	s.clients[profile] = client
+}
+
+func (s *sessionStatus) eventReceived(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.eventsReceived++
+	s.lastEventAt = at
+}
+
+// deliveryDone keeps the result of a delivery, dropping the oldest one past
+// recentDeliveriesSize
+func (s *sessionStatus) deliveryDone(record DeliveryRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.deliveries) == recentDeliveriesSize {
+		s.deliveries = s.deliveries[1:]
+	}
+	s.deliveries = append(s.deliveries, record)
+}
+
+// recentDeliveries returns the kept deliveries made after since, oldest first
+func (s *sessionStatus) recentDeliveries(since time.Time) []DeliveryRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := []DeliveryRecord{}
+	for _, record := range s.deliveries {
+		if record.Time.After(since) {
+			deliveries = append(deliveries, record)
+		}
+	}
+
+	return deliveries
+}
+
+func (s *sessionStatus) status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		State:          s.state,
+		Ready:          s.state == stateReady && s.secret != "",
+		EventsReceived: s.eventsReceived,
+		Reconnects:     s.reconnects,
+	}
+
+	for _, client := range s.clients {
+		status.Reconnects += client.Reconnects()
+	}
+
+	if !s.lastEventAt.IsZero() {
+		lastEventAt := s.lastEventAt
+		status.LastEventAt = &lastEventAt
+	}
+
+	return status
+}
+
+// newStatusServer serves the readiness of the proxy on /ready, its status as
+// JSON on /status and its latest deliveries as JSON on /deliveries, optionally
+// only those made after the RFC 3339 time of the since query parameter. The
+// custom events POSTed to /events are forwarded like the events from Stripe.
+func newStatusServer(port int, status func() Status, deliveries func(since time.Time) []DeliveryRecord, deliverEvent func(payload []byte) error, logger *log.Logger) (*localServer, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		if !status().Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(status())
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"prefix": "proxy.statusServer",
+			}).Debugf("Failed to write the status: %v", err)
+		}
+	})
+	mux.HandleFunc("/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		var since time.Time
+		if value := r.URL.Query().Get("since"); value != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid since %s, expected an RFC 3339 time", value), http.StatusBadRequest)
+				return
+			}
+			since = parsed
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(deliveries(since))
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"prefix": "proxy.statusServer",
+			}).Debugf("Failed to write the deliveries: %v", err)
+		}
+	})
+
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxCustomEventSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = deliverEvent(payload)
+		switch {
+		case errors.Is(err, errNotReady):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+
+	return newLocalServer(port, "status", mux)
+}
@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+func TestSessionStatus(t *testing.T) {
+	s := newSessionStatus()
+
+	status := s.status()
+	require.Equal(t, stateConnecting, status.State)
+	require.False(t, status.Ready)
+	require.Nil(t, status.LastEventAt)
+
+	receivedAt := time.Unix(1600000000, 0)
+	s.setReady("whsec_123")
+	s.eventReceived(receivedAt)
+	s.eventReceived(receivedAt)
+
+	status = s.status()
+	require.True(t, status.Ready)
+	require.Equal(t, 2, status.EventsReceived)
+	require.Equal(t, receivedAt, *status.LastEventAt)
+
+	s.setState(stateReconnecting)
+	require.False(t, s.status().Ready)
+}
+
+func TestStatusServer(t *testing.T) {
+	ready := false
+	server, err := newStatusServer(0, func() Status {
+		if ready {
+			return Status{State: stateReady, Ready: true, EventsReceived: 3}
+		}
+		return Status{State: stateConnecting}
+	}, func(time.Time) []DeliveryRecord { return nil }, nil, nil)
+	require.NoError(t, err)
+	defer server.listener.Close()
+
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	ready = true
+
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	require.Equal(t, "ready", status.State)
+	require.Equal(t, 3, status.EventsReceived)
+}
+
+func TestStatusServerPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	_, err = newStatusServer(port, func() Status { return Status{} }, nil, nil, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is it already in use?")
+}
+
+func TestSessionStatusReconnectsPerProfile(t *testing.T) {
+	s := newSessionStatus()
+
+	s.setClient("default", websocket.NewClient("", "", "", nil))
+	s.setClient("other", websocket.NewClient("", "", "", nil))
+	require.Equal(t, 0, s.status().Reconnects)
+
+	s.setClient("other", websocket.NewClient("", "", "", nil))
+	require.Equal(t, 1, s.status().Reconnects)
+}
+
+func TestInitFailureLeavesStatusPortFree(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	_, err = Init(context.Background(), &Config{
+		StatusPort:         port,
+		ForwardHeadersFile: filepath.Join(t.TempDir(), "missing.json"),
+	})
+	require.Error(t, err)
+
+	listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+	require.NoError(t, err)
+	listener.Close()
+}
+
+func TestRecentDeliveries(t *testing.T) {
+	s := newSessionStatus()
+	start := time.Unix(1600000000, 0)
+
+	for i := 0; i < recentDeliveriesSize+2; i++ {
+		s.deliveryDone(DeliveryRecord{Time: start.Add(time.Duration(i) * time.Second), EventID: fmt.Sprintf("evt_%d", i)})
+	}
+
+	deliveries := s.recentDeliveries(time.Time{})
+	require.Len(t, deliveries, recentDeliveriesSize)
+	require.Equal(t, "evt_2", deliveries[0].EventID)
+
+	deliveries = s.recentDeliveries(start.Add(time.Duration(recentDeliveriesSize) * time.Second))
+	require.Len(t, deliveries, 1)
+	require.Equal(t, fmt.Sprintf("evt_%d", recentDeliveriesSize+1), deliveries[0].EventID)
+}
+
+func TestStatusServerDeliveries(t *testing.T) {
+	at := time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC)
+	var requestedSince time.Time
+	server, err := newStatusServer(0, func() Status { return Status{} }, func(since time.Time) []DeliveryRecord {
+		requestedSince = since
+		return []DeliveryRecord{{Time: at, EventID: "evt_123", EventType: "customer.created", StatusCode: 200}}
+	}, nil, nil)
+	require.NoError(t, err)
+	defer server.listener.Close()
+
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries?since=2021-03-14T15:00:00.5Z", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, time.Date(2021, time.March, 14, 15, 0, 0, 500000000, time.UTC), requestedSince)
+
+	var deliveries []DeliveryRecord
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deliveries))
+	require.Equal(t, "evt_123", deliveries[0].EventID)
+	require.Equal(t, 200, deliveries[0].StatusCode)
+
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/deliveries?since=yesterday", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestStatusServerEvents(t *testing.T) {
+	var delivered []byte
+	ready := false
+	server, err := newStatusServer(0, func() Status { return Status{} }, nil, func(payload []byte) error {
+		if !ready {
+			return errNotReady
+		}
+		delivered = payload
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	defer server.listener.Close()
+
+	rr := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id": "evt_123"}`)))
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	ready = true
+
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"id": "evt_123"}`)))
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	require.Equal(t, `{"id": "evt_123"}`, string(delivered))
+
+	rr = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
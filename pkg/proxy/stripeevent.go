@@ -12,10 +12,17 @@ type StripeEvent struct {
 	Data            map[string]interface{} `json:"data"`
 	ID              string                 `json:"id"`
 	Livemode        bool                   `json:"livemode"`
+	Object          string                 `json:"object"`
 	PendingWebhooks int                    `json:"pending_webhooks"`
 	Type            string                 `json:"type"`
 	RequestData     interface{}            `json:"request"`
 	Request         StripeRequest
+	// RelatedObject is set for thin events only
+	RelatedObject *RelatedObject `json:"related_object"`
+	// Profile and ProfileAccount are the name and account ID of the profile
+	// the event was received for, when listening with several profiles
+	Profile        string `json:"-"`
+	ProfileAccount string `json:"-"`
 }
 
 // StripeRequest is a representation of the Request field in a Stripe `event` object
@@ -29,6 +36,12 @@ func (e *StripeEvent) IsConnect() bool {
 	return e.Account != ""
 }
 
+// IsThin returns true if *StripeEvent is a v2 thin event, which references its
+// related object rather than embedding it.
+func (e *StripeEvent) IsThin() bool {
+	return e.Object == thinEventObject
+}
+
 // URLForEventID builds a full URL from a StripeEvent ID.
 func (e *StripeEvent) URLForEventID() string {
 	return fmt.Sprintf("%s/events/%s", baseDashboardURL(e.Livemode, e.Account), e.ID)
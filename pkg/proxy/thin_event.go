@@ -0,0 +1,188 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+	"github.com/stripe/stripe-cli/pkg/requests"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+//
+// Public types
+//
+
+// RelatedObject is the object a thin event is about. Unlike snapshot events,
+// thin events don't embed the object.
+type RelatedObject struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ExpandedRelatedObject is the related object of a thin event, fetched from the
+// API when ExpandThinEvents is set
+type ExpandedRelatedObject struct {
+	Event  *StripeEvent
+	Object json.RawMessage
+	Err    error
+}
+
+//
+// Private constants
+//
+
+// thinEventObject is the object of the v2 thin events
+const thinEventObject = "v2.core.event"
+
+//
+// Private types
+//
+
+// thinEventPayload is the payload of thin events, which differs from snapshot
+// events: created is a timestamp string and the account is in context
+type thinEventPayload struct {
+	ID            string         `json:"id"`
+	Object        string         `json:"object"`
+	Type          string         `json:"type"`
+	Created       string         `json:"created"`
+	Livemode      bool           `json:"livemode"`
+	Context       string         `json:"context"`
+	RelatedObject *RelatedObject `json:"related_object"`
+	Reason        struct {
+		Request interface{} `json:"request"`
+	} `json:"reason"`
+}
+
+//
+// Private functions
+//
+
+// parseStripeEvent parses the payload of both snapshot and thin events
+func parseStripeEvent(payload string) (StripeEvent, error) {
+	var probe struct {
+		Object string `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(payload), &probe); err != nil {
+		return StripeEvent{}, err
+	}
+
+	if probe.Object == thinEventObject {
+		return parseThinEvent(payload)
+	}
+
+	var evt StripeEvent
+	if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+		return StripeEvent{}, err
+	}
+
+	req, err := ExtractRequestData(evt.RequestData)
+	if err != nil {
+		return StripeEvent{}, err
+	}
+	evt.Request = req
+
+	return evt, nil
+}
+
+func parseThinEvent(payload string) (StripeEvent, error) {
+	var thin thinEventPayload
+	if err := json.Unmarshal([]byte(payload), &thin); err != nil {
+		return StripeEvent{}, err
+	}
+
+	if thin.RelatedObject == nil {
+		return StripeEvent{}, errors.New("Received thin event without a related object")
+	}
+
+	evt := StripeEvent{
+		Account:       thin.Context,
+		ID:            thin.ID,
+		Livemode:      thin.Livemode,
+		Object:        thin.Object,
+		Type:          thin.Type,
+		RelatedObject: thin.RelatedObject,
+		RequestData:   thin.Reason.Request,
+	}
+
+	if created, err := time.Parse(time.RFC3339Nano, thin.Created); err == nil {
+		evt.Created = int(created.Unix())
+	}
+
+	// thin events that weren't caused by an API request have no request
+	if thin.Reason.Request != nil {
+		req, err := ExtractRequestData(thin.Reason.Request)
+		if err != nil {
+			return StripeEvent{}, err
+		}
+		evt.Request = req
+	}
+
+	return evt, nil
+}
+
+// expandThinEvent fetches the related object of the thin event with the key
+// of the profile it was received for and sends it to the output channel
+func (p *Proxy) expandThinEvent(ctx context.Context, key string, evt *StripeEvent) {
+	if key == "" {
+		key = p.cfg.Key
+	}
+
+	apiBaseURL := p.cfg.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = stripe.DefaultAPIBaseURL
+	}
+
+	params := &requests.RequestParameters{}
+	if evt.Account != "" {
+		params.SetStripeAccount(evt.Account)
+	}
+
+	base := &requests.Base{
+		Profile:        &config.Profile{},
+		Method:         http.MethodGet,
+		SuppressOutput: true,
+		APIBaseURL:     apiBaseURL,
+	}
+
+	object, err := base.MakeRequest(ctx, key, evt.RelatedObject.URL, params, true)
+	if err != nil {
+		p.cfg.Log.WithFields(log.Fields{
+			"prefix":   "proxy.Proxy.expandThinEvent",
+			"event_id": evt.ID,
+		}).Debugf("Failed to fetch the related object: %v", err)
+	}
+
+	p.emitLifecycle(LifecycleEvent{
+		Type:              lifecycleThinEventExpanded,
+		EventID:           evt.ID,
+		EventType:         evt.Type,
+		RelatedObjectData: compactPayload(string(object)),
+		Error:             errorString(err),
+		Profile:           evt.Profile,
+		ProfileAccount:    evt.ProfileAccount,
+	})
+
+	p.cfg.OutCh <- websocket.DataElement{
+		Data: ExpandedRelatedObject{
+			Event:  evt,
+			Object: object,
+			Err:    err,
+		},
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStripeEventThin(t *testing.T) {
+	payload := `{
+  "id": "evt_test_65R",
+  "object": "v2.core.event",
+  "type": "v1.billing.meter.error_report_triggered",
+  "created": "2024-09-17T06:20:52.246Z",
+  "livemode": false,
+  "context": "acct_123",
+  "related_object": {
+    "id": "mtr_test_61R",
+    "type": "billing.meter",
+    "url": "/v1/billing/meters/mtr_test_61R"
+  },
+  "reason": {
+    "type": "request",
+    "request": {"id": "req_123", "idempotency_key": "key_123"}
+  }
+}`
+
+	evt, err := parseStripeEvent(payload)
+	require.NoError(t, err)
+	require.True(t, evt.IsThin())
+	require.Equal(t, "evt_test_65R", evt.ID)
+	require.Equal(t, "v1.billing.meter.error_report_triggered", evt.Type)
+	require.Equal(t, 1726554052, evt.Created)
+	require.True(t, evt.IsConnect())
+	require.Equal(t, &RelatedObject{ID: "mtr_test_61R", Type: "billing.meter", URL: "/v1/billing/meters/mtr_test_61R"}, evt.RelatedObject)
+	require.Equal(t, StripeRequest{ID: "req_123", IdempotencyKey: "key_123"}, evt.Request)
+}
+
+func TestParseStripeEventThinWithoutRequest(t *testing.T) {
+	evt, err := parseStripeEvent(`{"id":"evt_1","object":"v2.core.event","type":"v1.billing.meter.no_meter_found","created":"2024-09-17T06:20:52Z","related_object":{"id":"mtr_1","type":"billing.meter","url":"/v1/billing/meters/mtr_1"}}`)
+	require.NoError(t, err)
+	require.True(t, evt.IsThin())
+	require.False(t, evt.IsConnect())
+	require.Equal(t, StripeRequest{}, evt.Request)
+
+	_, err = parseStripeEvent(`{"id":"evt_1","object":"v2.core.event","type":"v1.billing.meter.no_meter_found"}`)
+	require.Error(t, err)
+}
+
+func TestParseStripeEventSnapshot(t *testing.T) {
+	evt, err := parseStripeEvent(`{"id":"evt_1","object":"event","type":"charge.succeeded","created":1600000000,"request":{"id":"req_1","idempotency_key":null}}`)
+	require.NoError(t, err)
+	require.False(t, evt.IsThin())
+	require.Nil(t, evt.RelatedObject)
+	require.Equal(t, 1600000000, evt.Created)
+	require.Equal(t, "req_1", evt.Request.ID)
+
+	_, err = parseStripeEvent(`{"id":"evt_1","object":"event","type":"charge.succeeded"}`)
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+)
+
+//
+// Private functions
+//
+
+// buildTLSConfig builds the TLS configuration used when forwarding events to
+// HTTPS endpoints. The client certificate and CA files are loaded eagerly so
+// that configuration errors surface at startup rather than on the first event.
+func buildTLSConfig(cfg *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.SkipVerify,
+	}
+
+	if (cfg.ClientCertFile == "") != (cfg.ClientKeyFile == "") {
+		return nil, errors.New("client_cert and client_key must be provided together")
+	}
+
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CACertFile != "" {
+		caCert, err := ioutil.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the CA certificate: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("Failed to parse the CA certificate %s: no PEM certificates found", cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// skipsVerifyForHost reports whether the host of the endpoint URL is one of
+// hosts, which match any port unless they include one
+func skipsVerifyForHost(hosts []string, endpointURL string) bool {
+	u, err := url.Parse(endpointURL)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+
+	for _, host := range hosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLocalURL reports whether the endpoint URL is on the local machine, in
+// which case its traffic can't be intercepted
+func isLocalURL(endpointURL string) bool {
+	if isUnixSocketURL(endpointURL) {
+		return true
+	}
+
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKeyPair(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestKeyPair(t, dir)
+
+	tlsConfig, err := buildTLSConfig(&Config{SkipVerify: true})
+	require.NoError(t, err)
+	require.True(t, tlsConfig.InsecureSkipVerify)
+	require.Empty(t, tlsConfig.Certificates)
+	require.Nil(t, tlsConfig.RootCAs)
+
+	tlsConfig, err = buildTLSConfig(&Config{ClientCertFile: certFile, ClientKeyFile: keyFile, CACertFile: certFile})
+	require.NoError(t, err)
+	require.Len(t, tlsConfig.Certificates, 1)
+	require.NotNil(t, tlsConfig.RootCAs)
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestKeyPair(t, dir)
+
+	_, err := buildTLSConfig(&Config{ClientCertFile: certFile})
+	require.EqualError(t, err, "client_cert and client_key must be provided together")
+
+	_, err = buildTLSConfig(&Config{ClientCertFile: keyFile, ClientKeyFile: keyFile})
+	require.Error(t, err)
+
+	_, err = buildTLSConfig(&Config{CACertFile: filepath.Join(dir, "missing.pem")})
+	require.Error(t, err)
+
+	_, err = buildTLSConfig(&Config{CACertFile: keyFile})
+	require.Error(t, err)
+
+	_, err = Init(context.Background(), &Config{ClientCertFile: certFile, ClientKeyFile: filepath.Join(dir, "missing.pem")})
+	require.Error(t, err)
+}
+
+func TestSkipsVerifyForHost(t *testing.T) {
+	hosts := []string{"localhost:8443", "dev.internal"}
+
+	require.True(t, skipsVerifyForHost(hosts, "https://localhost:8443/webhooks"))
+	require.False(t, skipsVerifyForHost(hosts, "https://localhost:9443/webhooks"))
+	require.True(t, skipsVerifyForHost(hosts, "https://dev.internal:8443/webhooks"))
+	require.True(t, skipsVerifyForHost(hosts, "https://DEV.internal/webhooks"))
+	require.False(t, skipsVerifyForHost(hosts, "https://staging.example.com/webhooks"))
+	require.False(t, skipsVerifyForHost(nil, "https://localhost:8443/webhooks"))
+}
+
+func TestIsLocalURL(t *testing.T) {
+	require.True(t, isLocalURL("https://localhost:8443/webhooks"))
+	require.True(t, isLocalURL("https://app.localhost/webhooks"))
+	require.True(t, isLocalURL("https://127.0.0.1:8443/webhooks"))
+	require.True(t, isLocalURL("https://[::1]:8443/webhooks"))
+	require.True(t, isLocalURL("unix:///tmp/app.sock:/webhooks"))
+	require.False(t, isLocalURL("https://staging.example.com/webhooks"))
+}
+
+func TestNewEndpointClientSkipVerifyHosts(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.New()
+	logger.Out = &logs
+
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs:     []string{"https://localhost:8443/webhooks", "https://staging.example.com/webhooks"},
+		SkipVerifyHosts: []string{"localhost:8443"},
+		Log:             logger,
+	})
+	require.NoError(t, err)
+
+	transport := p.endpointClients[0].cfg.HTTPClient.Transport.(*http.Transport)
+	require.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+	transport = p.endpointClients[1].cfg.HTTPClient.Transport.(*http.Transport)
+	require.False(t, transport.TLSClientConfig.InsecureSkipVerify)
+	require.Empty(t, logs.String())
+
+	_, err = Init(context.Background(), &Config{
+		ForwardURLs: []string{"https://staging.example.com/webhooks"},
+		SkipVerify:  true,
+		Log:         logger,
+	})
+	require.NoError(t, err)
+	require.Contains(t, logs.String(), "Certificate verification is disabled for https://staging.example.com/webhooks")
+}
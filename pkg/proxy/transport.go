@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+//
+// Private constants
+//
+
+const (
+	// maxIdleConnsPerEndpoint is the number of keep-alive connections kept
+	// open to each endpoint. Go's default of 2 closes connections during
+	// bursts of events, only to open new ones for the next deliveries.
+	maxIdleConnsPerEndpoint = 32
+
+	idleConnTimeout     = 90 * time.Second
+	dialTimeout         = 30 * time.Second
+	keepAliveInterval   = 30 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+//
+// Private functions
+//
+
+// newEndpointTransport returns the transport of an endpoint, which is reused
+// by all of its deliveries. Connections are kept alive between deliveries and
+// HTTP/2 is negotiated with HTTPS endpoints supporting it, which setting a
+// custom TLS configuration otherwise disables. The TLS configuration isn't to
+// be shared with other transports.
+func newEndpointTransport(url string, tlsConfig *tls.Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: keepAliveInterval,
+	}
+
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        maxIdleConnsPerEndpoint,
+		MaxIdleConnsPerHost: maxIdleConnsPerEndpoint,
+		IdleConnTimeout:     idleConnTimeout,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+	}
+
+	if socketPath, _, ok := parseUnixSocketURL(url); ok {
+		transport.DialContext = unixSocketDialer(socketPath)
+	}
+
+	return transport
+}
@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/websocket"
+)
+
+// countConnections counts the connections opened to the server
+func countConnections(ts *httptest.Server) *int32 {
+	var conns int32
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+
+	return &conns
+}
+
+// forwardEvents forwards events one after the other, waiting for each
+// response
+func forwardEvents(t *testing.T, p *Proxy, outCh chan websocket.IElement, n int) {
+	evt := &StripeEvent{ID: "evt_123", Type: "charge.succeeded"}
+
+	for i := 0; i < n; i++ {
+		p.forwardEvent(eventContext{event: evt}, p.destinationsFor(evt), "{}", map[string]string{})
+
+		el := <-outCh
+		de, ok := el.(websocket.DataElement)
+		require.True(t, ok, "expected a response, got %#v", el)
+		require.Equal(t, http.StatusOK, de.Data.(EndpointResponse).Resp.StatusCode)
+	}
+}
+
+func TestEndpointTransportReusesConnections(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	conns := countConnections(ts)
+	ts.Start()
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{ts.URL},
+		OutCh:       outCh,
+	})
+	require.NoError(t, err)
+
+	forwardEvents(t, p, outCh, 5)
+	require.Equal(t, int32(1), atomic.LoadInt32(conns))
+}
+
+func TestEndpointTransportHTTP2WithClientCert(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t, t.TempDir())
+
+	var protoMajor, peerCertificates int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.StoreInt32(&protoMajor, int32(r.ProtoMajor))
+		atomic.StoreInt32(&peerCertificates, int32(len(r.TLS.PeerCertificates)))
+	}))
+	ts.EnableHTTP2 = true
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	conns := countConnections(ts)
+	ts.StartTLS()
+	defer ts.Close()
+
+	outCh := make(chan websocket.IElement, 10)
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs:    []string{ts.URL},
+		SkipVerify:     true,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+		OutCh:          outCh,
+	})
+	require.NoError(t, err)
+
+	forwardEvents(t, p, outCh, 5)
+	require.Equal(t, int32(1), atomic.LoadInt32(conns))
+	require.Equal(t, int32(2), atomic.LoadInt32(&protoMajor))
+	require.Equal(t, int32(1), atomic.LoadInt32(&peerCertificates))
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+//
+// Private constants
+//
+
+// unixSocketScheme prefixes forward URLs of endpoints listening on a Unix
+// domain socket, e.g. `unix:///tmp/app.sock:/webhook`
+const unixSocketScheme = "unix://"
+
+//
+// Private functions
+//
+
+func isUnixSocketURL(u string) bool {
+	return strings.HasPrefix(u, unixSocketScheme)
+}
+
+// parseUnixSocketURL splits a `unix:///path/to/app.sock:/webhook` URL into
+// the path of the socket and the HTTP URL requested over it. The request path
+// defaults to `/` when omitted.
+func parseUnixSocketURL(u string) (socketPath string, requestURL string, ok bool) {
+	if !isUnixSocketURL(u) {
+		return "", "", false
+	}
+
+	rest := strings.TrimPrefix(u, unixSocketScheme)
+	requestPath := "/"
+
+	if idx := strings.Index(rest, ":"); idx != -1 {
+		socketPath = rest[:idx]
+		requestPath = rest[idx+1:]
+	} else {
+		socketPath = rest
+	}
+
+	if !strings.HasPrefix(requestPath, "/") {
+		requestPath = "/" + requestPath
+	}
+
+	return socketPath, "http://localhost" + requestPath, true
+}
+
+// buildUnixSocketURL builds a Unix domain socket forward URL
+func buildUnixSocketURL(socketPath string, requestPath string) string {
+	return unixSocketScheme + socketPath + ":" + requestPath
+}
+
+// unixSocketDialer returns a DialContext function connecting to the socket
+// regardless of the address requested, so that the Host header and path of
+// the request are preserved.
+func unixSocketDialer(socketPath string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+}
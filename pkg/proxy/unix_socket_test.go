@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnixSocketURL(t *testing.T) {
+	socketPath, requestURL, ok := parseUnixSocketURL("unix:///tmp/app.sock:/webhook")
+	require.True(t, ok)
+	require.Equal(t, "/tmp/app.sock", socketPath)
+	require.Equal(t, "http://localhost/webhook", requestURL)
+
+	socketPath, requestURL, ok = parseUnixSocketURL("unix:///tmp/app.sock")
+	require.True(t, ok)
+	require.Equal(t, "/tmp/app.sock", socketPath)
+	require.Equal(t, "http://localhost/", requestURL)
+
+	_, _, ok = parseUnixSocketURL("http://localhost:3000/webhook")
+	require.False(t, ok)
+
+	require.Equal(t, "unix:///tmp/app.sock:/webhook", parseURL("unix:///tmp/app.sock:/webhook"))
+}
+
+func TestBuildForwardURLUnixSocket(t *testing.T) {
+	f, err := url.Parse("http://example.com/hooks")
+	require.NoError(t, err)
+
+	forwardURL, err := buildForwardURL("unix:///tmp/app.sock", f, true)
+	require.NoError(t, err)
+	require.Equal(t, "unix:///tmp/app.sock:/hooks", forwardURL)
+
+	forwardURL, err = buildForwardURL("unix:///tmp/app.sock:/stripe/", f, true)
+	require.NoError(t, err)
+	require.Equal(t, "unix:///tmp/app.sock:/stripe/hooks", forwardURL)
+}
+
+func TestInitRejectsUnixSocketURLWithoutPath(t *testing.T) {
+	_, err := Init(context.Background(), &Config{ForwardURLs: []string{"unix://:/webhook"}})
+	require.Error(t, err)
+}
+
+func TestForwardToUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets are not supported on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "stripe-cli")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "app.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/webhook", r.URL.Path)
+		require.Equal(t, "localhost", r.Host)
+		require.Equal(t, "t=123,v1=hunter2", r.Header.Get("Stripe-Signature"))
+		w.WriteHeader(http.StatusOK)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	p, err := Init(context.Background(), &Config{
+		ForwardURLs: []string{"unix://" + socketPath + ":/webhook"},
+	})
+	require.NoError(t, err)
+
+	statusCode := 0
+	endpoint := p.endpointClients[0]
+	endpoint.cfg.ResponseHandler = EndpointResponseHandlerFunc(func(evtCtx eventContext, forwardURL string, resp *http.Response) {
+		require.Equal(t, "unix://"+socketPath+":/webhook", forwardURL)
+		statusCode = resp.StatusCode
+	})
+
+	err = endpoint.Post(eventContext{event: &StripeEvent{ID: "evt_123"}}, "{}", map[string]string{
+		"Stripe-Signature": "t=123,v1=hunter2",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, statusCode)
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// Private constants
+//
+
+// queueDepthWarningFactor is the multiple of the pool size above which the
+// number of queued deliveries is logged
+const queueDepthWarningFactor = 2
+
+//
+// Private types
+//
+
+// workerPool bounds the number of deliveries in flight across all endpoints.
+// Deliveries beyond that are queued per endpoint and started in the order
+// they arrived.
+type workerPool struct {
+	slots chan struct{}
+	log   *log.Logger
+
+	mu            sync.Mutex
+	queues        map[*EndpointClient]*deliveryQueue
+	queued        int
+	overThreshold bool
+	closed        bool
+}
+
+type deliveryQueue struct {
+	mu     sync.Mutex
+	jobs   []func()
+	notify chan struct{}
+}
+
+//
+// Private functions
+//
+
+func newWorkerPool(size int, logger *log.Logger) *workerPool {
+	return &workerPool{
+		slots:  make(chan struct{}, size),
+		log:    logger,
+		queues: make(map[*EndpointClient]*deliveryQueue),
+	}
+}
+
+// submit queues a delivery to the endpoint. It never blocks: deliveries wait
+// in the endpoint's queue until a worker is available. Deliveries submitted
+// once the pool is closed are dropped.
+func (wp *workerPool) submit(endpoint *EndpointClient, job func()) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.closed {
+		return
+	}
+
+	q, ok := wp.queues[endpoint]
+	if !ok {
+		q = &deliveryQueue{notify: make(chan struct{}, 1)}
+		wp.queues[endpoint] = q
+		go wp.dispatch(q)
+	}
+	wp.setQueued(wp.queued + 1)
+
+	q.mu.Lock()
+	q.jobs = append(q.jobs, job)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// close stops the dispatchers of the queues once they started the deliveries
+// queued so far, when the proxy stops
+func (wp *workerPool) close() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.closed {
+		return
+	}
+
+	wp.closed = true
+	for _, q := range wp.queues {
+		close(q.notify)
+	}
+}
+
+// dispatch starts the deliveries of a queue one at a time, in order, each as
+// soon as a worker is available. It returns once the pool is closed.
+func (wp *workerPool) dispatch(q *deliveryQueue) {
+	for range q.notify {
+		for {
+			q.mu.Lock()
+			if len(q.jobs) == 0 {
+				q.mu.Unlock()
+				break
+			}
+			job := q.jobs[0]
+			q.jobs = q.jobs[1:]
+			q.mu.Unlock()
+
+			wp.slots <- struct{}{}
+
+			wp.mu.Lock()
+			wp.setQueued(wp.queued - 1)
+			wp.mu.Unlock()
+
+			go func() {
+				defer func() { <-wp.slots }()
+				job()
+			}()
+		}
+	}
+}
+
+// setQueued updates the number of queued deliveries, logging when it goes
+// above the threshold. It must be called with wp.mu held.
+func (wp *workerPool) setQueued(queued int) {
+	wp.queued = queued
+
+	threshold := queueDepthWarningFactor * cap(wp.slots)
+
+	if queued > threshold && !wp.overThreshold {
+		wp.overThreshold = true
+		wp.log.WithFields(log.Fields{
+			"prefix": "proxy.workerPool",
+			"queued": queued,
+		}).Debugf("More than %d deliveries are waiting for one of %d workers, your endpoint may be slow to respond", threshold, cap(wp.slots))
+	} else if queued <= threshold && wp.overThreshold {
+		wp.overThreshold = false
+		wp.log.WithFields(log.Fields{
+			"prefix": "proxy.workerPool",
+			"queued": queued,
+		}).Debug("Delivery queue is back under the threshold")
+	}
+}
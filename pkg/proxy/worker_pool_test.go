@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPoolBoundsConcurrency(t *testing.T) {
+	pool := newWorkerPool(2, &log.Logger{Out: ioutil.Discard})
+	endpointA := NewEndpointClient("http://localhost:3000", []string{}, false, []string{"*"}, nil)
+	endpointB := NewEndpointClient("http://localhost:4000", []string{}, false, []string{"*"}, nil)
+
+	var mu sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+	orderA := make([]int, 0)
+
+	release := make(chan struct{})
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		i := i
+		for _, endpoint := range []*EndpointClient{endpointA, endpointB} {
+			endpoint := endpoint
+			wg.Add(1)
+			pool.submit(endpoint, func() {
+				defer wg.Done()
+
+				mu.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				if endpoint == endpointA {
+					orderA = append(orderA, i)
+				}
+				mu.Unlock()
+
+				<-release
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			})
+		}
+	}
+
+	close(release)
+	wg.Wait()
+
+	require.LessOrEqual(t, maxInFlight, 2)
+	require.Len(t, orderA, 10)
+}
+
+func TestWorkerPoolPreservesOrder(t *testing.T) {
+	pool := newWorkerPool(1, &log.Logger{Out: ioutil.Discard})
+	endpoint := NewEndpointClient("http://localhost:3000", []string{}, false, []string{"*"}, nil)
+
+	var mu sync.Mutex
+	order := make([]int, 0)
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(1)
+		pool.submit(endpoint, func() {
+			defer wg.Done()
+
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, order)
+}
+
+func TestWorkerPoolClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	pool := newWorkerPool(2, &log.Logger{Out: ioutil.Discard})
+	endpointA := NewEndpointClient("http://localhost:3000", []string{}, false, []string{"*"}, nil)
+	endpointB := NewEndpointClient("http://localhost:4000", []string{}, false, []string{"*"}, nil)
+
+	wg := &sync.WaitGroup{}
+	for _, endpoint := range []*EndpointClient{endpointA, endpointB} {
+		wg.Add(1)
+		pool.submit(endpoint, wg.Done)
+	}
+	wg.Wait()
+
+	pool.close()
+
+	// the dispatchers of the queues return
+	require.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= before
+	}, time.Second, time.Millisecond)
+
+	// and the deliveries submitted afterwards are dropped
+	pool.submit(endpointA, func() { t.Error("delivery submitted after close was started") })
+	pool.close()
+}
@@ -2,10 +2,13 @@ package requests
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
@@ -14,7 +17,9 @@ import (
 	"github.com/stripe/stripe-cli/pkg/ansi"
 	"github.com/stripe/stripe-cli/pkg/config"
 	"github.com/stripe/stripe-cli/pkg/stripe"
+	"github.com/stripe/stripe-cli/pkg/validators"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -26,6 +31,7 @@ type RequestParameters struct {
 	endingBefore  string
 	idempotency   string
 	limit         string
+	page          string
 	version       string
 	stripeAccount string
 }
@@ -45,6 +51,11 @@ func (r *RequestParameters) SetIdempotency(value string) {
 	r.idempotency = value
 }
 
+// SetPage sets the cursor of the page of search results to retrieve.
+func (r *RequestParameters) SetPage(value string) {
+	r.page = value
+}
+
 // SetStripeAccount sets the value for the `Stripe-Account` header.
 func (r *RequestParameters) SetStripeAccount(value string) {
 	r.stripeAccount = value
@@ -83,10 +94,48 @@ type Base struct {
 
 	APIBaseURL string
 
+	// FilesBaseURL is where the files of --file are uploaded,
+	// stripe.DefaultFilesAPIBaseURL if empty
+	FilesBaseURL string
+
 	Livemode bool
 
+	// StatusCode is set to the status code of the response by MakeRequest
+	StatusCode int
+	// ResponseHeader is set to the headers of the response by MakeRequest
+	ResponseHeader http.Header
+
 	autoConfirm bool
+	// force skips the confirmation of the destructive requests in live mode
+	force       bool
 	showHeaders bool
+	paginate    bool
+	query       string
+	output      string
+	columns     []string
+
+	maxRetries      int
+	showTiming      bool
+	autoIdempotency bool
+	// autoIdempotencyKey is the key generated by --auto-idempotency, sent
+	// again by the requests that follow
+	autoIdempotencyKey string
+
+	jsonInput string
+	// jsonInputDone is set once the JSON input is read into the parameters
+	jsonInputDone bool
+	// jsonDocument is the JSON input of the /v2 requests, sent as their body
+	jsonDocument *orderedObject
+
+	file    string
+	purpose string
+
+	previewFeatures     []string
+	stripeVersionSuffix string
+
+	showCurl       bool
+	showCurlUnsafe bool
+	showCurlOnly   bool
 }
 
 var confirmationCommands = map[string]bool{http.MethodDelete: true}
@@ -101,22 +150,22 @@ func (rb *Base) RunRequestsCmd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	confirmed, err := rb.confirmCommand()
+	apiKey, err := rb.Profile.GetAPIKey(rb.Livemode)
 	if err != nil {
 		return err
-	} else if !confirmed {
-		fmt.Println("Exiting without execution. User did not confirm the command.")
-		return nil
 	}
 
-	apiKey, err := rb.Profile.GetAPIKey(rb.Livemode)
+	path, err := createOrNormalizePath(args[0])
 	if err != nil {
 		return err
 	}
 
-	path, err := createOrNormalizePath(args[0])
+	confirmed, err := rb.ConfirmRequest(apiKey, path)
 	if err != nil {
 		return err
+	} else if !confirmed {
+		fmt.Println("Exiting without execution. User did not confirm the command.")
+		return nil
 	}
 
 	_, err = rb.MakeRequest(cmd.Context(), apiKey, path, &rb.Parameters, false)
@@ -129,15 +178,46 @@ func (rb *Base) InitFlags() {
 	if rb.Cmd.Flags().Lookup("confirm") == nil {
 		rb.Cmd.Flags().BoolVarP(&rb.autoConfirm, "confirm", "c", false, "Skip the warning prompt and automatically confirm the command being entered")
 	}
+	if rb.Cmd.Flags().Lookup("force") == nil {
+		rb.Cmd.Flags().BoolVarP(&rb.force, "force", "f", false, "Skip the confirmation of the requests deleting or canceling objects in live mode, like in scripts")
+	}
 
 	rb.Cmd.Flags().StringArrayVarP(&rb.Parameters.data, "data", "d", []string{}, "Data for the API request")
 	rb.Cmd.Flags().StringArrayVarP(&rb.Parameters.expand, "expand", "e", []string{}, "Response attributes to expand inline")
 	rb.Cmd.Flags().StringVarP(&rb.Parameters.idempotency, "idempotency", "i", "", "Set the idempotency key for the request, prevents replaying the same requests within 24 hours")
+	if rb.Cmd.Flags().Lookup("idempotency-key") == nil {
+		rb.Cmd.Flags().StringVar(&rb.Parameters.idempotency, "idempotency-key", "", "Set the Idempotency-Key header of the request, like --idempotency")
+	}
+	if rb.Cmd.Flags().Lookup("max-retries") == nil {
+		rb.Cmd.Flags().IntVar(&rb.maxRetries, "max-retries", 0, "Retry the requests rate limited or failing with a transient error up to this many times, if they are idempotent or have an idempotency key")
+	}
+	if rb.Cmd.Flags().Lookup("show-timing") == nil {
+		rb.Cmd.Flags().BoolVar(&rb.showTiming, "show-timing", false, "Print how long the DNS lookup, connection, TLS handshake and response of the request took to stderr")
+	}
+	if rb.Cmd.Flags().Lookup("json-input") == nil {
+		rb.Cmd.Flags().StringVar(&rb.jsonInput, "json-input", "", "Read the parameters of the request from this JSON file, or from stdin with -, like -d @<file> or -d @-")
+	}
+	if rb.Cmd.Flags().Lookup("auto-idempotency") == nil {
+		rb.Cmd.Flags().BoolVar(&rb.autoIdempotency, "auto-idempotency", false, "Send a generated idempotency key, printed to stderr, so that the request is safe to retry")
+	}
 	rb.Cmd.Flags().StringVarP(&rb.Parameters.version, "stripe-version", "v", "", "Set the Stripe API version to use for your request")
-	rb.Cmd.Flags().StringVar(&rb.Parameters.stripeAccount, "stripe-account", "", "Set a header identifying the connected account")
+	rb.Cmd.Flags().StringArrayVar(&rb.previewFeatures, "preview-feature", []string{}, "Enable a preview feature with the Stripe-Version header, like feature_beta=v3 (can be given several times, requires --stripe-version)")
+	rb.Cmd.Flags().StringVar(&rb.stripeVersionSuffix, "stripe-version-suffix", "", "Append this to the Stripe-Version header as is, after the preview features")
 	rb.Cmd.Flags().BoolVarP(&rb.showHeaders, "show-headers", "s", false, "Show response headers")
+	rb.Cmd.Flags().BoolVar(&rb.showCurl, "show-curl", false, "Print the curl command of the request to stderr, with the API key masked")
+	rb.Cmd.Flags().BoolVar(&rb.showCurlUnsafe, "show-curl-unsafe", false, "Print the curl command of the request to stderr, with the API key")
+	rb.Cmd.Flags().BoolVar(&rb.showCurlOnly, "show-curl-only", false, "Print the curl command of the request to stderr without sending it, with the API key masked unless --show-curl-unsafe")
 	rb.Cmd.Flags().BoolVar(&rb.Livemode, "live", false, "Make a live request (default: test)")
 	rb.Cmd.Flags().BoolVar(&rb.DarkStyle, "dark-style", false, "Use a darker color scheme better suited for lighter command-lines")
+	if rb.Cmd.Flags().Lookup("query") == nil {
+		rb.Cmd.Flags().StringVarP(&rb.query, "query", "q", "", "Only print what this JMESPath-like query selects in the response (e.g. data[].{id: id, amount: amount})")
+	}
+	if rb.Cmd.Flags().Lookup("output") == nil {
+		rb.Cmd.Flags().StringVarP(&rb.output, "output", "o", "", "Print the response as a table or csv instead of JSON, with a row per object of a list")
+	}
+	if rb.Cmd.Flags().Lookup("columns") == nil {
+		rb.Cmd.Flags().StringSliceVar(&rb.columns, "columns", []string{}, "The fields printed as columns by --output, as dotted paths (e.g. id,email,address.city)")
+	}
 
 	// Conditionally add flags for GET requests. I'm doing it here to keep `limit`, `start_after` and `ending_before` unexported
 	if rb.Method == http.MethodGet {
@@ -152,6 +232,26 @@ func (rb *Base) InitFlags() {
 		if rb.Cmd.Flags().Lookup("ending-before") == nil {
 			rb.Cmd.Flags().StringVarP(&rb.Parameters.endingBefore, "ending-before", "b", "", "Retrieve the previous page in the list. This is a cursor for pagination and should be an object ID")
 		}
+
+		if rb.Cmd.Flags().Lookup("paginate") == nil {
+			rb.Cmd.Flags().BoolVar(&rb.paginate, "paginate", false, "Retrieve every page of the list, printing each object as a line of JSON. --limit caps the total number of objects")
+		}
+	}
+
+	// Uploads to the files API
+	if rb.Method == http.MethodPost {
+		if rb.Cmd.Flags().Lookup("file") == nil {
+			rb.Cmd.Flags().StringVar(&rb.file, "file", "", "Upload this file as multipart/form-data to the files API, like stripe post /v1/files --file ./doc.pdf --purpose dispute_evidence")
+		}
+
+		if rb.Cmd.Flags().Lookup("purpose") == nil {
+			rb.Cmd.Flags().StringVar(&rb.purpose, "purpose", "", "The purpose of the file of --file, like dispute_evidence or identity_document")
+		}
+
+		if rb.Cmd.Flags().Lookup("files-base") == nil {
+			rb.Cmd.Flags().StringVar(&rb.FilesBaseURL, "files-base", stripe.DefaultFilesAPIBaseURL, "Sets the files API base URL")
+			rb.Cmd.Flags().MarkHidden("files-base") // #nosec G104
+		}
 	}
 
 	// Hidden configuration flags, useful for dev/debugging
@@ -161,15 +261,32 @@ func (rb *Base) InitFlags() {
 
 // MakeRequest will make a request to the Stripe API with the specific variables given to it
 func (rb *Base) MakeRequest(ctx context.Context, apiKey, path string, params *RequestParameters, errOnStatus bool) ([]byte, error) {
-	parsedBaseURL, err := url.Parse(rb.APIBaseURL)
+	if rb.file != "" {
+		return rb.uploadFile(ctx, apiKey, path, params, errOnStatus)
+	}
+
+	if err := rb.prepareRequest(path, params); err != nil {
+		return []byte{}, err
+	}
+
+	client, err := rb.newClient(rb.APIBaseURL, apiKey)
 	if err != nil {
 		return []byte{}, err
 	}
 
-	client := &stripe.Client{
-		BaseURL: parsedBaseURL,
-		APIKey:  apiKey,
-		Verbose: rb.showHeaders,
+	if rb.paginate && rb.Method == http.MethodGet {
+		return []byte{}, rb.makePaginatedRequest(ctx, client, path, params, os.Stdout)
+	}
+
+	if isV2Path(path) && rb.Method == http.MethodPost {
+		body, err := rb.buildJSONBody(params, os.Stderr)
+		if err != nil {
+			return []byte{}, err
+		}
+
+		return rb.performRequest(ctx, client, path, body, func(req *http.Request) {
+			req.Header.Set("Content-Type", "application/json")
+		}, params, errOnStatus)
 	}
 
 	data, err := rb.buildDataForRequest(params)
@@ -177,16 +294,113 @@ func (rb *Base) MakeRequest(ctx context.Context, apiKey, path string, params *Re
 		return []byte{}, err
 	}
 
+	return rb.performRequest(ctx, client, path, data, nil, params, errOnStatus)
+}
+
+func (rb *Base) newClient(baseURL, apiKey string) (*stripe.Client, error) {
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stripe.Client{
+		BaseURL:    parsedBaseURL,
+		APIKey:     apiKey,
+		Verbose:    rb.showHeaders,
+		MaxRetries: rb.maxRetries,
+		ShowTiming: rb.showTiming,
+	}, nil
+}
+
+// MultipartFile is a file sent as a part of a multipart/form-data request
+type MultipartFile struct {
+	// Field is the name of the form field of the file
+	Field    string
+	Filename string
+	Content  []byte
+	// ContentType is the type of the file, detected from its name or content
+	// if empty
+	ContentType string
+}
+
+// MakeMultipartRequest will make a request to the Stripe API with the params
+// and the file sent as multipart/form-data, like uploads to the files API
+func (rb *Base) MakeMultipartRequest(ctx context.Context, apiKey, path string, params *RequestParameters, file MultipartFile, errOnStatus bool) ([]byte, error) {
+	return rb.makeMultipartRequest(ctx, rb.APIBaseURL, apiKey, path, params, file, errOnStatus)
+}
+
+func (rb *Base) makeMultipartRequest(ctx context.Context, baseURL, apiKey, path string, params *RequestParameters, file MultipartFile, errOnStatus bool) ([]byte, error) {
+	if err := rb.prepareRequest(path, params); err != nil {
+		return []byte{}, err
+	}
+
+	client, err := rb.newClient(baseURL, apiKey)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, datum := range params.data {
+		splitDatum := strings.SplitN(datum, "=", 2)
+		if len(splitDatum) < 2 {
+			return []byte{}, fmt.Errorf("Invalid data argument: %s", datum)
+		}
+
+		if err := writer.WriteField(splitDatum[0], splitDatum[1]); err != nil {
+			return []byte{}, err
+		}
+	}
+
+	for _, datum := range params.expand {
+		if err := writer.WriteField("expand[]", datum); err != nil {
+			return []byte{}, err
+		}
+	}
+
+	part, err := writer.CreatePart(filePartHeader(file))
+	if err != nil {
+		return []byte{}, err
+	}
+	if _, err := part.Write(file.Content); err != nil {
+		return []byte{}, err
+	}
+	if err := writer.Close(); err != nil {
+		return []byte{}, err
+	}
+
+	contentType := writer.FormDataContentType()
+	configure := func(req *http.Request) {
+		req.Header.Set("Content-Type", contentType)
+		rb.reportUploadProgress(req, file)
+	}
+
+	return rb.performRequest(ctx, client, path, body.String(), configure, params, errOnStatus)
+}
+
+// performRequest sends the request with the encoded data, with configure
+// changing the request if set, like its content type
+func (rb *Base) performRequest(ctx context.Context, client *stripe.Client, path, data string, configure func(*http.Request), params *RequestParameters, errOnStatus bool) ([]byte, error) {
 	configureReq := func(req *http.Request) {
+		if configure != nil {
+			configure(req)
+		}
 		rb.setIdempotencyHeader(req, params)
 		rb.setStripeAccountHeader(req, params)
 		rb.setVersionHeader(req, params)
 	}
 
+	if send, err := rb.printCurl(ctx, client, path, data, configureReq); err != nil || !send {
+		return []byte{}, err
+	}
+
 	resp, err := client.PerformRequest(ctx, rb.Method, path, data, configureReq)
 	if err != nil {
 		return []byte{}, err
 	}
+	rb.StatusCode = resp.StatusCode
+	rb.ResponseHeader = resp.Header
 
 	defer resp.Body.Close()
 
@@ -202,6 +416,21 @@ func (rb *Base) MakeRequest(ctx context.Context, apiKey, path string, params *Re
 			return []byte{}, err
 		}
 
+		// The errors are printed as JSON still
+		if rb.query != "" && resp.StatusCode < 300 {
+			result, err := queryJSON(body, rb.query)
+			if err != nil {
+				return body, err
+			}
+			fmt.Println(result)
+			return body, nil
+		}
+
+		// The errors are printed as JSON still
+		if rb.output != "" && resp.StatusCode < 300 {
+			return body, printFormatted(body, rb.output, rb.columns, os.Stdout)
+		}
+
 		result := ansi.ColorizeJSON(string(body), rb.DarkStyle, os.Stdout)
 		fmt.Print(result)
 	}
@@ -209,6 +438,77 @@ func (rb *Base) MakeRequest(ctx context.Context, apiKey, path string, params *Re
 	return body, nil
 }
 
+// prepareRequest checks the flags of the request and sets the parameters
+// they imply
+func (rb *Base) prepareRequest(path string, params *RequestParameters) error {
+	if err := rb.checkOutputFlags(); err != nil {
+		return err
+	}
+
+	if err := rb.setJSONInput(path, params, os.Stdin, os.Stderr); err != nil {
+		return err
+	}
+
+	if _, err := rb.stripeVersion(params); err != nil {
+		return err
+	}
+
+	if isV2Path(path) && len(params.expand) > 0 {
+		return fmt.Errorf("--expand isn't supported by the /v2 API")
+	}
+
+	if rb.purpose != "" && rb.file == "" {
+		return fmt.Errorf("--purpose requires --file")
+	}
+
+	if account := rb.stripeAccount(params); account != "" {
+		if err := validators.AccountID(account); err != nil {
+			return err
+		}
+	}
+
+	return rb.setAutoIdempotency(params, os.Stderr)
+}
+
+// checkOutputFlags returns an error if --query, --output and --columns don't
+// go together
+func (rb *Base) checkOutputFlags() error {
+	if err := checkOutputFormat(rb.output); err != nil {
+		return err
+	}
+
+	if rb.query != "" {
+		if rb.output != "" {
+			return fmt.Errorf("--query can't be used with --output")
+		}
+		if _, err := parseQuery(rb.query); err != nil {
+			return err
+		}
+	}
+
+	if len(rb.columns) > 0 && rb.output == "" {
+		return fmt.Errorf("--columns requires --output table or csv")
+	}
+
+	return nil
+}
+
+// queryJSON returns what the query selects in the JSON, as indented JSON,
+// or as is if it is a string
+func queryJSON(body []byte, expression string) (string, error) {
+	q, err := parseQuery(expression)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := q.search(body)
+	if err != nil {
+		return "", err
+	}
+
+	return formatQueryResult(result, true)
+}
+
 func compileRequestError(body []byte, statusCode int) RequestError {
 	type requestErrorContent struct {
 		Type string `json:"type"`
@@ -216,11 +516,20 @@ func compileRequestError(body []byte, statusCode int) RequestError {
 
 	type requestErrorBody struct {
 		Content requestErrorContent `json:"error"`
+		// Type is the type of the errors of the /v2 API which aren't
+		// wrapped in an error object
+		Type string `json:"type"`
 	}
 
 	var errorBody requestErrorBody
 	json.Unmarshal(body, &errorBody)
-	return RequestError{"Request failed", statusCode, errorBody.Content.Type, string(body)}
+
+	errorType := errorBody.Content.Type
+	if errorType == "" {
+		errorType = errorBody.Type
+	}
+
+	return RequestError{"Request failed", statusCode, errorType, string(body)}
 }
 
 // Confirm calls the confirmCommand() function, triggering the confirmation process
@@ -271,6 +580,11 @@ func (rb *Base) buildDataForRequest(params *RequestParameters) (string, error) {
 			keys = append(keys, "ending_before")
 			values = append(values, params.endingBefore)
 		}
+
+		if params.page != "" {
+			keys = append(keys, "page")
+			values = append(values, params.page)
+		}
 	}
 
 	return encode(keys, values), nil
@@ -304,6 +618,36 @@ func encode(keys []string, values []string) string {
 	return buf.String()
 }
 
+// setAutoIdempotency sets the idempotency key of the parameters to a
+// generated one with --auto-idempotency, printing it so that the request can
+// be retried by hand with --idempotency-key
+func (rb *Base) setAutoIdempotency(params *RequestParameters, w io.Writer) error {
+	if !rb.autoIdempotency {
+		return nil
+	}
+
+	if rb.autoIdempotencyKey != "" && params.idempotency == rb.autoIdempotencyKey {
+		return nil
+	}
+
+	if rb.Method == http.MethodGet {
+		return fmt.Errorf("--auto-idempotency can only be used with POST and DELETE requests")
+	}
+
+	if params.idempotency != "" {
+		return fmt.Errorf("--auto-idempotency can't be used with --idempotency-key")
+	}
+
+	rb.autoIdempotencyKey = uuid.New().String()
+	params.idempotency = rb.autoIdempotencyKey
+
+	if !rb.SuppressOutput {
+		fmt.Fprintf(w, "Idempotency-Key: %s\n", rb.autoIdempotencyKey)
+	}
+
+	return nil
+}
+
 func (rb *Base) setIdempotencyHeader(request *http.Request, params *RequestParameters) {
 	if params.idempotency != "" {
 		request.Header.Set("Idempotency-Key", params.idempotency)
@@ -319,15 +663,32 @@ func (rb *Base) setIdempotencyHeader(request *http.Request, params *RequestParam
 	}
 }
 
+// stripeVersion returns the Stripe-Version header of the request, with the
+// preview features and the suffix of the flags
+func (rb *Base) stripeVersion(params *RequestParameters) (string, error) {
+	return stripeVersionHeader(params.version, rb.previewFeatures, rb.stripeVersionSuffix)
+}
+
 func (rb *Base) setVersionHeader(request *http.Request, params *RequestParameters) {
-	if params.version != "" {
-		request.Header.Set("Stripe-Version", params.version)
+	// The version is checked by prepareRequest
+	if version, err := rb.stripeVersion(params); err == nil && version != "" {
+		request.Header.Set("Stripe-Version", version)
 	}
 }
 
+// stripeAccount returns the connected account the request is made on, the
+// one of the parameters or else the one of --stripe-account
+func (rb *Base) stripeAccount(params *RequestParameters) string {
+	if params.stripeAccount == "" && rb.Profile != nil {
+		return rb.Profile.StripeAccount
+	}
+
+	return params.stripeAccount
+}
+
 func (rb *Base) setStripeAccountHeader(request *http.Request, params *RequestParameters) {
-	if params.stripeAccount != "" {
-		request.Header.Set("Stripe-Account", params.stripeAccount)
+	if account := rb.stripeAccount(params); account != "" {
+		request.Header.Set("Stripe-Account", account)
 	}
 }
 
@@ -371,11 +732,11 @@ func createOrNormalizePath(arg string) (string, error) {
 }
 
 func normalizePath(path string) string {
-	if strings.HasPrefix(path, "/v1/") {
+	if strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/v2/") {
 		return path
 	}
 
-	if strings.HasPrefix(path, "v1/") {
+	if strings.HasPrefix(path, "v1/") || strings.HasPrefix(path, "v2/") {
 		return "/" + path
 	}
 
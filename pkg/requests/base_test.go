@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/config"
 )
 
 func TestBuildDataForRequest(t *testing.T) {
@@ -128,6 +130,44 @@ func TestMakeRequest_ErrOnStatus(t *testing.T) {
 	require.Equal(t, "Request failed, status=500, body=:(", err.Error())
 }
 
+func TestMakeMultipartRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/v1/files", r.URL.Path)
+		require.Equal(t, "Bearer sk_test_1234", r.Header.Get("Authorization"))
+		require.Equal(t, "acct_123", r.Header.Get("Stripe-Account"))
+		require.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data; boundary="))
+
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		require.Equal(t, "dispute_evidence", r.FormValue("purpose"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(file)
+		require.NoError(t, err)
+		require.Equal(t, "evidence.png", header.Filename)
+		require.Equal(t, "PNG", string(content))
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "file_123"}`))
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, SuppressOutput: true}
+	rb.Method = http.MethodPost
+
+	params := &RequestParameters{data: []string{"purpose=dispute_evidence"}}
+	params.SetStripeAccount("acct_123")
+
+	body, err := rb.MakeMultipartRequest(context.Background(), "sk_test_1234", "/v1/files", params, MultipartFile{
+		Field:    "file",
+		Filename: "evidence.png",
+		Content:  []byte("PNG"),
+	}, true)
+	require.NoError(t, err)
+	require.Equal(t, `{"id": "file_123"}`, string(body))
+}
+
 func TestGetUserConfirmationRequired(t *testing.T) {
 	reader := bufio.NewReader(strings.NewReader("yes\n"))
 
@@ -181,6 +221,8 @@ func TestNormalizePath(t *testing.T) {
 	require.Equal(t, "/v1/charges", normalizePath("v1/charges"))
 	require.Equal(t, "/v1/charges", normalizePath("/charges"))
 	require.Equal(t, "/v1/charges", normalizePath("charges"))
+	require.Equal(t, "/v2/core/events", normalizePath("/v2/core/events"))
+	require.Equal(t, "/v2/core/events", normalizePath("v2/core/events"))
 }
 
 func TestCreateOrNormalizePath(t *testing.T) {
@@ -208,3 +250,79 @@ func TestCreateOrNormalizePath(t *testing.T) {
 	result, _ = createOrNormalizePath("charges")
 	require.Equal(t, "/v1/charges", result)
 }
+
+func TestMakeRequestAutoIdempotency(t *testing.T) {
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.Write([]byte(`{"id": "cus_1"}`))
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, Method: http.MethodPost, SuppressOutput: true, autoIdempotency: true}
+	params := &RequestParameters{}
+
+	_, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", params, true)
+	require.NoError(t, err)
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", params, true)
+	require.NoError(t, err)
+
+	// The generated key is sent again
+	require.Len(t, keys, 2)
+	require.Len(t, keys[0], 36)
+	require.Equal(t, keys[0], keys[1])
+
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{idempotency: "foo"}, true)
+	require.EqualError(t, err, "--auto-idempotency can't be used with --idempotency-key")
+
+	rb.Method = http.MethodGet
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{}, true)
+	require.EqualError(t, err, "--auto-idempotency can only be used with POST and DELETE requests")
+}
+
+func TestMakeRequestMaxRetries(t *testing.T) {
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id": "cus_1"}`))
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, Method: http.MethodPost, SuppressOutput: true, autoIdempotency: true, maxRetries: 2}
+
+	body, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{}, true)
+	require.NoError(t, err)
+	require.Equal(t, `{"id": "cus_1"}`, string(body))
+
+	// The retry is sent with the same generated key
+	require.Len(t, keys, 2)
+	require.NotEmpty(t, keys[0])
+	require.Equal(t, keys[0], keys[1])
+}
+
+func TestMakeRequestStripeAccount(t *testing.T) {
+	var accounts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accounts = append(accounts, r.Header.Get("Stripe-Account"))
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, Method: http.MethodGet, SuppressOutput: true, Profile: &config.Profile{StripeAccount: "acct_123"}}
+
+	_, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{}, true)
+	require.NoError(t, err)
+	// The account of the parameters wins
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{stripeAccount: "acct_456"}, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"acct_123", "acct_456"}, accounts)
+
+	rb.Profile.StripeAccount = "cus_123"
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{}, true)
+	require.EqualError(t, err, "cus_123 is not a valid account ID, it must start with acct_")
+}
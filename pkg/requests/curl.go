@@ -0,0 +1,166 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+// curlSkippedHeaders are the headers of the requests which aren't needed to
+// repeat them with curl
+var curlSkippedHeaders = map[string]bool{
+	"Accept-Encoding":            true,
+	"User-Agent":                 true,
+	"X-Stripe-Client-User-Agent": true,
+}
+
+var apiKeyPrefixRegexp = regexp.MustCompile(`^[a-z]+_(test|live)_`)
+
+// printCurl prints the curl command of the request on stderr with
+// --show-curl, and returns whether the request is to be sent, which it isn't
+// with --show-curl-only
+func (rb *Base) printCurl(ctx context.Context, client *stripe.Client, path, data string, configure func(*http.Request)) (bool, error) {
+	if !rb.showCurl && !rb.showCurlUnsafe && !rb.showCurlOnly {
+		return true, nil
+	}
+
+	req, err := client.NewRequest(ctx, rb.Method, path, data, configure)
+	if err != nil {
+		return false, err
+	}
+
+	command, err := curlCommand(req, data, rb.showCurlUnsafe)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintln(os.Stderr, command)
+
+	return !rb.showCurlOnly, nil
+}
+
+// curlCommand returns the curl command which sends the request with the
+// body, with the API key masked unless unsafe. The headers curl sets by
+// itself, like the content type of forms, are left out.
+func curlCommand(req *http.Request, body string, unsafe bool) (string, error) {
+	// A line per flag
+	lines := []string{"curl " + shellQuote(req.URL.String())}
+	// Without globbing, for the brackets of the query like expand[]
+	if strings.ContainsAny(req.URL.String(), "[]") {
+		lines = append(lines, "-g")
+	}
+	if req.Method != http.MethodGet {
+		lines = append(lines, "-X "+req.Method)
+	}
+
+	hasBody := req.Method == http.MethodPost && body != ""
+	mediaType, mediaParams, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if curlSkippedHeaders[name] {
+			continue
+		}
+		if name == "Content-Type" && (!hasBody || mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data") {
+			continue
+		}
+
+		for _, value := range req.Header[name] {
+			if name == "Authorization" && !unsafe {
+				value = maskAuthorization(value)
+			}
+			lines = append(lines, "-H "+shellQuote(name+": "+value))
+		}
+	}
+
+	if hasBody {
+		switch mediaType {
+		case "application/x-www-form-urlencoded":
+			for _, pair := range strings.Split(body, "&") {
+				lines = append(lines, "-d "+shellQuote(unescapeBrackets(pair)))
+			}
+		case "multipart/form-data":
+			fields, err := curlFormFields(body, mediaParams["boundary"])
+			if err != nil {
+				return "", err
+			}
+			lines = append(lines, fields...)
+		default:
+			lines = append(lines, "-d "+shellQuote(body))
+		}
+	}
+
+	return strings.Join(lines, " \\\n  "), nil
+}
+
+// curlFormFields returns the -F flags of the fields of a multipart/form-data
+// body, with the files read from their name
+func curlFormFields(body, boundary string) ([]string, error) {
+	lines := []string{}
+	reader := multipart.NewReader(strings.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FileName() != "" {
+			lines = append(lines, "-F "+shellQuote(part.FormName()+"=@"+part.FileName()))
+			continue
+		}
+
+		var value bytes.Buffer
+		if _, err := io.Copy(&value, part); err != nil {
+			return nil, err
+		}
+		lines = append(lines, "-F "+shellQuote(part.FormName()+"="+value.String()))
+	}
+}
+
+// maskAuthorization masks the API key of the Authorization header, keeping
+// its prefix, like Bearer sk_test_***
+func maskAuthorization(value string) string {
+	split := strings.SplitN(value, " ", 2)
+	if len(split) < 2 {
+		return "***"
+	}
+
+	return split[0] + " " + apiKeyPrefixRegexp.FindString(split[1]) + "***"
+}
+
+// unescapeBrackets makes the keys of the form data readable, like
+// metadata[order] instead of metadata%5Border%5D, which the API accepts
+func unescapeBrackets(pair string) string {
+	split := strings.SplitN(pair, "=", 2)
+	split[0] = strings.NewReplacer("%5B", "[", "%5D", "]").Replace(split[0])
+
+	return strings.Join(split, "=")
+}
+
+var shellSafeRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-./:=@%,+]+$`)
+
+// shellQuote quotes the argument for a POSIX shell if it needs to be
+func shellQuote(arg string) string {
+	if shellSafeRegexp.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,108 @@
+package requests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+func newCurlRequest(t *testing.T, method, path, data string, configure func(*http.Request)) *http.Request {
+	baseURL, _ := url.Parse("https://api.stripe.com")
+	client := &stripe.Client{BaseURL: baseURL, APIKey: "sk_test_1234"}
+
+	req, err := client.NewRequest(context.Background(), method, path, data, configure)
+	require.NoError(t, err)
+
+	return req
+}
+
+func TestCurlCommand(t *testing.T) {
+	req := newCurlRequest(t, http.MethodPost, "/v1/customers", "email=bender%40example.com&metadata%5Border%5D=6735", func(req *http.Request) {
+		req.Header.Set("Idempotency-Key", "key_123")
+	})
+
+	command, err := curlCommand(req, "email=bender%40example.com&metadata%5Border%5D=6735", false)
+	require.NoError(t, err)
+	require.Equal(t, `curl https://api.stripe.com/v1/customers \
+  -X POST \
+  -H 'Authorization: Bearer sk_test_***' \
+  -H 'Idempotency-Key: key_123' \
+  -d email=bender%40example.com \
+  -d 'metadata[order]=6735'`, command)
+
+	// The API key is printed unsafe
+	command, err = curlCommand(req, "email=bender%40example.com", true)
+	require.NoError(t, err)
+	require.Contains(t, command, "'Authorization: Bearer sk_test_1234'")
+}
+
+func TestCurlCommandGet(t *testing.T) {
+	req := newCurlRequest(t, http.MethodGet, "/v1/charges", "limit=3&expand[]=data.customer", nil)
+
+	command, err := curlCommand(req, "limit=3&expand[]=data.customer", false)
+	require.NoError(t, err)
+	require.Equal(t, `curl 'https://api.stripe.com/v1/charges?limit=3&expand[]=data.customer' \
+  -g \
+  -H 'Authorization: Bearer sk_test_***'`, command)
+}
+
+func TestCurlCommandJSON(t *testing.T) {
+	body := `{"event_name":"api_requests","payload":{"value":25}}`
+	req := newCurlRequest(t, http.MethodPost, "/v2/billing/meter_events", body, func(req *http.Request) {
+		req.Header.Set("Content-Type", "application/json")
+	})
+
+	command, err := curlCommand(req, body, false)
+	require.NoError(t, err)
+	require.Equal(t, `curl https://api.stripe.com/v2/billing/meter_events \
+  -X POST \
+  -H 'Authorization: Bearer sk_test_***' \
+  -H 'Content-Type: application/json' \
+  -d '{"event_name":"api_requests","payload":{"value":25}}'`, command)
+}
+
+func TestMaskAuthorization(t *testing.T) {
+	require.Equal(t, "Bearer sk_test_***", maskAuthorization("Bearer sk_test_1234"))
+	require.Equal(t, "Bearer rk_live_***", maskAuthorization("Bearer rk_live_1234"))
+	require.Equal(t, "Bearer ***", maskAuthorization("Bearer 1234"))
+	require.Equal(t, "***", maskAuthorization("1234"))
+}
+
+func TestShellQuote(t *testing.T) {
+	require.Equal(t, "amount=2000", shellQuote("amount=2000"))
+	require.Equal(t, "'name=Bender Rodriguez'", shellQuote("name=Bender Rodriguez"))
+	require.Equal(t, `'name=Bender'\''s'`, shellQuote("name=Bender's"))
+}
+
+func TestMakeRequestShowCurlOnly(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("The request was sent")
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, Method: http.MethodPost, showCurlOnly: true}
+
+	_, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{data: []string{"email=bender@example.com"}}, true)
+	require.NoError(t, err)
+
+	rb.Method = http.MethodGet
+	rb.paginate = true
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/customers", &RequestParameters{}, true)
+	require.NoError(t, err)
+}
+
+func TestCurlFormFields(t *testing.T) {
+	body := "--boundary\r\nContent-Disposition: form-data; name=\"purpose\"\r\n\r\ndispute_evidence\r\n" +
+		"--boundary\r\nContent-Disposition: form-data; name=\"file\"; filename=\"doc.pdf\"\r\nContent-Type: application/pdf\r\n\r\n%PDF\r\n" +
+		"--boundary--\r\n"
+
+	fields, err := curlFormFields(body, "boundary")
+	require.NoError(t, err)
+	require.Equal(t, []string{"-F purpose=dispute_evidence", "-F file=@doc.pdf"}, fields)
+}
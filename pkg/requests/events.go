@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/stripe/stripe-cli/pkg/config"
+)
+
+// EventList is a page of the events of the account, most recent first
+type EventList struct {
+	Data    []json.RawMessage `json:"data"`
+	HasMore bool              `json:"has_more"`
+}
+
+// EventsList returns up to limit events of the account, most recent first,
+// starting after the event with the ID startingAfter when it is set
+func EventsList(ctx context.Context, baseURL, apiVersion, apiKey, startingAfter string, limit int, profile *config.Profile) (EventList, error) {
+	params := &RequestParameters{
+		limit:         strconv.Itoa(limit),
+		startingAfter: startingAfter,
+		version:       apiVersion,
+	}
+
+	base := &Base{
+		Profile:        profile,
+		Method:         http.MethodGet,
+		SuppressOutput: true,
+		APIBaseURL:     baseURL,
+	}
+
+	data := EventList{}
+
+	resp, err := base.MakeRequest(ctx, apiKey, "/v1/events", params, true)
+	if err != nil {
+		return data, err
+	}
+
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,173 @@
+package requests
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// readJSONInput reads the JSON document of --json-input, from stdin if it is
+// "-" or from the file otherwise
+func readJSONInput(input string, stdin io.Reader) ([]byte, error) {
+	if input == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+
+	return ioutil.ReadFile(input)
+}
+
+// jsonToFormData converts a JSON object into the data of a request in the
+// form encoding of the API, with brackets for the nested fields, like:
+//
+//	{"line_items": [{"price": "price_123", "quantity": 2}], "metadata": {"order": "6735"}}
+//
+// into line_items[0][price]=price_123, line_items[0][quantity]=2 and
+// metadata[order]=6735. Arrays of values are sent like expand[]=customer.
+// null and empty values are sent as an empty string, which unsets them.
+func jsonToFormData(input []byte) ([]string, error) {
+	object, err := decodeJSONObject(input)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []string{}
+	for _, key := range object.keys {
+		data = appendFormData(data, key, object.values[key])
+	}
+
+	return data, nil
+}
+
+// decodeJSONObject decodes the JSON input, which must be an object
+func decodeJSONObject(input []byte) (*orderedObject, error) {
+	value, err := decodeOrdered(input)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JSON input: %w", err)
+	}
+
+	object, ok := value.(*orderedObject)
+	if !ok {
+		return nil, fmt.Errorf("Invalid JSON input: expected an object of the parameters of the request")
+	}
+
+	return object, nil
+}
+
+func appendFormData(data []string, key string, value interface{}) []string {
+	switch v := value.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			return append(data, key+"=")
+		}
+		for _, field := range v.keys {
+			data = appendFormData(data, fmt.Sprintf("%s[%s]", key, field), v.values[field])
+		}
+		return data
+	case []interface{}:
+		if len(v) == 0 {
+			return append(data, key+"=")
+		}
+		for i, element := range v {
+			switch element.(type) {
+			case *orderedObject, []interface{}:
+				data = appendFormData(data, fmt.Sprintf("%s[%d]", key, i), element)
+			default:
+				data = appendFormData(data, key+"[]", element)
+			}
+		}
+		return data
+	case nil:
+		return append(data, key+"=")
+	default:
+		return append(data, fmt.Sprintf("%s=%v", key, v))
+	}
+}
+
+// mergeFormData returns the data of the JSON input followed by the data of
+// the -d flags, without the values of the JSON input which the flags set
+// again. The keys overridden are returned so that they can be warned about.
+func mergeFormData(jsonData, flagData []string) ([]string, []string) {
+	flagKeys := make(map[string]bool)
+	for _, datum := range flagData {
+		flagKeys[strings.SplitN(datum, "=", 2)[0]] = true
+	}
+
+	merged := []string{}
+	overridden := []string{}
+	seen := make(map[string]bool)
+	for _, datum := range jsonData {
+		key := strings.SplitN(datum, "=", 2)[0]
+		if flagKeys[key] {
+			if !seen[key] {
+				overridden = append(overridden, key)
+				seen[key] = true
+			}
+			continue
+		}
+		merged = append(merged, datum)
+	}
+
+	return append(merged, flagData...), overridden
+}
+
+// setJSONInput converts the JSON document of --json-input, or of -d @- or
+// -d @<file>, into the data of the request. The -d flags win over the JSON
+// document, with a warning. The document of the POST requests of the /v2
+// API is kept as it is instead, to be sent as their body.
+func (rb *Base) setJSONInput(path string, params *RequestParameters, stdin io.Reader, warnings io.Writer) error {
+	if rb.jsonInputDone {
+		return nil
+	}
+
+	input := rb.jsonInput
+	flagData := []string{}
+	for _, datum := range params.data {
+		if strings.HasPrefix(datum, "@") {
+			if input != "" {
+				return fmt.Errorf("Only one JSON input can be given, with --json-input or -d @<file>")
+			}
+			input = strings.TrimPrefix(datum, "@")
+			continue
+		}
+		flagData = append(flagData, datum)
+	}
+
+	if input == "" {
+		return nil
+	}
+
+	content, err := readJSONInput(input, stdin)
+	if err != nil {
+		return fmt.Errorf("Failed to read the JSON input: %w", err)
+	}
+
+	if isV2Path(path) && rb.Method == http.MethodPost {
+		document, err := decodeJSONObject(content)
+		if err != nil {
+			return err
+		}
+
+		rb.jsonDocument = document
+		params.data = flagData
+		rb.jsonInputDone = true
+
+		return nil
+	}
+
+	jsonData, err := jsonToFormData(content)
+	if err != nil {
+		return err
+	}
+
+	merged, overridden := mergeFormData(jsonData, flagData)
+	for _, key := range overridden {
+		fmt.Fprintf(warnings, "Warning: -d %s overrides the value of the JSON input\n", key)
+	}
+
+	params.data = merged
+	rb.jsonInputDone = true
+
+	return nil
+}
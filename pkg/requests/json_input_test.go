@@ -0,0 +1,87 @@
+package requests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToFormData(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"values", `{"amount": 2000, "currency": "usd", "capture": false, "rate": 1.5}`, []string{"amount=2000", "currency=usd", "capture=false", "rate=1.5"}},
+		{"nested objects", `{"metadata": {"order": "6735", "shipping": {"carrier": "ups"}}}`, []string{"metadata[order]=6735", "metadata[shipping][carrier]=ups"}},
+		{"arrays of values", `{"payment_method_types": ["card", "sepa_debit"]}`, []string{"payment_method_types[]=card", "payment_method_types[]=sepa_debit"}},
+		{
+			"arrays of objects",
+			`{"line_items": [{"price_data": {"currency": "usd", "product_data": {"name": "T-shirt"}}, "quantity": 1}, {"price": "price_123", "quantity": 2}]}`,
+			[]string{
+				"line_items[0][price_data][currency]=usd",
+				"line_items[0][price_data][product_data][name]=T-shirt",
+				"line_items[0][quantity]=1",
+				"line_items[1][price]=price_123",
+				"line_items[1][quantity]=2",
+			},
+		},
+		{"empty values", `{"description": null, "metadata": {}, "tax_rates": []}`, []string{"description=", "metadata=", "tax_rates="}},
+		{"large numbers", `{"created": 1672531200000}`, []string{"created=1672531200000"}},
+		{"empty object", `{}`, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := jsonToFormData([]byte(test.input))
+			require.NoError(t, err)
+			require.Equal(t, test.expected, data)
+		})
+	}
+}
+
+func TestJSONToFormDataErrors(t *testing.T) {
+	_, err := jsonToFormData([]byte(`["card"]`))
+	require.EqualError(t, err, "Invalid JSON input: expected an object of the parameters of the request")
+
+	_, err = jsonToFormData([]byte(`{"amount": `))
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "Invalid JSON input: "))
+}
+
+func TestSetJSONInput(t *testing.T) {
+	rb := Base{}
+	params := &RequestParameters{data: []string{"@-", "metadata[order]=6736", "description=Shirts"}}
+	var warnings bytes.Buffer
+
+	err := rb.setJSONInput("/v1/charges", params, strings.NewReader(`{"amount": 2000, "metadata": {"order": "6735"}}`), &warnings)
+	require.NoError(t, err)
+	// -d wins
+	require.Equal(t, []string{"amount=2000", "metadata[order]=6736", "description=Shirts"}, params.data)
+	require.Equal(t, "Warning: -d metadata[order] overrides the value of the JSON input\n", warnings.String())
+
+	// Read once
+	err = rb.setJSONInput("/v1/charges", params, strings.NewReader(`{}`), &warnings)
+	require.NoError(t, err)
+	require.Equal(t, []string{"amount=2000", "metadata[order]=6736", "description=Shirts"}, params.data)
+}
+
+func TestSetJSONInputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"email": "bender@example.com"}`), os.ModePerm))
+
+	rb := Base{jsonInput: path}
+	params := &RequestParameters{}
+	err := rb.setJSONInput("/v1/charges", params, strings.NewReader(""), &bytes.Buffer{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"email=bender@example.com"}, params.data)
+
+	rb = Base{jsonInput: path}
+	err = rb.setJSONInput("/v1/charges", &RequestParameters{data: []string{"@-"}}, strings.NewReader(""), &bytes.Buffer{})
+	require.EqualError(t, err, "Only one JSON input can be given, with --json-input or -d @<file>")
+}
@@ -0,0 +1,101 @@
+package requests
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	"github.com/stripe/stripe-cli/pkg/validators"
+)
+
+// destructiveActions are the last segments of the paths of the POST requests
+// which can't be undone, like /v1/invoices/{invoice}/void
+var destructiveActions = map[string]bool{
+	"cancel":             true,
+	"close":              true,
+	"detach":             true,
+	"expire":             true,
+	"mark_uncollectible": true,
+	"reject":             true,
+	"void":               true,
+}
+
+// ConfirmRequest asks for the confirmation of the request to the path with
+// the API key. The destructive requests in live mode are confirmed by typing
+// the ID of the object or yes, unless --force, and the other DELETE requests
+// like Confirm.
+func (rb *Base) ConfirmRequest(apiKey, path string) (bool, error) {
+	reader := bufio.NewReader(os.Stdin)
+	if validators.IsLiveAPIKey(apiKey) && isDestructiveRequest(rb.Method, path) {
+		return rb.getLiveConfirmation(path, reader, ansi.IsTerminal(os.Stdin))
+	}
+
+	return rb.getUserConfirmation(reader)
+}
+
+// getLiveConfirmation asks for the ID of the object of the path or yes. It
+// fails without a terminal to ask on, rather than wait for an answer.
+func (rb *Base) getLiveConfirmation(path string, reader *bufio.Reader, interactive bool) (bool, error) {
+	if rb.force {
+		return true, nil
+	}
+
+	if !interactive {
+		return false, fmt.Errorf("Refusing to %s %s in live mode without confirmation. Run it with --force to skip the confirmation, like in scripts", rb.Method, path)
+	}
+
+	id := destructiveObjectID(path)
+	if id != "" {
+		fmt.Printf("You are about to %s %s in live mode, which can't be undone.\nEnter the ID %s or 'yes' to confirm: ", rb.Method, path, id)
+	} else {
+		fmt.Printf("You are about to %s %s in live mode, which can't be undone.\nEnter 'yes' to confirm: ", rb.Method, path)
+	}
+
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	input = strings.Trim(input, " \r\n")
+
+	return strings.ToLower(input) == "yes" || (id != "" && input == id), nil
+}
+
+var objectIDRegexp = regexp.MustCompile(`^[a-z]+_(?:[a-z_]+_)?[A-Za-z0-9]*[A-Z0-9][A-Za-z0-9]*$`)
+
+// isDestructiveRequest returns whether the request deletes an object, or
+// makes a change to it which can't be undone, like canceling it
+func isDestructiveRequest(method, path string) bool {
+	if confirmationCommands[method] {
+		return true
+	}
+
+	return method == http.MethodPost && destructiveActions[lastPathSegment(path)]
+}
+
+// destructiveObjectID returns the ID of the object of the destructive
+// request, like in_123 of /v1/invoices/in_123/void, or "" if it has none
+func destructiveObjectID(path string) string {
+	segments := strings.Split(strings.Trim(strings.SplitN(path, "?", 2)[0], "/"), "/")
+	if destructiveActions[segments[len(segments)-1]] {
+		segments = segments[:len(segments)-1]
+	}
+
+	// The IDs have a prefix and a random part, unlike the names of the
+	// resources like payment_methods
+	id := segments[len(segments)-1]
+	if !objectIDRegexp.MatchString(id) {
+		return ""
+	}
+
+	return id
+}
+
+func lastPathSegment(path string) string {
+	path = strings.TrimRight(strings.SplitN(path, "?", 2)[0], "/")
+
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,78 @@
+package requests
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmRequestTestmode(t *testing.T) {
+	// Nothing is read from stdin
+	rb := Base{Method: http.MethodPost}
+
+	confirmed, err := rb.ConfirmRequest("sk_test_1234", "/v1/invoices/in_1234/void")
+	require.NoError(t, err)
+	require.True(t, confirmed)
+}
+
+func TestConfirmRequestLivemodeForce(t *testing.T) {
+	rb := Base{Method: http.MethodDelete, force: true}
+
+	confirmed, err := rb.ConfirmRequest("sk_live_1234", "/v1/customers/cus_FROPkgsHVRRspg")
+	require.NoError(t, err)
+	require.True(t, confirmed)
+}
+
+func TestGetLiveConfirmation(t *testing.T) {
+	tests := []struct {
+		input     string
+		confirmed bool
+	}{
+		{"cus_FROPkgsHVRRspg\n", true},
+		{"yes\n", true},
+		{" YES \r\n", true},
+		{"no\n", false},
+		{"cus_G6GQwbr1dWXt9O\n", false},
+		{"\n", false},
+	}
+
+	for _, test := range tests {
+		rb := Base{Method: http.MethodDelete}
+
+		confirmed, err := rb.getLiveConfirmation("/v1/customers/cus_FROPkgsHVRRspg", bufio.NewReader(strings.NewReader(test.input)), true)
+		require.NoError(t, err)
+		require.Equal(t, test.confirmed, confirmed, test.input)
+	}
+}
+
+func TestGetLiveConfirmationNotInteractive(t *testing.T) {
+	rb := Base{Method: http.MethodDelete}
+
+	confirmed, err := rb.getLiveConfirmation("/v1/customers/cus_FROPkgsHVRRspg", bufio.NewReader(strings.NewReader("yes\n")), false)
+	require.EqualError(t, err, "Refusing to DELETE /v1/customers/cus_FROPkgsHVRRspg in live mode without confirmation. Run it with --force to skip the confirmation, like in scripts")
+	require.False(t, confirmed)
+
+	rb.force = true
+	confirmed, err = rb.getLiveConfirmation("/v1/customers/cus_FROPkgsHVRRspg", bufio.NewReader(strings.NewReader("")), false)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+}
+
+func TestIsDestructiveRequest(t *testing.T) {
+	require.True(t, isDestructiveRequest(http.MethodDelete, "/v1/customers/cus_FROPkgsHVRRspg"))
+	require.True(t, isDestructiveRequest(http.MethodPost, "/v1/invoices/in_1234/void"))
+	require.True(t, isDestructiveRequest(http.MethodPost, "/v1/subscriptions/sub_1234/cancel"))
+	require.False(t, isDestructiveRequest(http.MethodPost, "/v1/customers"))
+	require.False(t, isDestructiveRequest(http.MethodGet, "/v1/invoices/in_1234/void"))
+}
+
+func TestDestructiveObjectID(t *testing.T) {
+	require.Equal(t, "cus_FROPkgsHVRRspg", destructiveObjectID("/v1/customers/cus_FROPkgsHVRRspg"))
+	require.Equal(t, "in_1234", destructiveObjectID("/v1/invoices/in_1234/mark_uncollectible"))
+	require.Equal(t, "ed_test_61RM8ltWcTW4mbsxf16RJyfa", destructiveObjectID("/v2/core/event_destinations/ed_test_61RM8ltWcTW4mbsxf16RJyfa"))
+	require.Equal(t, "", destructiveObjectID("/v1/payment_methods"))
+	require.Equal(t, "", destructiveObjectID("/v1/customers/cus_FROPkgsHVRRspg/discount"))
+}
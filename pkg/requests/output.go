@@ -0,0 +1,224 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	outputTable = "table"
+	outputCSV   = "csv"
+)
+
+// timestampLayout is how the timestamps are printed in tables
+const timestampLayout = "2006-01-02 15:04:05"
+
+// checkOutputFormat returns an error if the format isn't one of --output
+func checkOutputFormat(format string) error {
+	switch format {
+	case "", outputTable, outputCSV:
+		return nil
+	default:
+		return fmt.Errorf("Invalid output format %s, expected table or csv", format)
+	}
+}
+
+// formatter prints objects as the rows of a table or CSV, with the columns
+// given by --columns as dotted paths, or else the top level fields of the
+// first object which aren't objects or lists
+type formatter struct {
+	format        string
+	columns       []string
+	headerPrinted bool
+
+	table *tabwriter.Writer
+	csv   *csv.Writer
+}
+
+func newFormatter(format string, columns []string, w io.Writer) *formatter {
+	f := &formatter{format: format, columns: columns}
+	if format == outputCSV {
+		f.csv = csv.NewWriter(w)
+	} else {
+		f.table = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	}
+
+	return f
+}
+
+// printFormatted prints the response as a table or CSV, with one row per
+// object of a list, or a row of key and value per field of an object in a
+// table
+func printFormatted(body []byte, format string, columns []string, w io.Writer) error {
+	response := gjson.ParseBytes(body)
+	f := newFormatter(format, columns, w)
+
+	if response.Get("object").String() == "list" && response.Get("data").IsArray() {
+		for _, object := range response.Get("data").Array() {
+			if err := f.writeRow(object); err != nil {
+				return err
+			}
+		}
+		return f.flush()
+	}
+
+	if format == outputCSV {
+		if err := f.writeRow(response); err != nil {
+			return err
+		}
+		return f.flush()
+	}
+
+	if err := f.writeFields(response); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+// writeRow prints the object as a row, after the header if it is the first
+func (f *formatter) writeRow(object gjson.Result) error {
+	if !f.headerPrinted {
+		if len(f.columns) == 0 {
+			f.columns = scalarFields(object)
+		}
+		if err := f.write(f.headerValues()); err != nil {
+			return err
+		}
+		f.headerPrinted = true
+	}
+
+	values := make([]string, 0, len(f.columns))
+	for _, column := range f.columns {
+		values = append(values, f.value(column, object.Get(column)))
+	}
+
+	return f.write(values)
+}
+
+// writeFields prints the fields of the object, or its --columns, as rows of
+// key and value
+func (f *formatter) writeFields(object gjson.Result) error {
+	columns := f.columns
+	if len(columns) == 0 {
+		object.ForEach(func(key, _ gjson.Result) bool {
+			columns = append(columns, key.String())
+			return true
+		})
+	}
+
+	for _, column := range columns {
+		if err := f.write([]string{column, f.value(column, object.Get(column))}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *formatter) headerValues() []string {
+	if f.csv != nil {
+		return f.columns
+	}
+
+	header := make([]string, 0, len(f.columns))
+	for _, column := range f.columns {
+		header = append(header, strings.ToUpper(column))
+	}
+
+	return header
+}
+
+// value returns the value of a field as printed, with the timestamps of
+// tables made readable
+func (f *formatter) value(column string, value gjson.Result) string {
+	switch value.Type {
+	case gjson.Null:
+		return ""
+	case gjson.String:
+		return value.Str
+	case gjson.Number:
+		if f.table != nil && isTimestampField(column) && value.Int() > 0 {
+			return time.Unix(value.Int(), 0).Format(timestampLayout)
+		}
+		return value.Raw
+	case gjson.JSON:
+		return compactJSON(value.Raw)
+	default:
+		return value.Raw
+	}
+}
+
+func (f *formatter) write(values []string) error {
+	if f.csv != nil {
+		return f.csv.Write(values)
+	}
+
+	// Tabs and newlines would break the alignment of the columns
+	cells := make([]string, 0, len(values))
+	for _, value := range values {
+		cells = append(cells, strings.NewReplacer("\t", " ", "\n", " ").Replace(value))
+	}
+	_, err := fmt.Fprintln(f.table, strings.Join(cells, "\t"))
+
+	return err
+}
+
+func (f *formatter) flush() error {
+	if f.csv != nil {
+		f.csv.Flush()
+		return f.csv.Error()
+	}
+
+	return f.table.Flush()
+}
+
+// scalarFields returns the top level fields of the object which aren't
+// objects or lists, in order
+func scalarFields(object gjson.Result) []string {
+	var fields []string
+	object.ForEach(func(key, value gjson.Result) bool {
+		if value.Type != gjson.JSON {
+			fields = append(fields, key.String())
+		}
+		return true
+	})
+
+	return fields
+}
+
+// isTimestampField returns whether the field is a time, like created or
+// expires_at, from its name
+func isTimestampField(column string) bool {
+	field := column
+	if i := strings.LastIndex(column, "."); i != -1 {
+		field = column[i+1:]
+	}
+
+	switch {
+	case field == "created" || field == "date":
+		return true
+	case strings.HasSuffix(field, "_at"), strings.HasSuffix(field, "_date"):
+		return true
+	case strings.HasSuffix(field, "_start"), strings.HasSuffix(field, "_end"):
+		return true
+	default:
+		return false
+	}
+}
+
+func compactJSON(raw string) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(raw)); err != nil {
+		return raw
+	}
+
+	return compacted.String()
+}
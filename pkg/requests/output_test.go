@@ -0,0 +1,73 @@
+package requests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const customerList = `{
+  "object": "list",
+  "data": [
+    {"id": "cus_1", "object": "customer", "email": "bender@example.com", "created": 1615734566, "address": {"city": "New New York"}, "metadata": {}},
+    {"id": "cus_2", "object": "customer", "email": null, "created": 1615734600, "address": null, "metadata": {}}
+  ],
+  "has_more": false
+}`
+
+func TestPrintFormattedTable(t *testing.T) {
+	var out bytes.Buffer
+	err := printFormatted([]byte(customerList), outputTable, []string{"id", "email", "created", "address.city"}, &out)
+	require.NoError(t, err)
+
+	created := time.Unix(1615734566, 0).Format(timestampLayout)
+	require.Equal(t, ""+
+		"ID     EMAIL               CREATED              ADDRESS.CITY\n"+
+		"cus_1  bender@example.com  "+created+"  New New York\n"+
+		"cus_2                      "+time.Unix(1615734600, 0).Format(timestampLayout)+"  \n", out.String())
+}
+
+func TestPrintFormattedCSV(t *testing.T) {
+	var out bytes.Buffer
+	err := printFormatted([]byte(customerList), outputCSV, nil, &out)
+	require.NoError(t, err)
+
+	// The fields which aren't objects by default, with raw timestamps
+	require.Equal(t, ""+
+		"id,object,email,created\n"+
+		"cus_1,customer,bender@example.com,1615734566\n"+
+		"cus_2,customer,,1615734600\n", out.String())
+}
+
+func TestPrintFormattedObject(t *testing.T) {
+	body := []byte(`{"id": "cus_1", "email": "bender@example.com", "address": {"city": "New New York"}}`)
+
+	var out bytes.Buffer
+	err := printFormatted(body, outputTable, nil, &out)
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"id       cus_1\n"+
+		"email    bender@example.com\n"+
+		"address  {\"city\":\"New New York\"}\n", out.String())
+
+	out.Reset()
+	err = printFormatted(body, outputCSV, []string{"id", "address.city"}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "id,address.city\ncus_1,New New York\n", out.String())
+}
+
+func TestCheckOutputFlags(t *testing.T) {
+	require.NoError(t, (&Base{output: "table", columns: []string{"id"}}).checkOutputFlags())
+	require.EqualError(t, (&Base{output: "yaml"}).checkOutputFlags(), "Invalid output format yaml, expected table or csv")
+	require.EqualError(t, (&Base{output: "csv", query: ".id"}).checkOutputFlags(), "--query can't be used with --output")
+	require.EqualError(t, (&Base{columns: []string{"id"}}).checkOutputFlags(), "--columns requires --output table or csv")
+}
+
+func TestIsTimestampField(t *testing.T) {
+	require.True(t, isTimestampField("created"))
+	require.True(t, isTimestampField("current_period_end"))
+	require.True(t, isTimestampField("status_transitions.paid_at"))
+	require.False(t, isTimestampField("amount"))
+}
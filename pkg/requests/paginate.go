@@ -0,0 +1,410 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+const (
+	// paginatePageSize is the most objects the API returns per page
+	paginatePageSize = 100
+	// paginateMaxRetries is how many times a rate limited page is requested
+	// again before giving up
+	paginateMaxRetries = 3
+	// paginateLowRemaining is the number of requests left under which the
+	// pages are requested more slowly, when the rate limit headers say so
+	paginateLowRemaining = 5
+	// paginateDelay is the delay between the pages once the rate limit is
+	// close, or before a rate limited page is requested again by default
+	paginateDelay = time.Second
+	// paginateMaxDelay caps the delay asked for by Retry-After
+	paginateMaxDelay = 30 * time.Second
+)
+
+// listPage is a page of a list endpoint, or of a search endpoint
+type listPage struct {
+	Object  string            `json:"object"`
+	Data    []json.RawMessage `json:"data"`
+	HasMore bool              `json:"has_more"`
+	// NextPage is the cursor of the next page of search results, which are
+	// paginated with it instead of the IDs of the objects
+	NextPage string `json:"next_page"`
+	// NextPageURL is the path of the next page of the lists of the /v2 API,
+	// empty on the last one
+	NextPageURL string `json:"next_page_url"`
+}
+
+// makePaginatedRequest requests the pages of a list until it has no more, printing each
+// object as a line of JSON once its page is received. --limit caps the total
+// number of objects. --query applies to each object, or to each page when it
+// queries its data, and the lists it selects are printed an element per line,
+// as if concatenated.
+func (rb *Base) makePaginatedRequest(ctx context.Context, client *stripe.Client, path string, params *RequestParameters, w io.Writer) error {
+	pageParams := paginationParams(params)
+
+	total := -1
+	if pageParams.limit != "" {
+		limit, err := strconv.Atoi(pageParams.limit)
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("Invalid limit %s, expected a positive number of objects with --paginate", pageParams.limit)
+		}
+		total = limit
+	}
+
+	// The pages are followed backwards from --ending-before
+	backwards := pageParams.endingBefore != ""
+
+	// The lists of the /v2 API have no object and are followed with their
+	// next_page_url, which has the parameters of the list in its page token
+	v2 := isV2Path(path)
+	followingURL := false
+
+	// The table is printed once complete, and the CSV page by page
+	var f *formatter
+	if rb.output != "" {
+		f = newFormatter(rb.output, rb.columns, w)
+	}
+
+	printed := 0
+	for total < 0 || printed < total {
+		if !followingURL {
+			pageParams.limit = strconv.Itoa(paginatePageSize)
+			if total >= 0 && total-printed < paginatePageSize {
+				pageParams.limit = strconv.Itoa(total - printed)
+			}
+		}
+
+		body, header, err := rb.requestPage(ctx, client, path, &pageParams)
+		if err != nil {
+			return err
+		}
+
+		// The first page can't be followed without being sent
+		if rb.showCurlOnly {
+			return nil
+		}
+
+		var page listPage
+		err = json.Unmarshal(body, &page)
+		isList := page.Object == "list" || page.Object == "search_result" || (v2 && page.Data != nil)
+		if err != nil || !isList {
+			// Not a list, like a single object, which is printed as is
+			if printed > 0 {
+				return fmt.Errorf("Failed to paginate %s, a page isn't a list", path)
+			}
+			return rb.printJSON(body, w)
+		}
+
+		objects := page.Data
+		if backwards {
+			// The objects before the cursor are still listed newest first
+			for i, j := 0, len(objects)-1; i < j; i, j = i+1, j-1 {
+				objects[i], objects[j] = objects[j], objects[i]
+			}
+		}
+
+		if total >= 0 && total-printed < len(objects) {
+			objects = objects[:total-printed]
+		}
+
+		if rb.query != "" {
+			if err := rb.printPageQuery(body, objects, w); err != nil {
+				return err
+			}
+			printed += len(objects)
+			objects = nil
+		}
+
+		for _, object := range objects {
+
+			if f != nil {
+				if err := f.writeRow(gjson.ParseBytes(object)); err != nil {
+					return err
+				}
+				printed++
+				continue
+			}
+
+			var line bytes.Buffer
+			if err := json.Compact(&line, object); err != nil {
+				return err
+			}
+			if err := rb.printJSON(line.Bytes(), w); err != nil {
+				return err
+			}
+			printed++
+		}
+
+		more := page.HasMore
+		if v2 {
+			more = page.NextPageURL != ""
+		}
+		more = more && len(page.Data) > 0
+
+		if f != nil && (f.csv != nil || !more) {
+			if err := f.flush(); err != nil {
+				return err
+			}
+		}
+
+		if !more {
+			return nil
+		}
+
+		switch {
+		case v2:
+			path = page.NextPageURL
+			pageParams.data = nil
+			pageParams.limit = ""
+			pageParams.page = ""
+			pageParams.startingAfter = ""
+			pageParams.endingBefore = ""
+			followingURL = true
+		case page.Object == "search_result":
+			// The search results are paginated with a cursor of their own
+			if page.NextPage == "" {
+				return fmt.Errorf("Failed to paginate %s, the page has no next_page", path)
+			}
+			pageParams.page = page.NextPage
+		default:
+			lastID := gjson.GetBytes(page.Data[len(page.Data)-1], "id").String()
+			if lastID == "" {
+				return fmt.Errorf("Failed to paginate %s, its objects have no id", path)
+			}
+			// Once reversed, the last object is the newest one either way
+			if backwards {
+				pageParams.endingBefore = lastID
+			} else {
+				pageParams.startingAfter = lastID
+			}
+		}
+
+		if err := wait(ctx, pageDelay(header)); err != nil {
+			return err
+		}
+	}
+
+	if f != nil {
+		return f.flush()
+	}
+
+	return nil
+}
+
+// paginationParams returns a copy of the parameters with the pagination ones
+// given as data, like by the flags of the list operations, moved to their
+// fields so that they aren't sent twice
+func paginationParams(params *RequestParameters) RequestParameters {
+	pageParams := *params
+	pageParams.data = nil
+
+	for _, datum := range params.data {
+		split := strings.SplitN(datum, "=", 2)
+		switch {
+		case len(split) < 2:
+			pageParams.data = append(pageParams.data, datum)
+		case split[0] == "limit":
+			pageParams.limit = split[1]
+		case split[0] == "starting_after":
+			pageParams.startingAfter = split[1]
+		case split[0] == "ending_before":
+			pageParams.endingBefore = split[1]
+		case split[0] == "page":
+			pageParams.page = split[1]
+		default:
+			pageParams.data = append(pageParams.data, datum)
+		}
+	}
+
+	return pageParams
+}
+
+// requestPage requests a page, again after a delay while it is rate limited
+func (rb *Base) requestPage(ctx context.Context, client *stripe.Client, path string, params *RequestParameters) ([]byte, http.Header, error) {
+	data, err := rb.buildDataForRequest(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configureReq := func(req *http.Request) {
+		rb.setIdempotencyHeader(req, params)
+		rb.setStripeAccountHeader(req, params)
+		rb.setVersionHeader(req, params)
+	}
+
+	if send, err := rb.printCurl(ctx, client, path, data, configureReq); err != nil || !send {
+		return nil, nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := client.PerformRequest(ctx, rb.Method, path, data, configureReq)
+		if err != nil {
+			return nil, nil, err
+		}
+		rb.StatusCode = resp.StatusCode
+		rb.ResponseHeader = resp.Header
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < paginateMaxRetries {
+			delay := pageDelay(resp.Header)
+			if delay == 0 && resp.Header.Get("Retry-After") == "" {
+				delay = paginateDelay
+			}
+			if err := wait(ctx, delay); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode >= 300 {
+			return nil, nil, compileRequestError(body, resp.StatusCode)
+		}
+
+		return body, resp.Header, nil
+	}
+}
+
+// pageDelay returns how long to wait before the next page according to the
+// rate limit headers of the response, if any
+func pageDelay(header http.Header) time.Duration {
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil && seconds >= 0 {
+			delay := time.Duration(seconds) * time.Second
+			if delay > paginateMaxDelay {
+				delay = paginateMaxDelay
+			}
+			return delay
+		}
+	}
+
+	if remaining := header.Get("X-RateLimit-Remaining"); remaining != "" {
+		if n, err := strconv.Atoi(remaining); err == nil && n <= paginateLowRemaining {
+			return paginateDelay
+		}
+	}
+
+	return 0
+}
+
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// printPageQuery prints what --query selects in the page, with the objects
+// of the page replaced by the ones to print. Queries on the data of the page,
+// like `data[].id`, apply to the page, and others, like `.id`, to each object.
+func (rb *Base) printPageQuery(body []byte, objects []json.RawMessage, w io.Writer) error {
+	q, err := parseQuery(rb.query)
+	if err != nil {
+		return err
+	}
+
+	value, err := decodeOrdered(body)
+	if err != nil {
+		return err
+	}
+	page := value.(*orderedObject)
+
+	data := make([]interface{}, 0, len(objects))
+	for _, object := range objects {
+		decoded, err := decodeOrdered(object)
+		if err != nil {
+			return err
+		}
+		data = append(data, decoded)
+	}
+	page.set("data", data)
+
+	results := []interface{}{}
+	if result := q.root.eval(page); leftmostField(q.root) == "data" && result != nil {
+		if list, ok := result.([]interface{}); ok {
+			results = list
+		} else {
+			results = append(results, result)
+		}
+	} else {
+		// Like `.id`, or `.data.object.id` for the data of events
+		for _, object := range data {
+			results = append(results, q.root.eval(object))
+		}
+	}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		line, err := formatQueryResult(result, false)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leftmostField returns the field the query starts with, if any
+func leftmostField(node queryNode) string {
+	switch n := node.(type) {
+	case fieldNode:
+		return n.name
+	case subexpressionNode:
+		return leftmostField(n.left)
+	case pipeNode:
+		return leftmostField(n.left)
+	case projectionNode:
+		return leftmostField(n.left)
+	default:
+		return ""
+	}
+}
+
+// printJSON prints the JSON, or the values --query selects in it, on a line,
+// or as --output
+func (rb *Base) printJSON(body []byte, w io.Writer) error {
+	if rb.query != "" {
+		result, err := queryJSON(body, rb.query)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, result)
+		return err
+	}
+
+	if rb.output != "" {
+		return printFormatted(body, rb.output, rb.columns, w)
+	}
+
+	_, err := fmt.Fprintln(w, ansi.ColorizeJSON(string(bytes.TrimSpace(body)), rb.DarkStyle, w))
+	return err
+}
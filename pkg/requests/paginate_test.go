@@ -0,0 +1,190 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+func TestMakePaginatedRequest(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("starting_after") {
+		case "":
+			w.Write([]byte(`{"object": "list", "data": [{"id": "cus_1", "name": "Bender"}, {"id": "cus_2"}], "has_more": true}`))
+		case "cus_2":
+			w.Write([]byte(`{"object": "list", "data": [{"id": "cus_3"}], "has_more": false}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := &stripe.Client{BaseURL: baseURL, APIKey: "sk_test_1234"}
+
+	rb := Base{Method: http.MethodGet}
+	var out bytes.Buffer
+	err := rb.makePaginatedRequest(context.Background(), client, "/v1/customers", &RequestParameters{data: []string{"email=bender@example.com"}}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":\"cus_1\",\"name\":\"Bender\"}\n{\"id\":\"cus_2\"}\n{\"id\":\"cus_3\"}\n", out.String())
+	require.Equal(t, []string{
+		"email=bender%40example.com&limit=100",
+		"email=bender%40example.com&limit=100&starting_after=cus_2",
+	}, queries)
+
+	// --limit caps the total, and the IDs are printed one per line
+	queries = nil
+	out.Reset()
+	rb.query = ".id"
+	err = rb.makePaginatedRequest(context.Background(), client, "/v1/customers", &RequestParameters{data: []string{"limit=1"}}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "cus_1\n", out.String())
+	require.Equal(t, []string{"limit=1"}, queries)
+
+	// and each object is queried
+	out.Reset()
+	rb.query = "{id: id, name: name}"
+	err = rb.makePaginatedRequest(context.Background(), client, "/v1/customers", &RequestParameters{}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":\"cus_1\",\"name\":\"Bender\"}\n{\"id\":\"cus_2\",\"name\":null}\n{\"id\":\"cus_3\",\"name\":null}\n", out.String())
+
+	// A query of the data applies to each page, and the results are
+	// concatenated
+	out.Reset()
+	rb.query = "data[?id != 'cus_2'].{id: id, name: name}"
+	err = rb.makePaginatedRequest(context.Background(), client, "/v1/customers", &RequestParameters{}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":\"cus_1\",\"name\":\"Bender\"}\n{\"id\":\"cus_3\",\"name\":null}\n", out.String())
+
+	// The CSV of every page has a single header
+	out.Reset()
+	rb.query = ""
+	rb.output = outputCSV
+	rb.columns = []string{"id"}
+	err = rb.makePaginatedRequest(context.Background(), client, "/v1/customers", &RequestParameters{}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "id\ncus_1\ncus_2\ncus_3\n", out.String())
+}
+
+func TestMakePaginatedRequestRateLimited(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"object": "list", "data": [{"id": "ch_1"}], "has_more": false}`))
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := &stripe.Client{BaseURL: baseURL, APIKey: "sk_test_1234"}
+
+	rb := Base{Method: http.MethodGet, query: "id"}
+	var out bytes.Buffer
+	err := rb.makePaginatedRequest(context.Background(), client, "/v1/charges", &RequestParameters{}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "ch_1\n", out.String())
+	require.Equal(t, 2, requests)
+}
+
+func TestMakePaginatedRequestError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"type": "invalid_request_error"}}`))
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := &stripe.Client{BaseURL: baseURL, APIKey: "sk_test_1234"}
+
+	rb := Base{Method: http.MethodGet}
+	err := rb.makePaginatedRequest(context.Background(), client, "/v1/charges", &RequestParameters{limit: "abc"}, &bytes.Buffer{})
+	require.EqualError(t, err, "Invalid limit abc, expected a positive number of objects with --paginate")
+
+	err = rb.makePaginatedRequest(context.Background(), client, "/v1/charges", &RequestParameters{}, &bytes.Buffer{})
+	require.EqualError(t, err, `Request failed, status=401, body={"error": {"type": "invalid_request_error"}}`)
+}
+
+func TestPageDelay(t *testing.T) {
+	require.Equal(t, time.Duration(0), pageDelay(http.Header{}))
+	require.Equal(t, 2*time.Second, pageDelay(http.Header{"Retry-After": []string{"2"}}))
+	require.Equal(t, paginateMaxDelay, pageDelay(http.Header{"Retry-After": []string{fmt.Sprint(3600)}}))
+	require.Equal(t, paginateDelay, pageDelay(http.Header{"X-Ratelimit-Remaining": []string{"3"}}))
+	require.Equal(t, time.Duration(0), pageDelay(http.Header{"X-Ratelimit-Remaining": []string{"50"}}))
+}
+
+func TestMakePaginatedRequestSearch(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Write([]byte(`{"object": "search_result", "data": [{"id": "cus_1"}], "has_more": true, "next_page": "page_2"}`))
+		case "page_2":
+			w.Write([]byte(`{"object": "search_result", "data": [{"id": "cus_2"}], "has_more": false, "next_page": null}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := &stripe.Client{BaseURL: baseURL, APIKey: "sk_test_1234"}
+
+	rb := Base{Method: http.MethodGet}
+	var out bytes.Buffer
+	err := rb.makePaginatedRequest(context.Background(), client, "/v1/customers/search", &RequestParameters{data: []string{"query=email:'bender@example.com'"}}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":\"cus_1\"}\n{\"id\":\"cus_2\"}\n", out.String())
+	require.Equal(t, []string{
+		"query=email%3A%27bender%40example.com%27&limit=100",
+		"query=email%3A%27bender%40example.com%27&limit=100&page=page_2",
+	}, queries)
+}
+
+func TestMakePaginatedRequestV2(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Write([]byte(`{"data": [{"id": "evt_1"}, {"id": "evt_2"}], "next_page_url": "/v2/core/events?page=page_2", "previous_page_url": null}`))
+		case "page_2":
+			w.Write([]byte(`{"data": [{"id": "evt_3"}], "next_page_url": null, "previous_page_url": "/v2/core/events?page=page_1"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := &stripe.Client{BaseURL: baseURL, APIKey: "sk_test_1234"}
+
+	rb := Base{Method: http.MethodGet}
+	var out bytes.Buffer
+	err := rb.makePaginatedRequest(context.Background(), client, "/v2/core/events", &RequestParameters{data: []string{"object_id=mtr_123"}}, &out)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":\"evt_1\"}\n{\"id\":\"evt_2\"}\n{\"id\":\"evt_3\"}\n", out.String())
+	// The next pages are requested with their URL only
+	require.Equal(t, []string{
+		"object_id=mtr_123&limit=100",
+		"page=page_2",
+	}, queries)
+}
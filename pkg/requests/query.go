@@ -0,0 +1,985 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The queries of --query are a subset of JMESPath (https://jmespath.org):
+// fields like `id` or `address.city`, indexes like `data[0]`, projections
+// like `data[].id` or `data[*].id`, filters like `data[?amount > `100`]`,
+// multiselects like `{id: id, amt: amount}` or `[id, amount]`, pipes, and
+// the `==`, `!=`, `<`, `<=`, `>`, `>=`, `&&`, `||` and `!` operators in
+// filters. Literals are either raw strings like 'usd' or JSON between
+// backticks like `100`. A leading dot, like in jq, is ignored.
+
+// QueryError is an error in a query, at the position of the expression where
+// it was found
+type QueryError struct {
+	Expression string
+	Position   int
+	Message    string
+}
+
+func (e QueryError) Error() string {
+	return fmt.Sprintf("Invalid query, %s at position %d:\n  %s\n  %s^", e.Message, e.Position, e.Expression, strings.Repeat(" ", e.Position))
+}
+
+// query is a compiled --query
+type query struct {
+	root queryNode
+}
+
+// parseQuery compiles the expression
+func parseQuery(expression string) (*query, error) {
+	tokens, err := lexQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{expression: expression, tokens: tokens}
+	root, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+	}
+
+	return &query{root: root}, nil
+}
+
+// search returns the result of the query on the JSON
+func (q *query) search(body []byte) (interface{}, error) {
+	value, err := decodeOrdered(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.root.eval(value), nil
+}
+
+// formatQueryResult returns the result as JSON, or as is if it is a string
+func formatQueryResult(result interface{}, indent bool) (string, error) {
+	if s, ok := result.(string); ok {
+		return s, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if indent {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(result); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+//
+// Values
+//
+
+// orderedObject is a JSON object which keeps the order of its fields, so
+// that the results are printed in the order of the response and of the
+// multiselects
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{values: make(map[string]interface{})}
+}
+
+func (o *orderedObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON encodes the object with its fields in order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes JSON into nil, bool, json.Number, string,
+// []interface{} and *orderedObject values
+func decodeOrdered(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Invalid JSON response, expected a single value")
+	}
+
+	return value, nil
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		object := newOrderedObject()
+		for decoder.More() {
+			keyTok, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(keyTok.(string), value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return array, nil
+	default:
+		return tok, nil
+	}
+}
+
+// truthy returns whether the value is true in a filter: not false, null, nor
+// an empty string, list or object
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case *orderedObject:
+		return len(v.keys) > 0
+	default:
+		return true
+	}
+}
+
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+
+	objA, okA := a.(*orderedObject)
+	objB, okB := b.(*orderedObject)
+	if okA || okB {
+		if !okA || !okB || len(objA.keys) != len(objB.keys) {
+			return false
+		}
+		for _, key := range objA.keys {
+			other, ok := objB.values[key]
+			if !ok || !equal(objA.values[key], other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	arrayA, okA := a.([]interface{})
+	arrayB, okB := b.([]interface{})
+	if okA || okB {
+		if !okA || !okB || len(arrayA) != len(arrayB) {
+			return false
+		}
+		for i := range arrayA {
+			if !equal(arrayA[i], arrayB[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+//
+// Evaluation
+//
+
+type queryNode interface {
+	eval(value interface{}) interface{}
+}
+
+type currentNode struct{}
+
+func (currentNode) eval(value interface{}) interface{} { return value }
+
+type fieldNode struct{ name string }
+
+func (n fieldNode) eval(value interface{}) interface{} {
+	if object, ok := value.(*orderedObject); ok {
+		return object.values[n.name]
+	}
+	return nil
+}
+
+type indexNode struct{ index int }
+
+func (n indexNode) eval(value interface{}) interface{} {
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	i := n.index
+	if i < 0 {
+		i += len(array)
+	}
+	if i < 0 || i >= len(array) {
+		return nil
+	}
+
+	return array[i]
+}
+
+type literalNode struct{ value interface{} }
+
+func (n literalNode) eval(interface{}) interface{} { return n.value }
+
+// subexpressionNode evaluates right on the result of left
+type subexpressionNode struct{ left, right queryNode }
+
+func (n subexpressionNode) eval(value interface{}) interface{} {
+	left := n.left.eval(value)
+	if left == nil {
+		return nil
+	}
+	return n.right.eval(left)
+}
+
+// pipeNode is like a subexpression, but stops projections
+type pipeNode struct{ left, right queryNode }
+
+func (n pipeNode) eval(value interface{}) interface{} {
+	return n.right.eval(n.left.eval(value))
+}
+
+type projectionKind int
+
+const (
+	listProjection projectionKind = iota
+	flattenProjection
+	valuesProjection
+	filterProjection
+)
+
+// projectionNode evaluates right on each element of the result of left,
+// keeping the results which aren't null
+type projectionNode struct {
+	kind      projectionKind
+	left      queryNode
+	condition queryNode
+	right     queryNode
+}
+
+func (n projectionNode) eval(value interface{}) interface{} {
+	left := n.left.eval(value)
+
+	var elements []interface{}
+	switch n.kind {
+	case valuesProjection:
+		object, ok := left.(*orderedObject)
+		if !ok {
+			return nil
+		}
+		for _, key := range object.keys {
+			elements = append(elements, object.values[key])
+		}
+	case flattenProjection:
+		array, ok := left.([]interface{})
+		if !ok {
+			return nil
+		}
+		for _, element := range array {
+			if nested, ok := element.([]interface{}); ok {
+				elements = append(elements, nested...)
+			} else {
+				elements = append(elements, element)
+			}
+		}
+	default:
+		array, ok := left.([]interface{})
+		if !ok {
+			return nil
+		}
+		elements = array
+	}
+
+	results := []interface{}{}
+	for _, element := range elements {
+		if n.kind == filterProjection && !truthy(n.condition.eval(element)) {
+			continue
+		}
+		if result := n.right.eval(element); result != nil {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+type multiselectListNode struct{ elements []queryNode }
+
+func (n multiselectListNode) eval(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	results := make([]interface{}, 0, len(n.elements))
+	for _, element := range n.elements {
+		results = append(results, element.eval(value))
+	}
+	return results
+}
+
+type multiselectHashNode struct {
+	keys     []string
+	elements []queryNode
+}
+
+func (n multiselectHashNode) eval(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	object := newOrderedObject()
+	for i, key := range n.keys {
+		object.set(key, n.elements[i].eval(value))
+	}
+	return object
+}
+
+type comparisonNode struct {
+	op          tokenKind
+	left, right queryNode
+}
+
+func (n comparisonNode) eval(value interface{}) interface{} {
+	left, right := n.left.eval(value), n.right.eval(value)
+
+	switch n.op {
+	case tokEq:
+		return equal(left, right)
+	case tokNe:
+		return !equal(left, right)
+	}
+
+	x, okX := number(left)
+	y, okY := number(right)
+	if !okX || !okY {
+		return nil
+	}
+
+	switch n.op {
+	case tokLt:
+		return x < y
+	case tokLte:
+		return x <= y
+	case tokGt:
+		return x > y
+	default:
+		return x >= y
+	}
+}
+
+type andNode struct{ left, right queryNode }
+
+func (n andNode) eval(value interface{}) interface{} {
+	left := n.left.eval(value)
+	if !truthy(left) {
+		return left
+	}
+	return n.right.eval(value)
+}
+
+type orNode struct{ left, right queryNode }
+
+func (n orNode) eval(value interface{}) interface{} {
+	left := n.left.eval(value)
+	if truthy(left) {
+		return left
+	}
+	return n.right.eval(value)
+}
+
+type notNode struct{ expression queryNode }
+
+func (n notNode) eval(value interface{}) interface{} {
+	return !truthy(n.expression.eval(value))
+}
+
+//
+// Lexing
+//
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdentifier
+	tokQuotedIdentifier
+	tokNumber
+	tokLiteral
+	tokRawString
+	tokDot
+	tokStar
+	tokFlatten
+	tokFilter
+	tokLbracket
+	tokRbracket
+	tokLbrace
+	tokRbrace
+	tokLparen
+	tokRparen
+	tokComma
+	tokColon
+	tokPipe
+	tokOr
+	tokAnd
+	tokNot
+	tokAt
+	tokEq
+	tokNe
+	tokLt
+	tokLte
+	tokGt
+	tokGte
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	position int
+}
+
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of the query"
+	}
+	return strconv.Quote(t.value)
+}
+
+var simpleTokens = []struct {
+	text string
+	kind tokenKind
+}{
+	// The longest first
+	{"[]", tokFlatten},
+	{"[?", tokFilter},
+	{"||", tokOr},
+	{"&&", tokAnd},
+	{"==", tokEq},
+	{"!=", tokNe},
+	{"<=", tokLte},
+	{">=", tokGte},
+	{"<", tokLt},
+	{">", tokGt},
+	{"!", tokNot},
+	{".", tokDot},
+	{"*", tokStar},
+	{"[", tokLbracket},
+	{"]", tokRbracket},
+	{"{", tokLbrace},
+	{"}", tokRbrace},
+	{"(", tokLparen},
+	{")", tokRparen},
+	{",", tokComma},
+	{":", tokColon},
+	{"|", tokPipe},
+	{"@", tokAt},
+}
+
+func lexQuery(expression string) ([]token, error) {
+	var tokens []token
+
+	// A dot alone, like in jq, is the whole JSON
+	if strings.TrimSpace(expression) == "." {
+		return []token{{tokAt, ".", 0}, {tokEOF, "", len(expression)}}, nil
+	}
+
+	i := 0
+	// A leading dot, like `.id` in jq, is the same as `id`
+	if strings.HasPrefix(expression, ".") && len(expression) > 1 && isIdentifierStart(rune(expression[1])) {
+		i = 1
+	}
+
+	for i < len(expression) {
+		c := rune(expression[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case isIdentifierStart(c):
+			start := i
+			for i < len(expression) && isIdentifierPart(rune(expression[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdentifier, expression[start:i], start})
+			continue
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			for i < len(expression) && expression[i] >= '0' && expression[i] <= '9' {
+				i++
+			}
+			if expression[start:i] == "-" {
+				return nil, QueryError{expression, start, "expected a number after -"}
+			}
+			tokens = append(tokens, token{tokNumber, expression[start:i], start})
+			continue
+		case c == '"', c == '\'', c == '`':
+			end, err := closingQuote(expression, i)
+			if err != nil {
+				return nil, err
+			}
+			tok, err := quotedToken(expression, i, end)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = end + 1
+			continue
+		}
+
+		matched := false
+		for _, simple := range simpleTokens {
+			if strings.HasPrefix(expression[i:], simple.text) {
+				tokens = append(tokens, token{simple.kind, simple.text, i})
+				i += len(simple.text)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, QueryError{expression, i, fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+
+	return append(tokens, token{tokEOF, "", len(expression)}), nil
+}
+
+func isIdentifierStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c rune) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
+
+// closingQuote returns the position of the quote closing the one at start,
+// skipping the escaped ones
+func closingQuote(expression string, start int) (int, error) {
+	quote := expression[start]
+	for i := start + 1; i < len(expression); i++ {
+		switch expression[i] {
+		case '\\':
+			i++
+		case quote:
+			return i, nil
+		}
+	}
+
+	return 0, QueryError{expression, start, fmt.Sprintf("unclosed %c", quote)}
+}
+
+func quotedToken(expression string, start, end int) (token, error) {
+	content := expression[start+1 : end]
+
+	switch expression[start] {
+	case '"':
+		name, err := strconv.Unquote(expression[start : end+1])
+		if err != nil {
+			return token{}, QueryError{expression, start, "invalid quoted field"}
+		}
+		return token{tokQuotedIdentifier, name, start}, nil
+	case '\'':
+		return token{tokRawString, strings.ReplaceAll(content, `\'`, `'`), start}, nil
+	default:
+		literal := strings.ReplaceAll(content, "\\`", "`")
+		if _, err := decodeOrdered([]byte(literal)); err != nil {
+			return token{}, QueryError{expression, start, "invalid JSON literal"}
+		}
+		return token{tokLiteral, literal, start}, nil
+	}
+}
+
+//
+// Parsing
+//
+
+// The binding powers of the tokens, as in the JMESPath grammar. A projection
+// applies to what follows it until a token binding less than tokProjectionStop.
+var bindingPowers = map[tokenKind]int{
+	tokPipe:     1,
+	tokOr:       2,
+	tokAnd:      3,
+	tokEq:       5,
+	tokNe:       5,
+	tokLt:       5,
+	tokLte:      5,
+	tokGt:       5,
+	tokGte:      5,
+	tokFlatten:  9,
+	tokStar:     20,
+	tokFilter:   21,
+	tokDot:      40,
+	tokNot:      45,
+	tokLbrace:   50,
+	tokLbracket: 55,
+	tokLparen:   60,
+}
+
+const projectionStop = 10
+
+type queryParser struct {
+	expression string
+	tokens     []token
+	pos        int
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.errorAt(tok, fmt.Sprintf("expected %s but found %s", what, tok.describe()))
+	}
+	return tok, nil
+}
+
+func (p *queryParser) errorAt(tok token, message string) error {
+	return QueryError{p.expression, tok.position, message}
+}
+
+func (p *queryParser) parseExpression(bindingPower int) (queryNode, error) {
+	left, err := p.nud(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	for bindingPower < bindingPowers[p.peek().kind] {
+		left, err = p.led(p.next(), left)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return left, nil
+}
+
+// nud parses the expressions starting with the token
+func (p *queryParser) nud(tok token) (queryNode, error) {
+	switch tok.kind {
+	case tokIdentifier, tokQuotedIdentifier:
+		return fieldNode{tok.value}, nil
+	case tokAt:
+		return currentNode{}, nil
+	case tokRawString:
+		return literalNode{tok.value}, nil
+	case tokLiteral:
+		value, err := decodeOrdered([]byte(tok.value))
+		if err != nil {
+			return nil, p.errorAt(tok, "invalid JSON literal")
+		}
+		return literalNode{value}, nil
+	case tokStar:
+		right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+		if err != nil {
+			return nil, err
+		}
+		return projectionNode{kind: valuesProjection, left: currentNode{}, right: right}, nil
+	case tokFlatten:
+		right, err := p.parseProjectionRHS(bindingPowers[tokFlatten])
+		if err != nil {
+			return nil, err
+		}
+		return projectionNode{kind: flattenProjection, left: currentNode{}, right: right}, nil
+	case tokFilter:
+		return p.parseFilter(currentNode{})
+	case tokLbracket:
+		return p.parseBracket(currentNode{}, true)
+	case tokLbrace:
+		return p.parseMultiselectHash()
+	case tokNot:
+		expression, err := p.parseExpression(bindingPowers[tokNot])
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expression}, nil
+	case tokLparen:
+		expression, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRparen, ")"); err != nil {
+			return nil, err
+		}
+		return expression, nil
+	case tokEOF:
+		return nil, p.errorAt(tok, "unexpected end of the query")
+	default:
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+	}
+}
+
+// led parses the expressions continuing left with the token
+func (p *queryParser) led(tok token, left queryNode) (queryNode, error) {
+	switch tok.kind {
+	case tokDot:
+		if p.peek().kind == tokStar {
+			p.next()
+			right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+			if err != nil {
+				return nil, err
+			}
+			return projectionNode{kind: valuesProjection, left: left, right: right}, nil
+		}
+		right, err := p.parseDotRHS(bindingPowers[tokDot])
+		if err != nil {
+			return nil, err
+		}
+		return subexpressionNode{left, right}, nil
+	case tokPipe:
+		right, err := p.parseExpression(bindingPowers[tokPipe])
+		if err != nil {
+			return nil, err
+		}
+		return pipeNode{left, right}, nil
+	case tokOr:
+		right, err := p.parseExpression(bindingPowers[tokOr])
+		if err != nil {
+			return nil, err
+		}
+		return orNode{left, right}, nil
+	case tokAnd:
+		right, err := p.parseExpression(bindingPowers[tokAnd])
+		if err != nil {
+			return nil, err
+		}
+		return andNode{left, right}, nil
+	case tokEq, tokNe, tokLt, tokLte, tokGt, tokGte:
+		right, err := p.parseExpression(bindingPowers[tok.kind])
+		if err != nil {
+			return nil, err
+		}
+		return comparisonNode{tok.kind, left, right}, nil
+	case tokFlatten:
+		right, err := p.parseProjectionRHS(bindingPowers[tokFlatten])
+		if err != nil {
+			return nil, err
+		}
+		return projectionNode{kind: flattenProjection, left: left, right: right}, nil
+	case tokFilter:
+		return p.parseFilter(left)
+	case tokLbracket:
+		return p.parseBracket(left, false)
+	default:
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+	}
+}
+
+// parseBracket parses what follows a [, an index, a projection or a
+// multiselect list
+func (p *queryParser) parseBracket(left queryNode, atStart bool) (queryNode, error) {
+	switch tok := p.peek(); tok.kind {
+	case tokNumber:
+		p.next()
+		index, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, p.errorAt(tok, "invalid index")
+		}
+		if p.peek().kind == tokColon {
+			return nil, p.errorAt(p.peek(), "slices aren't supported")
+		}
+		if _, err := p.expect(tokRbracket, "]"); err != nil {
+			return nil, err
+		}
+		return subexpressionNode{left, indexNode{index}}, nil
+	case tokStar:
+		p.next()
+		if _, err := p.expect(tokRbracket, "]"); err != nil {
+			return nil, err
+		}
+		right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+		if err != nil {
+			return nil, err
+		}
+		return projectionNode{kind: listProjection, left: left, right: right}, nil
+	default:
+		if !atStart {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected an index, * or ] but found %s", tok.describe()))
+		}
+		return p.parseMultiselectList()
+	}
+}
+
+func (p *queryParser) parseFilter(left queryNode) (queryNode, error) {
+	condition, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRbracket, "]"); err != nil {
+		return nil, err
+	}
+
+	right, err := p.parseProjectionRHS(bindingPowers[tokFilter])
+	if err != nil {
+		return nil, err
+	}
+
+	return projectionNode{kind: filterProjection, left: left, condition: condition, right: right}, nil
+}
+
+// parseProjectionRHS parses what a projection applies to each element
+func (p *queryParser) parseProjectionRHS(bindingPower int) (queryNode, error) {
+	switch tok := p.peek(); {
+	case bindingPowers[tok.kind] < projectionStop:
+		return currentNode{}, nil
+	case tok.kind == tokLbracket || tok.kind == tokFilter || tok.kind == tokFlatten:
+		return p.parseExpression(bindingPower)
+	case tok.kind == tokDot:
+		p.next()
+		return p.parseDotRHS(bindingPower)
+	default:
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s after a projection", tok.describe()))
+	}
+}
+
+// parseDotRHS parses what follows a dot
+func (p *queryParser) parseDotRHS(bindingPower int) (queryNode, error) {
+	switch tok := p.peek(); tok.kind {
+	case tokIdentifier, tokQuotedIdentifier, tokStar:
+		return p.parseExpression(bindingPower)
+	case tokLbracket:
+		p.next()
+		return p.parseMultiselectList()
+	case tokLbrace:
+		p.next()
+		return p.parseMultiselectHash()
+	default:
+		return nil, p.errorAt(tok, fmt.Sprintf("expected a field after . but found %s", tok.describe()))
+	}
+}
+
+func (p *queryParser) parseMultiselectList() (queryNode, error) {
+	var elements []queryNode
+	for {
+		element, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		tok := p.next()
+		if tok.kind == tokRbracket {
+			return multiselectListNode{elements}, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected , or ] but found %s", tok.describe()))
+		}
+	}
+}
+
+func (p *queryParser) parseMultiselectHash() (queryNode, error) {
+	node := multiselectHashNode{}
+	for {
+		key := p.next()
+		if key.kind != tokIdentifier && key.kind != tokQuotedIdentifier {
+			return nil, p.errorAt(key, fmt.Sprintf("expected the name of a field but found %s", key.describe()))
+		}
+		if _, err := p.expect(tokColon, ":"); err != nil {
+			return nil, err
+		}
+
+		element, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key.value)
+		node.elements = append(node.elements, element)
+
+		tok := p.next()
+		if tok.kind == tokRbrace {
+			return node, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected , or } but found %s", tok.describe()))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package requests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const queryBody = `{
+  "object": "list",
+  "data": [
+    {"id": "ch_1", "amount": 500, "currency": "usd", "paid": true, "refunds": [{"id": "re_1"}], "metadata": {"order": "6735"}},
+    {"id": "ch_2", "amount": 2000, "currency": "eur", "paid": false, "refunds": [], "metadata": {}},
+    {"id": "ch_3", "amount": 150, "currency": "usd", "paid": true, "refunds": [{"id": "re_2"}, {"id": "re_3"}], "metadata": {}}
+  ],
+  "has_more": false
+}`
+
+func TestQueryJSON(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"object", "list"},
+		{".object", "list"},
+		{"has_more", "false"},
+		{"data[0].amount", "500"},
+		{"data[-1].id", "ch_3"},
+		{"data[0].metadata", "{\n  \"order\": \"6735\"\n}"},
+		{"data[0].\"metadata\".order", "6735"},
+		{"data[5].id", "null"},
+		{"email", "null"},
+		{"data[].id", "[\n  \"ch_1\",\n  \"ch_2\",\n  \"ch_3\"\n]"},
+		{"data[*].refunds[].id | [0]", "re_1"},
+		{"data[].refunds[] | [1].id", "re_2"},
+		{"data[?currency == 'usd'].id | [1]", "ch_3"},
+		{"data[?amount > `400` && paid].id | [0]", "ch_1"},
+		{"data[?!paid].id | [0]", "ch_2"},
+		{"data[?metadata.order].id | [0]", "ch_1"},
+		{"data[0].{amt: amount, id: id}", "{\n  \"amt\": 500,\n  \"id\": \"ch_1\"\n}"},
+		{"data[1].[id, currency]", "[\n  \"ch_2\",\n  \"eur\"\n]"},
+		{"data[0].metadata.*", "[\n  \"6735\"\n]"},
+		{".", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			result, err := queryJSON([]byte(queryBody), test.query)
+			require.NoError(t, err)
+			if test.query == "." {
+				require.Contains(t, result, "\"has_more\": false")
+				return
+			}
+			require.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestQueryJSONErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		position int
+		message  string
+	}{
+		{"data[0", 6, "expected ] but found end of the query"},
+		{"data[].", 7, "expected a field after . but found end of the query"},
+		{"data[?amount > 'usd]", 15, "unclosed '"},
+		{"data[?amount > `abc`]", 15, "invalid JSON literal"},
+		{"data[0:2]", 6, "slices aren't supported"},
+		{"{id id}", 4, "expected : but found \"id\""},
+		{"data # id", 5, "unexpected character '#'"},
+		{"data id", 5, "unexpected \"id\""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := queryJSON([]byte(queryBody), test.query)
+			require.Error(t, err)
+
+			queryErr, ok := err.(QueryError)
+			require.True(t, ok)
+			require.Equal(t, test.position, queryErr.Position)
+			require.Equal(t, test.message, queryErr.Message)
+		})
+	}
+
+	_, err := queryJSON([]byte(queryBody), "data[0")
+	require.EqualError(t, err, "Invalid query, expected ] but found end of the query at position 6:\n  data[0\n        ^")
+}
@@ -0,0 +1,112 @@
+package requests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stripe/stripe-cli/pkg/ansi"
+	"github.com/stripe/stripe-cli/pkg/stripe"
+)
+
+// uploadProgressMinSize is the size of the files whose upload progress is
+// printed
+const uploadProgressMinSize = 1 << 20
+
+// uploadFile uploads the file of --file as multipart/form-data to the files
+// API, with the data of the request as the other fields of the form
+func (rb *Base) uploadFile(ctx context.Context, apiKey, path string, params *RequestParameters, errOnStatus bool) ([]byte, error) {
+	content, err := ioutil.ReadFile(rb.file)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to read the file %s: %v", rb.file, err)
+	}
+
+	if rb.purpose != "" {
+		params.AppendData([]string{"purpose=" + rb.purpose})
+	}
+
+	baseURL := rb.FilesBaseURL
+	if baseURL == "" {
+		baseURL = stripe.DefaultFilesAPIBaseURL
+	}
+
+	return rb.makeMultipartRequest(ctx, baseURL, apiKey, path, params, MultipartFile{
+		Field:    "file",
+		Filename: filepath.Base(rb.file),
+		Content:  content,
+	}, errOnStatus)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// filePartHeader returns the header of the part of the file, with its
+// content type detected from its name or else its content
+func filePartHeader(file MultipartFile) textproto.MIMEHeader {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(file.Filename))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(file.Content)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(file.Field), quoteEscaper.Replace(file.Filename)))
+	header.Set("Content-Type", contentType)
+
+	return header
+}
+
+// reportUploadProgress prints the progress of the upload of large files on
+// stderr, when it is a terminal
+func (rb *Base) reportUploadProgress(req *http.Request, file MultipartFile) {
+	if rb.SuppressOutput || len(file.Content) < uploadProgressMinSize || req.Body == nil || !ansi.IsTerminal(os.Stderr) {
+		return
+	}
+
+	req.Body = newProgressReader(req.Body, file.Filename, req.ContentLength, os.Stderr)
+}
+
+// progressReader prints how much of the body of a request is sent
+type progressReader struct {
+	io.ReadCloser
+	name  string
+	total int64
+	read  int64
+	// printed is the percentage last printed
+	printed int64
+	done    bool
+	w       io.Writer
+}
+
+func newProgressReader(body io.ReadCloser, name string, total int64, w io.Writer) *progressReader {
+	return &progressReader{ReadCloser: body, name: name, total: total, printed: -1, w: w}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+
+	if r.done || r.total <= 0 {
+		return n, err
+	}
+
+	if percent := r.read * 100 / r.total; percent != r.printed {
+		r.printed = percent
+		fmt.Fprintf(r.w, "\rUploading %s: %d%% (%.1f of %.1f MB)", r.name, percent, float64(r.read)/(1<<20), float64(r.total)/(1<<20))
+	}
+
+	if r.read >= r.total || err == io.EOF {
+		r.done = true
+		fmt.Fprintln(r.w)
+	}
+
+	return n, err
+}
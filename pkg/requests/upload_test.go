@@ -0,0 +1,79 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/v1/files", r.URL.Path)
+
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		require.Equal(t, "dispute_evidence", r.FormValue("purpose"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(file)
+		require.NoError(t, err)
+		require.Equal(t, "doc.pdf", header.Filename)
+		require.Equal(t, "application/pdf", header.Header.Get("Content-Type"))
+		require.Equal(t, "%PDF-1.4", string(content))
+
+		w.Write([]byte(`{"id": "file_123"}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	require.NoError(t, ioutil.WriteFile(path, []byte("%PDF-1.4"), os.ModePerm))
+
+	// Uploaded to the files API and not the API
+	rb := Base{APIBaseURL: "http://localhost:1", FilesBaseURL: ts.URL, Method: http.MethodPost, SuppressOutput: true, file: path, purpose: "dispute_evidence"}
+	body, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/files", &RequestParameters{}, true)
+	require.NoError(t, err)
+	require.Equal(t, `{"id": "file_123"}`, string(body))
+}
+
+func TestUploadFileMissing(t *testing.T) {
+	rb := Base{Method: http.MethodPost, file: filepath.Join(t.TempDir(), "missing.pdf")}
+	_, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/files", &RequestParameters{}, true)
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "Failed to read the file "))
+
+	rb = Base{Method: http.MethodPost, purpose: "dispute_evidence"}
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/files", &RequestParameters{}, true)
+	require.EqualError(t, err, "--purpose requires --file")
+}
+
+func TestFilePartHeader(t *testing.T) {
+	header := filePartHeader(MultipartFile{Field: "file", Filename: "evidence.png", Content: []byte("PNG")})
+	require.Equal(t, `form-data; name="file"; filename="evidence.png"`, header.Get("Content-Disposition"))
+	require.Equal(t, "image/png", header.Get("Content-Type"))
+
+	// From the content without a known extension
+	header = filePartHeader(MultipartFile{Field: "file", Filename: "evidence", Content: []byte("%PDF-1.4")})
+	require.Equal(t, "application/pdf", header.Get("Content-Type"))
+}
+
+func TestProgressReader(t *testing.T) {
+	var out bytes.Buffer
+	content := strings.Repeat("a", 4<<20)
+	reader := newProgressReader(ioutil.NopCloser(strings.NewReader(content)), "doc.pdf", int64(len(content)), &out)
+
+	read, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, len(content), len(read))
+
+	require.True(t, strings.HasSuffix(out.String(), "\rUploading doc.pdf: 100% (4.0 of 4.0 MB)\n"), out.String())
+	require.Equal(t, 1, strings.Count(out.String(), "\n"))
+}
@@ -0,0 +1,150 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The /v2 API takes JSON bodies instead of the form encoding of /v1. The -d
+// flags are converted into JSON with the same bracket syntax, like
+// -d "metadata[order]=6735" into {"metadata": {"order": "6735"}}. Their
+// values are always strings, typed JSON values are taken from the document of
+// --json-input, which is sent as is with the -d flags set on it.
+
+// isV2Path returns whether the path is of the /v2 API
+func isV2Path(path string) bool {
+	return strings.HasPrefix(path, "/v2/")
+}
+
+// splitBracketKey splits a key like line_items[0][price] into its fields
+func splitBracketKey(key string) ([]string, error) {
+	start := strings.Index(key, "[")
+	if start < 0 {
+		return []string{key}, nil
+	}
+	if start == 0 {
+		return nil, fmt.Errorf("Invalid data argument: %s has no field before its brackets", key)
+	}
+
+	fields := []string{key[:start]}
+	rest := key[start:]
+	for rest != "" {
+		end := strings.Index(rest, "]")
+		if rest[0] != '[' || end < 0 {
+			return nil, fmt.Errorf("Invalid data argument: %s has unbalanced brackets", key)
+		}
+		fields = append(fields, rest[1:end])
+		rest = rest[end+1:]
+	}
+
+	return fields, nil
+}
+
+// setJSONField returns the value with the value set at the fields, creating
+// the objects and arrays on the way. An empty field appends to an array and
+// a number indexes one, like in line_items[0][price] or expand[].
+func setJSONField(current interface{}, fields []string, value interface{}) (interface{}, error) {
+	if len(fields) == 0 {
+		return value, nil
+	}
+
+	field := fields[0]
+	index, err := strconv.Atoi(field)
+	if field == "" || err == nil {
+		array, _ := current.([]interface{})
+		if field == "" {
+			index = len(array)
+		}
+		if index < 0 || index > len(array) {
+			return nil, fmt.Errorf("Invalid data argument: the index %d is out of the list of %d values", index, len(array))
+		}
+		if index == len(array) {
+			array = append(array, nil)
+		}
+
+		element, err := setJSONField(array[index], fields[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		array[index] = element
+
+		return array, nil
+	}
+
+	object, ok := current.(*orderedObject)
+	if !ok {
+		object = newOrderedObject()
+	}
+
+	element, err := setJSONField(object.values[field], fields[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	object.set(field, element)
+
+	return object, nil
+}
+
+// hasJSONField returns whether the object has a value at the fields
+func hasJSONField(object *orderedObject, fields []string) bool {
+	var value interface{} = object
+	for _, field := range fields {
+		switch v := value.(type) {
+		case *orderedObject:
+			next, ok := v.values[field]
+			if !ok {
+				return false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(field)
+			if err != nil || index >= len(v) {
+				return false
+			}
+			value = v[index]
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildJSONBody returns the JSON body of a /v2 request, the JSON input with
+// the -d flags set on it
+func (rb *Base) buildJSONBody(params *RequestParameters, warnings io.Writer) (string, error) {
+	body := newOrderedObject()
+	if rb.jsonDocument != nil {
+		body = rb.jsonDocument
+	}
+
+	for _, datum := range params.data {
+		split := strings.SplitN(datum, "=", 2)
+		if len(split) < 2 {
+			return "", fmt.Errorf("Invalid data argument: %s", datum)
+		}
+
+		fields, err := splitBracketKey(split[0])
+		if err != nil {
+			return "", err
+		}
+
+		if rb.jsonDocument != nil && hasJSONField(rb.jsonDocument, fields) {
+			fmt.Fprintf(warnings, "Warning: -d %s overrides the value of the JSON input\n", split[0])
+		}
+
+		if _, err := setJSONField(body, fields, split[1]); err != nil {
+			return "", err
+		}
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
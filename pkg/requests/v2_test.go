@@ -0,0 +1,97 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildJSONBody(t *testing.T) {
+	tests := []struct {
+		data     []string
+		expected string
+	}{
+		{[]string{"event_name=api_requests", "payload[value]=25"}, `{"event_name":"api_requests","payload":{"value":"25"}}`},
+		{[]string{"enabled=true", "description=null", "amount=-1.5e3", "code=007"}, `{"enabled":"true","description":"null","amount":"-1.5e3","code":"007"}`},
+		{[]string{"enabled_events[]=v1.billing.meter.error_report_triggered", "enabled_events[]=v1.billing.meter.no_meter_found"}, `{"enabled_events":["v1.billing.meter.error_report_triggered","v1.billing.meter.no_meter_found"]}`},
+		{[]string{"items[0][price]=price_123", "items[0][quantity]=2", "items[1][price]=price_456"}, `{"items":[{"price":"price_123","quantity":"2"},{"price":"price_456"}]}`},
+		{[]string{"name=a=b"}, `{"name":"a=b"}`},
+		{[]string{}, `{}`},
+	}
+
+	for _, test := range tests {
+		rb := Base{}
+		body, err := rb.buildJSONBody(&RequestParameters{data: test.data}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, test.expected, body)
+	}
+}
+
+func TestBuildJSONBodyErrors(t *testing.T) {
+	tests := []struct {
+		data     []string
+		expected string
+	}{
+		{[]string{"name"}, "Invalid data argument: name"},
+		{[]string{"[value]=25"}, "Invalid data argument: [value] has no field before its brackets"},
+		{[]string{"payload[value=25"}, "Invalid data argument: payload[value has unbalanced brackets"},
+		{[]string{"items[2]=a"}, "Invalid data argument: the index 2 is out of the list of 0 values"},
+	}
+
+	for _, test := range tests {
+		rb := Base{}
+		_, err := rb.buildJSONBody(&RequestParameters{data: test.data}, &bytes.Buffer{})
+		require.EqualError(t, err, test.expected)
+	}
+}
+
+func TestBuildJSONBodyJSONInput(t *testing.T) {
+	rb := Base{Method: http.MethodPost, jsonInput: "-"}
+	params := &RequestParameters{data: []string{"payload[value]=30"}}
+
+	err := rb.setJSONInput("/v2/billing/meter_events", params, strings.NewReader(`{"event_name": "api_requests", "payload": {"value": 25, "stripe_customer_id": "cus_123"}}`), &bytes.Buffer{})
+	require.NoError(t, err)
+
+	var warnings bytes.Buffer
+	body, err := rb.buildJSONBody(params, &warnings)
+	require.NoError(t, err)
+	require.Equal(t, `{"event_name":"api_requests","payload":{"value":"30","stripe_customer_id":"cus_123"}}`, body)
+	require.Equal(t, "Warning: -d payload[value] overrides the value of the JSON input\n", warnings.String())
+}
+
+func TestMakeRequestV2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		require.Equal(t, "/v2/billing/meter_events", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if string(body) != `{"event_name":"api_requests","payload":{"value":"25"}}` {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"type": "invalid_request_error", "code": "invalid_payload"}`))
+			return
+		}
+		w.Write([]byte(`{"object": "v2.billing.meter_event"}`))
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, Method: http.MethodPost, SuppressOutput: true}
+
+	_, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v2/billing/meter_events", &RequestParameters{data: []string{"event_name=api_requests", "payload[value]=25"}}, true)
+	require.NoError(t, err)
+
+	// The errors of the /v2 API may not be wrapped in an error object
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v2/billing/meter_events", &RequestParameters{}, true)
+	require.Error(t, err)
+	require.Equal(t, "invalid_request_error", err.(RequestError).ErrorType)
+
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v2/billing/meter_events", &RequestParameters{expand: []string{"payload"}}, true)
+	require.EqualError(t, err, "--expand isn't supported by the /v2 API")
+}
@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stripeVersionHeader returns the Stripe-Version header of the version of
+// --stripe-version with the preview features of --preview-feature and the
+// suffix of --stripe-version-suffix, like:
+//
+//	2024-06-20; feature_beta=v3; other_beta=v1
+//
+// A feature replaces the value of the same feature given before it, in the
+// version or an earlier flag, so that the last one wins.
+func stripeVersionHeader(version string, previewFeatures []string, suffix string) (string, error) {
+	segments := []string{}
+	for _, segment := range strings.Split(version, ";") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	if len(previewFeatures) > 0 && (len(segments) == 0 || strings.Contains(segments[0], "=")) {
+		return "", fmt.Errorf("--preview-feature requires --stripe-version, like --stripe-version 2024-06-20 --preview-feature %s", previewFeatures[0])
+	}
+
+	for _, feature := range previewFeatures {
+		split := strings.SplitN(feature, "=", 2)
+		if len(split) < 2 || split[0] == "" || split[1] == "" || strings.ContainsAny(feature, "; ") {
+			return "", fmt.Errorf("Invalid preview feature %s, expected name=version like feature_beta=v3", feature)
+		}
+
+		replaced := false
+		for i, segment := range segments[1:] {
+			if strings.HasPrefix(segment, split[0]+"=") {
+				segments[i+1] = feature
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			segments = append(segments, feature)
+		}
+	}
+
+	if suffix = strings.Trim(suffix, "; "); suffix != "" {
+		segments = append(segments, suffix)
+	}
+
+	return strings.Join(segments, "; "), nil
+}
@@ -0,0 +1,62 @@
+package requests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripeVersionHeader(t *testing.T) {
+	tests := []struct {
+		version  string
+		features []string
+		suffix   string
+		expected string
+	}{
+		{"", nil, "", ""},
+		{"2024-06-20", nil, "", "2024-06-20"},
+		{"2024-06-20", []string{"feature_beta=v3"}, "", "2024-06-20; feature_beta=v3"},
+		{"2024-06-20", []string{"feature_beta=v3", "other_beta=v1"}, "", "2024-06-20; feature_beta=v3; other_beta=v1"},
+		// The last value of a feature wins
+		{"2024-06-20; feature_beta=v1", []string{"feature_beta=v3"}, "", "2024-06-20; feature_beta=v3"},
+		{"2024-06-20", []string{"feature_beta=v1", "feature_beta=v3"}, "", "2024-06-20; feature_beta=v3"},
+		{"2024-06-20;feature_beta=v1 ", []string{"other_beta=v1"}, "", "2024-06-20; feature_beta=v1; other_beta=v1"},
+		// The suffix is appended as is, last
+		{"2024-06-20", []string{"feature_beta=v3"}, "; custom_beta=v2", "2024-06-20; feature_beta=v3; custom_beta=v2"},
+		{"", nil, "custom_beta=v2", "custom_beta=v2"},
+	}
+
+	for _, test := range tests {
+		header, err := stripeVersionHeader(test.version, test.features, test.suffix)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, header)
+	}
+}
+
+func TestStripeVersionHeaderErrors(t *testing.T) {
+	_, err := stripeVersionHeader("", []string{"feature_beta=v3"}, "")
+	require.EqualError(t, err, "--preview-feature requires --stripe-version, like --stripe-version 2024-06-20 --preview-feature feature_beta=v3")
+
+	for _, feature := range []string{"feature_beta", "=v3", "feature_beta=", "feature_beta=v3; other_beta=v1"} {
+		_, err = stripeVersionHeader("2024-06-20", []string{feature}, "")
+		require.EqualError(t, err, "Invalid preview feature "+feature+", expected name=version like feature_beta=v3")
+	}
+}
+
+func TestMakeRequestPreviewFeature(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2024-06-20; feature_beta=v3", r.Header.Get("Stripe-Version"))
+	}))
+	defer ts.Close()
+
+	rb := Base{APIBaseURL: ts.URL, Method: http.MethodGet, SuppressOutput: true, previewFeatures: []string{"feature_beta=v3"}}
+
+	_, err := rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/charges", &RequestParameters{version: "2024-06-20"}, true)
+	require.NoError(t, err)
+
+	_, err = rb.MakeRequest(context.Background(), "sk_test_1234", "/v1/charges", &RequestParameters{}, true)
+	require.Error(t, err)
+}
@@ -27,7 +27,8 @@ func TestFixturesReturnsData(t *testing.T) {
 		Fixture: `{
   "_meta": {
     "template_version": 0,
-    "exclude_metadata": false
+    "exclude_metadata": false,
+    "description": "Creates a customer"
   },
   "fixtures": [
     {
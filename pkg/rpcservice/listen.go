@@ -54,14 +54,15 @@ func (srv *RPCService) Listen(req *rpc.ListenRequest, stream rpc.StripeCLI_Liste
 	p, err := createProxy(ctx, &proxy.Config{
 		DeviceName:            deviceName,
 		Key:                   key,
-		ForwardURL:            req.ForwardTo,
+		ForwardURLs:           nonEmpty(req.ForwardTo),
 		ForwardHeaders:        req.Headers,
-		ForwardConnectURL:     req.ForwardConnectTo,
+		ForwardConnectURLs:    nonEmpty(req.ForwardConnectTo),
 		ForwardConnectHeaders: req.ConnectHeaders,
 		UseConfiguredWebhooks: req.UseConfiguredWebhooks,
 		WebSocketFeature:      webhooksWebSocketFeature,
 		UseLatestAPIVersion:   req.Latest,
 		SkipVerify:            req.SkipVerify,
+		ForwardTimeout:        proxy.DefaultForwardTimeout,
 		Log:                   logger,
 		Events:                req.Events,
 		OutCh:                 proxyOutCh,
@@ -117,6 +118,9 @@ func createProxyVisitor(stream *rpc.StripeCLI_ListenServer) *websocket.Visitor {
 				}
 				(*stream).Send(resp)
 				return nil
+			case proxy.EndpointsSummary:
+				// Each endpoint response has already been streamed individually
+				return nil
 			default:
 				return fmt.Errorf("VisitData received unexpected type for DataElement, got %T", de)
 			}
@@ -210,6 +214,15 @@ func buildStripeEventResp(raw *proxy.StripeEvent) (*rpc.ListenResponse, error) {
 	}, nil
 }
 
+// nonEmpty wraps a single optional value into a list, which is empty if the
+// value is
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
 func getRPCMethodFromRequestMethod(raw string) rpc.ListenResponse_EndpointResponse_Data_HttpMethod {
 	var httpMethodResponse rpc.ListenResponse_EndpointResponse_Data_HttpMethod
 	httpMethodResponse, ok := httpMethodMap[raw]
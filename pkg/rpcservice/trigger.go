@@ -25,12 +25,21 @@ func (srv *RPCService) Trigger(ctx context.Context, req *rpc.TriggerRequest) (*r
 		req.Event,
 		req.StripeAccount,
 		baseURL,
+		"",
 		apiKey,
 		req.Skip,
+		nil,
 		req.Override,
 		req.Add,
 		req.Remove,
+		nil,
 		req.Raw,
+		"",
+		0,
+		"",
+		"",
+		"",
+		nil,
 	)
 	if err != nil {
 		return nil, err
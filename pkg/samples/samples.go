@@ -307,7 +307,7 @@ func (s *Samples) ConfigureDotEnv(ctx context.Context, sampleLocation string) er
 
 		authClient := stripeauth.NewClient(apiKey, nil)
 
-		authSession, err := authClient.Authorize(ctx, deviceName, "webhooks", nil, nil)
+		authSession, err := authClient.Authorize(ctx, deviceName, "webhooks", nil, nil, "")
 		if err != nil {
 			return err
 		}
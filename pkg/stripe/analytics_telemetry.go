@@ -46,7 +46,7 @@ type CLIAnalyticsEventMetadata struct {
 
 // TelemetryClient is an interface that can send two types of events: an API request, and just general events.
 type TelemetryClient interface {
-	SendAPIRequestEvent(ctx context.Context, requestID string, livemode bool) (*http.Response, error)
+	SendAPIRequestEvent(ctx context.Context, requestID string, livemode bool, timing *RequestTiming) (*http.Response, error)
 	SendEvent(ctx context.Context, eventName string, eventValue string)
 }
 
@@ -133,8 +133,9 @@ func (e *CLIAnalyticsEventMetadata) SetCommandPath(commandPath string) {
 	e.CommandPath = commandPath
 }
 
-// SendAPIRequestEvent is a special function for API requests
-func (a *AnalyticsTelemetryClient) SendAPIRequestEvent(ctx context.Context, requestID string, livemode bool) (*http.Response, error) {
+// SendAPIRequestEvent is a special function for API requests, with their
+// timing in milliseconds if it was captured
+func (a *AnalyticsTelemetryClient) SendAPIRequestEvent(ctx context.Context, requestID string, livemode bool, timing *RequestTiming) (*http.Response, error) {
 	a.wg.Add(1)
 	defer a.wg.Done()
 	telemetryMetadata := GetEventMetadata(ctx)
@@ -149,6 +150,14 @@ func (a *AnalyticsTelemetryClient) SendAPIRequestEvent(ctx context.Context, requ
 		data.Set("event_value", "")
 		data.Set("created", fmt.Sprint((time.Now().Unix())))
 
+		if timing != nil {
+			data.Set("dns_lookup_ms", fmt.Sprint(timing.DNSLookup.Milliseconds()))
+			data.Set("connect_ms", fmt.Sprint(timing.Connect.Milliseconds()))
+			data.Set("tls_handshake_ms", fmt.Sprint(timing.TLSHandshake.Milliseconds()))
+			data.Set("time_to_first_byte_ms", fmt.Sprint(timing.TimeToFirstByte.Milliseconds()))
+			data.Set("total_ms", fmt.Sprint(timing.Total.Milliseconds()))
+		}
+
 		return a.sendData(ctx, data)
 	}
 	return nil, nil
@@ -216,7 +225,7 @@ func (a *AnalyticsTelemetryClient) Wait() {
 }
 
 // SendAPIRequestEvent does nothing
-func (a *NoOpTelemetryClient) SendAPIRequestEvent(ctx context.Context, requestID string, livemode bool) (*http.Response, error) {
+func (a *NoOpTelemetryClient) SendAPIRequestEvent(ctx context.Context, requestID string, livemode bool, timing *RequestTiming) (*http.Response, error) {
 	return nil, nil
 }
 
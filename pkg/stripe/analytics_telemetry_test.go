@@ -110,7 +110,7 @@ func TestSendAPIRequestEvent(t *testing.T) {
 	}
 	processCtx := stripe.WithEventMetadata(context.Background(), telemetryMetadata)
 	analyticsClient := stripe.AnalyticsTelemetryClient{BaseURL: baseURL, HTTPClient: &http.Client{}}
-	resp, err := analyticsClient.SendAPIRequestEvent(processCtx, "req_zzz", false)
+	resp, err := analyticsClient.SendAPIRequestEvent(processCtx, "req_zzz", false, nil)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	resp.Body.Close()
@@ -123,7 +123,7 @@ func TestSkipsSendAPIRequestEventWhenMetadataIsEmpty(t *testing.T) {
 	defer ts.Close()
 	baseURL, _ := url.Parse(ts.URL)
 	analyticsClient := stripe.AnalyticsTelemetryClient{BaseURL: baseURL, HTTPClient: &http.Client{}}
-	resp, err := analyticsClient.SendAPIRequestEvent(context.Background(), "req_zzz", false)
+	resp, err := analyticsClient.SendAPIRequestEvent(context.Background(), "req_zzz", false, nil)
 	require.NoError(t, err)
 	require.Nil(t, resp)
 
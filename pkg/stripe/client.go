@@ -3,6 +3,7 @@ package stripe
 import (
 	"context"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -18,6 +19,9 @@ import (
 // DefaultAPIBaseURL is the default base URL for API requests
 const DefaultAPIBaseURL = "https://api.stripe.com"
 
+// DefaultFilesAPIBaseURL is the default base URL for file uploads
+const DefaultFilesAPIBaseURL = "https://files.stripe.com"
+
 // DefaultDashboardBaseURL is the default base URL for dashboard requests
 const DefaultDashboardBaseURL = "https://dashboard.stripe.com"
 
@@ -35,6 +39,19 @@ type Client struct {
 	// stdout.
 	Verbose bool
 
+	// MaxRetries is how many times a request failing with a 429 or a
+	// transient 5xx is sent again, if it is idempotent or has an idempotency
+	// key. Requests aren't retried by default.
+	MaxRetries int
+
+	// When this is enabled, how long the DNS lookup, connection, TLS
+	// handshake and response took are printed to stderr once the response is
+	// read, and sent with the telemetry of the request.
+	ShowTiming bool
+
+	// timingOut is where the timing is printed, stderr by default
+	timingOut io.Writer
+
 	// Cached HTTP client, lazily created the first time the Client is used to
 	// send a request.
 	httpClient *http.Client
@@ -42,6 +59,88 @@ type Client struct {
 
 // PerformRequest sends a request to Stripe and returns the response.
 func (c *Client) PerformRequest(ctx context.Context, method, path string, params string, configure func(*http.Request)) (*http.Response, error) {
+	url, err := c.requestURL(method, path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = newHTTPClient(c.Verbose, os.Getenv("STRIPE_CLI_UNIX_SOCKET"))
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := c.newRequest(ctx, method, url, params, configure)
+		if err != nil {
+			return nil, err
+		}
+
+		var trace *timingTrace
+		if c.ShowTiming {
+			req, trace = traceRequest(req)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, DescribeProxyError(req, err)
+		}
+
+		// RequestID of the API Request
+		requestID := resp.Header.Get("Request-Id")
+
+		if attempt < c.MaxRetries && shouldRetry(req, resp) {
+			delay := retryDelay(resp.Header, attempt)
+			log.Debugf("Retrying request %s after status %d in %s (retry %d of %d)", requestID, resp.StatusCode, delay, attempt+1, c.MaxRetries)
+
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		livemode := strings.Contains(c.APIKey, "live")
+		if trace == nil {
+			go sendTelemetryEvent(ctx, requestID, livemode, nil)
+			return resp, nil
+		}
+
+		// The timing is complete once the response is read
+		resp.Body = &timedBody{ReadCloser: resp.Body, done: func() {
+			timing := trace.finish()
+			log.WithFields(log.Fields{
+				"prefix":     "stripe.Client.PerformRequest",
+				"request_id": requestID,
+				"timing":     timing.String(),
+			}).Debug("Request timing")
+
+			out := c.timingOut
+			if out == nil {
+				out = os.Stderr
+			}
+			printTiming(out, timing)
+
+			go sendTelemetryEvent(ctx, requestID, livemode, &timing)
+		}}
+		return resp, nil
+	}
+}
+
+// NewRequest returns the request that PerformRequest sends, without sending
+// it, like to print it.
+func (c *Client) NewRequest(ctx context.Context, method, path string, params string, configure func(*http.Request)) (*http.Request, error) {
+	url, err := c.requestURL(method, path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newRequest(ctx, method, url, params, configure)
+}
+
+// requestURL returns the URL of the request, with the params as its query
+// unless it is a POST
+func (c *Client) requestURL(method, path string, params string) (*url.URL, error) {
 	url, err := url.Parse(path)
 	if err != nil {
 		return nil, err
@@ -49,11 +148,26 @@ func (c *Client) PerformRequest(ctx context.Context, method, path string, params
 
 	url = c.BaseURL.ResolveReference(url)
 
+	// The query of the path is kept, like the page of the next_page_url of
+	// the /v2 API
+	if method != http.MethodPost {
+		switch {
+		case url.RawQuery == "":
+			url.RawQuery = params
+		case params != "":
+			url.RawQuery += "&" + params
+		}
+	}
+
+	return url, nil
+}
+
+// newRequest builds the request, again for each retry since its body is read
+// when sent
+func (c *Client) newRequest(ctx context.Context, method string, url *url.URL, params string, configure func(*http.Request)) (*http.Request, error) {
 	var body io.Reader
 	if method == http.MethodPost {
 		body = strings.NewReader(params)
-	} else {
-		url.RawQuery = params
 	}
 
 	req, err := http.NewRequest(method, url.String(), body)
@@ -74,30 +188,17 @@ func (c *Client) PerformRequest(ctx context.Context, method, path string, params
 		configure(req)
 	}
 
-	if c.httpClient == nil {
-		c.httpClient = newHTTPClient(c.Verbose, os.Getenv("STRIPE_CLI_UNIX_SOCKET"))
-	}
-
 	if ctx != nil {
 		req = req.WithContext(ctx)
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	// RequestID of the API Request
-	requestID := resp.Header.Get("Request-Id")
-	livemode := strings.Contains(c.APIKey, "live")
-	go sendTelemetryEvent(ctx, requestID, livemode)
-	return resp, nil
+	return req, nil
 }
 
-func sendTelemetryEvent(ctx context.Context, requestID string, livemode bool) {
+func sendTelemetryEvent(ctx context.Context, requestID string, livemode bool, timing *RequestTiming) {
 	telemetryClient := GetTelemetryClient(ctx)
 	if telemetryClient != nil {
-		resp, err := telemetryClient.SendAPIRequestEvent(ctx, requestID, livemode)
+		resp, err := telemetryClient.SendAPIRequestEvent(ctx, requestID, livemode, timing)
 		// Don't throw exception if we fail to send the event
 		if err != nil {
 			log.Debugf("Error while sending telemetry data: %v\n", err)
@@ -127,7 +228,7 @@ func newHTTPClient(verbose bool, unixSocket string) *http.Client {
 		}
 	} else {
 		httpTransport = &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: ProxyFromEnvironment,
 			DialContext: (&net.Dialer{
 				Timeout:   30 * time.Second,
 				KeepAlive: 30 * time.Second,
@@ -63,6 +63,24 @@ func TestPerformRequest_ParamsEncoding_Get(t *testing.T) {
 	defer resp.Body.Close()
 }
 
+func TestPerformRequest_ParamsEncoding_GetPathQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/core/events", r.URL.Path)
+		require.Equal(t, "page=page_123&limit=10", r.URL.RawQuery)
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := Client{
+		BaseURL: baseURL,
+	}
+
+	resp, err := client.PerformRequest(context.Background(), http.MethodGet, "/v2/core/events?page=page_123", "limit=10", nil)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+}
+
 func TestPerformRequest_ParamsEncoding_Post(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		require.Equal(t, "/post", r.URL.Path)
@@ -0,0 +1,94 @@
+package stripe
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func clearProxyEnvironment(t *testing.T) {
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "all_proxy", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+}
+
+func proxyFor(t *testing.T, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+
+	proxy, err := ProxyFromEnvironment(&http.Request{URL: u})
+	require.NoError(t, err)
+
+	if proxy == nil {
+		return ""
+	}
+
+	return proxy.String()
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	clearProxyEnvironment(t)
+	require.Equal(t, "", proxyFor(t, "https://api.stripe.com/v1/charges"))
+
+	t.Setenv("ALL_PROXY", "socks5://proxy.example.com:1080")
+	require.Equal(t, "socks5://proxy.example.com:1080", proxyFor(t, "https://api.stripe.com/v1/charges"))
+
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	require.Equal(t, "http://proxy.example.com:3128", proxyFor(t, "https://api.stripe.com/v1/charges"))
+
+	t.Setenv("NO_PROXY", "stripe.com")
+	require.Equal(t, "", proxyFor(t, "https://api.stripe.com/v1/charges"))
+}
+
+func TestSetProxyOverridesEnvironment(t *testing.T) {
+	clearProxyEnvironment(t)
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "stripe.com")
+
+	require.NoError(t, SetProxy("corporate-proxy:8080"))
+	defer SetProxy("")
+
+	require.Equal(t, "http://corporate-proxy:8080", proxyFor(t, "https://api.stripe.com/v1/charges"))
+
+	require.NoError(t, SetProxy(""))
+	require.Equal(t, "", proxyFor(t, "https://api.stripe.com/v1/charges"))
+}
+
+func TestSetProxyErrors(t *testing.T) {
+	require.EqualError(t, SetProxy("ftp://proxy.example.com"), "Unsupported proxy scheme ftp, expected http, https or socks5")
+	require.EqualError(t, SetProxy("http://user:secret@"), "Invalid proxy URL http://user:xxxxx@: missing host")
+}
+
+func TestPerformRequestProxyRefusesConnect(t *testing.T) {
+	clearProxyEnvironment(t)
+
+	var proxyAuthorization string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodConnect, r.Method)
+		require.Equal(t, "api.stripe.test:443", r.Host)
+		proxyAuthorization = r.Header.Get("Proxy-Authorization")
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxy.Close()
+
+	proxyURL := strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+	require.NoError(t, SetProxy(proxyURL))
+	defer SetProxy("")
+
+	baseURL, _ := url.Parse("https://api.stripe.test")
+	client := Client{BaseURL: baseURL}
+
+	_, err := client.PerformRequest(context.Background(), http.MethodGet, "/v1/charges", "", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Failed to connect to api.stripe.test through the proxy http://user:xxxxx@")
+	require.Contains(t, err.Error(), "Proxy Authentication Required")
+	require.NotContains(t, err.Error(), "secret")
+
+	require.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), proxyAuthorization)
+}
@@ -0,0 +1,84 @@
+package stripe
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// minRetryDelay is the delay before the first retry, doubled for each of
+	// the next ones
+	minRetryDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the delays, including the ones asked for by
+	// Retry-After
+	maxRetryDelay = 30 * time.Second
+)
+
+// shouldRetry returns whether the request should be sent again after the
+// response: if it was rate limited or failed with a transient error, and is
+// safe to send again
+func shouldRetry(req *http.Request, resp *http.Response) bool {
+	if resp.Header.Get("Stripe-Should-Retry") == "false" {
+		return false
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodDelete || req.Header.Get("Idempotency-Key") != ""
+	if !idempotent {
+		return false
+	}
+
+	if resp.Header.Get("Stripe-Should-Retry") == "true" {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryDelay returns how long to wait before the retry following the
+// attempt, as asked for by Retry-After or with an exponential backoff
+func retryDelay(header http.Header, attempt int) time.Duration {
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			delay := time.Duration(seconds) * time.Second
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+			return delay
+		}
+	}
+
+	delay := minRetryDelay << uint(attempt)
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+
+	// Between half and all of the delay, so that the clients rate limited
+	// together don't retry together
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	if ctx == nil {
+		time.Sleep(delay)
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
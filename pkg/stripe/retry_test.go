@@ -0,0 +1,101 @@
+package stripe
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerformRequest_RetryRateLimited(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id": "cus_1"}`))
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	client := Client{BaseURL: baseURL, MaxRetries: 2}
+
+	resp, err := client.PerformRequest(context.Background(), http.MethodPost, "/v1/customers", "email=bender%40example.com", func(req *http.Request) {
+		req.Header.Set("Idempotency-Key", "foo")
+	})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	// The body is sent again
+	require.Equal(t, []string{"email=bender%40example.com", "email=bender%40example.com"}, bodies)
+}
+
+func TestPerformRequest_NoRetry(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+
+	// Not by default
+	client := Client{BaseURL: baseURL}
+	resp, err := client.PerformRequest(context.Background(), http.MethodGet, "/v1/customers", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 1, requests)
+
+	// Not without an idempotency key
+	requests = 0
+	client = Client{BaseURL: baseURL, MaxRetries: 2}
+	resp, err = client.PerformRequest(context.Background(), http.MethodPost, "/v1/customers", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 1, requests)
+
+	// Up to the max
+	requests = 0
+	resp, err = client.PerformRequest(context.Background(), http.MethodGet, "/v1/customers", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, 3, requests)
+}
+
+func TestShouldRetry(t *testing.T) {
+	get := httptest.NewRequest(http.MethodGet, "/v1/customers", nil)
+
+	require.True(t, shouldRetry(get, &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}))
+	require.True(t, shouldRetry(get, &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}))
+	require.False(t, shouldRetry(get, &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}))
+	require.False(t, shouldRetry(get, &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{"Stripe-Should-Retry": []string{"false"}}}))
+	require.True(t, shouldRetry(get, &http.Response{StatusCode: http.StatusConflict, Header: http.Header{"Stripe-Should-Retry": []string{"true"}}}))
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, 2*time.Second, retryDelay(http.Header{"Retry-After": []string{"2"}}, 0))
+	require.Equal(t, maxRetryDelay, retryDelay(http.Header{"Retry-After": []string{"3600"}}, 0))
+
+	for attempt := 0; attempt < 3; attempt++ {
+		delay := retryDelay(http.Header{}, attempt)
+		max := minRetryDelay << uint(attempt)
+		require.True(t, delay >= max/2 && delay <= max, "delay %s out of bounds for attempt %d", delay, attempt)
+	}
+
+	require.True(t, retryDelay(http.Header{}, 100) <= maxRetryDelay)
+}
@@ -0,0 +1,140 @@
+package stripe
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestTiming is how long the steps of a request took
+type RequestTiming struct {
+	DNSLookup       time.Duration
+	Connect         time.Duration
+	TLSHandshake    time.Duration
+	TimeToFirstByte time.Duration
+	// Total is until the response is read
+	Total time.Duration
+	// Reused is set when the request was sent on an open connection, without
+	// DNS lookup, connection or TLS handshake
+	Reused bool
+}
+
+// String returns the timing as a line, like
+// "DNS lookup 2ms, connect 20ms, TLS handshake 45ms, time to first byte 180ms, total 190ms"
+func (t RequestTiming) String() string {
+	var steps []string
+	if t.Reused {
+		steps = append(steps, "reused connection")
+	} else {
+		steps = append(steps,
+			"DNS lookup "+formatDuration(t.DNSLookup),
+			"connect "+formatDuration(t.Connect),
+		)
+		if t.TLSHandshake > 0 {
+			steps = append(steps, "TLS handshake "+formatDuration(t.TLSHandshake))
+		}
+	}
+	steps = append(steps,
+		"time to first byte "+formatDuration(t.TimeToFirstByte),
+		"total "+formatDuration(t.Total),
+	)
+
+	return strings.Join(steps, ", ")
+}
+
+func formatDuration(d time.Duration) string {
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+// timingTrace captures the timing of a request with httptrace
+type timingTrace struct {
+	mu     sync.Mutex
+	timing RequestTiming
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+}
+
+// traceRequest returns the request with the trace capturing its timing
+func traceRequest(req *http.Request) (*http.Request, *timingTrace) {
+	t := &timingTrace{start: time.Now()}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.timing.DNSLookup = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			t.record(func() { t.connectStart = time.Now() })
+		},
+		ConnectDone: func(string, string, error) {
+			t.record(func() { t.timing.Connect = time.Since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.timing.TLSHandshake = time.Since(t.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() { t.timing.Reused = info.Reused })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.timing.TimeToFirstByte = time.Since(t.start) })
+		},
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+func (t *timingTrace) record(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn()
+}
+
+// finish returns the timing, once the response is read
+func (t *timingTrace) finish() RequestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timing.Total = time.Since(t.start)
+	return t.timing
+}
+
+// timedBody calls done once the body is read or closed
+type timedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+func printTiming(w io.Writer, timing RequestTiming) {
+	fmt.Fprintf(w, "Timing: %s\n", timing)
+}
@@ -0,0 +1,51 @@
+package stripe
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerformRequest_ShowTiming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "cus_1"}`))
+	}))
+	defer ts.Close()
+
+	baseURL, _ := url.Parse(ts.URL)
+	var out bytes.Buffer
+	client := Client{BaseURL: baseURL, ShowTiming: true, timingOut: &out}
+
+	resp, err := client.PerformRequest(context.Background(), http.MethodGet, "/v1/customers", "", nil)
+	require.NoError(t, err)
+
+	// Printed once the response is read
+	require.Empty(t, out.String())
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, `{"id": "cus_1"}`, string(body))
+	require.Regexp(t, `^Timing: DNS lookup .+, connect .+, time to first byte .+, total .+\n$`, out.String())
+}
+
+func TestRequestTimingString(t *testing.T) {
+	timing := RequestTiming{
+		DNSLookup:       1500 * time.Microsecond,
+		Connect:         20 * time.Millisecond,
+		TLSHandshake:    45 * time.Millisecond,
+		TimeToFirstByte: 180 * time.Millisecond,
+		Total:           190 * time.Millisecond,
+	}
+	require.Equal(t, "DNS lookup 2ms, connect 20ms, TLS handshake 45ms, time to first byte 180ms, total 190ms", timing.String())
+
+	timing = RequestTiming{Reused: true, TimeToFirstByte: 250 * time.Microsecond, Total: time.Second}
+	require.Equal(t, "reused connection, time to first byte 250µs, total 1s", timing.String())
+}
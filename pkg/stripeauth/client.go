@@ -26,6 +26,10 @@ type Config struct {
 	HTTPClient *http.Client
 
 	APIBaseURL string
+
+	// StripeAccount is the connected account the sessions are for, sent as
+	// the Stripe-Account header
+	StripeAccount string
 }
 
 // Client is the client used to initiate new CLI sessions with Stripe.
@@ -43,8 +47,33 @@ type DeviceURLMap struct {
 	ForwardConnectURL string
 }
 
-// Authorize sends a request to Stripe to initiate a new CLI session.
-func (c *Client) Authorize(ctx context.Context, deviceName string, websocketFeature string, filters *string, devURLMap *DeviceURLMap) (*StripeCLISession, error) {
+// InvalidAPIVersionError is returned by Authorize when Stripe rejects the API
+// version the session was requested with.
+type InvalidAPIVersionError struct {
+	APIVersion string
+	Message    string
+}
+
+func (e InvalidAPIVersionError) Error() string {
+	return fmt.Sprintf("Stripe rejected the API version %s: %s", e.APIVersion, e.Message)
+}
+
+// ConnectedAccountError is returned by Authorize when Stripe rejects a session
+// for the connected account of the config, like when the account isn't
+// connected to the platform.
+type ConnectedAccountError struct {
+	Account string
+	Message string
+}
+
+func (e ConnectedAccountError) Error() string {
+	return fmt.Sprintf("Stripe rejected the session for the connected account %s: %s", e.Account, e.Message)
+}
+
+// Authorize sends a request to Stripe to initiate a new CLI session. When
+// apiVersion is set, events sent over the session are rendered at that API
+// version rather than the account's default or latest version.
+func (c *Client) Authorize(ctx context.Context, deviceName string, websocketFeature string, filters *string, devURLMap *DeviceURLMap, apiVersion string) (*StripeCLISession, error) {
 	c.cfg.Log.WithFields(log.Fields{
 		"prefix": "stripeauth.client.Authorize",
 	}).Debug("Authenticating with Stripe...")
@@ -70,12 +99,23 @@ func (c *Client) Authorize(ctx context.Context, deviceName string, websocketFeat
 		form.Add("forward_connect_to_url", devURLMap.ForwardConnectURL)
 	}
 
+	if apiVersion != "" {
+		form.Add("api_version", apiVersion)
+	}
+
 	client := &stripe.Client{
 		BaseURL: parsedBaseURL,
 		APIKey:  c.apiKey,
 	}
 
-	resp, err := client.PerformRequest(ctx, http.MethodPost, stripeCLISessionPath, form.Encode(), nil)
+	var configure func(*http.Request)
+	if c.cfg.StripeAccount != "" {
+		configure = func(req *http.Request) {
+			req.Header.Set("Stripe-Account", c.cfg.StripeAccount)
+		}
+	}
+
+	resp, err := client.PerformRequest(ctx, http.MethodPost, stripeCLISessionPath, form.Encode(), configure)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +128,19 @@ func (c *Client) Authorize(ctx context.Context, deviceName string, websocketFeat
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		if apiVersion != "" {
+			if message, ok := apiVersionErrorMessage(body); ok {
+				return nil, InvalidAPIVersionError{APIVersion: apiVersion, Message: message}
+			}
+		}
+
+		// Stripe rejects the account rather than showing the platform's data
+		if c.cfg.StripeAccount != "" && resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			if message, ok := errorMessage(body); ok {
+				return nil, ConnectedAccountError{Account: c.cfg.StripeAccount, Message: message}
+			}
+		}
+
 		err := fmt.Errorf("Authorization failed, status=%d, body=%s", resp.StatusCode, body)
 		return nil, err
 	}
@@ -130,3 +183,39 @@ func NewClient(key string, cfg *Config) *Client {
 		cfg:    cfg,
 	}
 }
+
+//
+// Private functions
+//
+
+// apiVersionErrorMessage returns the message of a Stripe error about the
+// api_version parameter
+func apiVersionErrorMessage(body []byte) (string, bool) {
+	var stripeErr struct {
+		Error struct {
+			Message string `json:"message"`
+			Param   string `json:"param"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &stripeErr); err != nil || stripeErr.Error.Param != "api_version" {
+		return "", false
+	}
+
+	return stripeErr.Error.Message, true
+}
+
+// errorMessage returns the message of the Stripe error of a response body
+func errorMessage(body []byte) (string, bool) {
+	var stripeErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &stripeErr); err != nil || stripeErr.Error.Message == "" {
+		return "", false
+	}
+
+	return stripeErr.Error.Message, true
+}
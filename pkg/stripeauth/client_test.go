@@ -39,7 +39,7 @@ func TestAuthorize(t *testing.T) {
 	client := NewClient("sk_test_123", &Config{
 		APIBaseURL: ts.URL,
 	})
-	session, err := client.Authorize(context.Background(), "my-device", "webhooks", nil, nil)
+	session, err := client.Authorize(context.Background(), "my-device", "webhooks", nil, nil, "")
 	require.NoError(t, err)
 	require.Equal(t, "some-id", session.WebSocketID)
 	require.Equal(t, "wss://example.com/subscribe/acct_123", session.WebSocketURL)
@@ -59,7 +59,7 @@ func TestUserAgent(t *testing.T) {
 	client := NewClient("sk_test_123", &Config{
 		APIBaseURL: ts.URL,
 	})
-	client.Authorize(context.Background(), "my-device", "webhooks", nil, nil)
+	client.Authorize(context.Background(), "my-device", "webhooks", nil, nil, "")
 }
 
 func TestStripeClientUserAgent(t *testing.T) {
@@ -82,7 +82,7 @@ func TestStripeClientUserAgent(t *testing.T) {
 	client := NewClient("sk_test_123", &Config{
 		APIBaseURL: ts.URL,
 	})
-	client.Authorize(context.Background(), "my-device", "webhooks", nil, nil)
+	client.Authorize(context.Background(), "my-device", "webhooks", nil, nil, "")
 }
 
 func TestAuthorizeWithURLDeviceMap(t *testing.T) {
@@ -105,5 +105,71 @@ func TestAuthorizeWithURLDeviceMap(t *testing.T) {
 		ForwardConnectURL: "http://localhost:3000/connect/events",
 	}
 
-	client.Authorize(context.Background(), "my-device", "webhooks", nil, &devURLMap)
+	client.Authorize(context.Background(), "my-device", "webhooks", nil, &devURLMap, "")
+}
+
+func TestAuthorizeWithAPIVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2023-10-16", r.FormValue("api_version"))
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"websocket_id": "some-id"}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient("sk_test_123", &Config{
+		APIBaseURL: ts.URL,
+	})
+	session, err := client.Authorize(context.Background(), "my-device", "webhooks", nil, nil, "2023-10-16")
+	require.NoError(t, err)
+	require.Equal(t, "some-id", session.WebSocketID)
+}
+
+func TestAuthorizeWithRejectedAPIVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "Invalid API version: 1999-01-01", "param": "api_version", "type": "invalid_request_error"}}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient("sk_test_123", &Config{
+		APIBaseURL: ts.URL,
+	})
+	_, err := client.Authorize(context.Background(), "my-device", "webhooks", nil, nil, "1999-01-01")
+	require.EqualError(t, err, "Stripe rejected the API version 1999-01-01: Invalid API version: 1999-01-01")
+	require.IsType(t, InvalidAPIVersionError{}, err)
+}
+
+func TestAuthorizeWithStripeAccount(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "acct_123", r.Header.Get("Stripe-Account"))
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"websocket_id": "some-id"}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient("sk_test_123", &Config{
+		APIBaseURL:    ts.URL,
+		StripeAccount: "acct_123",
+	})
+	session, err := client.Authorize(context.Background(), "my-device", "request_logs", nil, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, "some-id", session.WebSocketID)
+}
+
+func TestAuthorizeWithRejectedStripeAccount(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": {"message": "The provided key does not have access to account 'acct_123'.", "type": "invalid_request_error"}}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient("sk_test_123", &Config{
+		APIBaseURL:    ts.URL,
+		StripeAccount: "acct_123",
+	})
+	_, err := client.Authorize(context.Background(), "my-device", "request_logs", nil, nil, "")
+	require.EqualError(t, err, "Stripe rejected the session for the connected account acct_123: The provided key does not have access to account 'acct_123'.")
+	require.IsType(t, ConnectedAccountError{}, err)
 }
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ArgValidator is an argument validator. It accepts a string and returns an
@@ -88,6 +90,17 @@ func APIKeyNotRestricted(input string) error {
 	return nil
 }
 
+// IsLiveAPIKey returns whether a string looks like a live mode secret or
+// restricted API key, which acts on the real data of the account.
+func IsLiveAPIKey(input string) bool {
+	keyParts := strings.Split(input, "_")
+	if len(keyParts) < 3 {
+		return false
+	}
+
+	return (keyParts[0] == "sk" || keyParts[0] == "rk") && keyParts[1] == "live"
+}
+
 // Account validates that a string is an acceptable account filter.
 func Account(account string) error {
 	accountUpper := strings.ToUpper(account)
@@ -99,6 +112,66 @@ func Account(account string) error {
 	return fmt.Errorf("%s is not an acceptable account filter (CONNECT_IN, CONNECT_OUT, SELF)", account)
 }
 
+// AccountID validates that a string looks like a Stripe account ID.
+func AccountID(input string) error {
+	return objectID(input, "acct_", "account ID")
+}
+
+// RequestID validates that a string looks like the ID of a request, which
+// its logs are found by.
+func RequestID(input string) error {
+	return objectID(input, "req_", "request ID")
+}
+
+func objectID(input string, prefix string, name string) error {
+	if !strings.HasPrefix(input, prefix) || len(input) == len(prefix) {
+		return fmt.Errorf("%s is not a valid %s, it must start with %s", input, name, prefix)
+	}
+
+	for _, r := range strings.TrimPrefix(input, prefix) {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return fmt.Errorf("%s is not a valid %s, it can only contain alphanumeric characters", input, name)
+		}
+	}
+
+	return nil
+}
+
+// APIVersion validates that a string looks like a Stripe API version, a date
+// like 2020-08-27 optionally followed by the name of a release like
+// 2024-09-30.acacia.
+func APIVersion(input string) error {
+	date := input
+	release := ""
+	if i := strings.Index(input, "."); i != -1 {
+		date, release = input[:i], input[i+1:]
+		if release == "" {
+			return fmt.Errorf("%s is not a valid API version, expected a date like 2020-08-27", input)
+		}
+	}
+
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("%s is not a valid API version, expected a date like 2020-08-27", input)
+	}
+
+	for _, r := range release {
+		if !(r >= 'a' && r <= 'z') {
+			return fmt.Errorf("%s is not a valid API version, the name of the release can only contain lowercase letters", input)
+		}
+	}
+
+	return nil
+}
+
+// WebhookSigningSecret validates that a string looks like a webhook signing secret.
+func WebhookSigningSecret(input string) error {
+	if !strings.HasPrefix(input, "whsec_") || len(input) == len("whsec_") {
+		return errors.New("the webhook signing secret provided is not valid, it must start with whsec_")
+	}
+
+	return nil
+}
+
 // HTTPMethod validates that a string is an acceptable HTTP method.
 func HTTPMethod(method string) error {
 	methodUpper := strings.ToUpper(method)
@@ -114,11 +187,11 @@ func HTTPMethod(method string) error {
 func RequestSource(source string) error {
 	sourceUpper := strings.ToUpper(source)
 
-	if sourceUpper == "API" || sourceUpper == "DASHBOARD" {
+	if sourceUpper == "API" || sourceUpper == "DASHBOARD" || sourceUpper == "CLI" {
 		return nil
 	}
 
-	return fmt.Errorf("%s is not an acceptable source (API, DASHBOARD)", source)
+	return fmt.Errorf("%s is not an acceptable source (API, DASHBOARD, CLI)", source)
 }
 
 // RequestStatus validates that a string is an acceptable request status.
@@ -132,6 +205,30 @@ func RequestStatus(status string) error {
 	return fmt.Errorf("%s is not an acceptable request status (SUCCEEDED, FAILED)", status)
 }
 
+// RequestPath validates that a string is a path requests are made to, like
+// /v1/payment_intents.
+func RequestPath(path string) error {
+	if strings.HasPrefix(path, "/") {
+		return nil
+	}
+
+	return fmt.Errorf("%s is not an acceptable request path, it must start with / (e.g. /v1/payment_intents)", path)
+}
+
+// RequestPathPattern validates that a string is either the start of a request
+// path, like /v1/balance, or a glob of request paths, like /v1/customers/*.
+func RequestPathPattern(pattern string) error {
+	if err := RequestPath(pattern); err != nil {
+		return err
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("%s is not an acceptable request path pattern: %v", pattern, err)
+	}
+
+	return nil
+}
+
 // StatusCode validates that a provided status code is within the range of
 // those used in the Stripe API.
 func StatusCode(code string) error {
@@ -42,6 +42,15 @@ func TestTestmodeRestrictedAPIKey(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestIsLiveAPIKey(t *testing.T) {
+	require.True(t, IsLiveAPIKey("sk_live_12345"))
+	require.True(t, IsLiveAPIKey("rk_live_12345"))
+	require.False(t, IsLiveAPIKey("sk_test_12345"))
+	require.False(t, IsLiveAPIKey("rk_test_12345"))
+	require.False(t, IsLiveAPIKey("pk_live_12345"))
+	require.False(t, IsLiveAPIKey("sk_live"))
+}
+
 func TestHTTPMethod(t *testing.T) {
 	err := HTTPMethod("GET")
 	require.NoError(t, err)
@@ -67,6 +76,11 @@ func TestRequestSourceDashboard(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRequestSourceCLI(t *testing.T) {
+	err := RequestSource("cli")
+	require.NoError(t, err)
+}
+
 func TestRequestStatusSucceeded(t *testing.T) {
 	err := RequestStatus("succeeded")
 	require.NoError(t, err)
@@ -84,7 +98,25 @@ func TestRequestStatusInvalid(t *testing.T) {
 
 func TestRequestSourceInvalid(t *testing.T) {
 	err := RequestSource("invalid")
-	require.Equal(t, "invalid is not an acceptable source (API, DASHBOARD)", fmt.Sprintf("%s", err))
+	require.Equal(t, "invalid is not an acceptable source (API, DASHBOARD, CLI)", fmt.Sprintf("%s", err))
+}
+
+func TestRequestPath(t *testing.T) {
+	require.NoError(t, RequestPath("/v1/payment_intents"))
+
+	err := RequestPath("v1/payment_intents")
+	require.Equal(t, "v1/payment_intents is not an acceptable request path, it must start with / (e.g. /v1/payment_intents)", fmt.Sprintf("%s", err))
+}
+
+func TestRequestPathPattern(t *testing.T) {
+	require.NoError(t, RequestPathPattern("/v1/balance"))
+	require.NoError(t, RequestPathPattern("/v1/customers/*/sources"))
+
+	err := RequestPathPattern("/v1/customers/[")
+	require.EqualError(t, err, "/v1/customers/[ is not an acceptable request path pattern: syntax error in pattern")
+
+	err = RequestPathPattern("v1/balance")
+	require.EqualError(t, err, "v1/balance is not an acceptable request path, it must start with / (e.g. /v1/payment_intents)")
 }
 
 func TestStatusCode(t *testing.T) {
@@ -111,3 +143,53 @@ func TestStatusCodeNotXs(t *testing.T) {
 	err := StatusCodeType("201")
 	require.Equal(t, "Provided status code type 201 is not a valid type (2XX, 4XX, 5XX)", fmt.Sprintf("%s", err))
 }
+
+func TestWebhookSigningSecret(t *testing.T) {
+	require.NoError(t, WebhookSigningSecret("whsec_1234567890"))
+
+	err := WebhookSigningSecret("whsec_")
+	require.EqualError(t, err, "the webhook signing secret provided is not valid, it must start with whsec_")
+
+	err = WebhookSigningSecret("sk_test_123")
+	require.EqualError(t, err, "the webhook signing secret provided is not valid, it must start with whsec_")
+}
+
+func TestAPIVersion(t *testing.T) {
+	require.NoError(t, APIVersion("2020-08-27"))
+	require.NoError(t, APIVersion("2024-09-30.acacia"))
+
+	err := APIVersion("2020-13-01")
+	require.EqualError(t, err, "2020-13-01 is not a valid API version, expected a date like 2020-08-27")
+
+	err = APIVersion("latest")
+	require.EqualError(t, err, "latest is not a valid API version, expected a date like 2020-08-27")
+
+	err = APIVersion("2020-08-27.")
+	require.EqualError(t, err, "2020-08-27. is not a valid API version, expected a date like 2020-08-27")
+
+	err = APIVersion("2024-09-30.Acacia")
+	require.EqualError(t, err, "2024-09-30.Acacia is not a valid API version, the name of the release can only contain lowercase letters")
+}
+
+func TestAccountID(t *testing.T) {
+	require.NoError(t, AccountID("acct_1Gqj58KEaG2Vqfje"))
+
+	err := AccountID("acct_")
+	require.EqualError(t, err, "acct_ is not a valid account ID, it must start with acct_")
+
+	err = AccountID("cus_123")
+	require.EqualError(t, err, "cus_123 is not a valid account ID, it must start with acct_")
+
+	err = AccountID("acct_12 3")
+	require.EqualError(t, err, "acct_12 3 is not a valid account ID, it can only contain alphanumeric characters")
+}
+
+func TestRequestID(t *testing.T) {
+	require.NoError(t, RequestID("req_Jx4bVd2GHjc1Ek"))
+
+	err := RequestID("resp_Jx4bVd2GHjc1Ek")
+	require.EqualError(t, err, "resp_Jx4bVd2GHjc1Ek is not a valid request ID, it must start with req_")
+
+	err = RequestID("req_Jx4b-Vd2")
+	require.EqualError(t, err, "req_Jx4b-Vd2 is not a valid request ID, it can only contain alphanumeric characters")
+}
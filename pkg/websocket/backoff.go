@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"math/rand"
+	"time"
+)
+
+//
+// Private constants
+//
+
+const (
+	defaultReconnectInitialDelay = 1 * time.Second
+
+	defaultReconnectMaxDelay = 60 * time.Second
+
+	// stableConnectionPeriod is how long a connection must stay up for the
+	// reconnect backoff to be reset
+	stableConnectionPeriod = 1 * time.Minute
+)
+
+//
+// Private types
+//
+
+// clock abstracts time so that the reconnect logic can be tested
+// deterministically
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// reconnectBackoff computes the delay before each reconnection attempt. The
+// delay doubles with each consecutive attempt up to maxDelay, with a random
+// jitter so that clients disconnected at the same time don't reconnect in
+// lockstep. It is reset once a connection has been up for long enough.
+type reconnectBackoff struct {
+	initialDelay time.Duration
+	maxDelay     time.Duration
+
+	clock  clock
+	jitter func() float64
+
+	attempts    int
+	connectedAt time.Time
+}
+
+//
+// Private functions
+//
+
+func newReconnectBackoff(initialDelay, maxDelay time.Duration, c clock) *reconnectBackoff {
+	return &reconnectBackoff{
+		initialDelay: initialDelay,
+		maxDelay:     maxDelay,
+		clock:        c,
+		// #nosec G404 -- jitter does not need a cryptographically secure source
+		jitter: rand.Float64,
+	}
+}
+
+// next returns the delay before the next attempt and increments the number of
+// attempts
+func (b *reconnectBackoff) next() time.Duration {
+	delay := b.initialDelay
+	for i := 0; i < b.attempts && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > b.maxDelay {
+		delay = b.maxDelay
+	}
+
+	b.attempts++
+
+	// wait between half and all of the delay
+	half := delay / 2
+
+	return half + time.Duration(b.jitter()*float64(delay-half))
+}
+
+// connected records that a connection was established
+func (b *reconnectBackoff) connected() {
+	b.connectedAt = b.clock.Now()
+}
+
+// disconnected records that the connection was lost, resetting the backoff if
+// the connection was stable
+func (b *reconnectBackoff) disconnected() {
+	if !b.connectedAt.IsZero() && b.clock.Now().Sub(b.connectedAt) >= stableConnectionPeriod {
+		b.attempts = 0
+	}
+
+	b.connectedAt = time.Time{}
+}
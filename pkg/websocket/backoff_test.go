@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	b := newReconnectBackoff(time.Second, 10*time.Second, &fakeClock{})
+
+	// without jitter, the full delay is used
+	b.jitter = func() float64 { return 1 }
+	require.Equal(t, 1*time.Second, b.next())
+	require.Equal(t, 2*time.Second, b.next())
+	require.Equal(t, 4*time.Second, b.next())
+	require.Equal(t, 8*time.Second, b.next())
+	require.Equal(t, 10*time.Second, b.next())
+	require.Equal(t, 10*time.Second, b.next())
+
+	// with the maximum jitter, half of the delay is used
+	b = newReconnectBackoff(time.Second, 10*time.Second, &fakeClock{})
+	b.jitter = func() float64 { return 0 }
+	require.Equal(t, 500*time.Millisecond, b.next())
+	require.Equal(t, 1*time.Second, b.next())
+	require.Equal(t, 2*time.Second, b.next())
+}
+
+func TestReconnectBackoffResetsAfterStableConnection(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	b := newReconnectBackoff(time.Second, time.Minute, clock)
+	b.jitter = func() float64 { return 1 }
+
+	require.Equal(t, 1*time.Second, b.next())
+	require.Equal(t, 2*time.Second, b.next())
+
+	// a short-lived connection doesn't reset the backoff
+	b.connected()
+	clock.Advance(30 * time.Second)
+	b.disconnected()
+	require.Equal(t, 4*time.Second, b.next())
+
+	// a connection surviving more than a minute does
+	b.connected()
+	clock.Advance(61 * time.Second)
+	b.disconnected()
+	require.Equal(t, 1*time.Second, b.next())
+}
@@ -7,14 +7,17 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	ws "github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/stripe/stripe-cli/pkg/stripe"
 	"github.com/stripe/stripe-cli/pkg/useragent"
 )
 
@@ -40,12 +43,25 @@ type Config struct {
 	// Interval at which the websocket client should reset the connection
 	ReconnectInterval time.Duration
 
+	// Delay before the first reconnection attempt after the connection is
+	// lost. Subsequent attempts back off exponentially, with jitter.
+	ReconnectInitialDelay time.Duration
+
+	// Maximum delay between two reconnection attempts
+	ReconnectMaxDelay time.Duration
+
 	// Duration to wait before closing connection
 	CloseDelayPeriod time.Duration
 
 	WriteWait time.Duration
 
 	EventHandler EventHandler
+
+	// OnConnect is called each time the client connects, including after
+	// losing or resetting its connection, before it starts reading the new
+	// connection. It shouldn't block, as the connection isn't kept alive
+	// meanwhile.
+	OnConnect func()
 }
 
 // EventHandler handles an event.
@@ -79,9 +95,11 @@ type Client struct {
 	// Optional configuration parameters
 	cfg *Config
 
+	backoff     *reconnectBackoff
 	conn        *ws.Conn
 	done        chan struct{}
 	isConnected bool
+	reconnects  int32
 
 	NotifyExpired chan struct{}
 	notifyClose   chan error
@@ -106,6 +124,12 @@ func (c *Client) Connected() <-chan struct{} {
 	return d
 }
 
+// Reconnects returns the number of times the client reconnected after the
+// server closed the connection.
+func (c *Client) Reconnects() int {
+	return int(atomic.LoadInt32(&c.reconnects))
+}
+
 // Run starts listening for incoming webhook requests from Stripe.
 func (c *Client) Run(ctx context.Context) {
 	for {
@@ -117,8 +141,11 @@ func (c *Client) Run(ctx context.Context) {
 		var err error
 		err = c.connect(ctx)
 		for err != nil {
+			delay := c.backoff.next()
 			c.cfg.Log.WithFields(log.Fields{
-				"prefix": "websocket.client.Run",
+				"prefix":  "websocket.client.Run",
+				"attempt": c.backoff.attempts,
+				"delay":   delay,
 			}).Debug("Failed to connect to Stripe. Retrying...")
 
 			if err == ErrUnknownID {
@@ -137,11 +164,13 @@ func (c *Client) Run(ctx context.Context) {
 			select {
 			case <-ctx.Done():
 				c.Stop()
-			case <-time.After(c.cfg.ConnectAttemptWait):
+			case <-c.backoff.clock.After(delay):
 			}
 			err = c.connect(ctx)
 		}
 
+		c.backoff.connected()
+
 		select {
 		case <-ctx.Done():
 			close(c.send)
@@ -158,6 +187,22 @@ func (c *Client) Run(ctx context.Context) {
 			}).Debug("Disconnected from Stripe")
 			c.Close(ws.CloseGoingAway, "Server closed the connection")
 			c.wg.Wait()
+
+			atomic.AddInt32(&c.reconnects, 1)
+			c.backoff.disconnected()
+			delay := c.backoff.next()
+			c.cfg.Log.WithFields(log.Fields{
+				"prefix":  "websocket.client.Run",
+				"attempt": c.backoff.attempts,
+				"delay":   delay,
+			}).Debug("Waiting before reconnecting")
+
+			select {
+			case <-ctx.Done():
+				close(c.send)
+				return
+			case <-c.backoff.clock.After(delay):
+			}
 		case <-time.After(c.cfg.ReconnectInterval):
 			c.cfg.Log.WithFields(log.Fields{
 				"prefix": "websocket.Client.Run",
@@ -256,6 +301,12 @@ func (c *Client) connect(ctx context.Context) error {
 
 	conn, resp, err := c.cfg.Dialer.DialContext(ctx, url, header)
 	if err != nil {
+		if proxyErr := describeProxyError(url, err); proxyErr != err {
+			c.cfg.Log.WithFields(log.Fields{
+				"prefix": "websocket.Client.connect",
+			}).Warn(proxyErr)
+		}
+
 		message := readWSConnectErrorMessage(resp)
 		c.cfg.Log.WithFields(log.Fields{
 			"prefix":  "websocket.Client.connect",
@@ -273,6 +324,10 @@ func (c *Client) connect(ctx context.Context) error {
 	c.changeConnection(conn)
 	c.isConnected = true
 
+	if c.cfg.OnConnect != nil {
+		c.cfg.OnConnect()
+	}
+
 	c.wg = &sync.WaitGroup{}
 	c.wg.Add(2)
 
@@ -485,6 +540,14 @@ func NewClient(url string, webSocketID string, websocketAuthorizedFeature string
 		cfg.ReconnectInterval = defaultReconnectInterval
 	}
 
+	if cfg.ReconnectInitialDelay == 0 {
+		cfg.ReconnectInitialDelay = defaultReconnectInitialDelay
+	}
+
+	if cfg.ReconnectMaxDelay == 0 {
+		cfg.ReconnectMaxDelay = defaultReconnectMaxDelay
+	}
+
 	if cfg.CloseDelayPeriod == 0 {
 		cfg.CloseDelayPeriod = defaultCloseDelayPeriod
 	}
@@ -502,6 +565,7 @@ func NewClient(url string, webSocketID string, websocketAuthorizedFeature string
 		WebSocketID:                webSocketID,
 		WebSocketAuthorizedFeature: websocketAuthorizedFeature,
 		cfg:                        cfg,
+		backoff:                    newReconnectBackoff(cfg.ReconnectInitialDelay, cfg.ReconnectMaxDelay, realClock{}),
 		done:                       make(chan struct{}),
 		send:                       make(chan *OutgoingMessage),
 		NotifyExpired:              make(chan struct{}),
@@ -536,6 +600,20 @@ var nullEventHandler = EventHandlerFunc(func(IncomingMessage) {})
 // Private functions
 //
 
+// describeProxyError mentions the proxy in errors connecting to the websocket
+// URL through a proxy, or returns err unchanged
+func describeProxyError(wsURL string, err error) error {
+	u, parseErr := neturl.Parse(wsURL)
+	if parseErr != nil {
+		return err
+	}
+
+	// proxies are configured for the HTTP schemes the websocket schemes map to
+	u.Scheme = strings.Replace(u.Scheme, "ws", "http", 1)
+
+	return stripe.DescribeProxyError(&http.Request{URL: u}, err)
+}
+
 func newWebSocketDialer(unixSocket string) *ws.Dialer {
 	var dialer *ws.Dialer
 
@@ -551,7 +629,7 @@ func newWebSocketDialer(unixSocket string) *ws.Dialer {
 	} else {
 		dialer = &ws.Dialer{
 			HandshakeTimeout: 10 * time.Second,
-			Proxy:            http.ProxyFromEnvironment,
+			Proxy:            stripe.ProxyFromEnvironment,
 			Subprotocols:     subprotocols[:],
 		}
 	}
@@ -12,6 +12,8 @@ import (
 
 	ws "github.com/gorilla/websocket"
 	"github.com/stretchr/testify/require"
+
+	"github.com/stripe/stripe-cli/pkg/stripe"
 )
 
 func TestClientWebhookEventHandler(t *testing.T) {
@@ -174,3 +176,23 @@ func TestClientExpiredError(t *testing.T) {
 		require.FailNow(t, "Timed out waiting for response from test server")
 	}
 }
+
+func TestWebSocketDialerUsesProxy(t *testing.T) {
+	var connectHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connectHost = r.Host
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	require.NoError(t, stripe.SetProxy(proxy.URL))
+	defer stripe.SetProxy("")
+
+	wsURL := "wss://stripe-cli.test/subscribe"
+	_, _, err := newWebSocketDialer("").Dial(wsURL, nil)
+	require.Error(t, err)
+	require.Equal(t, "stripe-cli.test:443", connectHost)
+
+	err = describeProxyError(wsURL, err)
+	require.EqualError(t, err, "Failed to connect to stripe-cli.test through the proxy "+proxy.URL+": Forbidden")
+}
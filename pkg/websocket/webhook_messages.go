@@ -26,6 +26,12 @@ type WebhookResponse struct {
 	Type                  string            `json:"type"`
 	WebhookConversationID string            `json:"webhook_conversation_id"`
 	WebhookID             string            `json:"webhook_id"`
+	// LatencyMs is how long the local endpoint took to respond, in
+	// milliseconds
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+	// Error describes why the event could not be delivered to the local
+	// endpoint when it didn't respond, in which case Status is 0
+	Error string `json:"error,omitempty"`
 }
 
 // NewWebhookResponse returns a new webhookResponse message.
@@ -66,3 +66,21 @@ func TestNewWebhookResponse(t *testing.T) {
 	require.Equal(t, "foo", msg.Body)
 	require.Equal(t, "bar", msg.HTTPHeaders["Response-Header"])
 }
+
+func TestMarshalWebhookResponseFailure(t *testing.T) {
+	msg := NewWebhookResponse("wh_123", "wc_123", "http://localhost:5000/webhooks", 0, "", map[string]string{})
+	msg.LatencyMs = 1500
+	msg.Error = "connection refused"
+
+	buf, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, int(gjson.GetBytes(buf, "status").Num))
+	require.Equal(t, 1500, int(gjson.GetBytes(buf, "latency_ms").Num))
+	require.Equal(t, "connection refused", gjson.GetBytes(buf, "error").String())
+
+	buf, err = json.Marshal(NewWebhookResponse("wh_123", "wc_123", "http://localhost:5000/webhooks", 200, "", nil))
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(buf, "error").Exists())
+	require.False(t, gjson.GetBytes(buf, "latency_ms").Exists())
+}